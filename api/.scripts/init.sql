@@ -2,22 +2,348 @@ CREATE TYPE INVOICE_SERVICE_NAME as ENUM('DMP', 'SSP');
 
 CREATE TYPE INVOICE_STATUS AS ENUM('PAID', 'PENDING', 'UNPAID');
 
+CREATE TYPE INVOICE_DIRECTION AS ENUM('OUTBOUND', 'INBOUND');
+
+CREATE FUNCTION current_tenant() RETURNS TEXT AS $$
+    SELECT nullif(current_setting('app.tenant_id', true), '');
+$$ LANGUAGE sql STABLE;
+
 CREATE TABLE invoices (
     id UUID PRIMARY KEY UNIQUE NOT NULL,
     service_name INVOICE_SERVICE_NAME NOT NULL,
     amount DOUBLE PRECISION NOT NULL,
     status INVOICE_STATUS NOT NULL,
-    date TIMESTAMP NOT NULL
-);
-
-INSERT INTO invoices (id, service_name, amount, status, date) VALUES
-    ('dda97bce-ac2a-4431-9c7b-3b6bcdfe8a23', 'DMP', 120.30, 'PAID', '2025-03-18 12:34:56'),
-    ('dc874c3f-2773-413e-a3c8-e9f24b04079c', 'SSP', 230.50, 'PENDING', '2024-03-18 12:34:56'),
-    ('550e8400-e29b-41d4-a716-446655440000', 'DMP', 150.75, 'UNPAID', '2024-04-01 09:00:00'),
-    ('6ba7b810-9dad-11d1-80b4-00c04fd430c8', 'SSP', 300.25, 'PAID', '2024-03-15 15:30:00'),
-    ('6ba7b811-9dad-11d1-80b4-00c04fd430c8', 'DMP', 175.90, 'PENDING', '2024-03-20 11:45:00'),
-    ('6ba7b812-9dad-11d1-80b4-00c04fd430c8', 'SSP', 450.00, 'PAID', '2024-03-25 14:20:00'),
-    ('6ba7b813-9dad-11d1-80b4-00c04fd430c8', 'DMP', 200.80, 'UNPAID', '2024-04-05 10:15:00'),
-    ('6ba7b814-9dad-11d1-80b4-00c04fd430c8', 'SSP', 275.60, 'PENDING', '2024-03-28 16:40:00'),
-    ('6ba7b815-9dad-11d1-80b4-00c04fd430c8', 'DMP', 180.45, 'PAID', '2024-03-22 13:50:00'),
-    ('6ba7b816-9dad-11d1-80b4-00c04fd430c8', 'SSP', 325.90, 'UNPAID', '2024-04-02 08:30:00');
+    date TIMESTAMP NOT NULL,
+    direction INVOICE_DIRECTION NOT NULL DEFAULT 'OUTBOUND',
+    series TEXT,
+    sequence_number BIGINT,
+    hash CHAR(64),
+    previous_hash CHAR(64),
+    finalized_at TIMESTAMP,
+    payment_reference VARCHAR(25) UNIQUE,
+    deleted_at TIMESTAMP,
+    tenant_id TEXT NOT NULL DEFAULT current_tenant(),
+    UNIQUE (tenant_id, series, sequence_number)
+);
+
+CREATE INDEX invoices_tenant_id_idx ON invoices (tenant_id);
+
+CREATE INDEX invoices_series_finalized_at_idx ON invoices (series, finalized_at);
+
+CREATE INDEX invoices_deleted_at_idx ON invoices (deleted_at) WHERE deleted_at IS NOT NULL;
+
+CREATE INDEX invoices_date_idx ON invoices (date) INCLUDE (service_name, status, amount) WHERE deleted_at IS NULL;
+
+CREATE TABLE invoice_chain_tombstones (
+    tenant_id TEXT NOT NULL,
+    series TEXT NOT NULL,
+    sequence_number BIGINT NOT NULL,
+    invoice_id UUID NOT NULL,
+    hash CHAR(64) NOT NULL,
+    previous_hash CHAR(64) NOT NULL,
+    finalized_at TIMESTAMP NOT NULL,
+    purged_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (tenant_id, series, sequence_number)
+);
+
+CREATE TABLE invoice_events (
+    id BIGSERIAL PRIMARY KEY,
+    invoice_id UUID NOT NULL,
+    type TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    request_id TEXT,
+    changes JSONB NOT NULL,
+    occurred_at TIMESTAMP NOT NULL,
+    tenant_id TEXT NOT NULL DEFAULT current_tenant()
+);
+
+CREATE INDEX invoice_events_invoice_id_idx ON invoice_events (invoice_id, id);
+
+CREATE FUNCTION invoice_events_tenant() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.tenant_id := coalesce(
+        (SELECT tenant_id FROM invoices WHERE id = NEW.invoice_id),
+        (SELECT tenant_id FROM invoice_events WHERE invoice_id = NEW.invoice_id ORDER BY id LIMIT 1),
+        current_tenant()
+    );
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER invoice_events_tenant
+    BEFORE INSERT ON invoice_events
+    FOR EACH ROW EXECUTE FUNCTION invoice_events_tenant();
+
+CREATE FUNCTION invoice_events_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'invoice_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER invoice_events_no_update_or_delete
+    BEFORE UPDATE OR DELETE ON invoice_events
+    FOR EACH ROW EXECUTE FUNCTION invoice_events_append_only();
+
+CREATE TRIGGER invoice_events_no_truncate
+    BEFORE TRUNCATE ON invoice_events
+    FOR EACH STATEMENT EXECUTE FUNCTION invoice_events_append_only();
+
+CREATE FUNCTION invoice_events_notify() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('invoice_events', NEW.id::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER invoice_events_notify
+    AFTER INSERT ON invoice_events
+    FOR EACH ROW EXECUTE FUNCTION invoice_events_notify();
+
+CREATE TABLE invoice_attachments (
+    id UUID PRIMARY KEY NOT NULL,
+    invoice_id UUID NOT NULL REFERENCES invoices (id) ON DELETE CASCADE,
+    file_name TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size BIGINT NOT NULL,
+    checksum CHAR(64) NOT NULL,
+    storage_key TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX invoice_attachments_invoice_id_idx ON invoice_attachments (invoice_id);
+
+CREATE TABLE outbox_events (
+    id BIGSERIAL PRIMARY KEY,
+    aggregate_type TEXT NOT NULL,
+    aggregate_id UUID NOT NULL,
+    type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    request_id TEXT,
+    created_at TIMESTAMP NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    last_error TEXT,
+    published_at TIMESTAMP,
+    failed_at TIMESTAMP,
+    tenant_id TEXT NOT NULL DEFAULT current_tenant()
+);
+
+CREATE INDEX outbox_events_pending_idx ON outbox_events (next_attempt_at, id) WHERE published_at IS NULL AND failed_at IS NULL;
+
+CREATE INDEX outbox_events_aggregate_pending_idx ON outbox_events (aggregate_id, id) WHERE published_at IS NULL AND failed_at IS NULL;
+
+CREATE INDEX outbox_events_failed_idx ON outbox_events (tenant_id, type) WHERE failed_at IS NOT NULL;
+
+CREATE TABLE webhook_subscriptions (
+    id UUID PRIMARY KEY NOT NULL,
+    url TEXT NOT NULL,
+    event_types TEXT[] NOT NULL,
+    secret TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    disabled_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL,
+    tenant_id TEXT NOT NULL DEFAULT current_tenant()
+);
+
+CREATE INDEX webhook_subscriptions_tenant_id_idx ON webhook_subscriptions (tenant_id);
+
+CREATE TABLE webhook_deliveries (
+    id UUID PRIMARY KEY NOT NULL,
+    subscription_id UUID NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+    event_id BIGINT NOT NULL,
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    response_status INTEGER,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    delivered_at TIMESTAMP,
+    tenant_id TEXT NOT NULL DEFAULT current_tenant(),
+    UNIQUE (subscription_id, event_id)
+);
+
+CREATE INDEX webhook_deliveries_pending_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'PENDING';
+
+CREATE TABLE usage_events (
+    id TEXT NOT NULL,
+    customer_id TEXT NOT NULL,
+    service_name INVOICE_SERVICE_NAME NOT NULL,
+    metric TEXT NOT NULL,
+    quantity DOUBLE PRECISION NOT NULL,
+    occurred_at TIMESTAMP NOT NULL,
+    received_at TIMESTAMP NOT NULL,
+    invoice_id UUID,
+    tenant_id TEXT NOT NULL DEFAULT current_tenant(),
+    PRIMARY KEY (tenant_id, customer_id, id)
+);
+
+CREATE INDEX usage_events_unbilled_idx ON usage_events (tenant_id, customer_id, service_name, occurred_at) WHERE invoice_id IS NULL;
+
+CREATE INDEX usage_events_invoice_id_idx ON usage_events (invoice_id) WHERE invoice_id IS NOT NULL;
+
+CREATE TABLE price_plans (
+    id UUID PRIMARY KEY NOT NULL,
+    service_name INVOICE_SERVICE_NAME NOT NULL,
+    metric TEXT NOT NULL,
+    model TEXT NOT NULL,
+    tiers JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    tenant_id TEXT NOT NULL DEFAULT current_tenant(),
+    UNIQUE (tenant_id, service_name, metric)
+);
+
+CREATE TABLE billing_periods (
+    invoice_id UUID PRIMARY KEY NOT NULL,
+    customer_id TEXT NOT NULL,
+    service_name INVOICE_SERVICE_NAME NOT NULL,
+    period_start TIMESTAMP NOT NULL,
+    period_end TIMESTAMP NOT NULL,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL,
+    tenant_id TEXT NOT NULL DEFAULT current_tenant(),
+    UNIQUE (tenant_id, customer_id, service_name, period_start, period_end)
+);
+
+CREATE TABLE invoice_lines (
+    invoice_id UUID NOT NULL REFERENCES invoices (id) ON DELETE CASCADE,
+    metric TEXT NOT NULL,
+    description TEXT NOT NULL,
+    quantity DOUBLE PRECISION NOT NULL,
+    unit_price DOUBLE PRECISION NOT NULL,
+    amount DOUBLE PRECISION NOT NULL,
+    PRIMARY KEY (invoice_id, metric)
+);
+
+CREATE TABLE dunning_notices (
+    invoice_id UUID NOT NULL REFERENCES invoices (id) ON DELETE CASCADE,
+    step TEXT NOT NULL,
+    step_order INTEGER NOT NULL,
+    recipient TEXT NOT NULL,
+    locale TEXT NOT NULL,
+    sent_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (invoice_id, step)
+);
+
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY NOT NULL,
+    name TEXT NOT NULL,
+    prefix TEXT UNIQUE NOT NULL,
+    scopes TEXT[] NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    hash CHAR(64) NOT NULL,
+    tenant_id TEXT NOT NULL DEFAULT current_tenant()
+);
+
+CREATE TABLE rate_limit_buckets (
+    key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    full_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX rate_limit_buckets_full_at_idx ON rate_limit_buckets (full_at);
+
+CREATE TABLE recurring_templates (
+    id UUID PRIMARY KEY NOT NULL,
+    service_name INVOICE_SERVICE_NAME NOT NULL,
+    amount DOUBLE PRECISION NOT NULL,
+    cadence TEXT NOT NULL,
+    start_at TIMESTAMP NOT NULL,
+    end_at TIMESTAMP,
+    next_run_at TIMESTAMP,
+    last_run_at TIMESTAMP,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL,
+    tenant_id TEXT NOT NULL DEFAULT current_tenant()
+);
+
+CREATE INDEX recurring_templates_next_run_at_idx ON recurring_templates (next_run_at) WHERE enabled;
+
+CREATE TABLE recurring_runs (
+    template_id UUID NOT NULL REFERENCES recurring_templates (id) ON DELETE CASCADE,
+    scheduled_at TIMESTAMP NOT NULL,
+    invoice_id UUID UNIQUE NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    tenant_id TEXT NOT NULL DEFAULT current_tenant(),
+    PRIMARY KEY (template_id, scheduled_at)
+);
+
+INSERT INTO invoices (id, service_name, amount, status, date, tenant_id) VALUES
+    ('dda97bce-ac2a-4431-9c7b-3b6bcdfe8a23', 'DMP', 120.30, 'PAID', '2025-03-18 12:34:56', 'default'),
+    ('dc874c3f-2773-413e-a3c8-e9f24b04079c', 'SSP', 230.50, 'PENDING', '2024-03-18 12:34:56', 'default'),
+    ('550e8400-e29b-41d4-a716-446655440000', 'DMP', 150.75, 'UNPAID', '2024-04-01 09:00:00', 'default'),
+    ('6ba7b810-9dad-11d1-80b4-00c04fd430c8', 'SSP', 300.25, 'PAID', '2024-03-15 15:30:00', 'default'),
+    ('6ba7b811-9dad-11d1-80b4-00c04fd430c8', 'DMP', 175.90, 'PENDING', '2024-03-20 11:45:00', 'default'),
+    ('6ba7b812-9dad-11d1-80b4-00c04fd430c8', 'SSP', 450.00, 'PAID', '2024-03-25 14:20:00', 'default'),
+    ('6ba7b813-9dad-11d1-80b4-00c04fd430c8', 'DMP', 200.80, 'UNPAID', '2024-04-05 10:15:00', 'default'),
+    ('6ba7b814-9dad-11d1-80b4-00c04fd430c8', 'SSP', 275.60, 'PENDING', '2024-03-28 16:40:00', 'default'),
+    ('6ba7b815-9dad-11d1-80b4-00c04fd430c8', 'DMP', 180.45, 'PAID', '2024-03-22 13:50:00', 'default'),
+    ('6ba7b816-9dad-11d1-80b4-00c04fd430c8', 'SSP', 325.90, 'UNPAID', '2024-04-02 08:30:00', 'default');
+
+ALTER TABLE invoices ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON invoices USING (tenant_id = current_tenant());
+
+ALTER TABLE invoice_chain_tombstones ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON invoice_chain_tombstones USING (tenant_id = current_tenant());
+
+ALTER TABLE invoice_events ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON invoice_events USING (tenant_id = current_tenant());
+
+ALTER TABLE api_keys ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON api_keys USING (tenant_id = current_tenant());
+
+ALTER TABLE invoice_attachments ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON invoice_attachments
+    USING (EXISTS (SELECT 1 FROM invoices WHERE invoices.id = invoice_attachments.invoice_id));
+
+ALTER TABLE invoice_lines ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON invoice_lines
+    USING (EXISTS (SELECT 1 FROM invoices WHERE invoices.id = invoice_lines.invoice_id));
+
+ALTER TABLE dunning_notices ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON dunning_notices
+    USING (EXISTS (SELECT 1 FROM invoices WHERE invoices.id = dunning_notices.invoice_id));
+
+ALTER TABLE outbox_events ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON outbox_events USING (tenant_id = current_tenant());
+
+ALTER TABLE webhook_subscriptions ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON webhook_subscriptions USING (tenant_id = current_tenant());
+
+ALTER TABLE webhook_deliveries ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON webhook_deliveries USING (tenant_id = current_tenant());
+
+ALTER TABLE recurring_templates ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON recurring_templates USING (tenant_id = current_tenant());
+
+ALTER TABLE recurring_runs ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON recurring_runs USING (tenant_id = current_tenant());
+
+ALTER TABLE usage_events ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON usage_events USING (tenant_id = current_tenant());
+
+ALTER TABLE price_plans ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON price_plans USING (tenant_id = current_tenant());
+
+ALTER TABLE billing_periods ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON billing_periods USING (tenant_id = current_tenant());
+
+CREATE ROLE invoice_tenant NOLOGIN;
+GRANT USAGE ON SCHEMA public TO invoice_tenant;
+GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO invoice_tenant;
+GRANT USAGE, SELECT ON ALL SEQUENCES IN SCHEMA public TO invoice_tenant;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT SELECT, INSERT, UPDATE, DELETE ON TABLES TO invoice_tenant;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT USAGE, SELECT ON SEQUENCES TO invoice_tenant;
+
+CREATE ROLE invoice_system NOLOGIN BYPASSRLS;
+GRANT USAGE ON SCHEMA public TO invoice_system;
+GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO invoice_system;
+GRANT USAGE, SELECT ON ALL SEQUENCES IN SCHEMA public TO invoice_system;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT SELECT, INSERT, UPDATE, DELETE ON TABLES TO invoice_system;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT USAGE, SELECT ON SEQUENCES TO invoice_system;
@@ -0,0 +1,276 @@
+package apikey
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"invoice-api/pkg/auth"
+	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/requestcontext"
+	"invoice-api/pkg/tracing"
+)
+
+type Handler struct {
+	server     *fiber.App
+	validator  *validator.Validate
+	repository Repository
+	authorizer *auth.Authorizer
+}
+
+func NewHandler(server *fiber.App, validator *validator.Validate, repository Repository, authorizer *auth.Authorizer) *Handler {
+	return &Handler{
+		server:     server,
+		validator:  validator,
+		repository: repository,
+		authorizer: authorizer,
+	}
+}
+
+func (h *Handler) RegisterRoutes() {
+	h.server.Post("/api-keys", h.authorizer.Require(PermissionWrite), h.CreateKey)
+	h.server.Get("/api-keys", h.authorizer.Require(PermissionRead), h.GetKeys)
+	h.server.Get("/api-keys/:id", h.authorizer.Require(PermissionRead), h.GetKeyById)
+	h.server.Post("/api-keys/:id/rotate", h.authorizer.Require(PermissionWrite), h.RotateKey)
+	h.server.Delete("/api-keys/:id", h.authorizer.Require(PermissionWrite), h.RevokeKey)
+}
+
+func (h *Handler) CreateKey(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "CreateKey"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "apikey.Handler.CreateKey")()
+
+	var reqBody CreateKeyRequest
+	if err := ctx.BodyParser(&reqBody); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid request body",
+			Severity: zap.WarnLevel,
+		}
+	}
+
+	if err := h.validator.StructCtx(ctx.UserContext(), &reqBody); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid request body",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	for _, scope := range reqBody.Scopes {
+		if !ValidScope(scope) {
+			return customError.CustomError{
+				Code:     fiber.StatusBadRequest,
+				Message:  "invalid api key scope",
+				Severity: zap.WarnLevel,
+				Fields:   []zap.Field{zap.String("scope", scope)},
+				Details:  fiber.Map{"scope": scope},
+			}
+		}
+	}
+	if err := h.authorizeScopes(ctx, reqBody.Scopes); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	if reqBody.ExpiresAt != nil && !reqBody.ExpiresAt.After(now) {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "api key expiry must be in the future",
+			Severity: zap.WarnLevel,
+		}
+	}
+
+	key, err := newKeyDTO(now)
+	if err != nil {
+		return err
+	}
+	key.Name = reqBody.Name
+	key.Scopes = reqBody.Scopes
+	if reqBody.ExpiresAt != nil {
+		expiresAt := reqBody.ExpiresAt.UTC().Truncate(time.Microsecond)
+		key.ExpiresAt = &expiresAt
+	}
+
+	if err = h.repository.CreateKey(ctx.UserContext(), key, Hash(key.Key)); err != nil {
+		return err
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	ctx.Location(fmt.Sprintf("/api-keys/%s", key.Id))
+	return ctx.Status(fiber.StatusCreated).JSON(key)
+}
+
+func (h *Handler) GetKeys(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "GetKeys"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "apikey.Handler.GetKeys")()
+
+	keys, err := h.repository.GetKeys(ctx.UserContext())
+	if err != nil {
+		return err
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	return ctx.JSON(keys)
+}
+
+func (h *Handler) GetKeyById(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "GetKeyById"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "apikey.Handler.GetKeyById")()
+
+	id, err := h.keyId(ctx)
+	if err != nil {
+		return err
+	}
+
+	key, err := h.repository.GetKeyById(ctx.UserContext(), id)
+	if err != nil {
+		return err
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	return ctx.JSON(key)
+}
+
+func (h *Handler) RotateKey(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "RotateKey"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "apikey.Handler.RotateKey")()
+
+	id, err := h.keyId(ctx)
+	if err != nil {
+		return err
+	}
+
+	var reqBody RotateKeyRequest
+	if len(ctx.Body()) > 0 {
+		if err = ctx.BodyParser(&reqBody); err != nil {
+			return customError.CustomError{
+				Code:     fiber.StatusBadRequest,
+				Message:  "invalid request body",
+				Severity: zap.WarnLevel,
+			}
+		}
+	}
+
+	if err = h.validator.StructCtx(ctx.UserContext(), &reqBody); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid request body",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	stored, err := h.repository.GetKeyById(ctx.UserContext(), id)
+	if err != nil {
+		return err
+	}
+	if err = h.authorizeScopes(ctx, stored.Scopes); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	key, err := newKeyDTO(now)
+	if err != nil {
+		return err
+	}
+
+	graceUntil := now.Add(time.Duration(reqBody.GracePeriodSeconds) * time.Second)
+	if err = h.repository.RotateKey(ctx.UserContext(), id, graceUntil, key, Hash(key.Key)); err != nil {
+		return err
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	ctx.Location(fmt.Sprintf("/api-keys/%s", key.Id))
+	return ctx.Status(fiber.StatusCreated).JSON(key)
+}
+
+func (h *Handler) RevokeKey(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "RevokeKey"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "apikey.Handler.RevokeKey")()
+
+	id, err := h.keyId(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = h.repository.RevokeKey(ctx.UserContext(), id, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// authorizeScopes rejects keys with scopes the caller does not hold, so issuing
+// or rotating a key can never widen the caller's own permissions. A write scope
+// is also held by callers that may both create and update the resource.
+func (h *Handler) authorizeScopes(ctx *fiber.Ctx, scopes []string) error {
+	for _, scope := range scopes {
+		err := h.authorizer.Check(ctx, scope)
+		if resource, action, _ := strings.Cut(scope, ":"); err != nil && action == ActionWrite {
+			if err = h.authorizer.Check(ctx, resource+":create"); err == nil {
+				err = h.authorizer.Check(ctx, resource+":update")
+			}
+		}
+		if err != nil {
+			return customError.CustomError{
+				Code:     fiber.StatusForbidden,
+				Message:  "api key scope exceeds caller permissions",
+				Severity: zap.WarnLevel,
+				Fields:   []zap.Field{zap.String("scope", scope)},
+				Details:  fiber.Map{"scope": scope},
+			}
+		}
+	}
+
+	return nil
+}
+
+func (h *Handler) keyId(ctx *fiber.Ctx) (string, error) {
+	id := ctx.Params("id")
+	if err := h.validator.VarCtx(ctx.UserContext(), id, "required,uuid4"); err != nil {
+		return "", customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid api key id",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return id, nil
+}
+
+func newKeyDTO(createdAt time.Time) (*KeyDTO, error) {
+	key, prefix, err := NewKey()
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to generate api key",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return &KeyDTO{
+		Id:        uuid.NewString(),
+		Prefix:    prefix,
+		CreatedAt: createdAt,
+		Key:       key,
+	}, nil
+}
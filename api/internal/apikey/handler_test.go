@@ -0,0 +1,329 @@
+package apikey
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	json "github.com/bytedance/sonic"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+
+	"invoice-api/pkg/auth"
+	customError "invoice-api/pkg/error"
+)
+
+const keyId = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+
+func TestHandler_CreateKey(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("happy path", func(t *testing.T) {
+		var storedHash string
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().CreateKey(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ any, key *KeyDTO, hash string) error {
+			storedHash = hash
+			return nil
+		})
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, testAuthorizer)
+		h.RegisterRoutes()
+
+		req := httptest.NewRequest(http.MethodPost, "/api-keys", strings.NewReader(`{"name":"billing batch","scopes":["invoices:read","invoices:write"]}`))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusCreated, res.StatusCode)
+
+		var key KeyDTO
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &key))
+		assert.Equal(t, "billing batch", key.Name)
+		assert.Equal(t, []string{"invoices:read", "invoices:write"}, key.Scopes)
+		assert.True(t, strings.HasPrefix(key.Key, key.Prefix+"_"))
+		assert.Equal(t, Hash(key.Key), storedHash)
+		assert.Equal(t, "/api-keys/"+key.Id, res.Header.Get(fiber.HeaderLocation))
+	})
+
+	t.Run("scopes beyond the caller", func(t *testing.T) {
+		authorizer := auth.NewAuthorizer(auth.Policy{
+			"accountant": {PermissionWrite, "invoices:read", "invoices:create", "invoices:update"},
+		})
+		tests := []struct {
+			name   string
+			scopes []string
+			body   string
+			status int
+		}{
+			{name: "role holds write", body: `{"name":"batch","scopes":["invoices:read","invoices:write"]}`, status: fiber.StatusCreated},
+			{name: "role lacks delete", body: `{"name":"batch","scopes":["invoices:delete"]}`, status: fiber.StatusForbidden},
+			{name: "role lacks resource", body: `{"name":"batch","scopes":["webhooks:read"]}`, status: fiber.StatusForbidden},
+			{name: "key holds read", scopes: []string{PermissionWrite, "invoices:read"}, body: `{"name":"batch","scopes":["invoices:read"]}`, status: fiber.StatusCreated},
+			{name: "key lacks write", scopes: []string{PermissionWrite, "invoices:read"}, body: `{"name":"batch","scopes":["invoices:write"]}`, status: fiber.StatusForbidden},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				mockRepository := NewMockRepository(mockController)
+				if test.status == fiber.StatusCreated {
+					mockRepository.EXPECT().CreateKey(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				}
+
+				server, validate := SetupServer(t)
+				server.Use(func(c *fiber.Ctx) error {
+					c.Locals(auth.ContextKeyRoles, []string{"accountant"})
+					if test.scopes != nil {
+						c.Locals(auth.ContextKeyScopes, test.scopes)
+					}
+					return c.Next()
+				})
+				NewHandler(server, validate, mockRepository, authorizer).RegisterRoutes()
+
+				req := httptest.NewRequest(http.MethodPost, "/api-keys", strings.NewReader(test.body))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+				res, err := server.Test(req, -1)
+				require.NoError(t, err)
+				assert.Equal(t, test.status, res.StatusCode)
+			})
+		}
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		for _, body := range []string{
+			`{"name":"billing batch","scopes":[]}`,
+			`{"scopes":["invoices:read"]}`,
+			`{"name":"billing batch","scopes":["webhooks:delete"]}`,
+			`{"name":"billing batch","scopes":["invoices:read"],"expiresAt":"2020-01-01T00:00:00Z"}`,
+			`{"name":`,
+		} {
+			server, validate := SetupServer(t)
+			h := NewHandler(server, validate, nil, testAuthorizer)
+			h.RegisterRoutes()
+
+			req := httptest.NewRequest(http.MethodPost, "/api-keys", strings.NewReader(body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+			res, err := server.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, fiber.StatusBadRequest, res.StatusCode, body)
+		}
+	})
+}
+
+func TestHandler_GetKeys(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	keys := []KeyDTO{{Id: keyId, Name: "billing batch", Prefix: "ik_abcd1234", Scopes: []string{"invoices:read"}, CreatedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}}
+	mockRepository := NewMockRepository(mockController)
+	mockRepository.EXPECT().GetKeys(gomock.Any()).Return(&keys, nil)
+
+	server, validate := SetupServer(t)
+	h := NewHandler(server, validate, mockRepository, testAuthorizer)
+	h.RegisterRoutes()
+
+	res, err := server.Test(httptest.NewRequest(http.MethodGet, "/api-keys", nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, res.StatusCode)
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.NotContains(t, string(body), `"key"`)
+}
+
+func TestHandler_GetKeyById(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("happy path", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetKeyById(gomock.Any(), keyId).Return(&KeyDTO{Id: keyId}, nil)
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, testAuthorizer)
+		h.RegisterRoutes()
+
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, "/api-keys/"+keyId, nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetKeyById(gomock.Any(), keyId).Return(nil, customError.CustomError{Code: fiber.StatusNotFound, Message: "api key not found"})
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, testAuthorizer)
+		h.RegisterRoutes()
+
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, "/api-keys/"+keyId, nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, "/api-keys/not-a-uuid", nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+	})
+}
+
+func TestHandler_RotateKey(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("with grace period", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetKeyById(gomock.Any(), keyId).Return(&KeyDTO{Id: keyId, Scopes: []string{"invoices:read"}}, nil)
+		mockRepository.EXPECT().RotateKey(gomock.Any(), keyId, gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ any, _ string, graceUntil time.Time, key *KeyDTO, hash string) error {
+				assert.Equal(t, time.Hour, graceUntil.Sub(key.CreatedAt))
+				assert.Equal(t, Hash(key.Key), hash)
+				key.Name = "billing batch"
+				key.Scopes = []string{"invoices:read"}
+				return nil
+			},
+		)
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, testAuthorizer)
+		h.RegisterRoutes()
+
+		req := httptest.NewRequest(http.MethodPost, "/api-keys/"+keyId+"/rotate", strings.NewReader(`{"gracePeriodSeconds":3600}`))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusCreated, res.StatusCode)
+
+		var key KeyDTO
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &key))
+		assert.NotEqual(t, keyId, key.Id)
+		assert.NotEmpty(t, key.Key)
+		assert.Equal(t, "billing batch", key.Name)
+	})
+
+	t.Run("without body", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetKeyById(gomock.Any(), keyId).Return(&KeyDTO{Id: keyId, Scopes: []string{"invoices:read"}}, nil)
+		mockRepository.EXPECT().RotateKey(gomock.Any(), keyId, gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ any, _ string, graceUntil time.Time, key *KeyDTO, _ string) error {
+				assert.Equal(t, key.CreatedAt, graceUntil)
+				return nil
+			},
+		)
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, testAuthorizer)
+		h.RegisterRoutes()
+
+		res, err := server.Test(httptest.NewRequest(http.MethodPost, "/api-keys/"+keyId+"/rotate", nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusCreated, res.StatusCode)
+	})
+
+	t.Run("scopes beyond the caller", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetKeyById(gomock.Any(), keyId).Return(&KeyDTO{Id: keyId, Scopes: []string{"invoices:delete"}}, nil)
+
+		server, validate := SetupServer(t)
+		server.Use(func(c *fiber.Ctx) error {
+			c.Locals(auth.ContextKeyRoles, []string{"accountant"})
+			return c.Next()
+		})
+		authorizer := auth.NewAuthorizer(auth.Policy{"accountant": {PermissionWrite, "invoices:read"}})
+		NewHandler(server, validate, mockRepository, authorizer).RegisterRoutes()
+
+		res, err := server.Test(httptest.NewRequest(http.MethodPost, "/api-keys/"+keyId+"/rotate", nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusForbidden, res.StatusCode)
+	})
+
+	t.Run("invalid grace period", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		req := httptest.NewRequest(http.MethodPost, "/api-keys/"+keyId+"/rotate", strings.NewReader(`{"gracePeriodSeconds":-1}`))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+	})
+}
+
+func TestHandler_RevokeKey(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("happy path", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().RevokeKey(gomock.Any(), keyId, gomock.Any()).Return(nil)
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, testAuthorizer)
+		h.RegisterRoutes()
+
+		res, err := server.Test(httptest.NewRequest(http.MethodDelete, "/api-keys/"+keyId, nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusNoContent, res.StatusCode)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().RevokeKey(gomock.Any(), keyId, gomock.Any()).Return(customError.CustomError{Code: fiber.StatusNotFound, Message: "api key not found"})
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, testAuthorizer)
+		h.RegisterRoutes()
+
+		res, err := server.Test(httptest.NewRequest(http.MethodDelete, "/api-keys/"+keyId, nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, res.StatusCode)
+	})
+}
+
+var testAuthorizer = auth.NewAuthorizer(auth.Policy{"admin": {auth.AllPermissions}})
+
+func SetupServer(t *testing.T) (*fiber.App, *validator.Validate) {
+	server := fiber.New(fiber.Config{
+		JSONDecoder:           json.Unmarshal,
+		JSONEncoder:           json.Marshal,
+		ErrorHandler:          customError.ErrorHandler,
+		DisableStartupMessage: true,
+	})
+
+	log, _ := zap.NewProduction()
+	defer func(log *zap.Logger) {
+		err := log.Sync()
+		if err != nil {
+			assert.FailNow(t, err.Error())
+		}
+	}(log)
+
+	server.Use(func(c *fiber.Ctx) error {
+		c.Locals("log", log)
+		c.Locals(auth.ContextKeyRoles, []string{"admin"})
+		return c.Next()
+	})
+
+	return server, validator.New()
+}
@@ -0,0 +1,68 @@
+package apikey
+
+import (
+	"context"
+	"time"
+
+	"invoice-api/pkg/metrics"
+	"invoice-api/pkg/tracing"
+)
+
+const repositoryName = "apikey"
+
+type InstrumentedRepository struct {
+	repository Repository
+}
+
+func NewInstrumentedRepository(repository Repository) *InstrumentedRepository {
+	return &InstrumentedRepository{repository: repository}
+}
+
+func (r *InstrumentedRepository) CreateKey(ctx context.Context, key *KeyDTO, hash string) (err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "CreateKey")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "CreateKey", time.Now(), &err)
+	return r.repository.CreateKey(ctx, key, hash)
+}
+
+func (r *InstrumentedRepository) GetKeys(ctx context.Context) (_ *[]KeyDTO, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "GetKeys")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "GetKeys", time.Now(), &err)
+	return r.repository.GetKeys(ctx)
+}
+
+func (r *InstrumentedRepository) GetKeyById(ctx context.Context, id string) (_ *KeyDTO, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "GetKeyById")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "GetKeyById", time.Now(), &err)
+	return r.repository.GetKeyById(ctx, id)
+}
+
+func (r *InstrumentedRepository) GetKeyByPrefix(ctx context.Context, prefix string) (_ *StoredKey, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "GetKeyByPrefix")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "GetKeyByPrefix", time.Now(), &err)
+	return r.repository.GetKeyByPrefix(ctx, prefix)
+}
+
+func (r *InstrumentedRepository) RotateKey(ctx context.Context, id string, graceUntil time.Time, key *KeyDTO, hash string) (err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "RotateKey")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "RotateKey", time.Now(), &err)
+	return r.repository.RotateKey(ctx, id, graceUntil, key, hash)
+}
+
+func (r *InstrumentedRepository) RevokeKey(ctx context.Context, id string, revokedAt time.Time) (err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "RevokeKey")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "RevokeKey", time.Now(), &err)
+	return r.repository.RevokeKey(ctx, id, revokedAt)
+}
+
+func (r *InstrumentedRepository) TouchKey(ctx context.Context, id string, usedAt time.Time) (err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "TouchKey")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "TouchKey", time.Now(), &err)
+	return r.repository.TouchKey(ctx, id, usedAt)
+}
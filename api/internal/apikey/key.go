@@ -0,0 +1,85 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"slices"
+	"strings"
+
+	"invoice-api/internal/invoice"
+	"invoice-api/pkg/auth"
+)
+
+const (
+	keyPrefix = "ik_"
+
+	ActionRead  = auth.ActionRead
+	ActionWrite = auth.ActionWrite
+)
+
+var resources = []string{
+	"invoices",
+	"documents",
+	"reports",
+	"webhooks",
+	"recurring-invoices",
+	"usage",
+	"billing-periods",
+	"price-plans",
+	"api-keys",
+}
+
+// namedScopes grant actions that a write scope does not include.
+var namedScopes = []string{
+	invoice.PermissionMarkPaid,
+	invoice.PermissionDelete,
+	invoice.PermissionRestore,
+}
+
+func NewKey() (string, string, error) {
+	prefix := make([]byte, 4)
+	if _, err := rand.Read(prefix); err != nil {
+		return "", "", err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+
+	visiblePrefix := keyPrefix + hex.EncodeToString(prefix)
+	return visiblePrefix + "_" + hex.EncodeToString(secret), visiblePrefix, nil
+}
+
+func Prefix(key string) (string, bool) {
+	if !strings.HasPrefix(key, keyPrefix) {
+		return "", false
+	}
+
+	prefix, secret, ok := strings.Cut(key[len(keyPrefix):], "_")
+	if !ok || prefix == "" || secret == "" {
+		return "", false
+	}
+
+	return keyPrefix + prefix, true
+}
+
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func Matches(key, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(Hash(key)), []byte(hash)) == 1
+}
+
+func ValidScope(scope string) bool {
+	resource, action, ok := strings.Cut(scope, ":")
+	if ok && slices.Contains(resources, resource) && (action == ActionRead || action == ActionWrite) {
+		return true
+	}
+
+	return slices.Contains(namedScopes, scope)
+}
@@ -0,0 +1,59 @@
+package apikey
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewKey(t *testing.T) {
+	key, prefix, err := NewKey()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key, prefix+"_"))
+	assert.Len(t, prefix, len(keyPrefix)+8)
+
+	parsed, ok := Prefix(key)
+	assert.True(t, ok)
+	assert.Equal(t, prefix, parsed)
+
+	assert.True(t, Matches(key, Hash(key)))
+	assert.False(t, Matches(key+"x", Hash(key)))
+
+	other, _, err := NewKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, key, other)
+}
+
+func TestPrefix(t *testing.T) {
+	tests := []struct {
+		key    string
+		prefix string
+		ok     bool
+	}{
+		{key: "ik_abcd1234_secret", prefix: "ik_abcd1234", ok: true},
+		{key: "ik_abcd1234", ok: false},
+		{key: "ik__secret", ok: false},
+		{key: "ik_abcd1234_", ok: false},
+		{key: "whsec_abcd1234_secret", ok: false},
+		{key: "", ok: false},
+	}
+
+	for _, test := range tests {
+		prefix, ok := Prefix(test.key)
+		assert.Equal(t, test.ok, ok, test.key)
+		assert.Equal(t, test.prefix, prefix, test.key)
+	}
+}
+
+func TestValidScope(t *testing.T) {
+	assert.True(t, ValidScope("invoices:read"))
+	assert.True(t, ValidScope("invoices:write"))
+	assert.True(t, ValidScope("billing-periods:write"))
+	assert.False(t, ValidScope("invoices"))
+	assert.True(t, ValidScope("invoices:delete"))
+	assert.True(t, ValidScope("invoices:mark-paid"))
+	assert.False(t, ValidScope("webhooks:delete"))
+	assert.False(t, ValidScope("unknown:read"))
+}
@@ -0,0 +1,81 @@
+package apikey
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+
+	"invoice-api/pkg/auth"
+	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/requestcontext"
+	"invoice-api/pkg/tenant"
+)
+
+const (
+	authorizationScheme = "ApiKey"
+	touchInterval       = time.Minute
+)
+
+func NewMiddleware(repository Repository) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		scheme, key, _ := strings.Cut(ctx.Get(fiber.HeaderAuthorization), " ")
+		if !strings.EqualFold(scheme, authorizationScheme) {
+			return ctx.Next()
+		}
+
+		stored, err := authenticate(ctx, repository, key)
+		if err != nil {
+			ctx.Set(fiber.HeaderWWWAuthenticate, authorizationScheme)
+			return err
+		}
+
+		now := time.Now().UTC()
+		if stored.LastUsedAt == nil || now.Sub(*stored.LastUsedAt) >= touchInterval {
+			if err = repository.TouchKey(requestcontext.WithTenant(ctx.UserContext(), stored.TenantId), stored.Id, now); err != nil {
+				ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Warn("failed to update api key usage", zap.Error(err))
+			}
+		}
+
+		subject := "api-key:" + stored.Prefix
+		log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger).With(zap.String("subject", subject))
+		ctx.Locals(customError.ContextKeyLog, log)
+		ctx.Locals(auth.ContextKeySubject, subject)
+		ctx.Locals(auth.ContextKeyScopes, stored.Scopes)
+		userContext := requestcontext.WithActor(ctx.UserContext(), subject)
+		ctx.SetUserContext(requestcontext.WithTenant(userContext, stored.TenantId))
+		return ctx.Next()
+	}
+}
+
+func authenticate(ctx *fiber.Ctx, repository Repository, key string) (*StoredKey, error) {
+	invalid := customError.CustomError{
+		Code:     fiber.StatusUnauthorized,
+		Message:  "invalid api key",
+		Severity: zap.WarnLevel,
+	}
+
+	prefix, ok := Prefix(strings.TrimSpace(key))
+	if !ok {
+		return nil, invalid
+	}
+
+	// The tenant is not known until the key is found, so the lookup runs
+	// across tenants.
+	stored, err := repository.GetKeyByPrefix(tenant.WithSystem(ctx.UserContext()), prefix)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil || !Matches(strings.TrimSpace(key), stored.Hash) {
+		return nil, invalid
+	}
+
+	now := time.Now().UTC()
+	if stored.RevokedAt != nil || (stored.ExpiresAt != nil && !now.Before(*stored.ExpiresAt)) {
+		invalid.Fields = []zap.Field{zap.String("prefix", prefix)}
+		return nil, invalid
+	}
+
+	return stored, nil
+}
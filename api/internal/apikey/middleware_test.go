@@ -0,0 +1,212 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+
+	"invoice-api/internal/invoice"
+	"invoice-api/pkg/auth"
+	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/requestcontext"
+	"invoice-api/pkg/tenant"
+)
+
+func TestNewMiddleware(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	key, prefix, err := NewKey()
+	require.NoError(t, err)
+	past := time.Now().UTC().Add(-time.Hour)
+	recent := time.Now().UTC().Add(-time.Second)
+	stored := func(modify func(key *StoredKey)) *StoredKey {
+		storedKey := &StoredKey{
+			KeyDTO: KeyDTO{
+				Id:     keyId,
+				Name:   "billing batch",
+				Prefix: prefix,
+				Scopes: []string{"invoices:read"},
+			},
+			Hash:     Hash(key),
+			TenantId: "acme",
+		}
+		if modify != nil {
+			modify(storedKey)
+		}
+		return storedKey
+	}
+
+	tests := []struct {
+		name          string
+		method        string
+		authorization string
+		setup         func(repository *MockRepository)
+		status        int
+	}{
+		{
+			name:          "valid key",
+			method:        http.MethodGet,
+			authorization: "ApiKey " + key,
+			setup: func(repository *MockRepository) {
+				repository.EXPECT().GetKeyByPrefix(gomock.Any(), prefix).DoAndReturn(func(ctx context.Context, _ string) (*StoredKey, error) {
+					assert.True(t, tenant.IsSystem(ctx))
+					return stored(nil), nil
+				})
+				repository.EXPECT().TouchKey(gomock.Any(), keyId, gomock.Any()).DoAndReturn(func(ctx context.Context, _ string, _ time.Time) error {
+					assert.Equal(t, "acme", requestcontext.Tenant(ctx))
+					return nil
+				})
+			},
+			status: fiber.StatusOK,
+		},
+		{
+			name:          "recently used key is not touched",
+			method:        http.MethodGet,
+			authorization: "ApiKey " + key,
+			setup: func(repository *MockRepository) {
+				repository.EXPECT().GetKeyByPrefix(gomock.Any(), prefix).Return(stored(func(key *StoredKey) { key.LastUsedAt = &recent }), nil)
+			},
+			status: fiber.StatusOK,
+		},
+		{
+			name:          "failed usage update does not fail the request",
+			method:        http.MethodGet,
+			authorization: "ApiKey " + key,
+			setup: func(repository *MockRepository) {
+				repository.EXPECT().GetKeyByPrefix(gomock.Any(), prefix).Return(stored(nil), nil)
+				repository.EXPECT().TouchKey(gomock.Any(), keyId, gomock.Any()).Return(errors.New("database down"))
+			},
+			status: fiber.StatusOK,
+		},
+		{
+			name:          "missing scope",
+			method:        http.MethodPost,
+			authorization: "ApiKey " + key,
+			setup: func(repository *MockRepository) {
+				repository.EXPECT().GetKeyByPrefix(gomock.Any(), prefix).Return(stored(func(key *StoredKey) { key.LastUsedAt = &recent }), nil)
+			},
+			status: fiber.StatusForbidden,
+		},
+		{
+			name:          "write scope",
+			method:        http.MethodPost,
+			authorization: "ApiKey " + key,
+			setup: func(repository *MockRepository) {
+				repository.EXPECT().GetKeyByPrefix(gomock.Any(), prefix).Return(stored(func(key *StoredKey) {
+					key.Scopes = []string{"invoices:write"}
+					key.LastUsedAt = &recent
+				}), nil)
+			},
+			status: fiber.StatusOK,
+		},
+		{
+			name:          "unknown key",
+			method:        http.MethodGet,
+			authorization: "ApiKey " + key,
+			setup: func(repository *MockRepository) {
+				repository.EXPECT().GetKeyByPrefix(gomock.Any(), prefix).Return(nil, nil)
+			},
+			status: fiber.StatusUnauthorized,
+		},
+		{
+			name:          "wrong secret",
+			method:        http.MethodGet,
+			authorization: "ApiKey " + prefix + "_0000",
+			setup: func(repository *MockRepository) {
+				repository.EXPECT().GetKeyByPrefix(gomock.Any(), prefix).Return(stored(nil), nil)
+			},
+			status: fiber.StatusUnauthorized,
+		},
+		{
+			name:          "revoked key",
+			method:        http.MethodGet,
+			authorization: "ApiKey " + key,
+			setup: func(repository *MockRepository) {
+				repository.EXPECT().GetKeyByPrefix(gomock.Any(), prefix).Return(stored(func(key *StoredKey) { key.RevokedAt = &past }), nil)
+			},
+			status: fiber.StatusUnauthorized,
+		},
+		{
+			name:          "expired key",
+			method:        http.MethodGet,
+			authorization: "ApiKey " + key,
+			setup: func(repository *MockRepository) {
+				repository.EXPECT().GetKeyByPrefix(gomock.Any(), prefix).Return(stored(func(key *StoredKey) { key.ExpiresAt = &past }), nil)
+			},
+			status: fiber.StatusUnauthorized,
+		},
+		{
+			name:          "malformed key",
+			method:        http.MethodGet,
+			authorization: "ApiKey not-a-key",
+			status:        fiber.StatusUnauthorized,
+		},
+		{
+			name:          "other authorization scheme is passed through",
+			method:        http.MethodGet,
+			authorization: "Bearer token",
+			status:        fiber.StatusOK,
+		},
+		{
+			name:   "without authorization",
+			method: http.MethodGet,
+			status: fiber.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepository := NewMockRepository(mockController)
+			if test.setup != nil {
+				test.setup(mockRepository)
+			}
+
+			server := fiber.New(fiber.Config{
+				ErrorHandler:          customError.ErrorHandler,
+				DisableStartupMessage: true,
+			})
+			server.Use(func(ctx *fiber.Ctx) error {
+				ctx.Locals(customError.ContextKeyLog, zap.NewNop())
+				ctx.Locals(auth.ContextKeyRoles, []string{"viewer"})
+				return ctx.Next()
+			})
+			server.Use(NewMiddleware(mockRepository))
+			handler := func(ctx *fiber.Ctx) error {
+				ctx.Set("X-Subject", auth.Subject(ctx))
+				ctx.Set("X-Actor", requestcontext.Actor(ctx.UserContext()))
+				ctx.Set("X-Tenant", requestcontext.Tenant(ctx.UserContext()))
+				return ctx.SendStatus(fiber.StatusOK)
+			}
+			authorizer := auth.NewAuthorizer(auth.Policy{"viewer": {invoice.PermissionRead}})
+			server.Get("/invoices", authorizer.Require(invoice.PermissionRead), handler)
+			server.Post("/invoices", authorizer.Require(invoice.PermissionCreate), handler)
+
+			req := httptest.NewRequest(test.method, "/invoices", nil)
+			if test.authorization != "" {
+				req.Header.Set(fiber.HeaderAuthorization, test.authorization)
+			}
+
+			res, err := server.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, test.status, res.StatusCode)
+			if test.status == fiber.StatusUnauthorized {
+				assert.Equal(t, "ApiKey", res.Header.Get(fiber.HeaderWWWAuthenticate))
+			}
+			if test.status == fiber.StatusOK && test.setup != nil {
+				assert.Equal(t, "api-key:"+prefix, res.Header.Get("X-Subject"))
+				assert.Equal(t, "api-key:"+prefix, res.Header.Get("X-Actor"))
+				assert.Equal(t, "acme", res.Header.Get("X-Tenant"))
+			}
+		})
+	}
+}
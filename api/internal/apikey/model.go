@@ -0,0 +1,33 @@
+package apikey
+
+import (
+	"time"
+)
+
+type CreateKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,required"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+type RotateKeyRequest struct {
+	GracePeriodSeconds int `json:"gracePeriodSeconds" validate:"min=0,max=604800"`
+}
+
+type KeyDTO struct {
+	Id         string     `json:"id" db:"id"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"prefix"`
+	Scopes     []string   `json:"scopes" db:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty" db:"expires_at"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty" db:"last_used_at"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty" db:"revoked_at"`
+	Key        string     `json:"key,omitempty" db:"-"`
+}
+
+type StoredKey struct {
+	KeyDTO
+	Hash     string `db:"hash"`
+	TenantId string `db:"tenant_id"`
+}
@@ -0,0 +1,6 @@
+package apikey
+
+const (
+	PermissionRead  = "api-keys:read"
+	PermissionWrite = "api-keys:write"
+)
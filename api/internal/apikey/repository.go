@@ -0,0 +1,498 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/requestcontext"
+	"invoice-api/pkg/tenant"
+	"invoice-api/pkg/tracing"
+)
+
+const keyColumns = "id, name, prefix, scopes, created_at, expires_at, last_used_at, revoked_at"
+
+type Repository interface {
+	CreateKey(ctx context.Context, key *KeyDTO, hash string) error
+	GetKeys(ctx context.Context) (*[]KeyDTO, error)
+	GetKeyById(ctx context.Context, id string) (*KeyDTO, error)
+	GetKeyByPrefix(ctx context.Context, prefix string) (*StoredKey, error)
+	RotateKey(ctx context.Context, id string, graceUntil time.Time, key *KeyDTO, hash string) error
+	RevokeKey(ctx context.Context, id string, revokedAt time.Time) error
+	TouchKey(ctx context.Context, id string, usedAt time.Time) error
+}
+
+type PgRepository struct {
+	connectionPool *pgxpool.Pool
+}
+
+func NewPgRepository(log *zap.Logger, host, port, username, password, database string) *PgRepository {
+	credentials := fmt.Sprintf("user=%s password=%s host=%s port=%s dbname=%s sslmode=disable", username, password, host, port, database)
+	pgConfig, err := pgxpool.ParseConfig(credentials)
+	if err != nil {
+		log.Fatal("failed to parse database config", zap.Error(err))
+	}
+	pgConfig.AfterConnect = tenant.AfterConnect
+	pgConfig.ConnConfig.Tracer = tracing.QueryTracer{}
+
+	var pgConnectionPool *pgxpool.Pool
+	pgConnectionPool, err = pgxpool.NewWithConfig(context.Background(), pgConfig)
+	if err != nil {
+		log.Fatal("failed to connect database", zap.Error(err))
+	}
+
+	var connection *pgxpool.Conn
+	connection, err = pgConnectionPool.Acquire(context.Background())
+	if err != nil {
+		log.Fatal("failed to acquire connection", zap.Error(err))
+	}
+	defer connection.Release()
+
+	err = connection.Ping(context.Background())
+	if err != nil {
+		log.Fatal("failed to ping database", zap.Error(err))
+	}
+
+	return &PgRepository{
+		connectionPool: pgConnectionPool,
+	}
+}
+
+func (r *PgRepository) Stat() *pgxpool.Stat {
+	return r.connectionPool.Stat()
+}
+
+func (r *PgRepository) Ping(ctx context.Context) error {
+	return r.connectionPool.Ping(ctx)
+}
+
+func (r *PgRepository) CreateKey(ctx context.Context, key *KeyDTO, hash string) error {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	if _, err = tx.Exec(
+		ctx,
+		"insert into api_keys ("+keyColumns+", hash) values ($1, $2, $3, $4, $5, $6, $7, $8, $9)",
+		key.Id,
+		key.Name,
+		key.Prefix,
+		key.Scopes,
+		key.CreatedAt,
+		key.ExpiresAt,
+		key.LastUsedAt,
+		key.RevokedAt,
+		hash,
+	); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to create api key",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return nil
+}
+
+func (r *PgRepository) GetKeys(ctx context.Context) (*[]KeyDTO, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	var rows pgx.Rows
+	rows, err = tx.Query(ctx, "select "+keyColumns+" from api_keys order by created_at")
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get api keys",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var keys []KeyDTO
+	keys, err = pgx.CollectRows(rows, pgx.RowToStructByPos[KeyDTO])
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect api keys",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return &keys, nil
+}
+
+func (r *PgRepository) GetKeyById(ctx context.Context, id string) (*KeyDTO, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	var rows pgx.Rows
+	rows, err = tx.Query(ctx, "select "+keyColumns+" from api_keys where id = $1", id)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get api key",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	key, err := pgx.CollectOneRow(rows, pgx.RowToStructByPos[KeyDTO])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, customError.CustomError{
+				Code:     fiber.StatusNotFound,
+				Message:  "api key not found",
+				Severity: zap.WarnLevel,
+			}
+		}
+
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect api key",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return &key, nil
+}
+
+func (r *PgRepository) GetKeyByPrefix(ctx context.Context, prefix string) (*StoredKey, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	var rows pgx.Rows
+	rows, err = tx.Query(ctx, "select "+keyColumns+", hash, tenant_id from api_keys where prefix = $1", prefix)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get api key",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	key, err := pgx.CollectOneRow(rows, pgx.RowToStructByPos[StoredKey])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect api key",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return &key, nil
+}
+
+func (r *PgRepository) RotateKey(ctx context.Context, id string, graceUntil time.Time, key *KeyDTO, hash string) error {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	err = tx.QueryRow(
+		ctx,
+		`update api_keys set expires_at = least(coalesce(expires_at, $2), $2)
+		where id = $1 and revoked_at is null and (expires_at is null or expires_at > $3)
+		returning name, scopes`,
+		id,
+		graceUntil,
+		key.CreatedAt,
+	).Scan(&key.Name, &key.Scopes)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return customError.CustomError{
+				Code:     fiber.StatusNotFound,
+				Message:  "api key not found",
+				Severity: zap.WarnLevel,
+			}
+		}
+
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to rotate api key",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if _, err = tx.Exec(
+		ctx,
+		"insert into api_keys ("+keyColumns+", hash) values ($1, $2, $3, $4, $5, $6, $7, $8, $9)",
+		key.Id,
+		key.Name,
+		key.Prefix,
+		key.Scopes,
+		key.CreatedAt,
+		key.ExpiresAt,
+		key.LastUsedAt,
+		key.RevokedAt,
+		hash,
+	); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to create api key",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return nil
+}
+
+func (r *PgRepository) RevokeKey(ctx context.Context, id string, revokedAt time.Time) error {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	tag, err := tx.Exec(ctx, "update api_keys set revoked_at = $2 where id = $1 and revoked_at is null", id, revokedAt)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to revoke api key",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if tag.RowsAffected() == 0 {
+		return customError.CustomError{
+			Code:     fiber.StatusNotFound,
+			Message:  "api key not found",
+			Severity: zap.WarnLevel,
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return nil
+}
+
+func (r *PgRepository) TouchKey(ctx context.Context, id string, usedAt time.Time) error {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	if _, err = tx.Exec(ctx, "update api_keys set last_used_at = $2 where id = $1", id, usedAt); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to update api key usage",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return nil
+}
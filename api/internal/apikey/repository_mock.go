@@ -0,0 +1,143 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/apikey/repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/apikey/repository.go -destination=internal/apikey/repository_mock.go -package=apikey
+//
+
+// Package apikey is a generated GoMock package.
+package apikey
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateKey mocks base method.
+func (m *MockRepository) CreateKey(ctx context.Context, key *KeyDTO, hash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateKey", ctx, key, hash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateKey indicates an expected call of CreateKey.
+func (mr *MockRepositoryMockRecorder) CreateKey(ctx, key, hash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateKey", reflect.TypeOf((*MockRepository)(nil).CreateKey), ctx, key, hash)
+}
+
+// GetKeyById mocks base method.
+func (m *MockRepository) GetKeyById(ctx context.Context, id string) (*KeyDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetKeyById", ctx, id)
+	ret0, _ := ret[0].(*KeyDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetKeyById indicates an expected call of GetKeyById.
+func (mr *MockRepositoryMockRecorder) GetKeyById(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKeyById", reflect.TypeOf((*MockRepository)(nil).GetKeyById), ctx, id)
+}
+
+// GetKeyByPrefix mocks base method.
+func (m *MockRepository) GetKeyByPrefix(ctx context.Context, prefix string) (*StoredKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetKeyByPrefix", ctx, prefix)
+	ret0, _ := ret[0].(*StoredKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetKeyByPrefix indicates an expected call of GetKeyByPrefix.
+func (mr *MockRepositoryMockRecorder) GetKeyByPrefix(ctx, prefix any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKeyByPrefix", reflect.TypeOf((*MockRepository)(nil).GetKeyByPrefix), ctx, prefix)
+}
+
+// GetKeys mocks base method.
+func (m *MockRepository) GetKeys(ctx context.Context) (*[]KeyDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetKeys", ctx)
+	ret0, _ := ret[0].(*[]KeyDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetKeys indicates an expected call of GetKeys.
+func (mr *MockRepositoryMockRecorder) GetKeys(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKeys", reflect.TypeOf((*MockRepository)(nil).GetKeys), ctx)
+}
+
+// RevokeKey mocks base method.
+func (m *MockRepository) RevokeKey(ctx context.Context, id string, revokedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeKey", ctx, id, revokedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeKey indicates an expected call of RevokeKey.
+func (mr *MockRepositoryMockRecorder) RevokeKey(ctx, id, revokedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeKey", reflect.TypeOf((*MockRepository)(nil).RevokeKey), ctx, id, revokedAt)
+}
+
+// RotateKey mocks base method.
+func (m *MockRepository) RotateKey(ctx context.Context, id string, graceUntil time.Time, key *KeyDTO, hash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateKey", ctx, id, graceUntil, key, hash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RotateKey indicates an expected call of RotateKey.
+func (mr *MockRepositoryMockRecorder) RotateKey(ctx, id, graceUntil, key, hash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateKey", reflect.TypeOf((*MockRepository)(nil).RotateKey), ctx, id, graceUntil, key, hash)
+}
+
+// TouchKey mocks base method.
+func (m *MockRepository) TouchKey(ctx context.Context, id string, usedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchKey", ctx, id, usedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchKey indicates an expected call of TouchKey.
+func (mr *MockRepositoryMockRecorder) TouchKey(ctx, id, usedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchKey", reflect.TypeOf((*MockRepository)(nil).TouchKey), ctx, id, usedAt)
+}
@@ -0,0 +1,100 @@
+package apikey
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+
+	"invoice-api/pkg/requestcontext"
+	"invoice-api/pkg/tenant"
+)
+
+var tenantContext = requestcontext.WithTenant(context.Background(), tenant.DefaultTenant)
+
+func TestPgRepository_Keys(t *testing.T) {
+	pgContainer := setupContainer(t)
+	pgHost, err := pgContainer.Host(context.Background())
+	require.NoError(t, err)
+
+	pgPort, err := pgContainer.MappedPort(context.Background(), "5432/tcp")
+	require.NoError(t, err)
+
+	pgRepository := NewPgRepository(nil, pgHost, pgPort.Port(), "root", "root", "test")
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	secret, prefix, err := NewKey()
+	require.NoError(t, err)
+	key := &KeyDTO{
+		Id:        uuid.NewString(),
+		Name:      "billing batch",
+		Prefix:    prefix,
+		Scopes:    []string{"invoices:read", "invoices:write"},
+		CreatedAt: now,
+	}
+	require.NoError(t, pgRepository.CreateKey(tenantContext, key, Hash(secret)))
+
+	stored, err := pgRepository.GetKeyByPrefix(tenantContext, prefix)
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, *key, stored.KeyDTO)
+	assert.True(t, Matches(secret, stored.Hash))
+
+	missing, err := pgRepository.GetKeyByPrefix(tenantContext, "ik_00000000")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	require.NoError(t, pgRepository.TouchKey(tenantContext, key.Id, now))
+	fetched, err := pgRepository.GetKeyById(tenantContext, key.Id)
+	require.NoError(t, err)
+	assert.Equal(t, now, *fetched.LastUsedAt)
+
+	_, rotatedPrefix, err := NewKey()
+	require.NoError(t, err)
+	rotated := &KeyDTO{Id: uuid.NewString(), Prefix: rotatedPrefix, CreatedAt: now}
+	graceUntil := now.Add(time.Hour)
+	require.NoError(t, pgRepository.RotateKey(tenantContext, key.Id, graceUntil, rotated, "hash"))
+	assert.Equal(t, key.Name, rotated.Name)
+	assert.Equal(t, key.Scopes, rotated.Scopes)
+
+	fetched, err = pgRepository.GetKeyById(tenantContext, key.Id)
+	require.NoError(t, err)
+	assert.Equal(t, graceUntil, *fetched.ExpiresAt)
+
+	keys, err := pgRepository.GetKeys(tenantContext)
+	require.NoError(t, err)
+	assert.Len(t, *keys, 2)
+
+	require.NoError(t, pgRepository.RevokeKey(tenantContext, key.Id, now))
+	assert.Error(t, pgRepository.RevokeKey(tenantContext, key.Id, now))
+	assert.Error(t, pgRepository.RotateKey(tenantContext, key.Id, now, &KeyDTO{Id: uuid.NewString(), Prefix: "ik_11111111", CreatedAt: now}, "hash"))
+
+	_, err = pgRepository.GetKeyById(tenantContext, uuid.NewString())
+	assert.Error(t, err)
+}
+
+func setupContainer(t *testing.T) *postgres.PostgresContainer {
+	ctx := context.Background()
+	postgresContainer, err := postgres.Run(
+		ctx,
+		"postgres",
+		postgres.WithDatabase("test"),
+		postgres.WithUsername("root"),
+		postgres.WithPassword("root"),
+		postgres.BasicWaitStrategies(),
+		postgres.WithSQLDriver("pgx"),
+		postgres.WithInitScripts("../../.scripts/init.sql"),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		err = postgresContainer.Terminate(ctx)
+		require.NoError(t, err)
+	})
+
+	return postgresContainer
+}
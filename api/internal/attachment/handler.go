@@ -0,0 +1,355 @@
+package attachment
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"invoice-api/internal/invoice"
+	"invoice-api/pkg/auth"
+	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/requestcontext"
+	"invoice-api/pkg/tracing"
+)
+
+const (
+	HeaderChecksum = "X-Checksum-Sha256"
+	sniffLength    = 512
+)
+
+type Handler struct {
+	server              *fiber.App
+	validator           *validator.Validate
+	repository          Repository
+	storage             Storage
+	maxSize             int64
+	allowedContentTypes []string
+	authorizer          *auth.Authorizer
+}
+
+func NewHandler(
+	server *fiber.App,
+	validator *validator.Validate,
+	repository Repository,
+	storage Storage,
+	maxSize int64,
+	allowedContentTypes []string,
+	authorizer *auth.Authorizer,
+) *Handler {
+	return &Handler{
+		server:              server,
+		validator:           validator,
+		repository:          repository,
+		storage:             storage,
+		maxSize:             maxSize,
+		allowedContentTypes: allowedContentTypes,
+		authorizer:          authorizer,
+	}
+}
+
+func (h *Handler) RegisterRoutes() {
+	h.server.Post("/invoices/:id/attachments", h.authorizer.Require(invoice.PermissionUpdate), h.CreateAttachment)
+	h.server.Get("/invoices/:id/attachments", h.authorizer.Require(invoice.PermissionRead), h.GetAttachments)
+	h.server.Get("/invoices/:id/attachments/:attachmentId", h.authorizer.Require(invoice.PermissionRead), h.GetAttachmentById)
+	h.server.Delete("/invoices/:id/attachments/:attachmentId", h.authorizer.Require(invoice.PermissionUpdate), h.DeleteAttachmentById)
+}
+
+func (h *Handler) CreateAttachment(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "CreateAttachment"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "attachment.Handler.CreateAttachment")()
+
+	invoiceId := ctx.Params("id")
+	if err := h.validator.VarCtx(ctx.UserContext(), invoiceId, "required,uuid4"); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid invoice id",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "missing file",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if fileHeader.Size == 0 {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "file is empty",
+			Severity: zap.WarnLevel,
+		}
+	}
+
+	if fileHeader.Size > h.maxSize {
+		return customError.CustomError{
+			Code:     fiber.StatusRequestEntityTooLarge,
+			Message:  fmt.Sprintf("file exceeds the maximum size of %d bytes", h.maxSize),
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Int64("size", fileHeader.Size)},
+		}
+	}
+
+	var file multipart.File
+	file, err = fileHeader.Open()
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "failed to read file",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer file.Close()
+
+	head := make([]byte, sniffLength)
+	var n int
+	n, err = io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "failed to read file",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	contentType := sniffContentType(head[:n], fileHeader.Filename)
+	if !slices.Contains(h.allowedContentTypes, contentType) {
+		return customError.CustomError{
+			Code:     fiber.StatusUnsupportedMediaType,
+			Message:  fmt.Sprintf("content type %s is not allowed", contentType),
+			Severity: zap.WarnLevel,
+		}
+	}
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, io.MultiReader(bytes.NewReader(head[:n]), file)); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "failed to read file",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	checksum := hex.EncodeToString(hash.Sum(nil))
+
+	if expected := strings.ToLower(ctx.Get(HeaderChecksum, ctx.FormValue("checksum"))); expected != "" && expected != checksum {
+		return customError.CustomError{
+			Code:     fiber.StatusUnprocessableEntity,
+			Message:  "checksum does not match the uploaded file",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.String("expected", expected), zap.String("actual", checksum)},
+		}
+	}
+
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to read file",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	attachment := &AttachmentDTO{
+		Id:          uuid.NewString(),
+		InvoiceId:   invoiceId,
+		FileName:    filepath.Base(fileHeader.Filename),
+		ContentType: contentType,
+		Size:        fileHeader.Size,
+		Checksum:    checksum,
+		CreatedAt:   time.Now().UTC().Truncate(time.Microsecond),
+	}
+	attachment.StorageKey = storageKey(invoiceId, attachment.Id)
+
+	if err = h.storage.Put(ctx.UserContext(), attachment.StorageKey, file, attachment.Size, contentType); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to store attachment",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if err = h.repository.CreateAttachment(ctx.UserContext(), attachment); err != nil {
+		_ = h.storage.Delete(ctx.UserContext(), attachment.StorageKey)
+		return err
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	ctx.Location(fmt.Sprintf("/invoices/%s/attachments/%s", invoiceId, attachment.Id))
+	return ctx.Status(fiber.StatusCreated).JSON(attachment)
+}
+
+func (h *Handler) GetAttachments(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "GetAttachments"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "attachment.Handler.GetAttachments")()
+
+	invoiceId := ctx.Params("id")
+	if err := h.validator.VarCtx(ctx.UserContext(), invoiceId, "required,uuid4"); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid invoice id",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	attachments, err := h.repository.GetAttachments(ctx.UserContext(), invoiceId)
+	if err != nil {
+		return err
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	return ctx.JSON(attachments)
+}
+
+func (h *Handler) GetAttachmentById(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "GetAttachmentById"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "attachment.Handler.GetAttachmentById")()
+
+	invoiceId, attachmentId, err := h.attachmentParams(ctx)
+	if err != nil {
+		return err
+	}
+
+	attachment, err := h.repository.GetAttachmentById(ctx.UserContext(), invoiceId, attachmentId)
+	if err != nil {
+		return err
+	}
+
+	object, err := h.storage.Get(ctx.UserContext(), attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, ErrObjectNotFound) {
+			return customError.CustomError{
+				Code:     fiber.StatusNotFound,
+				Message:  "attachment content not found",
+				Severity: zap.ErrorLevel,
+				Fields:   []zap.Field{zap.String("key", attachment.StorageKey)},
+			}
+		}
+
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to read attachment",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer object.Close()
+
+	var content []byte
+	content, err = io.ReadAll(io.LimitReader(object, attachment.Size+1))
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to read attachment",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	sum := sha256.Sum256(content)
+	if int64(len(content)) != attachment.Size || hex.EncodeToString(sum[:]) != attachment.Checksum {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "attachment checksum mismatch",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.String("id", attachment.Id), zap.String("key", attachment.StorageKey)},
+		}
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	ctx.Set(fiber.HeaderContentType, attachment.ContentType)
+	ctx.Set(fiber.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	ctx.Set(HeaderChecksum, attachment.Checksum)
+	return ctx.Send(content)
+}
+
+func (h *Handler) DeleteAttachmentById(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "DeleteAttachmentById"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "attachment.Handler.DeleteAttachmentById")()
+
+	invoiceId, attachmentId, err := h.attachmentParams(ctx)
+	if err != nil {
+		return err
+	}
+
+	attachment, err := h.repository.GetAttachmentById(ctx.UserContext(), invoiceId, attachmentId)
+	if err != nil {
+		return err
+	}
+
+	if err = h.repository.DeleteAttachmentById(ctx.UserContext(), invoiceId, attachmentId); err != nil {
+		return err
+	}
+
+	if err = h.storage.Delete(ctx.UserContext(), attachment.StorageKey); err != nil {
+		log.Warn("failed to delete attachment content", zap.String("key", attachment.StorageKey), zap.Error(err))
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) attachmentParams(ctx *fiber.Ctx) (string, string, error) {
+	invoiceId := ctx.Params("id")
+	if err := h.validator.VarCtx(ctx.UserContext(), invoiceId, "required,uuid4"); err != nil {
+		return "", "", customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid invoice id",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	attachmentId := ctx.Params("attachmentId")
+	if err := h.validator.VarCtx(ctx.UserContext(), attachmentId, "required,uuid4"); err != nil {
+		return "", "", customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid attachment id",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return invoiceId, attachmentId, nil
+}
+
+func sniffContentType(head []byte, fileName string) string {
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if contentType == "text/plain" && strings.EqualFold(filepath.Ext(fileName), ".csv") {
+		return "text/csv"
+	}
+
+	return contentType
+}
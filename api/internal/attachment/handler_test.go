@@ -0,0 +1,483 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	json "github.com/bytedance/sonic"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+
+	"invoice-api/pkg/auth"
+	customError "invoice-api/pkg/error"
+)
+
+const testMaxSize = 1 << 10
+
+var (
+	testAllowedContentTypes = []string{"application/pdf", "text/plain", "text/csv"}
+	testPDF                 = []byte("%PDF-1.4\n1 0 obj\n<< /Type /Catalog >>\nendobj\n%%EOF\n")
+)
+
+func TestHandler_NewHandler(t *testing.T) {
+	h := NewHandler(nil, nil, nil, nil, testMaxSize, testAllowedContentTypes, testAuthorizer)
+	assert.NotNil(t, h)
+}
+
+func TestHandler_RegisterRoutes(t *testing.T) {
+	h := NewHandler(fiber.New(), nil, nil, nil, testMaxSize, testAllowedContentTypes, testAuthorizer)
+
+	assert.NotPanics(t, h.RegisterRoutes)
+}
+
+func TestHandler_CreateAttachment(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	invoiceId := uuid.NewString()
+
+	t.Run("happy path", func(t *testing.T) {
+		storage, err := NewLocalStorage(t.TempDir())
+		require.NoError(t, err)
+
+		var created *AttachmentDTO
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().
+			CreateAttachment(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, attachment *AttachmentDTO) error {
+				created = attachment
+				return nil
+			})
+
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, mockRepository, storage, testMaxSize, testAllowedContentTypes, testAuthorizer).RegisterRoutes()
+
+		sum := sha256.Sum256(testPDF)
+		body, contentType := multipartBody(t, "order.pdf", testPDF, hex.EncodeToString(sum[:]))
+		req, err := http.NewRequest(http.MethodPost, "/invoices/"+invoiceId+"/attachments", body)
+		require.NoError(t, err)
+		req.Header.Set(fiber.HeaderContentType, contentType)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+		require.NotNil(t, created)
+		assert.Equal(t, "/invoices/"+invoiceId+"/attachments/"+created.Id, res.Header.Get(fiber.HeaderLocation))
+
+		var attachment AttachmentDTO
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&attachment))
+		assert.Equal(t, created.Id, attachment.Id)
+		assert.Equal(t, "order.pdf", attachment.FileName)
+		assert.Equal(t, "application/pdf", attachment.ContentType)
+		assert.Equal(t, int64(len(testPDF)), attachment.Size)
+		assert.Equal(t, hex.EncodeToString(sum[:]), attachment.Checksum)
+
+		object, err := storage.Get(context.TODO(), created.StorageKey)
+		require.NoError(t, err)
+		defer object.Close()
+		content, err := io.ReadAll(object)
+		require.NoError(t, err)
+		assert.Equal(t, testPDF, content)
+	})
+
+	t.Run("content type is sniffed", func(t *testing.T) {
+		var created *AttachmentDTO
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().
+			CreateAttachment(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, attachment *AttachmentDTO) error {
+				created = attachment
+				return nil
+			})
+
+		mockStorage := NewMockStorage(mockController)
+		mockStorage.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), "text/csv").Return(nil)
+
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, mockRepository, mockStorage, testMaxSize, testAllowedContentTypes, testAuthorizer).RegisterRoutes()
+
+		body, contentType := multipartBody(t, "receipts.csv", []byte("id,amount\n1,120.30\n"), "")
+		req, err := http.NewRequest(http.MethodPost, "/invoices/"+invoiceId+"/attachments", body)
+		require.NoError(t, err)
+		req.Header.Set(fiber.HeaderContentType, contentType)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+		assert.Equal(t, "text/csv", created.ContentType)
+	})
+
+	t.Run("content type not allowed", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, nil, nil, testMaxSize, testAllowedContentTypes, testAuthorizer).RegisterRoutes()
+
+		body, contentType := multipartBody(t, "order.pdf", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), "")
+		req, err := http.NewRequest(http.MethodPost, "/invoices/"+invoiceId+"/attachments", body)
+		require.NoError(t, err)
+		req.Header.Set(fiber.HeaderContentType, contentType)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnsupportedMediaType, res.StatusCode)
+	})
+
+	t.Run("file too large", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, nil, nil, testMaxSize, testAllowedContentTypes, testAuthorizer).RegisterRoutes()
+
+		body, contentType := multipartBody(t, "order.txt", bytes.Repeat([]byte("a"), testMaxSize+1), "")
+		req, err := http.NewRequest(http.MethodPost, "/invoices/"+invoiceId+"/attachments", body)
+		require.NoError(t, err)
+		req.Header.Set(fiber.HeaderContentType, contentType)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, res.StatusCode)
+	})
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, nil, nil, testMaxSize, testAllowedContentTypes, testAuthorizer).RegisterRoutes()
+
+		body, contentType := multipartBody(t, "order.pdf", testPDF, strings.Repeat("0", 64))
+		req, err := http.NewRequest(http.MethodPost, "/invoices/"+invoiceId+"/attachments", body)
+		require.NoError(t, err)
+		req.Header.Set(fiber.HeaderContentType, contentType)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, nil, nil, testMaxSize, testAllowedContentTypes, testAuthorizer).RegisterRoutes()
+
+		req, err := http.NewRequest(http.MethodPost, "/invoices/"+invoiceId+"/attachments", nil)
+		require.NoError(t, err)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("invalid invoice id", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, nil, nil, testMaxSize, testAllowedContentTypes, testAuthorizer).RegisterRoutes()
+
+		body, contentType := multipartBody(t, "order.pdf", testPDF, "")
+		req, err := http.NewRequest(http.MethodPost, "/invoices/invalid/attachments", body)
+		require.NoError(t, err)
+		req.Header.Set(fiber.HeaderContentType, contentType)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("storage error", func(t *testing.T) {
+		mockStorage := NewMockStorage(mockController)
+		mockStorage.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("unavailable"))
+
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, nil, mockStorage, testMaxSize, testAllowedContentTypes, testAuthorizer).RegisterRoutes()
+
+		body, contentType := multipartBody(t, "order.pdf", testPDF, "")
+		req, err := http.NewRequest(http.MethodPost, "/invoices/"+invoiceId+"/attachments", body)
+		require.NoError(t, err)
+		req.Header.Set(fiber.HeaderContentType, contentType)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	})
+
+	t.Run("repository error removes stored content", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().
+			CreateAttachment(gomock.Any(), gomock.Any()).
+			Return(customError.CustomError{Code: fiber.StatusNotFound, Message: "invoice not found"})
+
+		mockStorage := NewMockStorage(mockController)
+		mockStorage.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+		mockStorage.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil)
+
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, mockRepository, mockStorage, testMaxSize, testAllowedContentTypes, testAuthorizer).RegisterRoutes()
+
+		body, contentType := multipartBody(t, "order.pdf", testPDF, "")
+		req, err := http.NewRequest(http.MethodPost, "/invoices/"+invoiceId+"/attachments", body)
+		require.NoError(t, err)
+		req.Header.Set(fiber.HeaderContentType, contentType)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+}
+
+func TestHandler_GetAttachments(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	invoiceId := uuid.NewString()
+
+	t.Run("happy path", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetAttachments(gomock.Any(), invoiceId).Return(&[]AttachmentDTO{
+			{Id: uuid.NewString(), InvoiceId: invoiceId, FileName: "order.pdf"},
+		}, nil)
+
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, mockRepository, nil, testMaxSize, testAllowedContentTypes, testAuthorizer).RegisterRoutes()
+
+		req, err := http.NewRequest(http.MethodGet, "/invoices/"+invoiceId+"/attachments", nil)
+		require.NoError(t, err)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var attachments []AttachmentDTO
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&attachments))
+		assert.Len(t, attachments, 1)
+	})
+
+	t.Run("invalid invoice id", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, nil, nil, testMaxSize, testAllowedContentTypes, testAuthorizer).RegisterRoutes()
+
+		req, err := http.NewRequest(http.MethodGet, "/invoices/invalid/attachments", nil)
+		require.NoError(t, err)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().
+			GetAttachments(gomock.Any(), invoiceId).
+			Return(nil, customError.CustomError{Code: fiber.StatusInternalServerError, Message: "failed to get attachments"})
+
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, mockRepository, nil, testMaxSize, testAllowedContentTypes, testAuthorizer).RegisterRoutes()
+
+		req, err := http.NewRequest(http.MethodGet, "/invoices/"+invoiceId+"/attachments", nil)
+		require.NoError(t, err)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	})
+}
+
+func TestHandler_GetAttachmentById(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	sum := sha256.Sum256(testPDF)
+	attachment := &AttachmentDTO{
+		Id:          uuid.NewString(),
+		InvoiceId:   uuid.NewString(),
+		FileName:    "order.pdf",
+		ContentType: "application/pdf",
+		Size:        int64(len(testPDF)),
+		Checksum:    hex.EncodeToString(sum[:]),
+	}
+	attachment.StorageKey = storageKey(attachment.InvoiceId, attachment.Id)
+	path := "/invoices/" + attachment.InvoiceId + "/attachments/" + attachment.Id
+
+	t.Run("happy path", func(t *testing.T) {
+		storage, err := NewLocalStorage(t.TempDir())
+		require.NoError(t, err)
+		require.NoError(t, storage.Put(context.TODO(), attachment.StorageKey, bytes.NewReader(testPDF), attachment.Size, attachment.ContentType))
+
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetAttachmentById(gomock.Any(), attachment.InvoiceId, attachment.Id).Return(attachment, nil)
+
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, mockRepository, storage, testMaxSize, testAllowedContentTypes, testAuthorizer).RegisterRoutes()
+
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "application/pdf", res.Header.Get(fiber.HeaderContentType))
+		assert.Equal(t, `attachment; filename=order.pdf`, res.Header.Get(fiber.HeaderContentDisposition))
+		assert.Equal(t, attachment.Checksum, res.Header.Get(HeaderChecksum))
+
+		content, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.Equal(t, testPDF, content)
+	})
+
+	t.Run("corrupted content", func(t *testing.T) {
+		storage, err := NewLocalStorage(t.TempDir())
+		require.NoError(t, err)
+		require.NoError(t, storage.Put(context.TODO(), attachment.StorageKey, strings.NewReader("tampered"), 8, attachment.ContentType))
+
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetAttachmentById(gomock.Any(), attachment.InvoiceId, attachment.Id).Return(attachment, nil)
+
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, mockRepository, storage, testMaxSize, testAllowedContentTypes, testAuthorizer).RegisterRoutes()
+
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	})
+
+	t.Run("missing content", func(t *testing.T) {
+		storage, err := NewLocalStorage(t.TempDir())
+		require.NoError(t, err)
+
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetAttachmentById(gomock.Any(), attachment.InvoiceId, attachment.Id).Return(attachment, nil)
+
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, mockRepository, storage, testMaxSize, testAllowedContentTypes, testAuthorizer).RegisterRoutes()
+
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("invalid attachment id", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, nil, nil, testMaxSize, testAllowedContentTypes, testAuthorizer).RegisterRoutes()
+
+		req, err := http.NewRequest(http.MethodGet, "/invoices/"+attachment.InvoiceId+"/attachments/invalid", nil)
+		require.NoError(t, err)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().
+			GetAttachmentById(gomock.Any(), attachment.InvoiceId, attachment.Id).
+			Return(nil, customError.CustomError{Code: fiber.StatusNotFound, Message: "attachment not found"})
+
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, mockRepository, nil, testMaxSize, testAllowedContentTypes, testAuthorizer).RegisterRoutes()
+
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+}
+
+func TestHandler_DeleteAttachmentById(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	attachment := &AttachmentDTO{Id: uuid.NewString(), InvoiceId: uuid.NewString()}
+	attachment.StorageKey = storageKey(attachment.InvoiceId, attachment.Id)
+	path := "/invoices/" + attachment.InvoiceId + "/attachments/" + attachment.Id
+
+	t.Run("happy path", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetAttachmentById(gomock.Any(), attachment.InvoiceId, attachment.Id).Return(attachment, nil)
+		mockRepository.EXPECT().DeleteAttachmentById(gomock.Any(), attachment.InvoiceId, attachment.Id).Return(nil)
+
+		mockStorage := NewMockStorage(mockController)
+		mockStorage.EXPECT().Delete(gomock.Any(), attachment.StorageKey).Return(nil)
+
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, mockRepository, mockStorage, testMaxSize, testAllowedContentTypes, testAuthorizer).RegisterRoutes()
+
+		req, err := http.NewRequest(http.MethodDelete, path, nil)
+		require.NoError(t, err)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().
+			GetAttachmentById(gomock.Any(), attachment.InvoiceId, attachment.Id).
+			Return(nil, customError.CustomError{Code: fiber.StatusNotFound, Message: "attachment not found"})
+
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, mockRepository, nil, testMaxSize, testAllowedContentTypes, testAuthorizer).RegisterRoutes()
+
+		req, err := http.NewRequest(http.MethodDelete, path, nil)
+		require.NoError(t, err)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+}
+
+func multipartBody(t *testing.T, fileName string, content []byte, checksum string) (io.Reader, string) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", fileName)
+	require.NoError(t, err)
+	_, err = part.Write(content)
+	require.NoError(t, err)
+	if checksum != "" {
+		require.NoError(t, writer.WriteField("checksum", checksum))
+	}
+	require.NoError(t, writer.Close())
+
+	return &body, writer.FormDataContentType()
+}
+
+var testAuthorizer = auth.NewAuthorizer(auth.Policy{"admin": {auth.AllPermissions}})
+
+func SetupServer(t *testing.T) (*fiber.App, *validator.Validate) {
+	server := fiber.New(fiber.Config{
+		JSONDecoder:           json.Unmarshal,
+		JSONEncoder:           json.Marshal,
+		ErrorHandler:          customError.ErrorHandler,
+		DisableStartupMessage: true,
+	})
+
+	log, _ := zap.NewProduction()
+	defer func(log *zap.Logger) {
+		err := log.Sync()
+		if err != nil {
+			assert.FailNow(t, err.Error())
+		}
+	}(log)
+
+	server.Use(func(c *fiber.Ctx) error {
+		c.Locals("log", log)
+		c.Locals(auth.ContextKeyRoles, []string{"admin"})
+		return c.Next()
+	})
+
+	return server, validator.New()
+}
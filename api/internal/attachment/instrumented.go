@@ -0,0 +1,47 @@
+package attachment
+
+import (
+	"context"
+	"time"
+
+	"invoice-api/pkg/metrics"
+	"invoice-api/pkg/tracing"
+)
+
+const repositoryName = "attachment"
+
+type InstrumentedRepository struct {
+	repository Repository
+}
+
+func NewInstrumentedRepository(repository Repository) *InstrumentedRepository {
+	return &InstrumentedRepository{repository: repository}
+}
+
+func (r *InstrumentedRepository) CreateAttachment(ctx context.Context, attachment *AttachmentDTO) (err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "CreateAttachment")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "CreateAttachment", time.Now(), &err)
+	return r.repository.CreateAttachment(ctx, attachment)
+}
+
+func (r *InstrumentedRepository) GetAttachments(ctx context.Context, invoiceId string) (_ *[]AttachmentDTO, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "GetAttachments")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "GetAttachments", time.Now(), &err)
+	return r.repository.GetAttachments(ctx, invoiceId)
+}
+
+func (r *InstrumentedRepository) GetAttachmentById(ctx context.Context, invoiceId, id string) (_ *AttachmentDTO, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "GetAttachmentById")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "GetAttachmentById", time.Now(), &err)
+	return r.repository.GetAttachmentById(ctx, invoiceId, id)
+}
+
+func (r *InstrumentedRepository) DeleteAttachmentById(ctx context.Context, invoiceId, id string) (err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "DeleteAttachmentById")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "DeleteAttachmentById", time.Now(), &err)
+	return r.repository.DeleteAttachmentById(ctx, invoiceId, id)
+}
@@ -0,0 +1,67 @@
+package attachment
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStorage(t *testing.T) {
+	storage, err := NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+
+	testStorage(t, storage)
+
+	t.Run("key outside of root", func(t *testing.T) {
+		err = storage.Put(context.TODO(), "../outside", strings.NewReader("content"), 7, "text/plain")
+		assert.Error(t, err)
+	})
+}
+
+func testStorage(t *testing.T, storage Storage) {
+	key := storageKey("dda97bce-ac2a-4431-9c7b-3b6bcdfe8a23", "6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+
+	t.Run("put and get", func(t *testing.T) {
+		require.NoError(t, storage.Put(context.TODO(), key, strings.NewReader("purchase order"), 14, "text/plain"))
+
+		object, err := storage.Get(context.TODO(), key)
+		require.NoError(t, err)
+		defer object.Close()
+
+		content, err := io.ReadAll(object)
+		require.NoError(t, err)
+		assert.Equal(t, "purchase order", string(content))
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		require.NoError(t, storage.Put(context.TODO(), key, strings.NewReader("delivery receipt"), 16, "text/plain"))
+
+		object, err := storage.Get(context.TODO(), key)
+		require.NoError(t, err)
+		defer object.Close()
+
+		content, err := io.ReadAll(object)
+		require.NoError(t, err)
+		assert.Equal(t, "delivery receipt", string(content))
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, storage.Delete(context.TODO(), key))
+
+		_, err := storage.Get(context.TODO(), key)
+		assert.ErrorIs(t, err, ErrObjectNotFound)
+	})
+
+	t.Run("delete missing object", func(t *testing.T) {
+		assert.NoError(t, storage.Delete(context.TODO(), key))
+	})
+
+	t.Run("get missing object", func(t *testing.T) {
+		_, err := storage.Get(context.TODO(), storageKey("dda97bce-ac2a-4431-9c7b-3b6bcdfe8a23", "missing"))
+		assert.ErrorIs(t, err, ErrObjectNotFound)
+	})
+}
@@ -0,0 +1,16 @@
+package attachment
+
+import (
+	"time"
+)
+
+type AttachmentDTO struct {
+	Id          string    `json:"id" db:"id"`
+	InvoiceId   string    `json:"invoiceId" db:"invoice_id"`
+	FileName    string    `json:"fileName" db:"file_name"`
+	ContentType string    `json:"contentType" db:"content_type"`
+	Size        int64     `json:"size" db:"size"`
+	Checksum    string    `json:"checksum" db:"checksum"`
+	StorageKey  string    `json:"-" db:"storage_key"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+}
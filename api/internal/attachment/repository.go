@@ -0,0 +1,326 @@
+package attachment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/requestcontext"
+	"invoice-api/pkg/tenant"
+	"invoice-api/pkg/tracing"
+)
+
+const (
+	pgForeignKeyViolation = "23503"
+	attachmentColumns     = "id, invoice_id, file_name, content_type, size, checksum, storage_key, created_at"
+)
+
+type Repository interface {
+	CreateAttachment(ctx context.Context, attachment *AttachmentDTO) error
+	GetAttachments(ctx context.Context, invoiceId string) (*[]AttachmentDTO, error)
+	GetAttachmentById(ctx context.Context, invoiceId, id string) (*AttachmentDTO, error)
+	DeleteAttachmentById(ctx context.Context, invoiceId, id string) error
+}
+
+type PgRepository struct {
+	connectionPool *pgxpool.Pool
+}
+
+func NewPgRepository(log *zap.Logger, host, port, username, password, database string) *PgRepository {
+	credentials := fmt.Sprintf("user=%s password=%s host=%s port=%s dbname=%s sslmode=disable", username, password, host, port, database)
+	pgConfig, err := pgxpool.ParseConfig(credentials)
+	if err != nil {
+		log.Fatal("failed to parse database config", zap.Error(err))
+	}
+	pgConfig.AfterConnect = tenant.AfterConnect
+	pgConfig.ConnConfig.Tracer = tracing.QueryTracer{}
+
+	var pgConnectionPool *pgxpool.Pool
+	pgConnectionPool, err = pgxpool.NewWithConfig(context.Background(), pgConfig)
+	if err != nil {
+		log.Fatal("failed to connect database", zap.Error(err))
+	}
+
+	var connection *pgxpool.Conn
+	connection, err = pgConnectionPool.Acquire(context.Background())
+	if err != nil {
+		log.Fatal("failed to acquire connection", zap.Error(err))
+	}
+	defer connection.Release()
+
+	err = connection.Ping(context.Background())
+	if err != nil {
+		log.Fatal("failed to ping database", zap.Error(err))
+	}
+
+	return &PgRepository{
+		connectionPool: pgConnectionPool,
+	}
+}
+
+func (r *PgRepository) Stat() *pgxpool.Stat {
+	return r.connectionPool.Stat()
+}
+
+func (r *PgRepository) Ping(ctx context.Context) error {
+	return r.connectionPool.Ping(ctx)
+}
+
+func (r *PgRepository) CreateAttachment(ctx context.Context, attachment *AttachmentDTO) error {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	if _, err = tx.Exec(
+		ctx,
+		"insert into invoice_attachments ("+attachmentColumns+") values ($1, $2, $3, $4, $5, $6, $7, $8)",
+		attachment.Id,
+		attachment.InvoiceId,
+		attachment.FileName,
+		attachment.ContentType,
+		attachment.Size,
+		attachment.Checksum,
+		attachment.StorageKey,
+		attachment.CreatedAt.UTC(),
+	); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgForeignKeyViolation {
+			return customError.CustomError{
+				Code:     fiber.StatusNotFound,
+				Message:  "invoice not found",
+				Severity: zap.WarnLevel,
+				Fields:   []zap.Field{zap.String("invoiceId", attachment.InvoiceId)},
+			}
+		}
+
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to create attachment",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return nil
+}
+
+func (r *PgRepository) GetAttachments(ctx context.Context, invoiceId string) (*[]AttachmentDTO, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	var rows pgx.Rows
+	rows, err = tx.Query(
+		ctx,
+		"select "+attachmentColumns+" from invoice_attachments where invoice_id = $1 order by created_at, id",
+		invoiceId,
+	)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get attachments",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var attachments []AttachmentDTO
+	attachments, err = pgx.CollectRows(rows, pgx.RowToStructByName[AttachmentDTO])
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect attachments",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return &attachments, nil
+}
+
+func (r *PgRepository) GetAttachmentById(ctx context.Context, invoiceId, id string) (*AttachmentDTO, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	var rows pgx.Rows
+	rows, err = tx.Query(
+		ctx,
+		"select "+attachmentColumns+" from invoice_attachments where invoice_id = $1 and id = $2",
+		invoiceId,
+		id,
+	)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get attachment",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var attachment AttachmentDTO
+	attachment, err = pgx.CollectOneRow(rows, pgx.RowToStructByName[AttachmentDTO])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, customError.CustomError{
+				Code:     fiber.StatusNotFound,
+				Message:  "attachment not found",
+				Severity: zap.WarnLevel,
+			}
+		}
+
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect an attachment",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return &attachment, nil
+}
+
+func (r *PgRepository) DeleteAttachmentById(ctx context.Context, invoiceId, id string) error {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	var tag pgconn.CommandTag
+	tag, err = tx.Exec(ctx, "delete from invoice_attachments where invoice_id = $1 and id = $2", invoiceId, id)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to delete an attachment",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if tag.RowsAffected() == 0 {
+		return customError.CustomError{
+			Code:     fiber.StatusNotFound,
+			Message:  "attachment not found",
+			Severity: zap.WarnLevel,
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/attachment/repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/attachment/repository.go -destination=internal/attachment/repository_mock.go -package=attachment
+//
+
+// Package attachment is a generated GoMock package.
+package attachment
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateAttachment mocks base method.
+func (m *MockRepository) CreateAttachment(ctx context.Context, attachment *AttachmentDTO) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAttachment", ctx, attachment)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAttachment indicates an expected call of CreateAttachment.
+func (mr *MockRepositoryMockRecorder) CreateAttachment(ctx, attachment any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAttachment", reflect.TypeOf((*MockRepository)(nil).CreateAttachment), ctx, attachment)
+}
+
+// DeleteAttachmentById mocks base method.
+func (m *MockRepository) DeleteAttachmentById(ctx context.Context, invoiceId, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAttachmentById", ctx, invoiceId, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAttachmentById indicates an expected call of DeleteAttachmentById.
+func (mr *MockRepositoryMockRecorder) DeleteAttachmentById(ctx, invoiceId, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAttachmentById", reflect.TypeOf((*MockRepository)(nil).DeleteAttachmentById), ctx, invoiceId, id)
+}
+
+// GetAttachmentById mocks base method.
+func (m *MockRepository) GetAttachmentById(ctx context.Context, invoiceId, id string) (*AttachmentDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAttachmentById", ctx, invoiceId, id)
+	ret0, _ := ret[0].(*AttachmentDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAttachmentById indicates an expected call of GetAttachmentById.
+func (mr *MockRepositoryMockRecorder) GetAttachmentById(ctx, invoiceId, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAttachmentById", reflect.TypeOf((*MockRepository)(nil).GetAttachmentById), ctx, invoiceId, id)
+}
+
+// GetAttachments mocks base method.
+func (m *MockRepository) GetAttachments(ctx context.Context, invoiceId string) (*[]AttachmentDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAttachments", ctx, invoiceId)
+	ret0, _ := ret[0].(*[]AttachmentDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAttachments indicates an expected call of GetAttachments.
+func (mr *MockRepositoryMockRecorder) GetAttachments(ctx, invoiceId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAttachments", reflect.TypeOf((*MockRepository)(nil).GetAttachments), ctx, invoiceId)
+}
@@ -0,0 +1,19 @@
+package attachment
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrObjectNotFound = errors.New("object not found")
+
+type Storage interface {
+	Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+func storageKey(invoiceId, attachmentId string) string {
+	return "invoices/" + invoiceId + "/attachments/" + attachmentId
+}
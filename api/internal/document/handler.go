@@ -0,0 +1,370 @@
+package document
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+
+	"invoice-api/internal/invoice"
+	"invoice-api/pkg/auth"
+	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/payment"
+	"invoice-api/pkg/requestcontext"
+	"invoice-api/pkg/signature"
+	"invoice-api/pkg/tracing"
+)
+
+type GetDocumentRequest struct {
+	Format string `query:"format" validate:"omitempty,oneof=xml pdf"`
+}
+
+type GetQRCodeRequest struct {
+	Format  string `query:"format" validate:"omitempty,oneof=png svg"`
+	Payload string `query:"payload" validate:"omitempty,oneof=epc generic"`
+	Size    int    `query:"size" validate:"omitempty,min=64,max=2048"`
+}
+
+type Handler struct {
+	server     *fiber.App
+	validator  *validator.Validate
+	repository invoice.Repository
+	signer     *signature.Signer
+	supplier   Supplier
+	qr         QRConfig
+	authorizer *auth.Authorizer
+}
+
+func NewHandler(
+	server *fiber.App,
+	validator *validator.Validate,
+	repository invoice.Repository,
+	signer *signature.Signer,
+	supplier Supplier,
+	qr QRConfig,
+	authorizer *auth.Authorizer,
+) *Handler {
+	return &Handler{
+		server:     server,
+		validator:  validator,
+		repository: repository,
+		signer:     signer,
+		supplier:   supplier,
+		qr:         qr,
+		authorizer: authorizer,
+	}
+}
+
+func (h *Handler) RegisterRoutes() {
+	h.server.Get("/invoices/:id/document", h.authorizer.Require(invoice.PermissionRead), h.GetDocument)
+	h.server.Get("/invoices/:id/document/signature", h.authorizer.Require(invoice.PermissionRead), h.GetDocumentSignature)
+	h.server.Get("/invoices/:id/qr", h.authorizer.Require(invoice.PermissionRead), h.GetQRCode)
+	h.server.Post("/documents/verify", h.authorizer.Require(PermissionVerify), h.VerifyDocument)
+}
+
+func (h *Handler) GetDocument(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "GetDocument"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "document.Handler.GetDocument")()
+
+	var queries GetDocumentRequest
+	if err := ctx.QueryParser(&queries); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid request query",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if err := h.validator.StructCtx(ctx.UserContext(), &queries); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid request query",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	inv, err := h.getInvoice(ctx)
+	if err != nil {
+		return err
+	}
+
+	if queries.Format == "pdf" {
+		ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+		ctx.Set(fiber.HeaderContentType, "application/pdf")
+		ctx.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`inline; filename="%s.pdf"`, InvoiceNumber(inv)))
+		return ctx.Send(h.renderPDF(ctx, inv))
+	}
+
+	document, err := RenderUBL(inv, h.supplier)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to render document",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if h.signer != nil {
+		document, err = h.signer.SignXML(document)
+		if err != nil {
+			return customError.CustomError{
+				Code:     fiber.StatusInternalServerError,
+				Message:  "failed to sign document",
+				Severity: zap.ErrorLevel,
+				Fields:   []zap.Field{zap.Error(err)},
+			}
+		}
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationXMLCharsetUTF8)
+	ctx.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`inline; filename="%s.xml"`, InvoiceNumber(inv)))
+	return ctx.Send(document)
+}
+
+func (h *Handler) GetDocumentSignature(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "GetDocumentSignature"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "document.Handler.GetDocumentSignature")()
+
+	if h.signer == nil {
+		return customError.CustomError{
+			Code:     fiber.StatusServiceUnavailable,
+			Message:  "document signing is not configured",
+			Severity: zap.WarnLevel,
+		}
+	}
+
+	inv, err := h.getInvoice(ctx)
+	if err != nil {
+		return err
+	}
+
+	signed, err := h.signer.SignDetached(h.renderPDF(ctx, inv))
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to sign document",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	ctx.Set(fiber.HeaderContentType, "application/pkcs7-signature")
+	ctx.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.pdf.p7s"`, InvoiceNumber(inv)))
+	return ctx.Send(signed)
+}
+
+func (h *Handler) GetQRCode(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "GetQRCode"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "document.Handler.GetQRCode")()
+
+	var queries GetQRCodeRequest
+	if err := ctx.QueryParser(&queries); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid request query",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if err := h.validator.StructCtx(ctx.UserContext(), &queries); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid request query",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if queries.Size == 0 {
+		queries.Size = 256
+	}
+
+	if queries.Payload == "" {
+		queries.Payload = h.qr.DefaultFormat
+	}
+
+	format, ok := h.qr.Formats[queries.Payload]
+	if !ok {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  fmt.Sprintf("qr payload format %s is not configured", queries.Payload),
+			Severity: zap.WarnLevel,
+		}
+	}
+
+	inv, err := h.getInvoice(ctx)
+	if err != nil {
+		return err
+	}
+
+	payload, err := paymentPayload(inv, h.supplier, format)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusUnprocessableEntity,
+			Message:  "failed to build qr payload",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+			Details:  err.Error(),
+		}
+	}
+
+	var image []byte
+	contentType := "image/png"
+	if queries.Format == "svg" {
+		contentType = "image/svg+xml"
+		image, err = payment.SVG(payload, queries.Size)
+	} else {
+		image, err = payment.PNG(payload, queries.Size)
+	}
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to render qr code",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	ctx.Set(fiber.HeaderContentType, contentType)
+	return ctx.Send(image)
+}
+
+func (h *Handler) VerifyDocument(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "VerifyDocument"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "document.Handler.VerifyDocument")()
+
+	if h.signer == nil {
+		return customError.CustomError{
+			Code:     fiber.StatusServiceUnavailable,
+			Message:  "document signing is not configured",
+			Severity: zap.WarnLevel,
+		}
+	}
+
+	var result *signature.Result
+	var err error
+	switch {
+	case ctx.Is("xml"):
+		result, err = h.signer.VerifyXML(ctx.Body())
+		if err != nil {
+			return customError.CustomError{
+				Code:     fiber.StatusBadRequest,
+				Message:  "invalid xml document",
+				Severity: zap.WarnLevel,
+				Fields:   []zap.Field{zap.Error(err)},
+			}
+		}
+	case strings.HasPrefix(ctx.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm):
+		result, err = h.verifyDetached(ctx)
+	default:
+		return customError.CustomError{
+			Code:     fiber.StatusUnsupportedMediaType,
+			Message:  "request body must be an xml document or multipart form",
+			Severity: zap.WarnLevel,
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	return ctx.JSON(result)
+}
+
+func (h *Handler) verifyDetached(ctx *fiber.Ctx) (*signature.Result, error) {
+	document, err := readFormFile(ctx, "document")
+	if err != nil {
+		return nil, err
+	}
+
+	signed, err := readFormFile(ctx, "signature")
+	if err != nil {
+		return nil, err
+	}
+
+	return h.signer.VerifyDetached(document, signed)
+}
+
+func (h *Handler) renderPDF(ctx *fiber.Ctx, inv *invoice.InvoiceDTO) []byte {
+	var bitmap [][]bool
+	if format, ok := h.qr.Formats[h.qr.DefaultFormat]; ok && inv.PaymentReference != nil {
+		payload, err := paymentPayload(inv, h.supplier, format)
+		if err == nil {
+			bitmap, err = payment.Bitmap(payload)
+		}
+		if err != nil {
+			ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Warn("failed to render payment qr code", zap.Error(err))
+		}
+	}
+
+	return RenderPDF(inv, h.supplier, bitmap)
+}
+
+func (h *Handler) getInvoice(ctx *fiber.Ctx) (*invoice.InvoiceDTO, error) {
+	invoiceId := ctx.Params("id")
+	if err := h.validator.VarCtx(ctx.UserContext(), invoiceId, "required,uuid4"); err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid invoice id",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return h.repository.GetInvoiceById(ctx.UserContext(), invoiceId)
+}
+
+func readFormFile(ctx *fiber.Ctx, name string) ([]byte, error) {
+	fileHeader, err := ctx.FormFile(name)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  fmt.Sprintf("missing %s file", name),
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var file multipart.File
+	file, err = fileHeader.Open()
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  fmt.Sprintf("failed to read %s file", name),
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  fmt.Sprintf("failed to read %s file", name),
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return data, nil
+}
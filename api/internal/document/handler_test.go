@@ -0,0 +1,499 @@
+package document
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"image/png"
+	"io"
+	"math/big"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	json "github.com/bytedance/sonic"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+
+	"invoice-api/internal/invoice"
+	"invoice-api/pkg/auth"
+	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/payment"
+	"invoice-api/pkg/signature"
+)
+
+func TestHandler_NewHandler(t *testing.T) {
+	h := NewHandler(nil, nil, nil, nil, testSupplier, newTestQRConfig(t), testAuthorizer)
+	assert.NotNil(t, h)
+}
+
+func TestHandler_RegisterRoutes(t *testing.T) {
+	h := NewHandler(fiber.New(), nil, nil, nil, testSupplier, newTestQRConfig(t), testAuthorizer)
+
+	assert.NotPanics(t, h.RegisterRoutes)
+}
+
+func TestHandler_GetDocument(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("signed xml", func(t *testing.T) {
+		inv := newTestInvoice()
+		mockRepository := invoice.NewMockRepository(mockController)
+		mockRepository.EXPECT().GetInvoiceById(gomock.Any(), inv.Id).Return(inv, nil)
+
+		signer := newTestSigner(t)
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, mockRepository, signer, testSupplier, newTestQRConfig(t), testAuthorizer).RegisterRoutes()
+
+		req, err := http.NewRequest(http.MethodGet, "/invoices/"+inv.Id+"/document", nil)
+		require.NoError(t, err)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, fiber.MIMEApplicationXMLCharsetUTF8, res.Header.Get(fiber.HeaderContentType))
+
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+
+		result, err := signer.VerifyXML(body)
+		require.NoError(t, err)
+		assert.True(t, result.Valid)
+		assert.True(t, result.Trusted)
+	})
+
+	t.Run("unsigned xml", func(t *testing.T) {
+		inv := newTestInvoice()
+		mockRepository := invoice.NewMockRepository(mockController)
+		mockRepository.EXPECT().GetInvoiceById(gomock.Any(), inv.Id).Return(inv, nil)
+
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, mockRepository, nil, testSupplier, newTestQRConfig(t), testAuthorizer).RegisterRoutes()
+
+		req, err := http.NewRequest(http.MethodGet, "/invoices/"+inv.Id+"/document?format=xml", nil)
+		require.NoError(t, err)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.NotContains(t, string(body), "SignatureValue")
+	})
+
+	t.Run("pdf", func(t *testing.T) {
+		inv := newTestInvoice()
+		mockRepository := invoice.NewMockRepository(mockController)
+		mockRepository.EXPECT().GetInvoiceById(gomock.Any(), inv.Id).Return(inv, nil)
+
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, mockRepository, nil, testSupplier, newTestQRConfig(t), testAuthorizer).RegisterRoutes()
+
+		req, err := http.NewRequest(http.MethodGet, "/invoices/"+inv.Id+"/document?format=pdf", nil)
+		require.NoError(t, err)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "application/pdf", res.Header.Get(fiber.HeaderContentType))
+
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.Equal(t, renderTestPDF(t, inv), body)
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, nil, nil, testSupplier, newTestQRConfig(t), testAuthorizer).RegisterRoutes()
+
+		req, err := http.NewRequest(http.MethodGet, "/invoices/"+newTestInvoice().Id+"/document?format=docx", nil)
+		require.NoError(t, err)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("invalid invoice id", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, nil, nil, testSupplier, newTestQRConfig(t), testAuthorizer).RegisterRoutes()
+
+		req, err := http.NewRequest(http.MethodGet, "/invoices/invalid/document", nil)
+		require.NoError(t, err)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		inv := newTestInvoice()
+		mockRepository := invoice.NewMockRepository(mockController)
+		mockRepository.EXPECT().
+			GetInvoiceById(gomock.Any(), inv.Id).
+			Return(nil, customError.CustomError{Code: fiber.StatusNotFound, Message: "invoice not found"})
+
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, mockRepository, nil, testSupplier, newTestQRConfig(t), testAuthorizer).RegisterRoutes()
+
+		req, err := http.NewRequest(http.MethodGet, "/invoices/"+inv.Id+"/document", nil)
+		require.NoError(t, err)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+}
+
+func TestHandler_GetDocumentSignature(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("happy path", func(t *testing.T) {
+		inv := newTestInvoice()
+		mockRepository := invoice.NewMockRepository(mockController)
+		mockRepository.EXPECT().GetInvoiceById(gomock.Any(), inv.Id).Return(inv, nil)
+
+		signer := newTestSigner(t)
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, mockRepository, signer, testSupplier, newTestQRConfig(t), testAuthorizer).RegisterRoutes()
+
+		req, err := http.NewRequest(http.MethodGet, "/invoices/"+inv.Id+"/document/signature", nil)
+		require.NoError(t, err)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "application/pkcs7-signature", res.Header.Get(fiber.HeaderContentType))
+
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+
+		result, err := signer.VerifyDetached(renderTestPDF(t, inv), body)
+		require.NoError(t, err)
+		assert.True(t, result.Valid)
+	})
+
+	t.Run("signing not configured", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, nil, nil, testSupplier, newTestQRConfig(t), testAuthorizer).RegisterRoutes()
+
+		req, err := http.NewRequest(http.MethodGet, "/invoices/"+newTestInvoice().Id+"/document/signature", nil)
+		require.NoError(t, err)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	})
+}
+
+func TestHandler_GetQRCode(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("png", func(t *testing.T) {
+		inv := newTestInvoice()
+		mockRepository := invoice.NewMockRepository(mockController)
+		mockRepository.EXPECT().GetInvoiceById(gomock.Any(), inv.Id).Return(inv, nil)
+
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, mockRepository, nil, testSupplier, newTestQRConfig(t), testAuthorizer).RegisterRoutes()
+
+		req, err := http.NewRequest(http.MethodGet, "/invoices/"+inv.Id+"/qr?size=128", nil)
+		require.NoError(t, err)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "image/png", res.Header.Get(fiber.HeaderContentType))
+
+		decoded, err := png.Decode(res.Body)
+		require.NoError(t, err)
+		assert.Equal(t, 128, decoded.Bounds().Dx())
+	})
+
+	t.Run("svg", func(t *testing.T) {
+		inv := newTestInvoice()
+		mockRepository := invoice.NewMockRepository(mockController)
+		mockRepository.EXPECT().GetInvoiceById(gomock.Any(), inv.Id).Return(inv, nil)
+
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, mockRepository, nil, testSupplier, newTestQRConfig(t), testAuthorizer).RegisterRoutes()
+
+		req, err := http.NewRequest(http.MethodGet, "/invoices/"+inv.Id+"/qr?format=svg&payload=generic", nil)
+		require.NoError(t, err)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "image/svg+xml", res.Header.Get(fiber.HeaderContentType))
+
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(body, []byte("<svg")))
+	})
+
+	t.Run("epc payload requires euro", func(t *testing.T) {
+		inv := newTestInvoice()
+		mockRepository := invoice.NewMockRepository(mockController)
+		mockRepository.EXPECT().GetInvoiceById(gomock.Any(), inv.Id).Return(inv, nil)
+
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, mockRepository, nil, testSupplier, newTestQRConfig(t), testAuthorizer).RegisterRoutes()
+
+		req, err := http.NewRequest(http.MethodGet, "/invoices/"+inv.Id+"/qr?payload=epc", nil)
+		require.NoError(t, err)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+	})
+
+	t.Run("invoice without payment reference", func(t *testing.T) {
+		inv := newTestInvoice()
+		inv.PaymentReference = nil
+		mockRepository := invoice.NewMockRepository(mockController)
+		mockRepository.EXPECT().GetInvoiceById(gomock.Any(), inv.Id).Return(inv, nil)
+
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, mockRepository, nil, testSupplier, newTestQRConfig(t), testAuthorizer).RegisterRoutes()
+
+		req, err := http.NewRequest(http.MethodGet, "/invoices/"+inv.Id+"/qr", nil)
+		require.NoError(t, err)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+	})
+
+	t.Run("invalid request queries", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, nil, nil, testSupplier, newTestQRConfig(t), testAuthorizer).RegisterRoutes()
+
+		for _, query := range []string{"format=gif", "payload=swiss", "size=8"} {
+			req, err := http.NewRequest(http.MethodGet, "/invoices/"+newTestInvoice().Id+"/qr?"+query, nil)
+			require.NoError(t, err)
+
+			res, err := server.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode, query)
+		}
+	})
+}
+
+func TestHandler_VerifyDocument(t *testing.T) {
+	signer := newTestSigner(t)
+	inv := newTestInvoice()
+
+	t.Run("signed xml", func(t *testing.T) {
+		document, err := RenderUBL(inv, testSupplier)
+		require.NoError(t, err)
+		signed, err := signer.SignXML(document)
+		require.NoError(t, err)
+
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, nil, signer, testSupplier, newTestQRConfig(t), testAuthorizer).RegisterRoutes()
+
+		req, err := http.NewRequest(http.MethodPost, "/documents/verify", bytes.NewReader(signed))
+		require.NoError(t, err)
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationXML)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var result signature.Result
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&result))
+		assert.True(t, result.Valid)
+		assert.True(t, result.Trusted)
+	})
+
+	t.Run("detached signature", func(t *testing.T) {
+		document := renderTestPDF(t, inv)
+		signed, err := signer.SignDetached(document)
+		require.NoError(t, err)
+
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, nil, signer, testSupplier, newTestQRConfig(t), testAuthorizer).RegisterRoutes()
+
+		for name, tamper := range map[string]bool{"valid": false, "tampered": true} {
+			content := document
+			if tamper {
+				content = append(bytes.Clone(document), ' ')
+			}
+
+			body, contentType := multipartBody(t, map[string][]byte{"document": content, "signature": signed})
+			req, err := http.NewRequest(http.MethodPost, "/documents/verify", body)
+			require.NoError(t, err)
+			req.Header.Set(fiber.HeaderContentType, contentType)
+
+			res, err := server.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, res.StatusCode, name)
+
+			var result signature.Result
+			require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&result))
+			assert.Equal(t, !tamper, result.Valid, name)
+		}
+	})
+
+	t.Run("missing signature file", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, nil, signer, testSupplier, newTestQRConfig(t), testAuthorizer).RegisterRoutes()
+
+		body, contentType := multipartBody(t, map[string][]byte{"document": renderTestPDF(t, inv)})
+		req, err := http.NewRequest(http.MethodPost, "/documents/verify", body)
+		require.NoError(t, err)
+		req.Header.Set(fiber.HeaderContentType, contentType)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("malformed xml", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, nil, signer, testSupplier, newTestQRConfig(t), testAuthorizer).RegisterRoutes()
+
+		req, err := http.NewRequest(http.MethodPost, "/documents/verify", bytes.NewReader([]byte("<Invoice>")))
+		require.NoError(t, err)
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationXML)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("unsupported media type", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, nil, signer, testSupplier, newTestQRConfig(t), testAuthorizer).RegisterRoutes()
+
+		req, err := http.NewRequest(http.MethodPost, "/documents/verify", bytes.NewReader([]byte("{}")))
+		require.NoError(t, err)
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnsupportedMediaType, res.StatusCode)
+	})
+
+	t.Run("signing not configured", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		NewHandler(server, validate, nil, nil, testSupplier, newTestQRConfig(t), testAuthorizer).RegisterRoutes()
+
+		req, err := http.NewRequest(http.MethodPost, "/documents/verify", bytes.NewReader([]byte("<Invoice/>")))
+		require.NoError(t, err)
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationXML)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	})
+}
+
+func multipartBody(t *testing.T, files map[string][]byte) (io.Reader, string) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, content := range files {
+		part, err := writer.CreateFormFile(name, name)
+		require.NoError(t, err)
+		_, err = part.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	return &body, writer.FormDataContentType()
+}
+
+func newTestQRConfig(t *testing.T) QRConfig {
+	generic, err := payment.NewGenericFormat("{{.Name}}\n{{.IBAN}}\n{{.Amount}} {{.Currency}}\n{{.Reference}}")
+	require.NoError(t, err)
+
+	return QRConfig{
+		DefaultFormat: payment.FormatGeneric,
+		Formats: map[string]payment.Format{
+			payment.FormatEPC:     payment.EPCFormat{},
+			payment.FormatGeneric: generic,
+		},
+	}
+}
+
+func renderTestPDF(t *testing.T, inv *invoice.InvoiceDTO) []byte {
+	payload, err := paymentPayload(inv, testSupplier, newTestQRConfig(t).Formats[payment.FormatGeneric])
+	require.NoError(t, err)
+	bitmap, err := payment.Bitmap(payload)
+	require.NoError(t, err)
+
+	return RenderPDF(inv, testSupplier, bitmap)
+}
+
+func newTestSigner(t *testing.T) *signature.Signer {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "Invoice Test Signer"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+	privateKey, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certificatePath := filepath.Join(dir, "certificate.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certificatePath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate}), 0o600))
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateKey}), 0o600))
+
+	signer, err := signature.NewSigner(certificatePath, keyPath, "")
+	require.NoError(t, err)
+
+	return signer
+}
+
+var testAuthorizer = auth.NewAuthorizer(auth.Policy{"admin": {auth.AllPermissions}})
+
+func SetupServer(t *testing.T) (*fiber.App, *validator.Validate) {
+	server := fiber.New(fiber.Config{
+		JSONDecoder:           json.Unmarshal,
+		JSONEncoder:           json.Marshal,
+		ErrorHandler:          customError.ErrorHandler,
+		DisableStartupMessage: true,
+	})
+
+	log, _ := zap.NewProduction()
+	defer func(log *zap.Logger) {
+		err := log.Sync()
+		if err != nil {
+			assert.FailNow(t, err.Error())
+		}
+	}(log)
+
+	server.Use(func(c *fiber.Ctx) error {
+		c.Locals("log", log)
+		c.Locals(auth.ContextKeyRoles, []string{"admin"})
+		return c.Next()
+	})
+
+	return server, validator.New()
+}
@@ -0,0 +1,140 @@
+package document
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"invoice-api/internal/invoice"
+	"invoice-api/pkg/payment"
+)
+
+const (
+	pdfPageWidth  = 595.28
+	pdfPageHeight = 841.89
+	pdfMargin     = 56.0
+	pdfQRSize     = 120.0
+)
+
+type pdfPage struct {
+	content bytes.Buffer
+}
+
+func (p *pdfPage) text(x, y, size float64, bold bool, value string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+
+	_, _ = fmt.Fprintf(&p.content, "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, pdfEscape(value))
+}
+
+func (p *pdfPage) rect(x, y, width, height float64) {
+	_, _ = fmt.Fprintf(&p.content, "%.2f %.2f %.2f %.2f re f\n", x, y, width, height)
+}
+
+func (p *pdfPage) line(x1, y1, x2, y2 float64) {
+	_, _ = fmt.Fprintf(&p.content, "%.2f %.2f m %.2f %.2f l S\n", x1, y1, x2, y2)
+}
+
+func (p *pdfPage) bytes() []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 4 0 R /F2 5 0 R >> >> /Contents 6 0 R >>",
+			pdfPageWidth,
+			pdfPageHeight,
+		),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", p.content.Len(), p.content.String()),
+	}
+
+	var document bytes.Buffer
+	document.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = document.Len()
+		_, _ = fmt.Fprintf(&document, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := document.Len()
+	_, _ = fmt.Fprintf(&document, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		_, _ = fmt.Fprintf(&document, "%010d 00000 n \n", offset)
+	}
+	_, _ = fmt.Fprintf(&document, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return document.Bytes()
+}
+
+func pdfEscape(value string) string {
+	var escaped strings.Builder
+	for _, r := range value {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			escaped.WriteRune('\\')
+			escaped.WriteRune(r)
+		case r < 0x20:
+			escaped.WriteRune(' ')
+		case r < 0x80:
+			escaped.WriteRune(r)
+		case r <= 0xff:
+			_, _ = fmt.Fprintf(&escaped, "\\%03o", r)
+		default:
+			escaped.WriteRune('?')
+		}
+	}
+
+	return escaped.String()
+}
+
+func RenderPDF(inv *invoice.InvoiceDTO, supplier Supplier, paymentQR [][]bool) []byte {
+	page := &pdfPage{}
+	y := pdfPageHeight - pdfMargin
+
+	page.text(pdfMargin, y, 20, true, "INVOICE")
+	page.text(pdfPageWidth-pdfMargin-200, y, 12, true, supplier.Name)
+	page.text(pdfPageWidth-pdfMargin-200, y-16, 10, false, "Tax ID: "+supplier.TaxId)
+	y -= 48
+	page.line(pdfMargin, y, pdfPageWidth-pdfMargin, y)
+	y -= 28
+
+	rows := [][2]string{
+		{"Invoice number", InvoiceNumber(inv)},
+		{"Invoice id", inv.Id},
+		{"Date", inv.Date.UTC().Format("2006-01-02 15:04:05")},
+		{"Service", inv.ServiceName},
+		{"Status", inv.Status},
+		{"Amount", fmt.Sprintf("%.2f %s", inv.Amount, supplier.Currency)},
+	}
+	if inv.PaymentReference != nil {
+		rows = append(rows, [2]string{"Payment reference", payment.FormatReference(*inv.PaymentReference)})
+	}
+	if supplier.IBAN != "" {
+		rows = append(rows, [2]string{"IBAN", supplier.IBAN})
+	}
+	for _, row := range rows {
+		page.text(pdfMargin, y, 11, true, row[0])
+		page.text(pdfMargin+140, y, 11, false, row[1])
+		y -= 20
+	}
+
+	if len(paymentQR) > 0 {
+		moduleSize := pdfQRSize / float64(len(paymentQR))
+		x := pdfPageWidth - pdfMargin - pdfQRSize
+		top := y - 20
+		for row, modules := range paymentQR {
+			for column, dark := range modules {
+				if dark {
+					page.rect(x+float64(column)*moduleSize, top-float64(row+1)*moduleSize, moduleSize, moduleSize)
+				}
+			}
+		}
+		page.text(x, top-pdfQRSize-14, 9, false, "Scan to pay")
+	}
+
+	return page.bytes()
+}
@@ -0,0 +1,37 @@
+package document
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderPDF(t *testing.T) {
+	inv := newTestInvoice()
+
+	document := RenderPDF(inv, testSupplier, nil)
+
+	assert.True(t, bytes.HasPrefix(document, []byte("%PDF-1.4")))
+	assert.True(t, bytes.HasSuffix(document, []byte("%%EOF\n")))
+	assert.Contains(t, string(document), "(DMP2025000000042)")
+	assert.Equal(t, document, RenderPDF(inv, testSupplier, nil))
+}
+
+func TestRenderPDF_PaymentQR(t *testing.T) {
+	inv := newTestInvoice()
+	bitmap := [][]bool{{true, false}, {false, true}}
+
+	document := string(RenderPDF(inv, testSupplier, bitmap))
+
+	assert.Contains(t, document, "(RF18 5390 0754 7034)")
+	assert.Contains(t, document, "(Scan to pay)")
+	assert.Equal(t, 2, strings.Count(document, " re f\n"))
+}
+
+func TestPdfEscape(t *testing.T) {
+	assert.Equal(t, `a\(b\)\\c`, pdfEscape(`a(b)\c`))
+	assert.Equal(t, `\374`, pdfEscape("ü"))
+	assert.Equal(t, "?", pdfEscape("ş"))
+}
@@ -0,0 +1,5 @@
+package document
+
+const (
+	PermissionVerify = "documents:write"
+)
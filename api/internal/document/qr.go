@@ -0,0 +1,35 @@
+package document
+
+import (
+	"errors"
+	"math"
+
+	"invoice-api/internal/invoice"
+	"invoice-api/pkg/payment"
+)
+
+var errNoPaymentReference = errors.New("invoice has no payment reference")
+
+type QRConfig struct {
+	DefaultFormat string
+	Formats       map[string]payment.Format
+}
+
+func paymentPayload(inv *invoice.InvoiceDTO, supplier Supplier, format payment.Format) (string, error) {
+	if inv.PaymentReference == nil {
+		return "", errNoPaymentReference
+	}
+
+	return format.Payload(payment.PayloadData{
+		Creditor: payment.Creditor{
+			Name:     supplier.Name,
+			IBAN:     supplier.IBAN,
+			BIC:      supplier.BIC,
+			Currency: supplier.Currency,
+		},
+		InvoiceId:     inv.Id,
+		InvoiceNumber: InvoiceNumber(inv),
+		Reference:     *inv.PaymentReference,
+		Amount:        math.Round(float64(inv.Amount)*100) / 100,
+	})
+}
@@ -0,0 +1,91 @@
+package document
+
+import (
+	"fmt"
+
+	"github.com/beevik/etree"
+
+	"invoice-api/internal/invoice"
+)
+
+type Supplier struct {
+	Name     string
+	TaxId    string
+	Currency string
+	IBAN     string
+	BIC      string
+}
+
+func InvoiceNumber(inv *invoice.InvoiceDTO) string {
+	if inv.Series == nil || inv.SequenceNumber == nil {
+		return inv.Id
+	}
+
+	return fmt.Sprintf("%s%d%09d", *inv.Series, inv.FinalizedAt.Year(), *inv.SequenceNumber)
+}
+
+func RenderUBL(inv *invoice.InvoiceDTO, supplier Supplier) ([]byte, error) {
+	doc := etree.NewDocument()
+	doc.CreateProcInst("xml", `version="1.0" encoding="UTF-8"`)
+
+	root := doc.CreateElement("Invoice")
+	root.CreateAttr("xmlns", "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2")
+	root.CreateAttr("xmlns:cac", "urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2")
+	root.CreateAttr("xmlns:cbc", "urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2")
+	root.CreateAttr("xmlns:ext", "urn:oasis:names:specification:ubl:schema:xsd:CommonExtensionComponents-2")
+
+	root.CreateElement("ext:UBLExtensions").
+		CreateElement("ext:UBLExtension").
+		CreateElement("ext:ExtensionContent")
+
+	amount := fmt.Sprintf("%.2f", inv.Amount)
+	date := inv.Date.UTC()
+
+	root.CreateElement("cbc:UBLVersionID").SetText("2.1")
+	root.CreateElement("cbc:CustomizationID").SetText("TR1.2")
+	root.CreateElement("cbc:ProfileID").SetText("TICARIFATURA")
+	root.CreateElement("cbc:ID").SetText(InvoiceNumber(inv))
+	root.CreateElement("cbc:UUID").SetText(inv.Id)
+	root.CreateElement("cbc:IssueDate").SetText(date.Format("2006-01-02"))
+	root.CreateElement("cbc:IssueTime").SetText(date.Format("15:04:05"))
+	root.CreateElement("cbc:InvoiceTypeCode").SetText("SATIS")
+	root.CreateElement("cbc:DocumentCurrencyCode").SetText(supplier.Currency)
+	root.CreateElement("cbc:LineCountNumeric").SetText("1")
+
+	supplierParty := root.CreateElement("cac:AccountingSupplierParty").CreateElement("cac:Party")
+	partyIdentification := supplierParty.CreateElement("cac:PartyIdentification").CreateElement("cbc:ID")
+	partyIdentification.CreateAttr("schemeID", "VKN")
+	partyIdentification.SetText(supplier.TaxId)
+	supplierParty.CreateElement("cac:PartyName").CreateElement("cbc:Name").SetText(supplier.Name)
+
+	if inv.PaymentReference != nil {
+		paymentMeans := root.CreateElement("cac:PaymentMeans")
+		paymentMeans.CreateElement("cbc:PaymentMeansCode").SetText("42")
+		paymentMeans.CreateElement("cbc:PaymentID").SetText(*inv.PaymentReference)
+		if supplier.IBAN != "" {
+			paymentMeans.CreateElement("cac:PayeeFinancialAccount").CreateElement("cbc:ID").SetText(supplier.IBAN)
+		}
+	}
+
+	monetaryTotal := root.CreateElement("cac:LegalMonetaryTotal")
+	for _, name := range []string{"LineExtensionAmount", "TaxExclusiveAmount", "TaxInclusiveAmount", "PayableAmount"} {
+		element := monetaryTotal.CreateElement("cbc:" + name)
+		element.CreateAttr("currencyID", supplier.Currency)
+		element.SetText(amount)
+	}
+
+	line := root.CreateElement("cac:InvoiceLine")
+	line.CreateElement("cbc:ID").SetText("1")
+	quantity := line.CreateElement("cbc:InvoicedQuantity")
+	quantity.CreateAttr("unitCode", "C62")
+	quantity.SetText("1")
+	lineAmount := line.CreateElement("cbc:LineExtensionAmount")
+	lineAmount.CreateAttr("currencyID", supplier.Currency)
+	lineAmount.SetText(amount)
+	line.CreateElement("cac:Item").CreateElement("cbc:Name").SetText(inv.ServiceName)
+	priceAmount := line.CreateElement("cac:Price").CreateElement("cbc:PriceAmount")
+	priceAmount.CreateAttr("currencyID", supplier.Currency)
+	priceAmount.SetText(amount)
+
+	return doc.WriteToBytes()
+}
@@ -0,0 +1,68 @@
+package document
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"invoice-api/internal/invoice"
+)
+
+var testSupplier = Supplier{
+	Name:     "Invoice Manager",
+	TaxId:    "1234567890",
+	Currency: "TRY",
+	IBAN:     "TR330006100519786457841326",
+}
+
+func newTestInvoice() *invoice.InvoiceDTO {
+	series := "DMP"
+	sequenceNumber := int64(42)
+	finalizedAt := time.Date(2025, 3, 14, 10, 0, 0, 0, time.UTC)
+	paymentReference := "RF18539007547034"
+
+	return &invoice.InvoiceDTO{
+		Id:             uuid.NewString(),
+		ServiceName:    "DMP",
+		Amount:         125.5,
+		Status:         "UNPAID",
+		Date:           time.Date(2025, 3, 14, 9, 30, 0, 0, time.UTC),
+		Direction:      invoice.DirectionOutbound,
+		Series:         &series,
+		SequenceNumber: &sequenceNumber,
+		FinalizedAt:    &finalizedAt,
+
+		PaymentReference: &paymentReference,
+	}
+}
+
+func TestInvoiceNumber(t *testing.T) {
+	t.Run("chained invoice", func(t *testing.T) {
+		assert.Equal(t, "DMP2025000000042", InvoiceNumber(newTestInvoice()))
+	})
+
+	t.Run("unchained invoice", func(t *testing.T) {
+		inv := &invoice.InvoiceDTO{Id: uuid.NewString()}
+		assert.Equal(t, inv.Id, InvoiceNumber(inv))
+	})
+}
+
+func TestRenderUBL(t *testing.T) {
+	inv := newTestInvoice()
+
+	document, err := RenderUBL(inv, testSupplier)
+	require.NoError(t, err)
+
+	parsed, errs := invoice.ParseUBLInvoice(bytes.NewReader(document))
+	require.Empty(t, errs)
+	assert.Equal(t, inv.Id, parsed.Id)
+	assert.Equal(t, inv.ServiceName, parsed.ServiceName)
+	assert.Equal(t, inv.Amount, parsed.Amount)
+	assert.True(t, inv.Date.Equal(parsed.Date))
+	assert.Contains(t, string(document), "<cbc:PaymentID>RF18539007547034</cbc:PaymentID>")
+	assert.Contains(t, string(document), "<cac:PayeeFinancialAccount><cbc:ID>TR330006100519786457841326</cbc:ID>")
+}
@@ -0,0 +1,58 @@
+package dunning
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+
+	"invoice-api/internal/invoice"
+	"invoice-api/pkg/auth"
+	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/requestcontext"
+	"invoice-api/pkg/tracing"
+)
+
+type Handler struct {
+	server     *fiber.App
+	validator  *validator.Validate
+	repository Repository
+	authorizer *auth.Authorizer
+}
+
+func NewHandler(server *fiber.App, validator *validator.Validate, repository Repository, authorizer *auth.Authorizer) *Handler {
+	return &Handler{
+		server:     server,
+		validator:  validator,
+		repository: repository,
+		authorizer: authorizer,
+	}
+}
+
+func (h *Handler) RegisterRoutes() {
+	h.server.Get("/invoices/:id/reminders", h.authorizer.Require(invoice.PermissionRead), h.GetReminders)
+}
+
+func (h *Handler) GetReminders(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "GetReminders"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "dunning.Handler.GetReminders")()
+
+	id := ctx.Params("id")
+	if err := h.validator.VarCtx(ctx.UserContext(), id, "required,uuid4"); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid invoice id",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	notices, err := h.repository.GetNotices(ctx.UserContext(), id)
+	if err != nil {
+		return err
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	return ctx.JSON(notices)
+}
@@ -0,0 +1,107 @@
+package dunning
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	json "github.com/bytedance/sonic"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+
+	"invoice-api/pkg/auth"
+	customError "invoice-api/pkg/error"
+)
+
+const invoiceId = "2f4b8d6e-3c1a-4e5f-9b7d-8a6c4e2f0b1d"
+
+func TestHandler_GetReminders(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("happy path", func(t *testing.T) {
+		notices := []NoticeDTO{{
+			InvoiceId: invoiceId,
+			Step:      "due",
+			StepOrder: 2,
+			Recipient: "dmp@example.com",
+			Locale:    "en",
+			SentAt:    time.Date(2024, 3, 31, 9, 0, 0, 0, time.UTC),
+		}}
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetNotices(gomock.Any(), invoiceId).Return(&notices, nil)
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, testAuthorizer)
+		h.RegisterRoutes()
+
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, "/invoices/"+invoiceId+"/reminders", nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+
+		var actual []NoticeDTO
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &actual))
+		assert.Equal(t, notices, actual)
+	})
+
+	t.Run("invalid invoice id", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, "/invoices/not-a-uuid/reminders", nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetNotices(gomock.Any(), invoiceId).Return(nil, customError.CustomError{
+			Code:    fiber.StatusInternalServerError,
+			Message: "failed to get dunning notices",
+		})
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, testAuthorizer)
+		h.RegisterRoutes()
+
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, "/invoices/"+invoiceId+"/reminders", nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusInternalServerError, res.StatusCode)
+	})
+}
+
+var testAuthorizer = auth.NewAuthorizer(auth.Policy{"admin": {auth.AllPermissions}})
+
+func SetupServer(t *testing.T) (*fiber.App, *validator.Validate) {
+	server := fiber.New(fiber.Config{
+		JSONDecoder:           json.Unmarshal,
+		JSONEncoder:           json.Marshal,
+		ErrorHandler:          customError.ErrorHandler,
+		DisableStartupMessage: true,
+	})
+
+	log, _ := zap.NewProduction()
+	defer func(log *zap.Logger) {
+		err := log.Sync()
+		if err != nil {
+			assert.FailNow(t, err.Error())
+		}
+	}(log)
+
+	server.Use(func(c *fiber.Ctx) error {
+		c.Locals("log", log)
+		c.Locals(auth.ContextKeyRoles, []string{"admin"})
+		return c.Next()
+	})
+
+	return server, validator.New()
+}
@@ -0,0 +1,47 @@
+package dunning
+
+import (
+	"context"
+	"time"
+
+	"invoice-api/pkg/metrics"
+	"invoice-api/pkg/tracing"
+)
+
+const repositoryName = "dunning"
+
+type InstrumentedRepository struct {
+	repository Repository
+}
+
+func NewInstrumentedRepository(repository Repository) *InstrumentedRepository {
+	return &InstrumentedRepository{repository: repository}
+}
+
+func (r *InstrumentedRepository) GetDueNotices(ctx context.Context, steps []Step, paymentTermDays int, now time.Time, limit int) (_ []DueNotice, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "GetDueNotices")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "GetDueNotices", time.Now(), &err)
+	return r.repository.GetDueNotices(ctx, steps, paymentTermDays, now, limit)
+}
+
+func (r *InstrumentedRepository) RecordNotice(ctx context.Context, notice NoticeDTO) (err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "RecordNotice")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "RecordNotice", time.Now(), &err)
+	return r.repository.RecordNotice(ctx, notice)
+}
+
+func (r *InstrumentedRepository) GetNotices(ctx context.Context, invoiceId string) (_ *[]NoticeDTO, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "GetNotices")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "GetNotices", time.Now(), &err)
+	return r.repository.GetNotices(ctx, invoiceId)
+}
+
+func (r *InstrumentedRepository) WithLock(ctx context.Context, key int64, fn func(ctx context.Context) error) (_ bool, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "WithLock")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "WithLock", time.Now(), &err)
+	return r.repository.WithLock(ctx, key, fn)
+}
@@ -0,0 +1,45 @@
+package dunning
+
+import (
+	"time"
+)
+
+type Step struct {
+	Name       string
+	OffsetDays int
+	Escalate   bool
+}
+
+type Recipient struct {
+	Email  string
+	Locale string
+}
+
+type Policy struct {
+	PaymentTermDays int
+	Steps           []Step
+	Recipients      map[string]Recipient
+	Escalation      Recipient
+	From            string
+	Currency        string
+}
+
+type DueNotice struct {
+	InvoiceId        string    `db:"invoice_id"`
+	ServiceName      string    `db:"service_name"`
+	Amount           float64   `db:"amount"`
+	Date             time.Time `db:"date"`
+	PaymentReference *string   `db:"payment_reference"`
+	DueDate          time.Time `db:"due_date"`
+	Step             string    `db:"step"`
+	StepOrder        int       `db:"step_order"`
+}
+
+type NoticeDTO struct {
+	InvoiceId string    `json:"invoiceId" db:"invoice_id"`
+	Step      string    `json:"step" db:"step"`
+	StepOrder int       `json:"stepOrder" db:"step_order"`
+	Recipient string    `json:"recipient" db:"recipient"`
+	Locale    string    `json:"locale" db:"locale"`
+	SentAt    time.Time `json:"sentAt" db:"sent_at"`
+}
@@ -0,0 +1,321 @@
+package dunning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	"invoice-api/internal/invoice"
+	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/requestcontext"
+	"invoice-api/pkg/tenant"
+	"invoice-api/pkg/tracing"
+)
+
+const noticeColumns = "invoice_id, step, step_order, recipient, locale, sent_at"
+
+type Repository interface {
+	GetDueNotices(ctx context.Context, steps []Step, paymentTermDays int, now time.Time, limit int) ([]DueNotice, error)
+	RecordNotice(ctx context.Context, notice NoticeDTO) error
+	GetNotices(ctx context.Context, invoiceId string) (*[]NoticeDTO, error)
+	WithLock(ctx context.Context, key int64, fn func(ctx context.Context) error) (bool, error)
+}
+
+type PgRepository struct {
+	connectionPool *pgxpool.Pool
+}
+
+func NewPgRepository(log *zap.Logger, host, port, username, password, database string) *PgRepository {
+	credentials := fmt.Sprintf("user=%s password=%s host=%s port=%s dbname=%s sslmode=disable", username, password, host, port, database)
+	pgConfig, err := pgxpool.ParseConfig(credentials)
+	if err != nil {
+		log.Fatal("failed to parse database config", zap.Error(err))
+	}
+	pgConfig.AfterConnect = tenant.AfterConnect
+	pgConfig.ConnConfig.Tracer = tracing.QueryTracer{}
+
+	var pgConnectionPool *pgxpool.Pool
+	pgConnectionPool, err = pgxpool.NewWithConfig(context.Background(), pgConfig)
+	if err != nil {
+		log.Fatal("failed to connect database", zap.Error(err))
+	}
+
+	var connection *pgxpool.Conn
+	connection, err = pgConnectionPool.Acquire(context.Background())
+	if err != nil {
+		log.Fatal("failed to acquire connection", zap.Error(err))
+	}
+	defer connection.Release()
+
+	err = connection.Ping(context.Background())
+	if err != nil {
+		log.Fatal("failed to ping database", zap.Error(err))
+	}
+
+	return &PgRepository{
+		connectionPool: pgConnectionPool,
+	}
+}
+
+func (r *PgRepository) Stat() *pgxpool.Stat {
+	return r.connectionPool.Stat()
+}
+
+func (r *PgRepository) Ping(ctx context.Context) error {
+	return r.connectionPool.Ping(ctx)
+}
+
+func (r *PgRepository) GetDueNotices(ctx context.Context, steps []Step, paymentTermDays int, now time.Time, limit int) ([]DueNotice, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	names := make([]string, 0, len(steps))
+	offsets := make([]int32, 0, len(steps))
+	for _, step := range steps {
+		names = append(names, step.Name)
+		offsets = append(offsets, int32(step.OffsetDays))
+	}
+
+	var rows pgx.Rows
+	rows, err = tx.Query(
+		ctx,
+		`select i.id as invoice_id, i.service_name::text as service_name, i.amount, i.date, i.payment_reference,
+			i.date + make_interval(days => $3) as due_date, s.name as step, s.ord::int as step_order
+		from invoices i
+		cross join lateral (
+			select name, ord from unnest($1::text[], $2::int[]) with ordinality as steps (name, offset_days, ord)
+			where i.date + make_interval(days => $3 + offset_days) <= $4
+			order by ord desc
+			limit 1
+		) s
+		where i.direction = 'OUTBOUND' and i.deleted_at is null and i.status in ('UNPAID', 'PENDING')
+		and not exists (select 1 from dunning_notices n where n.invoice_id = i.id and n.step_order >= s.ord)
+		order by i.date, i.id
+		limit $5`,
+		names,
+		offsets,
+		paymentTermDays,
+		now,
+		limit,
+	)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get due dunning notices",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var notices []DueNotice
+	notices, err = pgx.CollectRows(rows, pgx.RowToStructByName[DueNotice])
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect due dunning notices",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return notices, nil
+}
+
+func (r *PgRepository) RecordNotice(ctx context.Context, notice NoticeDTO) error {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	var tag pgconn.CommandTag
+	tag, err = tx.Exec(
+		ctx,
+		"insert into dunning_notices ("+noticeColumns+") values ($1, $2, $3, $4, $5, $6) on conflict (invoice_id, step) do nothing",
+		notice.InvoiceId,
+		notice.Step,
+		notice.StepOrder,
+		notice.Recipient,
+		notice.Locale,
+		notice.SentAt,
+	)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to record dunning notice",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	if tag.RowsAffected() == 0 {
+		return nil
+	}
+
+	if _, err = tx.Exec(
+		ctx,
+		"insert into invoice_events (invoice_id, type, actor, changes, occurred_at) values ($1, $2, $3, $4, $5)",
+		notice.InvoiceId,
+		invoice.EventReminder,
+		requestcontext.Actor(ctx),
+		map[string]invoice.FieldChange{
+			"reminder": {After: map[string]string{"step": notice.Step, "recipient": notice.Recipient, "locale": notice.Locale}},
+		},
+		notice.SentAt,
+	); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to record invoice event",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return nil
+}
+
+func (r *PgRepository) GetNotices(ctx context.Context, invoiceId string) (*[]NoticeDTO, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	var rows pgx.Rows
+	rows, err = tx.Query(ctx, "select "+noticeColumns+" from dunning_notices where invoice_id = $1 order by sent_at", invoiceId)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get dunning notices",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var notices []NoticeDTO
+	notices, err = pgx.CollectRows(rows, pgx.RowToStructByPos[NoticeDTO])
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect dunning notices",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return &notices, nil
+}
+
+func (r *PgRepository) WithLock(ctx context.Context, key int64, fn func(ctx context.Context) error) (bool, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return false, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var acquired bool
+	if err = connection.QueryRow(ctx, "select pg_try_advisory_lock($1)", key).Scan(&acquired); err != nil {
+		return false, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire advisory lock",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	if !acquired {
+		return false, nil
+	}
+	defer func() {
+		if _, err := connection.Exec(context.Background(), "select pg_advisory_unlock($1)", key); err != nil {
+			_ = connection.Conn().Close(context.Background())
+		}
+	}()
+
+	return true, fn(ctx)
+}
@@ -0,0 +1,101 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/dunning/repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/dunning/repository.go -destination=internal/dunning/repository_mock.go -package=dunning
+//
+
+// Package dunning is a generated GoMock package.
+package dunning
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetDueNotices mocks base method.
+func (m *MockRepository) GetDueNotices(ctx context.Context, steps []Step, paymentTermDays int, now time.Time, limit int) ([]DueNotice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDueNotices", ctx, steps, paymentTermDays, now, limit)
+	ret0, _ := ret[0].([]DueNotice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDueNotices indicates an expected call of GetDueNotices.
+func (mr *MockRepositoryMockRecorder) GetDueNotices(ctx, steps, paymentTermDays, now, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDueNotices", reflect.TypeOf((*MockRepository)(nil).GetDueNotices), ctx, steps, paymentTermDays, now, limit)
+}
+
+// GetNotices mocks base method.
+func (m *MockRepository) GetNotices(ctx context.Context, invoiceId string) (*[]NoticeDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotices", ctx, invoiceId)
+	ret0, _ := ret[0].(*[]NoticeDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotices indicates an expected call of GetNotices.
+func (mr *MockRepositoryMockRecorder) GetNotices(ctx, invoiceId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotices", reflect.TypeOf((*MockRepository)(nil).GetNotices), ctx, invoiceId)
+}
+
+// RecordNotice mocks base method.
+func (m *MockRepository) RecordNotice(ctx context.Context, notice NoticeDTO) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordNotice", ctx, notice)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordNotice indicates an expected call of RecordNotice.
+func (mr *MockRepositoryMockRecorder) RecordNotice(ctx, notice any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordNotice", reflect.TypeOf((*MockRepository)(nil).RecordNotice), ctx, notice)
+}
+
+// WithLock mocks base method.
+func (m *MockRepository) WithLock(ctx context.Context, key int64, fn func(context.Context) error) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithLock", ctx, key, fn)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WithLock indicates an expected call of WithLock.
+func (mr *MockRepositoryMockRecorder) WithLock(ctx, key, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithLock", reflect.TypeOf((*MockRepository)(nil).WithLock), ctx, key, fn)
+}
@@ -0,0 +1,103 @@
+package dunning
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+
+	"invoice-api/internal/invoice"
+	"invoice-api/pkg/requestcontext"
+	"invoice-api/pkg/tenant"
+)
+
+var tenantContext = requestcontext.WithTenant(context.Background(), tenant.DefaultTenant)
+
+func TestPgRepository_Notices(t *testing.T) {
+	pgContainer := setupContainer(t)
+	pgHost, err := pgContainer.Host(context.Background())
+	require.NoError(t, err)
+
+	pgPort, err := pgContainer.MappedPort(context.Background(), "5432/tcp")
+	require.NoError(t, err)
+
+	pgRepository := NewPgRepository(nil, pgHost, pgPort.Port(), "root", "root", "test")
+	invoices := invoice.NewPgRepository(nil, pgHost, pgPort.Port(), "root", "root", "test")
+
+	date := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	unpaid := &invoice.InvoiceDTO{Id: uuid.NewString(), ServiceName: "DMP", Amount: 100, Status: "UNPAID", Date: date}
+	paid := &invoice.InvoiceDTO{Id: uuid.NewString(), ServiceName: "SSP", Amount: 50, Status: "PAID", Date: date}
+	require.NoError(t, invoices.CreateInvoice(tenantContext, unpaid))
+	require.NoError(t, invoices.CreateInvoice(tenantContext, paid))
+
+	steps := []Step{{Name: "reminder", OffsetDays: -3}, {Name: "due"}, {Name: "overdue", OffsetDays: 7}}
+	now := time.Date(2023, 2, 2, 0, 0, 0, 0, time.UTC)
+
+	due, err := pgRepository.GetDueNotices(tenantContext, steps, 30, now, 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, unpaid.Id, due[0].InvoiceId)
+	assert.Equal(t, "due", due[0].Step)
+	assert.Equal(t, 2, due[0].StepOrder)
+	assert.Equal(t, time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC), due[0].DueDate)
+
+	notice := NoticeDTO{
+		InvoiceId: unpaid.Id,
+		Step:      "due",
+		StepOrder: 2,
+		Recipient: "dmp@example.com",
+		Locale:    "en",
+		SentAt:    now,
+	}
+	require.NoError(t, pgRepository.RecordNotice(tenantContext, notice))
+	require.NoError(t, pgRepository.RecordNotice(tenantContext, notice))
+
+	due, err = pgRepository.GetDueNotices(tenantContext, steps, 30, now, 10)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+
+	due, err = pgRepository.GetDueNotices(tenantContext, steps, 30, now.AddDate(0, 0, 7), 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, "overdue", due[0].Step)
+
+	notices, err := pgRepository.GetNotices(tenantContext, unpaid.Id)
+	require.NoError(t, err)
+	assert.Equal(t, []NoticeDTO{notice}, *notices)
+
+	events, err := invoices.GetInvoiceEvents(tenantContext, unpaid.Id)
+	require.NoError(t, err)
+	var reminders int
+	for _, event := range *events {
+		if event.Type == invoice.EventReminder {
+			reminders++
+		}
+	}
+	assert.Equal(t, 1, reminders)
+}
+
+func setupContainer(t *testing.T) *postgres.PostgresContainer {
+	ctx := context.Background()
+	postgresContainer, err := postgres.Run(
+		ctx,
+		"postgres",
+		postgres.WithDatabase("test"),
+		postgres.WithUsername("root"),
+		postgres.WithPassword("root"),
+		postgres.BasicWaitStrategies(),
+		postgres.WithSQLDriver("pgx"),
+		postgres.WithInitScripts("../../.scripts/init.sql"),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		err = postgresContainer.Terminate(ctx)
+		require.NoError(t, err)
+	})
+
+	return postgresContainer
+}
@@ -0,0 +1,114 @@
+package dunning
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Body    string
+}
+
+type Sender interface {
+	Send(ctx context.Context, message Message) error
+}
+
+type SMTPSender struct {
+	host     string
+	port     int
+	username string
+	password string
+	timeout  time.Duration
+}
+
+func NewSMTPSender(host string, port int, username, password string, timeout time.Duration) *SMTPSender {
+	return &SMTPSender{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		timeout:  timeout,
+	}
+}
+
+func (s *SMTPSender) Send(ctx context.Context, message Message) error {
+	dialer := net.Dialer{Timeout: s.timeout}
+	connection, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(s.host, strconv.Itoa(s.port)))
+	if err != nil {
+		return fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	if err = connection.SetDeadline(time.Now().Add(s.timeout)); err != nil {
+		_ = connection.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(connection, s.host)
+	if err != nil {
+		_ = connection.Close()
+		return fmt.Errorf("failed to start smtp session: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err = client.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return fmt.Errorf("failed to start tls: %w", err)
+		}
+	}
+
+	if s.username != "" {
+		if err = client.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+
+	if err = client.Mail(message.From); err != nil {
+		return err
+	}
+	if err = client.Rcpt(message.To); err != nil {
+		return err
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = writer.Write(message.Bytes(time.Now())); err != nil {
+		return err
+	}
+	if err = writer.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func (m Message) Bytes(date time.Time) []byte {
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, "From: %s\r\n", m.From)
+	fmt.Fprintf(&buffer, "To: %s\r\n", m.To)
+	fmt.Fprintf(&buffer, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&buffer, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	fmt.Fprintf(&buffer, "Message-ID: <%s@invoice-api>\r\n", uuid.NewString())
+	buffer.WriteString("MIME-Version: 1.0\r\n")
+	buffer.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buffer.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	writer := quotedprintable.NewWriter(&buffer)
+	_, _ = writer.Write(bytes.ReplaceAll([]byte(m.Body), []byte("\n"), []byte("\r\n")))
+	_ = writer.Close()
+
+	return buffer.Bytes()
+}
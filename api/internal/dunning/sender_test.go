@@ -0,0 +1,125 @@
+package dunning
+
+import (
+	"bufio"
+	"context"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type receivedMail struct {
+	from string
+	to   []string
+	data string
+}
+
+func startSMTPServer(t *testing.T) (string, int, <-chan receivedMail) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	received := make(chan receivedMail, 1)
+	go func() {
+		connection, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = connection.Close() }()
+
+		reader := bufio.NewReader(connection)
+		reply := func(line string) { _, _ = connection.Write([]byte(line + "\r\n")) }
+
+		var message receivedMail
+		reply("220 localhost ESMTP")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			command := strings.TrimRight(line, "\r\n")
+			switch {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(command, "MAIL FROM:"):
+				message.from = strings.Trim(strings.TrimPrefix(command, "MAIL FROM:"), "<>")
+				reply("250 OK")
+			case strings.HasPrefix(command, "RCPT TO:"):
+				message.to = append(message.to, strings.Trim(strings.TrimPrefix(command, "RCPT TO:"), "<>"))
+				reply("250 OK")
+			case command == "DATA":
+				reply("354 end data with <CR><LF>.<CR><LF>")
+				var data strings.Builder
+				for {
+					line, err = reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				message.data = data.String()
+				reply("250 OK")
+			case command == "QUIT":
+				reply("221 bye")
+				received <- message
+				return
+			default:
+				reply("502 command not implemented")
+			}
+		}
+	}()
+
+	address := listener.Addr().(*net.TCPAddr)
+	return address.IP.String(), address.Port, received
+}
+
+func TestSMTPSender_Send(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		host, port, received := startSMTPServer(t)
+		sender := NewSMTPSender(host, port, "", "", 5*time.Second)
+
+		err := sender.Send(context.TODO(), Message{
+			From:    "billing@example.com",
+			To:      "finance@example.com",
+			Subject: "Gecikmiş ödeme",
+			Body:    "Merhaba,\nödeme gecikmede.\n",
+		})
+		require.NoError(t, err)
+
+		message := <-received
+		assert.Equal(t, "billing@example.com", message.from)
+		assert.Equal(t, []string{"finance@example.com"}, message.to)
+
+		parsed, err := mail.ReadMessage(strings.NewReader(message.data))
+		require.NoError(t, err)
+		subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+		require.NoError(t, err)
+		assert.Equal(t, "Gecikmiş ödeme", subject)
+
+		var body strings.Builder
+		_, err = bufio.NewReader(quotedprintable.NewReader(parsed.Body)).WriteTo(&body)
+		require.NoError(t, err)
+		assert.Equal(t, "Merhaba,\r\nödeme gecikmede.\r\n", body.String())
+	})
+
+	t.Run("connection refused", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		port := listener.Addr().(*net.TCPAddr).Port
+		require.NoError(t, listener.Close())
+
+		sender := NewSMTPSender("127.0.0.1", port, "", "", time.Second)
+		err = sender.Send(context.TODO(), Message{From: "a@example.com", To: "b@example.com"})
+		assert.ErrorContains(t, err, "failed to connect to smtp server")
+	})
+}
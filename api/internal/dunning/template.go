@@ -0,0 +1,115 @@
+package dunning
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const DefaultLocale = "en"
+
+//go:embed templates
+var templateFiles embed.FS
+
+type TemplateData struct {
+	InvoiceId        string
+	ServiceName      string
+	Amount           string
+	Currency         string
+	Date             string
+	DueDate          string
+	DaysOverdue      int
+	PaymentReference string
+}
+
+type Renderer struct {
+	templates map[string]*template.Template
+}
+
+func NewRenderer() (*Renderer, error) {
+	files, err := templateFiles.ReadDir("templates")
+	if err != nil {
+		return nil, err
+	}
+
+	renderer := &Renderer{templates: make(map[string]*template.Template)}
+	for _, locale := range files {
+		var steps []string
+		steps, err = fileNames(path.Join("templates", locale.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, step := range steps {
+			name := path.Join(locale.Name(), step)
+			var parsed *template.Template
+			parsed, err = template.New(name).Option("missingkey=error").ParseFS(templateFiles, path.Join("templates", name+".tmpl"))
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse dunning template %s: %w", name, err)
+			}
+			renderer.templates[name] = parsed
+		}
+	}
+
+	return renderer, nil
+}
+
+func (r *Renderer) Render(locale, step string, data TemplateData) (string, string, error) {
+	parsed, ok := r.templates[path.Join(locale, step)]
+	if !ok {
+		parsed, ok = r.templates[path.Join(DefaultLocale, step)]
+	}
+	if !ok {
+		return "", "", fmt.Errorf("no dunning template for step %s", step)
+	}
+
+	var subject, body bytes.Buffer
+	if err := parsed.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return "", "", err
+	}
+	if err := parsed.ExecuteTemplate(&body, "body", data); err != nil {
+		return "", "", err
+	}
+
+	return strings.TrimSpace(subject.String()), strings.TrimSpace(body.String()) + "\n", nil
+}
+
+func (r *Renderer) Has(step string) bool {
+	_, ok := r.templates[path.Join(DefaultLocale, step)]
+	return ok
+}
+
+func newTemplateData(notice DueNotice, currency string, now time.Time) TemplateData {
+	data := TemplateData{
+		InvoiceId:   notice.InvoiceId,
+		ServiceName: notice.ServiceName,
+		Amount:      fmt.Sprintf("%.2f", notice.Amount),
+		Currency:    currency,
+		Date:        notice.Date.Format(time.DateOnly),
+		DueDate:     notice.DueDate.Format(time.DateOnly),
+		DaysOverdue: max(0, int(now.Sub(notice.DueDate).Hours()/24)),
+	}
+	if notice.PaymentReference != nil {
+		data.PaymentReference = *notice.PaymentReference
+	}
+
+	return data
+}
+
+func fileNames(dir string) ([]string, error) {
+	entries, err := templateFiles.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".tmpl"))
+	}
+
+	return names, nil
+}
@@ -0,0 +1,66 @@
+package dunning
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderer_Render(t *testing.T) {
+	renderer, err := NewRenderer()
+	require.NoError(t, err)
+
+	reference := "RF18 5390 0754 7034"
+	data := newTemplateData(DueNotice{
+		InvoiceId:        "2f4b8d6e-3c1a-4e5f-9b7d-8a6c4e2f0b1d",
+		ServiceName:      "DMP",
+		Amount:           120.5,
+		Date:             time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		PaymentReference: &reference,
+		DueDate:          time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC),
+	}, "EUR", time.Date(2024, 4, 7, 9, 0, 0, 0, time.UTC))
+
+	t.Run("english", func(t *testing.T) {
+		subject, body, err := renderer.Render("en", "overdue", data)
+		require.NoError(t, err)
+		assert.Equal(t, "Overdue payment: invoice 2f4b8d6e-3c1a-4e5f-9b7d-8a6c4e2f0b1d", subject)
+		assert.Contains(t, body, "120.50 EUR")
+		assert.Contains(t, body, "7 days overdue")
+		assert.Contains(t, body, reference)
+	})
+
+	t.Run("turkish", func(t *testing.T) {
+		subject, body, err := renderer.Render("tr", "overdue", data)
+		require.NoError(t, err)
+		assert.Equal(t, "Gecikmiş ödeme: 2f4b8d6e-3c1a-4e5f-9b7d-8a6c4e2f0b1d numaralı fatura", subject)
+		assert.Contains(t, body, "7 gündür gecikmede")
+	})
+
+	t.Run("falls back to default locale", func(t *testing.T) {
+		subject, _, err := renderer.Render("de", "due", data)
+		require.NoError(t, err)
+		expected, _, err := renderer.Render(DefaultLocale, "due", data)
+		require.NoError(t, err)
+		assert.Equal(t, expected, subject)
+	})
+
+	t.Run("unknown step", func(t *testing.T) {
+		_, _, err := renderer.Render("en", "unknown", data)
+		assert.Error(t, err)
+		assert.False(t, renderer.Has("unknown"))
+	})
+
+	t.Run("every locale defines subject and body", func(t *testing.T) {
+		for _, step := range []string{"reminder", "due", "overdue", "final", "escalation"} {
+			assert.True(t, renderer.Has(step), step)
+			for _, locale := range []string{"en", "tr"} {
+				subject, body, err := renderer.Render(locale, step, TemplateData{})
+				require.NoError(t, err, locale+"/"+step)
+				assert.NotEmpty(t, subject, locale+"/"+step)
+				assert.NotEmpty(t, body, locale+"/"+step)
+			}
+		}
+	})
+}
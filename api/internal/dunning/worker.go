@@ -0,0 +1,147 @@
+package dunning
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+
+	"invoice-api/pkg/requestcontext"
+)
+
+const (
+	workerLockKey = 0x64756e6e
+	workerActor   = "dunning"
+)
+
+type Worker struct {
+	log        *zap.Logger
+	repository Repository
+	sender     Sender
+	renderer   *Renderer
+	policy     Policy
+	batchSize  int
+	interval   time.Duration
+	now        func() time.Time
+}
+
+func NewWorker(
+	log *zap.Logger,
+	repository Repository,
+	sender Sender,
+	renderer *Renderer,
+	policy Policy,
+	batchSize int,
+	interval time.Duration,
+) *Worker {
+	policy.Steps = slices.SortedStableFunc(slices.Values(policy.Steps), func(a, b Step) int {
+		return cmp.Compare(a.OffsetDays, b.OffsetDays)
+	})
+
+	return &Worker{
+		log:        log,
+		repository: repository,
+		sender:     sender,
+		renderer:   renderer,
+		policy:     policy,
+		batchSize:  batchSize,
+		interval:   interval,
+		now:        time.Now,
+	}
+}
+
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.Process(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Worker) Process(ctx context.Context) int {
+	var sent int
+	_, err := w.repository.WithLock(ctx, workerLockKey, func(ctx context.Context) error {
+		ctx = requestcontext.WithActor(ctx, workerActor)
+		now := w.now().UTC()
+
+		notices, err := w.repository.GetDueNotices(ctx, w.policy.Steps, w.policy.PaymentTermDays, now, w.batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, notice := range notices {
+			var ok bool
+			if ok, err = w.send(ctx, notice, now); err != nil {
+				w.log.Error(
+					"failed to send dunning notice",
+					zap.String("invoiceId", notice.InvoiceId),
+					zap.String("step", notice.Step),
+					zap.Error(err),
+				)
+				continue
+			}
+			if ok {
+				sent++
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		w.log.Error("failed to process dunning notices", zap.Error(err))
+	}
+
+	return sent
+}
+
+func (w *Worker) send(ctx context.Context, notice DueNotice, now time.Time) (bool, error) {
+	step := w.step(notice.Step)
+	recipient, ok := w.policy.Recipients[notice.ServiceName]
+	if step.Escalate && w.policy.Escalation.Email != "" {
+		recipient, ok = w.policy.Escalation, true
+	}
+	if !ok || recipient.Email == "" {
+		w.log.Warn("no dunning recipient configured", zap.String("serviceName", notice.ServiceName))
+		return false, nil
+	}
+	if recipient.Locale == "" {
+		recipient.Locale = DefaultLocale
+	}
+
+	subject, body, err := w.renderer.Render(recipient.Locale, notice.Step, newTemplateData(notice, w.policy.Currency, now))
+	if err != nil {
+		return false, err
+	}
+
+	if err = w.sender.Send(ctx, Message{From: w.policy.From, To: recipient.Email, Subject: subject, Body: body}); err != nil {
+		return false, err
+	}
+
+	return true, w.repository.RecordNotice(ctx, NoticeDTO{
+		InvoiceId: notice.InvoiceId,
+		Step:      notice.Step,
+		StepOrder: notice.StepOrder,
+		Recipient: recipient.Email,
+		Locale:    recipient.Locale,
+		SentAt:    now,
+	})
+}
+
+func (w *Worker) step(name string) Step {
+	for _, step := range w.policy.Steps {
+		if step.Name == name {
+			return step
+		}
+	}
+
+	return Step{Name: name}
+}
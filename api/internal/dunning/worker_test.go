@@ -0,0 +1,151 @@
+package dunning
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+
+	"invoice-api/pkg/requestcontext"
+)
+
+type fakeSender struct {
+	messages []Message
+	err      error
+}
+
+func (s *fakeSender) Send(_ context.Context, message Message) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.messages = append(s.messages, message)
+	return nil
+}
+
+func TestWorker_Process(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	renderer, err := NewRenderer()
+	require.NoError(t, err)
+
+	now := time.Date(2024, 4, 7, 9, 0, 0, 0, time.UTC)
+	policy := Policy{
+		PaymentTermDays: 30,
+		Steps: []Step{
+			{Name: "escalation", OffsetDays: 21, Escalate: true},
+			{Name: "reminder", OffsetDays: -3},
+			{Name: "due"},
+			{Name: "overdue", OffsetDays: 7},
+			{Name: "final", OffsetDays: 14},
+		},
+		Recipients: map[string]Recipient{
+			"DMP": {Email: "dmp@example.com", Locale: "en"},
+			"SSP": {Email: "ssp@example.com", Locale: "tr"},
+		},
+		Escalation: Recipient{Email: "collections@example.com"},
+		From:       "billing@example.com",
+		Currency:   "EUR",
+	}
+	notice := DueNotice{
+		InvoiceId:   "2f4b8d6e-3c1a-4e5f-9b7d-8a6c4e2f0b1d",
+		ServiceName: "SSP",
+		Amount:      120.5,
+		Date:        time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		DueDate:     time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC),
+		Step:        "overdue",
+		StepOrder:   3,
+	}
+	withLock := func(_ context.Context, key int64, fn func(ctx context.Context) error) (bool, error) {
+		assert.Equal(t, int64(workerLockKey), key)
+		return true, fn(context.TODO())
+	}
+	orderedSteps := []Step{policy.Steps[1], policy.Steps[2], policy.Steps[3], policy.Steps[4], policy.Steps[0]}
+
+	t.Run("sends localized reminder and records it", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().WithLock(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(withLock)
+		mockRepository.EXPECT().GetDueNotices(gomock.Any(), orderedSteps, 30, now, 10).Return([]DueNotice{notice}, nil)
+		mockRepository.EXPECT().RecordNotice(gomock.Any(), NoticeDTO{
+			InvoiceId: notice.InvoiceId,
+			Step:      "overdue",
+			StepOrder: 3,
+			Recipient: "ssp@example.com",
+			Locale:    "tr",
+			SentAt:    now,
+		}).DoAndReturn(func(ctx context.Context, _ NoticeDTO) error {
+			assert.Equal(t, workerActor, requestcontext.Actor(ctx))
+			return nil
+		})
+
+		sender := &fakeSender{}
+		worker := NewWorker(zap.NewNop(), mockRepository, sender, renderer, policy, 10, time.Hour)
+		worker.now = func() time.Time { return now }
+		assert.Equal(t, 1, worker.Process(context.TODO()))
+
+		require.Len(t, sender.messages, 1)
+		assert.Equal(t, "billing@example.com", sender.messages[0].From)
+		assert.Equal(t, "ssp@example.com", sender.messages[0].To)
+		assert.Contains(t, sender.messages[0].Subject, "Gecikmiş ödeme")
+	})
+
+	t.Run("escalation goes to escalation recipient", func(t *testing.T) {
+		escalation := notice
+		escalation.Step = "escalation"
+		escalation.StepOrder = 5
+
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().WithLock(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(withLock)
+		mockRepository.EXPECT().GetDueNotices(gomock.Any(), gomock.Any(), 30, now, 10).Return([]DueNotice{escalation}, nil)
+		mockRepository.EXPECT().RecordNotice(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, dto NoticeDTO) error {
+			assert.Equal(t, "collections@example.com", dto.Recipient)
+			assert.Equal(t, DefaultLocale, dto.Locale)
+			return nil
+		})
+
+		sender := &fakeSender{}
+		worker := NewWorker(zap.NewNop(), mockRepository, sender, renderer, policy, 10, time.Hour)
+		worker.now = func() time.Time { return now }
+		assert.Equal(t, 1, worker.Process(context.TODO()))
+		require.Len(t, sender.messages, 1)
+		assert.Equal(t, "collections@example.com", sender.messages[0].To)
+	})
+
+	t.Run("failed delivery is not recorded", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().WithLock(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(withLock)
+		mockRepository.EXPECT().GetDueNotices(gomock.Any(), gomock.Any(), 30, now, 10).Return([]DueNotice{notice}, nil)
+
+		worker := NewWorker(zap.NewNop(), mockRepository, &fakeSender{err: errors.New("smtp down")}, renderer, policy, 10, time.Hour)
+		worker.now = func() time.Time { return now }
+		assert.Equal(t, 0, worker.Process(context.TODO()))
+	})
+
+	t.Run("missing recipient is skipped", func(t *testing.T) {
+		withoutRecipient := policy
+		withoutRecipient.Recipients = map[string]Recipient{}
+
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().WithLock(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(withLock)
+		mockRepository.EXPECT().GetDueNotices(gomock.Any(), gomock.Any(), 30, now, 10).Return([]DueNotice{notice}, nil)
+
+		sender := &fakeSender{}
+		worker := NewWorker(zap.NewNop(), mockRepository, sender, renderer, withoutRecipient, 10, time.Hour)
+		worker.now = func() time.Time { return now }
+		assert.Equal(t, 0, worker.Process(context.TODO()))
+		assert.Empty(t, sender.messages)
+	})
+
+	t.Run("lock held by another instance", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().WithLock(gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil)
+
+		worker := NewWorker(zap.NewNop(), mockRepository, &fakeSender{}, renderer, policy, 10, time.Hour)
+		assert.Equal(t, 0, worker.Process(context.TODO()))
+	})
+}
@@ -0,0 +1,130 @@
+package invoice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/requestcontext"
+)
+
+const (
+	EventCreated  = "CREATED"
+	EventUpdated  = "UPDATED"
+	EventDeleted  = "DELETED"
+	EventRestored = "RESTORED"
+	EventPurged   = "PURGED"
+	EventReminder = "REMINDER_SENT"
+)
+
+type FieldChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+type InvoiceEventDTO struct {
+	Id         int64                  `json:"id" db:"id"`
+	InvoiceId  string                 `json:"invoiceId" db:"invoice_id"`
+	Type       string                 `json:"type" db:"type"`
+	Actor      string                 `json:"actor" db:"actor"`
+	RequestId  *string                `json:"requestId,omitempty" db:"request_id"`
+	Changes    map[string]FieldChange `json:"changes" db:"changes"`
+	OccurredAt time.Time              `json:"occurredAt" db:"occurred_at"`
+}
+
+func diffInvoices(before, after *InvoiceDTO) map[string]FieldChange {
+	beforeFields := invoiceFields(before)
+	afterFields := invoiceFields(after)
+
+	changes := make(map[string]FieldChange)
+	for field, value := range beforeFields {
+		if !reflect.DeepEqual(value, afterFields[field]) {
+			changes[field] = FieldChange{Before: value, After: afterFields[field]}
+		}
+	}
+	for field, value := range afterFields {
+		if _, ok := beforeFields[field]; !ok {
+			changes[field] = FieldChange{After: value}
+		}
+	}
+
+	return changes
+}
+
+func invoiceFields(invoice *InvoiceDTO) map[string]any {
+	fields := make(map[string]any)
+	if invoice == nil {
+		return fields
+	}
+
+	payload, _ := json.Marshal(invoice)
+	_ = json.Unmarshal(payload, &fields)
+	return fields
+}
+
+func (r *PgRepository) insertEvent(ctx context.Context, tx pgx.Tx, eventType, invoiceId string, before, after *InvoiceDTO) error {
+	var requestId *string
+	if id := requestcontext.RequestId(ctx); id != "" {
+		requestId = &id
+	}
+
+	if _, err := tx.Exec(
+		ctx,
+		"insert into invoice_events (invoice_id, type, actor, request_id, changes, occurred_at) values ($1, $2, $3, $4, $5, $6)",
+		invoiceId,
+		eventType,
+		requestcontext.Actor(ctx),
+		requestId,
+		diffInvoices(before, after),
+		time.Now().UTC(),
+	); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to record invoice event",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return nil
+}
+
+func (r *PgRepository) selectInvoiceForUpdate(ctx context.Context, tx pgx.Tx, id string, deleted bool) (*InvoiceDTO, error) {
+	condition := "deleted_at is null"
+	if deleted {
+		condition = "deleted_at is not null"
+	}
+
+	rows, err := tx.Query(ctx, "select "+invoiceColumns+" from invoices where id = $1 and "+condition+" for update", id)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get invoice",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	invoice, err := pgx.CollectOneRow(rows, pgx.RowToStructByPos[InvoiceDTO])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect an invoice",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return &invoice, nil
+}
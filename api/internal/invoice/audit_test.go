@@ -0,0 +1,52 @@
+package invoice
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffInvoices(t *testing.T) {
+	date := time.Date(2025, 3, 14, 9, 30, 0, 0, time.UTC)
+	before := &InvoiceDTO{
+		Id:          "dda97bce-ac2a-4431-9c7b-3b6bcdfe8a23",
+		ServiceName: "DMP",
+		Amount:      120.5,
+		Status:      "UNPAID",
+		Date:        date,
+		Direction:   DirectionOutbound,
+	}
+
+	t.Run("created", func(t *testing.T) {
+		changes := diffInvoices(nil, before)
+
+		assert.Equal(t, FieldChange{After: "DMP"}, changes["serviceName"])
+		assert.Equal(t, FieldChange{After: 120.5}, changes["amount"])
+		assert.Len(t, changes, 6)
+	})
+
+	t.Run("updated", func(t *testing.T) {
+		after := *before
+		after.Status = "PAID"
+		after.Amount = 99
+
+		changes := diffInvoices(before, &after)
+
+		assert.Equal(t, map[string]FieldChange{
+			"status": {Before: "UNPAID", After: "PAID"},
+			"amount": {Before: 120.5, After: float64(99)},
+		}, changes)
+	})
+
+	t.Run("deleted", func(t *testing.T) {
+		changes := diffInvoices(before, nil)
+
+		assert.Equal(t, FieldChange{Before: "UNPAID"}, changes["status"])
+		assert.Len(t, changes, 6)
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		assert.Empty(t, diffInvoices(before, before))
+	})
+}
@@ -0,0 +1,151 @@
+package invoice
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	brokerBatchSize        = 500
+	subscriberBufferSize   = 64
+	listenerReconnectDelay = time.Second
+)
+
+type Broker struct {
+	log          *zap.Logger
+	repository   Repository
+	pollInterval time.Duration
+
+	mutex       sync.Mutex
+	subscribers map[chan StreamEventDTO]struct{}
+	lastId      int64
+	closed      bool
+}
+
+func NewBroker(log *zap.Logger, repository Repository, pollInterval time.Duration) *Broker {
+	return &Broker{
+		log:          log,
+		repository:   repository,
+		pollInterval: pollInterval,
+		subscribers:  make(map[chan StreamEventDTO]struct{}),
+		lastId:       -1,
+	}
+}
+
+func (b *Broker) Subscribe() (<-chan StreamEventDTO, func()) {
+	events := make(chan StreamEventDTO, subscriberBufferSize)
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		close(events)
+		return events, func() {}
+	}
+
+	b.subscribers[events] = struct{}{}
+	return events, func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+
+		if _, ok := b.subscribers[events]; ok {
+			delete(b.subscribers, events)
+			close(events)
+		}
+	}
+}
+
+func (b *Broker) Run(ctx context.Context) {
+	notifications := make(chan struct{}, 1)
+	go b.listen(ctx, notifications)
+
+	ticker := time.NewTicker(b.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		b.poll(ctx)
+
+		select {
+		case <-ctx.Done():
+			b.Close()
+			return
+		case <-notifications:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (b *Broker) Close() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.closed = true
+	for events := range b.subscribers {
+		delete(b.subscribers, events)
+		close(events)
+	}
+}
+
+func (b *Broker) listen(ctx context.Context, notifications chan<- struct{}) {
+	for ctx.Err() == nil {
+		err := b.repository.ListenInvoiceEvents(ctx, notifications)
+		if ctx.Err() != nil {
+			return
+		}
+
+		b.log.Warn("invoice event listener stopped, reconnecting", zap.Error(err))
+		select {
+		case <-ctx.Done():
+		case <-time.After(listenerReconnectDelay):
+		}
+	}
+}
+
+func (b *Broker) poll(ctx context.Context) {
+	if b.lastId < 0 {
+		lastId, err := b.repository.GetLatestInvoiceEventId(ctx)
+		if err != nil {
+			b.log.Error("failed to get latest invoice event", zap.Error(err))
+			return
+		}
+		b.lastId = lastId
+	}
+
+	for {
+		events, err := b.repository.GetInvoiceStreamEvents(ctx, b.lastId, brokerBatchSize)
+		if err != nil {
+			b.log.Error("failed to get invoice events", zap.Error(err))
+			return
+		}
+
+		b.broadcast(events)
+		if len(events) < brokerBatchSize {
+			return
+		}
+	}
+}
+
+func (b *Broker) broadcast(events []StreamEventDTO) {
+	if len(events) == 0 {
+		return
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, event := range events {
+		for subscriber := range b.subscribers {
+			select {
+			case subscriber <- event:
+			default:
+				b.log.Warn("dropping slow invoice stream subscriber")
+				delete(b.subscribers, subscriber)
+				close(subscriber)
+			}
+		}
+	}
+	b.lastId = events[len(events)-1].Id
+}
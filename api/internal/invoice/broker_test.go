@@ -0,0 +1,131 @@
+package invoice
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func TestBroker_Poll(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("broadcasts new events", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		gomock.InOrder(
+			mockRepository.EXPECT().GetLatestInvoiceEventId(gomock.Any()).Return(int64(10), nil),
+			mockRepository.EXPECT().GetInvoiceStreamEvents(gomock.Any(), int64(10), brokerBatchSize).Return([]StreamEventDTO{{Id: 11}, {Id: 12}}, nil),
+			mockRepository.EXPECT().GetInvoiceStreamEvents(gomock.Any(), int64(12), brokerBatchSize).Return(nil, nil),
+		)
+
+		broker := NewBroker(zap.NewNop(), mockRepository, time.Second)
+		events, unsubscribe := broker.Subscribe()
+		defer unsubscribe()
+
+		broker.poll(context.TODO())
+		broker.poll(context.TODO())
+
+		assert.Equal(t, int64(11), (<-events).Id)
+		assert.Equal(t, int64(12), (<-events).Id)
+		assert.Empty(t, events)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetLatestInvoiceEventId(gomock.Any()).Return(int64(0), errors.New("unavailable"))
+
+		broker := NewBroker(zap.NewNop(), mockRepository, time.Second)
+
+		assert.NotPanics(t, func() { broker.poll(context.TODO()) })
+		assert.Equal(t, int64(-1), broker.lastId)
+	})
+}
+
+func TestBroker_Broadcast(t *testing.T) {
+	t.Run("drops slow subscribers", func(t *testing.T) {
+		broker := NewBroker(zap.NewNop(), nil, time.Second)
+		slow, _ := broker.Subscribe()
+
+		events := make([]StreamEventDTO, subscriberBufferSize+1)
+		for i := range events {
+			events[i].Id = int64(i + 1)
+		}
+		broker.broadcast(events)
+
+		received := 0
+		for range slow {
+			received++
+		}
+		assert.Equal(t, subscriberBufferSize, received)
+		assert.Empty(t, broker.subscribers)
+	})
+
+	t.Run("unsubscribe", func(t *testing.T) {
+		broker := NewBroker(zap.NewNop(), nil, time.Second)
+		events, unsubscribe := broker.Subscribe()
+		unsubscribe()
+		unsubscribe()
+
+		_, ok := <-events
+		assert.False(t, ok)
+	})
+
+	t.Run("close", func(t *testing.T) {
+		broker := NewBroker(zap.NewNop(), nil, time.Second)
+		events, unsubscribe := broker.Subscribe()
+		broker.Close()
+		unsubscribe()
+
+		_, ok := <-events
+		assert.False(t, ok)
+
+		events, _ = broker.Subscribe()
+		_, ok = <-events
+		assert.False(t, ok)
+	})
+}
+
+func TestBroker_Run(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	mockRepository := NewMockRepository(mockController)
+	mockRepository.EXPECT().
+		ListenInvoiceEvents(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, notifications chan<- struct{}) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}).
+		AnyTimes()
+	mockRepository.EXPECT().
+		GetLatestInvoiceEventId(gomock.Any()).
+		DoAndReturn(func(context.Context) (int64, error) {
+			cancel()
+			return 0, nil
+		})
+	mockRepository.EXPECT().GetInvoiceStreamEvents(gomock.Any(), int64(0), brokerBatchSize).Return(nil, nil)
+
+	broker := NewBroker(zap.NewNop(), mockRepository, time.Hour)
+	events, _ := broker.Subscribe()
+
+	done := make(chan struct{})
+	go func() {
+		broker.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("broker did not stop")
+	}
+
+	_, ok := <-events
+	assert.False(t, ok)
+}
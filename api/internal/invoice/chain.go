@@ -0,0 +1,222 @@
+package invoice
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ChainSeries = []string{"DMP", "SSP"}
+	genesisHash = strings.Repeat("0", sha256.Size*2)
+)
+
+type ChainVerification struct {
+	Series     string           `json:"series"`
+	Checked    int              `json:"checked"`
+	Valid      bool             `json:"valid"`
+	BrokenLink *ChainBrokenLink `json:"brokenLink,omitempty"`
+}
+
+type ChainBrokenLink struct {
+	InvoiceId      string `json:"invoiceId"`
+	SequenceNumber int64  `json:"sequenceNumber"`
+	Reason         string `json:"reason"`
+}
+
+// chainLink is an entry of a hash chain: a chained invoice, or the tombstone
+// left behind when a deleted invoice is purged. A tombstone keeps the hash of
+// the purged invoice so the links around it can still be verified, but its
+// contents are gone, so its own hash is taken as stored.
+type chainLink struct {
+	InvoiceDTO
+	Purged bool
+}
+
+type chainTombstone struct {
+	InvoiceId      string    `db:"invoice_id"`
+	Series         string    `db:"series"`
+	SequenceNumber int64     `db:"sequence_number"`
+	Hash           string    `db:"hash"`
+	PreviousHash   string    `db:"previous_hash"`
+	FinalizedAt    time.Time `db:"finalized_at"`
+}
+
+func (t chainTombstone) link() chainLink {
+	return chainLink{
+		InvoiceDTO: InvoiceDTO{
+			Id:             t.InvoiceId,
+			ServiceName:    t.Series,
+			Series:         &t.Series,
+			SequenceNumber: &t.SequenceNumber,
+			Hash:           &t.Hash,
+			PreviousHash:   &t.PreviousHash,
+			FinalizedAt:    &t.FinalizedAt,
+		},
+		Purged: true,
+	}
+}
+
+// mergeChainLinks orders invoices and tombstones of a series by sequence number.
+func mergeChainLinks(invoices []InvoiceDTO, tombstones []chainTombstone) []chainLink {
+	links := make([]chainLink, 0, len(invoices)+len(tombstones))
+	for _, invoice := range invoices {
+		links = append(links, chainLink{InvoiceDTO: invoice})
+	}
+	for _, tombstone := range tombstones {
+		links = append(links, tombstone.link())
+	}
+	slices.SortFunc(links, func(a, b chainLink) int {
+		return cmp.Compare(*a.SequenceNumber, *b.SequenceNumber)
+	})
+
+	return links
+}
+
+type chainRecord struct {
+	Series         string `json:"series"`
+	SequenceNumber int64  `json:"sequenceNumber"`
+	PreviousHash   string `json:"previousHash"`
+	Id             string `json:"id"`
+	ServiceName    string `json:"serviceName"`
+	Amount         string `json:"amount"`
+	Date           string `json:"date"`
+	FinalizedAt    string `json:"finalizedAt"`
+}
+
+func chainHash(invoice *InvoiceDTO) string {
+	payload, _ := json.Marshal(chainRecord{
+		Series:         *invoice.Series,
+		SequenceNumber: *invoice.SequenceNumber,
+		PreviousHash:   *invoice.PreviousHash,
+		Id:             invoice.Id,
+		ServiceName:    invoice.ServiceName,
+		Amount:         strconv.FormatFloat(float64(invoice.Amount), 'f', -1, 32),
+		Date:           invoice.Date.UTC().Format(time.RFC3339Nano),
+		FinalizedAt:    invoice.FinalizedAt.UTC().Format(time.RFC3339Nano),
+	})
+
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+func linkToChain(invoice *InvoiceDTO, previous *InvoiceDTO, finalizedAt time.Time) {
+	series := invoice.ServiceName
+	sequenceNumber := int64(1)
+	previousHash := genesisHash
+	if previous != nil {
+		sequenceNumber = *previous.SequenceNumber + 1
+		previousHash = *previous.Hash
+	}
+
+	invoice.Date = invoice.Date.UTC().Truncate(time.Microsecond)
+	finalizedAt = finalizedAt.UTC().Truncate(time.Microsecond)
+	invoice.Series = &series
+	invoice.SequenceNumber = &sequenceNumber
+	invoice.PreviousHash = &previousHash
+	invoice.FinalizedAt = &finalizedAt
+
+	hash := chainHash(invoice)
+	invoice.Hash = &hash
+}
+
+// changesChainedFields reports whether an update touches fields covered by the
+// hash of a finalized invoice; only its status may change once it is chained.
+func changesChainedFields(before *InvoiceDTO, after *InvoiceDTO) bool {
+	return before.FinalizedAt != nil && (before.ServiceName != after.ServiceName || before.Amount != after.Amount)
+}
+
+func verifyChain(series string, previous *chainLink, links []chainLink) *ChainVerification {
+	verification := &ChainVerification{Series: series, Valid: true}
+	for i := range links {
+		link := &links[i]
+		verification.Checked++
+
+		if reason := checkChainLink(previous, link); reason != "" {
+			verification.Valid = false
+			verification.BrokenLink = &ChainBrokenLink{
+				InvoiceId:      link.Id,
+				SequenceNumber: *link.SequenceNumber,
+				Reason:         reason,
+			}
+			return verification
+		}
+
+		previous = link
+	}
+
+	return verification
+}
+
+func checkChainLink(previous *chainLink, invoice *chainLink) string {
+	expectedSequenceNumber := int64(1)
+	expectedPreviousHash := genesisHash
+	if previous != nil {
+		expectedSequenceNumber = *previous.SequenceNumber + 1
+		expectedPreviousHash = *previous.Hash
+	}
+
+	switch {
+	case previous == nil && *invoice.SequenceNumber != 1:
+		return fmt.Sprintf("preceding invoice with sequence number %d is missing", *invoice.SequenceNumber-1)
+	case *invoice.SequenceNumber != expectedSequenceNumber:
+		return fmt.Sprintf("invoices with sequence numbers %d to %d are missing", expectedSequenceNumber, *invoice.SequenceNumber-1)
+	case *invoice.PreviousHash != expectedPreviousHash:
+		return "previous hash does not match the hash of the preceding invoice"
+	case !invoice.Purged && chainHash(&invoice.InvoiceDTO) != *invoice.Hash:
+		return "stored hash does not match the invoice contents"
+	}
+
+	return ""
+}
+
+func VerifyChains(ctx context.Context, repository Repository, series string, from, to time.Time) ([]ChainVerification, error) {
+	seriesToVerify := ChainSeries
+	if series != "" {
+		seriesToVerify = []string{series}
+	}
+
+	verifications := make([]ChainVerification, 0, len(seriesToVerify))
+	for _, s := range seriesToVerify {
+		verification, err := repository.VerifyChain(ctx, s, from, to)
+		if err != nil {
+			return nil, err
+		}
+		verifications = append(verifications, *verification)
+	}
+
+	return verifications, nil
+}
+
+func ParseChainRange(from, to string) (time.Time, time.Time, error) {
+	var fromDate time.Time
+	toDate := time.Now().UTC()
+	if from != "" {
+		parsed, err := time.Parse(time.DateOnly, from)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		fromDate = parsed
+	}
+
+	if to != "" {
+		parsed, err := time.Parse(time.DateOnly, to)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		toDate = parsed.AddDate(0, 0, 1)
+	}
+
+	if !fromDate.Before(toDate) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from date must be before to date")
+	}
+
+	return fromDate, toDate, nil
+}
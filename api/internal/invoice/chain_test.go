@@ -0,0 +1,165 @@
+package invoice
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func buildChain(t *testing.T, length int) []InvoiceDTO {
+	t.Helper()
+
+	invoices := make([]InvoiceDTO, 0, length)
+	for i := 0; i < length; i++ {
+		invoice := InvoiceDTO{
+			Id:          uuid.NewString(),
+			ServiceName: "DMP",
+			Amount:      float32(100 + i),
+			Status:      "UNPAID",
+			Date:        time.Now().UTC(),
+			Direction:   DirectionOutbound,
+		}
+
+		var previous *InvoiceDTO
+		if i > 0 {
+			previous = &invoices[i-1]
+		}
+		linkToChain(&invoice, previous, time.Now())
+		invoices = append(invoices, invoice)
+	}
+
+	return invoices
+}
+
+func chainLinks(invoices ...InvoiceDTO) []chainLink {
+	return mergeChainLinks(invoices, nil)
+}
+
+func tombstoneOf(invoice InvoiceDTO) chainTombstone {
+	return chainTombstone{
+		InvoiceId:      invoice.Id,
+		Series:         *invoice.Series,
+		SequenceNumber: *invoice.SequenceNumber,
+		Hash:           *invoice.Hash,
+		PreviousHash:   *invoice.PreviousHash,
+		FinalizedAt:    *invoice.FinalizedAt,
+	}
+}
+
+func TestLinkToChain(t *testing.T) {
+	invoices := buildChain(t, 2)
+
+	assert.Equal(t, int64(1), *invoices[0].SequenceNumber)
+	assert.Equal(t, genesisHash, *invoices[0].PreviousHash)
+	assert.Equal(t, int64(2), *invoices[1].SequenceNumber)
+	assert.Equal(t, *invoices[0].Hash, *invoices[1].PreviousHash)
+	assert.Len(t, *invoices[1].Hash, 64)
+}
+
+func TestVerifyChain(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		invoices := buildChain(t, 3)
+
+		verification := verifyChain("DMP", nil, chainLinks(invoices...))
+
+		assert.True(t, verification.Valid)
+		assert.Equal(t, 3, verification.Checked)
+		assert.Nil(t, verification.BrokenLink)
+	})
+
+	t.Run("continues from preceding invoice", func(t *testing.T) {
+		invoices := buildChain(t, 3)
+
+		verification := verifyChain("DMP", &chainLinks(invoices[0])[0], chainLinks(invoices[1:]...))
+
+		assert.True(t, verification.Valid)
+		assert.Equal(t, 2, verification.Checked)
+	})
+
+	t.Run("altered invoice", func(t *testing.T) {
+		invoices := buildChain(t, 3)
+		invoices[1].Amount = 1
+
+		verification := verifyChain("DMP", nil, chainLinks(invoices...))
+
+		assert.False(t, verification.Valid)
+		require.NotNil(t, verification.BrokenLink)
+		assert.Equal(t, invoices[1].Id, verification.BrokenLink.InvoiceId)
+		assert.Equal(t, int64(2), verification.BrokenLink.SequenceNumber)
+	})
+
+	t.Run("removed invoice", func(t *testing.T) {
+		invoices := buildChain(t, 3)
+
+		verification := verifyChain("DMP", nil, chainLinks(invoices[0], invoices[2]))
+
+		assert.False(t, verification.Valid)
+		require.NotNil(t, verification.BrokenLink)
+		assert.Equal(t, int64(3), verification.BrokenLink.SequenceNumber)
+	})
+
+	t.Run("purged invoice", func(t *testing.T) {
+		invoices := buildChain(t, 3)
+		links := mergeChainLinks([]InvoiceDTO{invoices[2], invoices[0]}, []chainTombstone{tombstoneOf(invoices[1])})
+
+		verification := verifyChain("DMP", nil, links)
+
+		assert.True(t, verification.Valid)
+		assert.Equal(t, 3, verification.Checked)
+		assert.True(t, links[1].Purged)
+	})
+
+	t.Run("altered tombstone", func(t *testing.T) {
+		invoices := buildChain(t, 3)
+		tombstone := tombstoneOf(invoices[1])
+		tombstone.Hash = genesisHash
+
+		verification := verifyChain("DMP", nil, mergeChainLinks([]InvoiceDTO{invoices[0], invoices[2]}, []chainTombstone{tombstone}))
+
+		assert.False(t, verification.Valid)
+		require.NotNil(t, verification.BrokenLink)
+		assert.Equal(t, int64(3), verification.BrokenLink.SequenceNumber)
+	})
+
+	t.Run("missing preceding invoice", func(t *testing.T) {
+		invoices := buildChain(t, 3)
+
+		verification := verifyChain("DMP", nil, chainLinks(invoices[1:]...))
+
+		assert.False(t, verification.Valid)
+		require.NotNil(t, verification.BrokenLink)
+		assert.Equal(t, int64(2), verification.BrokenLink.SequenceNumber)
+	})
+}
+
+func TestChangesChainedFields(t *testing.T) {
+	draft := InvoiceDTO{Id: uuid.NewString(), ServiceName: "DMP", Amount: 100, Status: "UNPAID", Date: time.Now().UTC()}
+	chained := buildChain(t, 1)[0]
+
+	paid := chained
+	paid.Status = "PAID"
+	repriced := chained
+	repriced.Amount = 1
+	renamed := chained
+	renamed.ServiceName = "SSP"
+	draftRepriced := draft
+	draftRepriced.Amount = 1
+
+	assert.False(t, changesChainedFields(&chained, &paid))
+	assert.True(t, changesChainedFields(&chained, &repriced))
+	assert.True(t, changesChainedFields(&chained, &renamed))
+	assert.False(t, changesChainedFields(&draft, &draftRepriced))
+}
+
+func TestParseChainRange(t *testing.T) {
+	from, to, err := ParseChainRange("2025-01-01", "2025-01-31")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), from)
+	assert.Equal(t, time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), to)
+
+	_, _, err = ParseChainRange("2025-02-01", "2025-01-01")
+	assert.Error(t, err)
+}
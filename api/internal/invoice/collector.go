@@ -0,0 +1,61 @@
+package invoice
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"invoice-api/pkg/tenant"
+)
+
+var (
+	unpaidAmount = prometheus.NewDesc(
+		"invoices_unpaid_amount",
+		"Total amount of unpaid invoices by tenant, service and status.",
+		[]string{"tenant", "service", "status"},
+		nil,
+	)
+	unpaidCount = prometheus.NewDesc(
+		"invoices_unpaid_count",
+		"Number of unpaid invoices by tenant, service and status.",
+		[]string{"tenant", "service", "status"},
+		nil,
+	)
+)
+
+type Collector struct {
+	log        *zap.Logger
+	repository Repository
+	timeout    time.Duration
+}
+
+func NewCollector(log *zap.Logger, repository Repository, timeout time.Duration) *Collector {
+	return &Collector{
+		log:        log,
+		repository: repository,
+		timeout:    timeout,
+	}
+}
+
+func (c *Collector) Describe(descriptions chan<- *prometheus.Desc) {
+	descriptions <- unpaidAmount
+	descriptions <- unpaidCount
+}
+
+func (c *Collector) Collect(metrics chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(tenant.WithSystem(context.Background()), c.timeout)
+	defer cancel()
+
+	totals, err := c.repository.GetUnpaidTotals(ctx)
+	if err != nil {
+		c.log.Warn("failed to collect unpaid invoice totals", zap.Error(err))
+		return
+	}
+
+	for _, total := range totals {
+		metrics <- prometheus.MustNewConstMetric(unpaidAmount, prometheus.GaugeValue, total.Amount, total.TenantId, total.ServiceName, total.Status)
+		metrics <- prometheus.MustNewConstMetric(unpaidCount, prometheus.GaugeValue, float64(total.Count), total.TenantId, total.ServiceName, total.Status)
+	}
+}
@@ -0,0 +1,62 @@
+package invoice
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func TestCollector_Collect(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("happy path", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetUnpaidTotals(gomock.Any()).Return([]UnpaidTotalDTO{
+			{TenantId: "default", ServiceName: "DMP", Status: "UNPAID", Count: 2, Amount: 240.5},
+			{TenantId: "default", ServiceName: "SSP", Status: "PENDING", Count: 1, Amount: 99},
+		}, nil)
+
+		expected := `
+# HELP invoices_unpaid_amount Total amount of unpaid invoices by tenant, service and status.
+# TYPE invoices_unpaid_amount gauge
+invoices_unpaid_amount{service="DMP",status="UNPAID",tenant="default"} 240.5
+invoices_unpaid_amount{service="SSP",status="PENDING",tenant="default"} 99
+# HELP invoices_unpaid_count Number of unpaid invoices by tenant, service and status.
+# TYPE invoices_unpaid_count gauge
+invoices_unpaid_count{service="DMP",status="UNPAID",tenant="default"} 2
+invoices_unpaid_count{service="SSP",status="PENDING",tenant="default"} 1
+`
+		collector := NewCollector(zap.NewNop(), mockRepository, time.Second)
+		assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected)))
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetUnpaidTotals(gomock.Any()).Return(nil, errors.New("connection reset"))
+
+		collector := NewCollector(zap.NewNop(), mockRepository, time.Second)
+		assert.Zero(t, testutil.CollectAndCount(collector))
+	})
+}
+
+func TestInstrumentedRepository(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	mockRepository := NewMockRepository(mockController)
+	mockRepository.EXPECT().GetInvoiceById(gomock.Any(), "dda97bce-ac2a-4431-9c7b-3b6bcdfe8a23").Return(&InvoiceDTO{ServiceName: "DMP"}, nil)
+	mockRepository.EXPECT().DeleteInvoiceById(gomock.Any(), "dda97bce-ac2a-4431-9c7b-3b6bcdfe8a23").Return(errors.New("connection reset"))
+
+	repository := NewInstrumentedRepository(mockRepository)
+	invoice, err := repository.GetInvoiceById(t.Context(), "dda97bce-ac2a-4431-9c7b-3b6bcdfe8a23")
+	assert.NoError(t, err)
+	assert.Equal(t, "DMP", invoice.ServiceName)
+	assert.EqualError(t, repository.DeleteInvoiceById(t.Context(), "dda97bce-ac2a-4431-9c7b-3b6bcdfe8a23"), "connection reset")
+}
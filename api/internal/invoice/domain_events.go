@@ -0,0 +1,47 @@
+package invoice
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+
+	"invoice-api/internal/outbox"
+)
+
+const (
+	AggregateInvoice = "invoice"
+
+	DomainEventCreated  = "invoice.created"
+	DomainEventUpdated  = "invoice.updated"
+	DomainEventPaid     = "invoice.paid"
+	DomainEventVoided   = "invoice.voided"
+	DomainEventRestored = "invoice.restored"
+)
+
+func domainEvents(eventType string, before, after *InvoiceDTO) []string {
+	switch eventType {
+	case EventCreated:
+		return []string{DomainEventCreated}
+	case EventUpdated:
+		if before.Status != "PAID" && after.Status == "PAID" {
+			return []string{DomainEventUpdated, DomainEventPaid}
+		}
+		return []string{DomainEventUpdated}
+	case EventDeleted:
+		return []string{DomainEventVoided}
+	case EventRestored:
+		return []string{DomainEventRestored}
+	default:
+		return nil
+	}
+}
+
+func (r *PgRepository) writeOutbox(ctx context.Context, tx pgx.Tx, eventType string, before, after *InvoiceDTO) error {
+	for _, domainEvent := range domainEvents(eventType, before, after) {
+		if err := outbox.Write(ctx, tx, AggregateInvoice, after.Id, domainEvent, after); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,20 @@
+package invoice
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDomainEvents(t *testing.T) {
+	unpaid := &InvoiceDTO{Status: "UNPAID"}
+	paid := &InvoiceDTO{Status: "PAID"}
+
+	assert.Equal(t, []string{DomainEventCreated}, domainEvents(EventCreated, nil, unpaid))
+	assert.Equal(t, []string{DomainEventUpdated}, domainEvents(EventUpdated, unpaid, unpaid))
+	assert.Equal(t, []string{DomainEventUpdated, DomainEventPaid}, domainEvents(EventUpdated, unpaid, paid))
+	assert.Equal(t, []string{DomainEventUpdated}, domainEvents(EventUpdated, paid, paid))
+	assert.Equal(t, []string{DomainEventVoided}, domainEvents(EventDeleted, paid, paid))
+	assert.Equal(t, []string{DomainEventRestored}, domainEvents(EventRestored, paid, paid))
+	assert.Empty(t, domainEvents(EventPurged, paid, nil))
+}
@@ -1,40 +1,66 @@
 package invoice
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 
+	"invoice-api/pkg/auth"
 	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/requestcontext"
+	"invoice-api/pkg/tracing"
+)
+
+const (
+	streamHeartbeatInterval = 15 * time.Second
+	streamReplayBatchSize   = 500
 )
 
 type Handler struct {
 	server     *fiber.App
 	validator  *validator.Validate
 	repository Repository
+	broker     *Broker
+	authorizer *auth.Authorizer
 }
 
-func NewHandler(server *fiber.App, validator *validator.Validate, repository Repository) *Handler {
+func NewHandler(server *fiber.App, validator *validator.Validate, repository Repository, broker *Broker, authorizer *auth.Authorizer) *Handler {
 	return &Handler{
 		server:     server,
 		validator:  validator,
 		repository: repository,
+		broker:     broker,
+		authorizer: authorizer,
 	}
 }
 
 func (h *Handler) RegisterRoutes() {
-	h.server.Post("/invoices", h.CreateInvoice)
-	h.server.Get("/invoices", h.GetInvoices)
-	h.server.Get("/invoices/:id", h.GetInvoiceById)
-	h.server.Put("/invoices/:id", h.UpdateInvoiceById)
-	h.server.Delete("/invoices/:id", h.DeleteInvoiceById)
+	h.server.Post("/invoices", h.authorizer.Require(PermissionCreate), h.CreateInvoice)
+	h.server.Post("/invoices/inbound", h.authorizer.Require(PermissionCreate), h.CreateInboundInvoice)
+	h.server.Get("/invoices", h.authorizer.Require(PermissionRead), h.GetInvoices)
+	h.server.Get("/invoices/chain/verify", h.authorizer.Require(PermissionRead), h.VerifyChain)
+	h.server.Get("/invoices/stream", h.authorizer.Require(PermissionRead), h.StreamInvoices)
+	h.server.Get("/invoices/:id", h.authorizer.Require(PermissionRead), h.GetInvoiceById)
+	h.server.Get("/invoices/:id/history", h.authorizer.Require(PermissionRead), h.GetInvoiceHistory)
+	h.server.Put("/invoices/:id", h.authorizer.Require(PermissionUpdate), h.UpdateInvoiceById)
+	h.server.Delete("/invoices/:id", h.authorizer.Require(PermissionDelete), h.DeleteInvoiceById)
+	h.server.Post("/invoices/:id/restore", h.authorizer.Require(PermissionRestore), h.RestoreInvoiceById)
 }
 
 func (h *Handler) CreateInvoice(ctx *fiber.Ctx) error {
 	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
-	log.With(zap.String("method", "CreateInvoice"))
-	ctx.Locals(customError.ContextKeyLog, log)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "CreateInvoice"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "invoice.Handler.CreateInvoice")()
 
 	var reqBody CreateInvoiceRequest
 	if err := ctx.BodyParser(&reqBody); err != nil {
@@ -59,7 +85,8 @@ func (h *Handler) CreateInvoice(ctx *fiber.Ctx) error {
 		ServiceName: reqBody.ServiceName,
 		Amount:      reqBody.Amount,
 		Status:      reqBody.Status,
-		Date:        reqBody.Date,
+		Date:        time.Now().UTC(),
+		Direction:   DirectionOutbound,
 	}); err != nil {
 		return err
 	}
@@ -68,10 +95,48 @@ func (h *Handler) CreateInvoice(ctx *fiber.Ctx) error {
 	return ctx.SendStatus(fiber.StatusCreated)
 }
 
+func (h *Handler) CreateInboundInvoice(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "CreateInboundInvoice"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "invoice.Handler.CreateInboundInvoice")()
+
+	if !ctx.Is("xml") {
+		return customError.CustomError{
+			Code:     fiber.StatusUnsupportedMediaType,
+			Message:  "request body must be an xml document",
+			Severity: zap.WarnLevel,
+		}
+	}
+
+	invoice, errs := ParseUBLInvoice(bytes.NewReader(ctx.Body()))
+	if len(errs) > 0 {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid ubl document",
+			Severity: zap.WarnLevel,
+			Details:  errs,
+		}
+	}
+
+	if invoice.Id == "" {
+		invoice.Id = uuid.NewString()
+	}
+
+	if err := h.repository.CreateInvoice(ctx.UserContext(), invoice); err != nil {
+		return err
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	ctx.Location(fmt.Sprintf("/invoices/%s", invoice.Id))
+	return ctx.SendStatus(fiber.StatusCreated)
+}
+
 func (h *Handler) GetInvoices(ctx *fiber.Ctx) error {
 	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
-	log.With(zap.String("method", "GetInvoices"))
-	ctx.Locals(customError.ContextKeyLog, log)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "GetInvoices"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "invoice.Handler.GetInvoices")()
 
 	var queries GetInvoicesRequest
 	if err := ctx.QueryParser(&queries); err != nil {
@@ -105,6 +170,7 @@ func (h *Handler) GetInvoices(ctx *fiber.Ctx) error {
 		queries.Page,
 		queries.PageSize,
 		queries.Search,
+		queries.Deleted,
 	)
 	if err != nil {
 		return err
@@ -114,10 +180,55 @@ func (h *Handler) GetInvoices(ctx *fiber.Ctx) error {
 	return ctx.JSON(invoices)
 }
 
+func (h *Handler) VerifyChain(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "VerifyChain"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "invoice.Handler.VerifyChain")()
+
+	var queries VerifyChainRequest
+	if err := ctx.QueryParser(&queries); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid request query",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if err := h.validator.StructCtx(ctx.UserContext(), &queries); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid request query",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	from, to, err := ParseChainRange(queries.From, queries.To)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid date range",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	verifications, err := VerifyChains(ctx.UserContext(), h.repository, queries.Series, from, to)
+	if err != nil {
+		return err
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	return ctx.JSON(verifications)
+}
+
 func (h *Handler) GetInvoiceById(ctx *fiber.Ctx) error {
 	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
-	log.With(zap.String("method", "GetInvoiceById"))
-	ctx.Locals(customError.ContextKeyLog, log)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "GetInvoiceById"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "invoice.Handler.GetInvoiceById")()
 
 	invoiceId := ctx.Params("id")
 	if err := h.validator.VarCtx(ctx.UserContext(), invoiceId, "required,uuid4"); err != nil {
@@ -138,10 +249,36 @@ func (h *Handler) GetInvoiceById(ctx *fiber.Ctx) error {
 	return ctx.JSON(invoice)
 }
 
+func (h *Handler) GetInvoiceHistory(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "GetInvoiceHistory"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "invoice.Handler.GetInvoiceHistory")()
+
+	invoiceId := ctx.Params("id")
+	if err := h.validator.VarCtx(ctx.UserContext(), invoiceId, "required,uuid4"); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid invoice id",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	events, err := h.repository.GetInvoiceEvents(ctx.UserContext(), invoiceId)
+	if err != nil {
+		return err
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	return ctx.JSON(events)
+}
+
 func (h *Handler) UpdateInvoiceById(ctx *fiber.Ctx) error {
 	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
-	log.With(zap.String("method", "UpdateInvoiceById"))
-	ctx.Locals(customError.ContextKeyLog, log)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "UpdateInvoiceById"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "invoice.Handler.UpdateInvoiceById")()
 
 	var reqBody CreateInvoiceRequest
 	if err := ctx.BodyParser(&reqBody); err != nil {
@@ -165,6 +302,12 @@ func (h *Handler) UpdateInvoiceById(ctx *fiber.Ctx) error {
 		}
 	}
 
+	if reqBody.Status == "PAID" {
+		if err := h.authorizer.Check(ctx, PermissionMarkPaid); err != nil {
+			return err
+		}
+	}
+
 	if err := h.repository.UpdateInvoiceById(ctx.UserContext(), invoiceId, &InvoiceDTO{
 		Id:          invoiceId,
 		ServiceName: reqBody.ServiceName,
@@ -180,8 +323,9 @@ func (h *Handler) UpdateInvoiceById(ctx *fiber.Ctx) error {
 
 func (h *Handler) DeleteInvoiceById(ctx *fiber.Ctx) error {
 	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
-	log.With(zap.String("method", "DeleteInvoiceById"))
-	ctx.Locals(customError.ContextKeyLog, log)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "DeleteInvoiceById"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "invoice.Handler.DeleteInvoiceById")()
 
 	id := ctx.Params("id")
 	if err := h.validator.VarCtx(ctx.UserContext(), id, "required,uuid4"); err != nil {
@@ -199,3 +343,176 @@ func (h *Handler) DeleteInvoiceById(ctx *fiber.Ctx) error {
 	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
 	return ctx.SendStatus(fiber.StatusNoContent)
 }
+
+func (h *Handler) RestoreInvoiceById(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "RestoreInvoiceById"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "invoice.Handler.RestoreInvoiceById")()
+
+	id := ctx.Params("id")
+	if err := h.validator.VarCtx(ctx.UserContext(), id, "required,uuid4"); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid invoice id",
+			Severity: zap.WarnLevel,
+		}
+	}
+
+	if err := h.repository.RestoreInvoiceById(ctx.UserContext(), id); err != nil {
+		return err
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) StreamInvoices(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "StreamInvoices"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "invoice.Handler.StreamInvoices")()
+
+	if h.broker == nil {
+		return customError.CustomError{
+			Code:     fiber.StatusServiceUnavailable,
+			Message:  "invoice stream is not available",
+			Severity: zap.WarnLevel,
+		}
+	}
+
+	var queries GetInvoicesRequest
+	if err := ctx.QueryParser(&queries); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid request query",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if err := h.validator.StructCtx(ctx.UserContext(), &queries); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid request query",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var lastEventId int64
+	if header := ctx.Get(HeaderLastEventId); header != "" {
+		var err error
+		lastEventId, err = strconv.ParseInt(header, 10, 64)
+		if err != nil || lastEventId < 0 {
+			return customError.CustomError{
+				Code:     fiber.StatusBadRequest,
+				Message:  "invalid last event id",
+				Severity: zap.WarnLevel,
+				Fields:   []zap.Field{zap.String("lastEventId", header)},
+			}
+		}
+	}
+
+	filter := StreamFilter{
+		Tenant:  requestcontext.Tenant(ctx.UserContext()),
+		Search:  queries.Search,
+		Deleted: queries.Deleted,
+	}
+	events, unsubscribe := h.broker.Subscribe()
+	userContext := ctx.UserContext()
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	ctx.Set(fiber.HeaderContentType, "text/event-stream")
+	ctx.Set(fiber.HeaderCacheControl, "no-cache")
+	ctx.Set(fiber.HeaderConnection, "keep-alive")
+	ctx.Set("X-Accel-Buffering", "no")
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+		h.stream(userContext, log, w, filter, lastEventId, events)
+	})
+	return nil
+}
+
+func (h *Handler) stream(
+	ctx context.Context,
+	log *zap.Logger,
+	w *bufio.Writer,
+	filter StreamFilter,
+	lastEventId int64,
+	events <-chan StreamEventDTO,
+) {
+	if _, err := fmt.Fprint(w, "retry: 3000\n\n"); err != nil {
+		return
+	}
+
+	if lastEventId > 0 {
+		for {
+			missed, err := h.repository.GetInvoiceStreamEvents(ctx, lastEventId, streamReplayBatchSize)
+			if err != nil {
+				log.Error("failed to replay invoice events", zap.Error(err))
+				return
+			}
+
+			for _, event := range missed {
+				lastEventId = event.Id
+				if filter.Match(event) {
+					if err = writeStreamEvent(w, event); err != nil {
+						return
+					}
+				}
+			}
+
+			if len(missed) < streamReplayBatchSize {
+				break
+			}
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+
+			if event.Id <= lastEventId {
+				continue
+			}
+			lastEventId = event.Id
+
+			if !filter.Match(event) {
+				continue
+			}
+
+			if err := writeStreamEvent(w, event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeStreamEvent(w *bufio.Writer, event StreamEventDTO) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\ndata: %s\n\n", event.Id, data)
+	return err
+}
@@ -1,7 +1,9 @@
 package invoice
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -17,16 +19,17 @@ import (
 	"go.uber.org/mock/gomock"
 	"go.uber.org/zap"
 
+	"invoice-api/pkg/auth"
 	customError "invoice-api/pkg/error"
 )
 
 func TestHandler_NewHandler(t *testing.T) {
-	h := NewHandler(nil, nil, nil)
+	h := NewHandler(nil, nil, nil, nil, nil)
 	assert.NotNil(t, h)
 }
 
 func TestHandler_RegisterRoutes(t *testing.T) {
-	h := NewHandler(fiber.New(), nil, nil)
+	h := NewHandler(fiber.New(), nil, nil, nil, nil)
 
 	assert.NotPanics(t, h.RegisterRoutes)
 }
@@ -40,7 +43,7 @@ func TestHandler_CreateInvoice(t *testing.T) {
 		mockRepository.EXPECT().CreateInvoice(gomock.Any(), gomock.Any()).Return(nil).Times(3)
 
 		server, validate := SetupServer(t)
-		h := NewHandler(server, validate, mockRepository)
+		h := NewHandler(server, validate, mockRepository, nil, testAuthorizer)
 		h.RegisterRoutes()
 
 		requestBody := []CreateInvoiceRequest{
@@ -81,9 +84,38 @@ func TestHandler_CreateInvoice(t *testing.T) {
 		}
 	})
 
+	t.Run("stores the creation time", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().CreateInvoice(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, invoice *InvoiceDTO) error {
+			assert.WithinDuration(t, time.Now().UTC(), invoice.Date, time.Minute)
+			assert.Equal(t, DirectionOutbound, invoice.Direction)
+			return nil
+		})
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		marshalledReqBody, err := json.Marshal(CreateInvoiceRequest{
+			ServiceName: "DMP",
+			Date:        time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC),
+			Amount:      1,
+			Status:      "PENDING",
+		})
+		assert.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/invoices", strings.NewReader(string(marshalledReqBody)))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		res, err := server.Test(req, -1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+	})
+
 	t.Run("invalid request body", func(t *testing.T) {
 		server, validate := SetupServer(t)
-		h := NewHandler(server, validate, nil)
+		h := NewHandler(server, validate, nil, nil, testAuthorizer)
 		h.RegisterRoutes()
 
 		requestBody := []interface{}{
@@ -137,7 +169,7 @@ func TestHandler_CreateInvoice(t *testing.T) {
 		})
 
 		server, validate := SetupServer(t)
-		h := NewHandler(server, validate, mockRepository)
+		h := NewHandler(server, validate, mockRepository, nil, testAuthorizer)
 		h.RegisterRoutes()
 
 		reqBody := CreateInvoiceRequest{
@@ -163,6 +195,92 @@ func TestHandler_CreateInvoice(t *testing.T) {
 	})
 }
 
+func TestHandler_CreateInboundInvoice(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("happy path", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.
+			EXPECT().
+			CreateInvoice(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ any, invoice *InvoiceDTO) error {
+				assert.Equal(t, DirectionInbound, invoice.Direction)
+				assert.Equal(t, "UNPAID", invoice.Status)
+				return nil
+			})
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		req := httptest.NewRequest(http.MethodPost, "/invoices/inbound", strings.NewReader(testUBLInvoice))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationXML)
+
+		res, err := server.Test(req, -1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+		assert.Equal(t, "/invoices/3f1c8a4e-5b7d-4c2a-9e1f-0a2b3c4d5e6f", res.Header.Get(fiber.HeaderLocation))
+	})
+
+	t.Run("unsupported media type", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, nil, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		req := httptest.NewRequest(http.MethodPost, "/invoices/inbound", strings.NewReader(testUBLInvoice))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		res, err := server.Test(req, -1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusUnsupportedMediaType, res.StatusCode)
+	})
+
+	t.Run("invalid document", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, nil, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		document := strings.Replace(testUBLInvoice, "<cbc:IssueDate>2025-03-18</cbc:IssueDate>", "", 1)
+		req := httptest.NewRequest(http.MethodPost, "/invoices/inbound", strings.NewReader(document))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationXML)
+
+		res, err := server.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		var resBody struct {
+			Details []UBLValidationError `json:"details"`
+		}
+		assert.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&resBody))
+		assert.Len(t, resBody.Details, 1)
+		assert.Equal(t, "/Invoice/cbc:IssueDate", resBody.Details[0].Path)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().CreateInvoice(gomock.Any(), gomock.Any()).Return(customError.CustomError{
+			Code:     http.StatusConflict,
+			Message:  "invoice already exists",
+			Severity: zap.WarnLevel,
+		})
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		req := httptest.NewRequest(http.MethodPost, "/invoices/inbound", strings.NewReader(testUBLInvoice))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationXML)
+
+		res, err := server.Test(req, -1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
+	})
+}
+
 func TestHandler_GetInvoices(t *testing.T) {
 	mockController := gomock.NewController(t)
 	defer mockController.Finish()
@@ -188,12 +306,12 @@ func TestHandler_GetInvoices(t *testing.T) {
 		mockRepository := NewMockRepository(mockController)
 		mockRepository.
 			EXPECT().
-			GetInvoices(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			GetInvoices(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 			Return(invoices, nil).
-			Times(6)
+			Times(8)
 
 		server, validate := SetupServer(t)
-		h := NewHandler(server, validate, mockRepository)
+		h := NewHandler(server, validate, mockRepository, nil, testAuthorizer)
 		h.RegisterRoutes()
 
 		queries := []map[string]string{
@@ -213,6 +331,12 @@ func TestHandler_GetInvoices(t *testing.T) {
 			{
 				"amount": "DESC",
 			},
+			{
+				"deleted": "only",
+			},
+			{
+				"deleted": "include",
+			},
 		}
 
 		for _, query := range queries {
@@ -239,16 +363,21 @@ func TestHandler_GetInvoices(t *testing.T) {
 	})
 
 	t.Run("invalid request queries", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+
 		server, validate := SetupServer(t)
-		h := NewHandler(server, validate, nil)
+		h := NewHandler(server, validate, mockRepository, nil, testAuthorizer)
 		h.RegisterRoutes()
 
 		queries := []map[string]string{
 			{
-				"date": "invalid",
+				"page": "invalid",
+			},
+			{
+				"pageSize": "invalid",
 			},
 			{
-				"amount": "invalid",
+				"deleted": "all",
 			},
 		}
 
@@ -279,7 +408,7 @@ func TestHandler_GetInvoices(t *testing.T) {
 		mockRepository := NewMockRepository(mockController)
 		mockRepository.
 			EXPECT().
-			GetInvoices(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			GetInvoices(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 			Return(&[]InvoiceDTO{}, customError.CustomError{
 				Code:     fiber.StatusInternalServerError,
 				Message:  "repository error",
@@ -287,7 +416,7 @@ func TestHandler_GetInvoices(t *testing.T) {
 			})
 
 		server, validate := SetupServer(t)
-		h := NewHandler(server, validate, mockRepository)
+		h := NewHandler(server, validate, mockRepository, nil, testAuthorizer)
 		h.RegisterRoutes()
 
 		req := httptest.NewRequest(http.MethodGet, "/invoices", nil)
@@ -301,6 +430,74 @@ func TestHandler_GetInvoices(t *testing.T) {
 	})
 }
 
+func TestHandler_VerifyChain(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("happy path", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().VerifyChain(gomock.Any(), "DMP", gomock.Any(), gomock.Any()).Return(&ChainVerification{
+			Series:  "DMP",
+			Checked: 2,
+			Valid:   true,
+		}, nil)
+		mockRepository.EXPECT().VerifyChain(gomock.Any(), "SSP", gomock.Any(), gomock.Any()).Return(&ChainVerification{
+			Series:     "SSP",
+			Checked:    1,
+			BrokenLink: &ChainBrokenLink{SequenceNumber: 1, Reason: "stored hash does not match the invoice contents"},
+		}, nil)
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		req := httptest.NewRequest(http.MethodGet, "/invoices/chain/verify?from=2025-01-01&to=2025-12-31", nil)
+
+		res, err := server.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+
+		var verifications []ChainVerification
+		assert.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&verifications))
+		assert.Len(t, verifications, 2)
+		assert.True(t, verifications[0].Valid)
+		assert.False(t, verifications[1].Valid)
+	})
+
+	t.Run("invalid request queries", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, nil, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		for _, query := range []string{"series=INVALID", "from=01-01-2025", "from=2025-02-01&to=2025-01-01"} {
+			req := httptest.NewRequest(http.MethodGet, "/invoices/chain/verify?"+query, nil)
+
+			res, err := server.Test(req, -1)
+			assert.NoError(t, err)
+			assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+		}
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().VerifyChain(gomock.Any(), "DMP", gomock.Any(), gomock.Any()).Return(nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "repository error",
+			Severity: zap.ErrorLevel,
+		})
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		req := httptest.NewRequest(http.MethodGet, "/invoices/chain/verify?series=DMP", nil)
+
+		res, err := server.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusInternalServerError, res.StatusCode)
+	})
+}
+
 func TestHandler_GetInvoiceById(t *testing.T) {
 	mockController := gomock.NewController(t)
 	defer mockController.Finish()
@@ -318,7 +515,7 @@ func TestHandler_GetInvoiceById(t *testing.T) {
 		}, nil)
 
 		server, validate := SetupServer(t)
-		h := NewHandler(server, validate, mockRepository)
+		h := NewHandler(server, validate, mockRepository, nil, testAuthorizer)
 		h.RegisterRoutes()
 
 		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/invoices/%s", id), nil)
@@ -332,7 +529,7 @@ func TestHandler_GetInvoiceById(t *testing.T) {
 
 	t.Run("invalid invoice id", func(t *testing.T) {
 		server, validate := SetupServer(t)
-		h := NewHandler(server, validate, nil)
+		h := NewHandler(server, validate, nil, nil, testAuthorizer)
 		h.RegisterRoutes()
 
 		req := httptest.NewRequest(http.MethodGet, "/invoices/123", nil)
@@ -352,7 +549,7 @@ func TestHandler_GetInvoiceById(t *testing.T) {
 		})
 
 		server, validate := SetupServer(t)
-		h := NewHandler(server, validate, mockRepository)
+		h := NewHandler(server, validate, mockRepository, nil, testAuthorizer)
 		h.RegisterRoutes()
 
 		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/invoices/%s", uuid.NewString()), nil)
@@ -364,6 +561,75 @@ func TestHandler_GetInvoiceById(t *testing.T) {
 	})
 }
 
+func TestHandler_GetInvoiceHistory(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("happy path", func(t *testing.T) {
+		id := uuid.NewString()
+
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetInvoiceEvents(gomock.Any(), id).Return(&[]InvoiceEventDTO{
+			{
+				Id:        1,
+				InvoiceId: id,
+				Type:      EventUpdated,
+				Actor:     "anonymous",
+				Changes: map[string]FieldChange{
+					"status": {Before: "UNPAID", After: "PAID"},
+				},
+				OccurredAt: time.Now().UTC(),
+			},
+		}, nil)
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/invoices/%s/history", id), nil)
+
+		res, err := server.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+
+		var events []InvoiceEventDTO
+		assert.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&events))
+		assert.Len(t, events, 1)
+		assert.Equal(t, FieldChange{Before: "UNPAID", After: "PAID"}, events[0].Changes["status"])
+	})
+
+	t.Run("invalid invoice id", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, nil, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		req := httptest.NewRequest(http.MethodGet, "/invoices/123/history", nil)
+
+		res, err := server.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetInvoiceEvents(gomock.Any(), gomock.Any()).Return(nil, customError.CustomError{
+			Code:     http.StatusInternalServerError,
+			Message:  "repository error",
+			Severity: zap.ErrorLevel,
+		})
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/invoices/%s/history", uuid.NewString()), nil)
+
+		res, err := server.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusInternalServerError, res.StatusCode)
+	})
+}
+
 func TestHandler_UpdateInvoiceById(t *testing.T) {
 	mockController := gomock.NewController(t)
 	defer mockController.Finish()
@@ -373,7 +639,7 @@ func TestHandler_UpdateInvoiceById(t *testing.T) {
 		mockRepository.EXPECT().UpdateInvoiceById(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(3)
 
 		server, validate := SetupServer(t)
-		h := NewHandler(server, validate, mockRepository)
+		h := NewHandler(server, validate, mockRepository, nil, testAuthorizer)
 		h.RegisterRoutes()
 
 		requestBody := []CreateInvoiceRequest{
@@ -415,7 +681,7 @@ func TestHandler_UpdateInvoiceById(t *testing.T) {
 
 	t.Run("invalid request body", func(t *testing.T) {
 		server, validate := SetupServer(t)
-		h := NewHandler(server, validate, nil)
+		h := NewHandler(server, validate, nil, nil, testAuthorizer)
 		h.RegisterRoutes()
 
 		requestBody := []CreateInvoiceRequest{
@@ -470,7 +736,7 @@ func TestHandler_UpdateInvoiceById(t *testing.T) {
 		})
 
 		server, validate := SetupServer(t)
-		h := NewHandler(server, validate, mockRepository)
+		h := NewHandler(server, validate, mockRepository, nil, testAuthorizer)
 		h.RegisterRoutes()
 
 		requestBody := CreateInvoiceRequest{
@@ -504,7 +770,7 @@ func TestHandler_DeleteInvoiceById(t *testing.T) {
 		mockRepository.EXPECT().DeleteInvoiceById(gomock.Any(), gomock.Any()).Return(nil)
 
 		server, validate := SetupServer(t)
-		h := NewHandler(server, validate, mockRepository)
+		h := NewHandler(server, validate, mockRepository, nil, testAuthorizer)
 		h.RegisterRoutes()
 
 		req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/invoices/%s", uuid.NewString()), nil)
@@ -517,7 +783,7 @@ func TestHandler_DeleteInvoiceById(t *testing.T) {
 
 	t.Run("invalid request body", func(t *testing.T) {
 		server, validate := SetupServer(t)
-		h := NewHandler(server, validate, nil)
+		h := NewHandler(server, validate, nil, nil, testAuthorizer)
 		h.RegisterRoutes()
 
 		req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/invoices/%s", "invalid-id"), nil)
@@ -538,7 +804,7 @@ func TestHandler_DeleteInvoiceById(t *testing.T) {
 		})
 
 		server, validate := SetupServer(t)
-		h := NewHandler(server, validate, mockRepository)
+		h := NewHandler(server, validate, mockRepository, nil, testAuthorizer)
 		h.RegisterRoutes()
 
 		req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/invoices/%s", uuid.NewString()), nil)
@@ -550,6 +816,123 @@ func TestHandler_DeleteInvoiceById(t *testing.T) {
 	})
 }
 
+func TestHandler_RestoreInvoiceById(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("happy path", func(t *testing.T) {
+		id := uuid.NewString()
+
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().RestoreInvoiceById(gomock.Any(), id).Return(nil)
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/invoices/%s/restore", id), nil)
+
+		res, err := server.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNoContent, res.StatusCode)
+	})
+
+	t.Run("invalid invoice id", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, nil, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		req := httptest.NewRequest(http.MethodPost, "/invoices/123/restore", nil)
+
+		res, err := server.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().RestoreInvoiceById(gomock.Any(), gomock.Any()).Return(customError.CustomError{
+			Code:     http.StatusNotFound,
+			Message:  "deleted invoice not found",
+			Severity: zap.WarnLevel,
+		})
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/invoices/%s/restore", uuid.NewString()), nil)
+
+		res, err := server.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, res.StatusCode)
+	})
+}
+
+func TestHandler_StreamInvoices(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("replays missed events", func(t *testing.T) {
+		active := &InvoiceDTO{Id: uuid.NewString(), ServiceName: "DMP", Status: "PAID"}
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetInvoiceStreamEvents(gomock.Any(), int64(5), streamReplayBatchSize).Return([]StreamEventDTO{
+			{Id: 6, Type: EventUpdated, InvoiceId: active.Id, Invoice: active},
+			{Id: 7, Type: EventPurged, InvoiceId: uuid.NewString()},
+		}, nil)
+
+		broker := NewBroker(zap.NewNop(), mockRepository, time.Second)
+		broker.Close()
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, broker, testAuthorizer)
+		h.RegisterRoutes()
+
+		req := httptest.NewRequest(http.MethodGet, "/invoices/stream?search=dmp", nil)
+		req.Header.Set(HeaderLastEventId, "5")
+
+		res, err := server.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+		assert.Equal(t, "text/event-stream", res.Header.Get(fiber.HeaderContentType))
+
+		body, err := io.ReadAll(res.Body)
+		assert.NoError(t, err)
+		assert.Contains(t, string(body), "retry: 3000\n\n")
+		assert.Contains(t, string(body), "id: 6\ndata: {")
+		assert.Contains(t, string(body), active.Id)
+		assert.NotContains(t, string(body), "id: 7")
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, nil, NewBroker(zap.NewNop(), nil, time.Second), testAuthorizer)
+		h.RegisterRoutes()
+
+		req := httptest.NewRequest(http.MethodGet, "/invoices/stream", nil)
+		req.Header.Set(HeaderLastEventId, "abc")
+		res, err := server.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+
+		res, err = server.Test(httptest.NewRequest(http.MethodGet, "/invoices/stream?deleted=all", nil), -1)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("stream not available", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, nil, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, "/invoices/stream", nil), -1)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusServiceUnavailable, res.StatusCode)
+	})
+}
+
+var testAuthorizer = auth.NewAuthorizer(auth.Policy{"admin": {auth.AllPermissions}})
+
 func SetupServer(t *testing.T) (*fiber.App, *validator.Validate) {
 	server := fiber.New(fiber.Config{
 		JSONDecoder:           json.Unmarshal,
@@ -568,6 +951,7 @@ func SetupServer(t *testing.T) (*fiber.App, *validator.Validate) {
 
 	server.Use(func(c *fiber.Ctx) error {
 		c.Locals("log", log)
+		c.Locals(auth.ContextKeyRoles, []string{"admin"})
 		return c.Next()
 	})
 
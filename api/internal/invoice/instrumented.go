@@ -0,0 +1,107 @@
+package invoice
+
+import (
+	"context"
+	"time"
+
+	"invoice-api/pkg/metrics"
+	"invoice-api/pkg/tracing"
+)
+
+const repositoryName = "invoice"
+
+type InstrumentedRepository struct {
+	repository Repository
+}
+
+func NewInstrumentedRepository(repository Repository) *InstrumentedRepository {
+	return &InstrumentedRepository{repository: repository}
+}
+
+func (r *InstrumentedRepository) CreateInvoice(ctx context.Context, invoice *InvoiceDTO) (err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "CreateInvoice")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "CreateInvoice", time.Now(), &err)
+	return r.repository.CreateInvoice(ctx, invoice)
+}
+
+func (r *InstrumentedRepository) GetInvoices(ctx context.Context, page int, pageSize int, search string, deleted string) (_ *[]InvoiceDTO, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "GetInvoices")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "GetInvoices", time.Now(), &err)
+	return r.repository.GetInvoices(ctx, page, pageSize, search, deleted)
+}
+
+func (r *InstrumentedRepository) GetInvoiceById(ctx context.Context, id string) (_ *InvoiceDTO, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "GetInvoiceById")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "GetInvoiceById", time.Now(), &err)
+	return r.repository.GetInvoiceById(ctx, id)
+}
+
+func (r *InstrumentedRepository) UpdateInvoiceById(ctx context.Context, id string, invoice *InvoiceDTO) (err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "UpdateInvoiceById")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "UpdateInvoiceById", time.Now(), &err)
+	return r.repository.UpdateInvoiceById(ctx, id, invoice)
+}
+
+func (r *InstrumentedRepository) DeleteInvoiceById(ctx context.Context, id string) (err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "DeleteInvoiceById")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "DeleteInvoiceById", time.Now(), &err)
+	return r.repository.DeleteInvoiceById(ctx, id)
+}
+
+func (r *InstrumentedRepository) RestoreInvoiceById(ctx context.Context, id string) (err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "RestoreInvoiceById")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "RestoreInvoiceById", time.Now(), &err)
+	return r.repository.RestoreInvoiceById(ctx, id)
+}
+
+func (r *InstrumentedRepository) PurgeDeletedInvoices(ctx context.Context, deletedBefore time.Time) (_ *PurgeResult, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "PurgeDeletedInvoices")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "PurgeDeletedInvoices", time.Now(), &err)
+	return r.repository.PurgeDeletedInvoices(ctx, deletedBefore)
+}
+
+func (r *InstrumentedRepository) GetInvoiceEvents(ctx context.Context, invoiceId string) (_ *[]InvoiceEventDTO, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "GetInvoiceEvents")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "GetInvoiceEvents", time.Now(), &err)
+	return r.repository.GetInvoiceEvents(ctx, invoiceId)
+}
+
+func (r *InstrumentedRepository) GetLatestInvoiceEventId(ctx context.Context) (_ int64, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "GetLatestInvoiceEventId")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "GetLatestInvoiceEventId", time.Now(), &err)
+	return r.repository.GetLatestInvoiceEventId(ctx)
+}
+
+func (r *InstrumentedRepository) GetInvoiceStreamEvents(ctx context.Context, afterId int64, limit int) (_ []StreamEventDTO, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "GetInvoiceStreamEvents")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "GetInvoiceStreamEvents", time.Now(), &err)
+	return r.repository.GetInvoiceStreamEvents(ctx, afterId, limit)
+}
+
+func (r *InstrumentedRepository) ListenInvoiceEvents(ctx context.Context, notifications chan<- struct{}) error {
+	return r.repository.ListenInvoiceEvents(ctx, notifications)
+}
+
+func (r *InstrumentedRepository) VerifyChain(ctx context.Context, series string, from, to time.Time) (_ *ChainVerification, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "VerifyChain")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "VerifyChain", time.Now(), &err)
+	return r.repository.VerifyChain(ctx, series, from, to)
+}
+
+func (r *InstrumentedRepository) GetUnpaidTotals(ctx context.Context) (_ []UnpaidTotalDTO, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "GetUnpaidTotals")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "GetUnpaidTotals", time.Now(), &err)
+	return r.repository.GetUnpaidTotals(ctx)
+}
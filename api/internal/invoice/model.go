@@ -4,6 +4,16 @@ import (
 	"time"
 )
 
+const (
+	DirectionOutbound = "OUTBOUND"
+	DirectionInbound  = "INBOUND"
+
+	DeletedOnly    = "only"
+	DeletedInclude = "include"
+
+	HeaderLastEventId = "Last-Event-ID"
+)
+
 type CreateInvoiceRequest struct {
 	ServiceName string    `json:"serviceName" validate:"required,oneof=DMP SSP"`
 	Amount      float32   `json:"amount" validate:"required,min=1"`
@@ -20,6 +30,13 @@ type GetInvoicesRequest struct {
 	Page     int    `query:"page,omitempty"`
 	PageSize int    `query:"pageSize,omitempty"`
 	Search   string `query:"search,omitempty"`
+	Deleted  string `query:"deleted,omitempty" validate:"omitempty,oneof=only include"`
+}
+
+type VerifyChainRequest struct {
+	Series string `query:"series" validate:"omitempty,oneof=DMP SSP"`
+	From   string `query:"from" validate:"omitempty,datetime=2006-01-02"`
+	To     string `query:"to" validate:"omitempty,datetime=2006-01-02"`
 }
 
 type InvoiceDTO struct {
@@ -28,4 +45,27 @@ type InvoiceDTO struct {
 	Amount      float32   `json:"amount" db:"amount"`
 	Status      string    `json:"status" db:"status"`
 	Date        time.Time `json:"date" db:"date"`
+	Direction   string    `json:"direction" db:"direction"`
+
+	Series         *string    `json:"series,omitempty" db:"series"`
+	SequenceNumber *int64     `json:"sequenceNumber,omitempty" db:"sequence_number"`
+	Hash           *string    `json:"hash,omitempty" db:"hash"`
+	PreviousHash   *string    `json:"previousHash,omitempty" db:"previous_hash"`
+	FinalizedAt    *time.Time `json:"finalizedAt,omitempty" db:"finalized_at"`
+
+	PaymentReference *string    `json:"paymentReference,omitempty" db:"payment_reference"`
+	DeletedAt        *time.Time `json:"deletedAt,omitempty" db:"deleted_at"`
+}
+
+type PurgeResult struct {
+	Purged      int64
+	StorageKeys []string
+}
+
+type UnpaidTotalDTO struct {
+	TenantId    string  `db:"tenant_id"`
+	ServiceName string  `db:"service_name"`
+	Status      string  `db:"status"`
+	Count       int64   `db:"count"`
+	Amount      float64 `db:"amount"`
 }
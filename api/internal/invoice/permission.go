@@ -0,0 +1,10 @@
+package invoice
+
+const (
+	PermissionRead     = "invoices:read"
+	PermissionCreate   = "invoices:create"
+	PermissionUpdate   = "invoices:update"
+	PermissionMarkPaid = "invoices:mark-paid"
+	PermissionDelete   = "invoices:delete"
+	PermissionRestore  = "invoices:restore"
+)
@@ -0,0 +1,159 @@
+package invoice
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"invoice-api/pkg/auth"
+	customError "invoice-api/pkg/error"
+)
+
+func TestHandler_Permissions(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	policy := auth.Policy{
+		"viewer":     {PermissionRead},
+		"clerk":      {PermissionRead, PermissionCreate, PermissionUpdate},
+		"accountant": {PermissionRead, PermissionCreate, PermissionUpdate, PermissionMarkPaid},
+		"admin":      {auth.AllPermissions},
+	}
+	id := "2f4b8d6e-3c1a-4e5f-9b7d-8a6c4e2f0b1d"
+	routes := []struct {
+		method     string
+		path       string
+		body       string
+		permission string
+		check      string
+		allowed    []string
+	}{
+		{http.MethodPost, "/invoices", "{}", PermissionCreate, "", []string{"clerk", "accountant", "admin"}},
+		{http.MethodPost, "/invoices/inbound", "{}", PermissionCreate, "", []string{"clerk", "accountant", "admin"}},
+		{http.MethodGet, "/invoices", "", PermissionRead, "", []string{"viewer", "clerk", "accountant", "admin"}},
+		{http.MethodGet, "/invoices/chain/verify", "", PermissionRead, "", []string{"viewer", "clerk", "accountant", "admin"}},
+		{http.MethodGet, "/invoices/stream", "", PermissionRead, "", []string{"viewer", "clerk", "accountant", "admin"}},
+		{http.MethodGet, "/invoices/" + id, "", PermissionRead, "", []string{"viewer", "clerk", "accountant", "admin"}},
+		{http.MethodGet, "/invoices/" + id + "/history", "", PermissionRead, "", []string{"viewer", "clerk", "accountant", "admin"}},
+		{http.MethodPut, "/invoices/" + id, `{"serviceName":"DMP","amount":10,"status":"UNPAID","date":"2024-03-01T00:00:00Z"}`, PermissionUpdate, "", []string{"clerk", "accountant", "admin"}},
+		{http.MethodPut, "/invoices/" + id, `{"serviceName":"DMP","amount":10,"status":"PAID","date":"2024-03-01T00:00:00Z"}`, PermissionUpdate, PermissionMarkPaid, []string{"accountant", "admin"}},
+		{http.MethodDelete, "/invoices/" + id, "", PermissionDelete, "", []string{"admin"}},
+		{http.MethodPost, "/invoices/" + id + "/restore", "", PermissionRestore, "", []string{"admin"}},
+	}
+	roles := []string{"viewer", "clerk", "accountant", "admin", "unknown"}
+
+	for _, route := range routes {
+		for _, role := range roles {
+			t.Run(route.method+" "+route.path+" "+route.body+" as "+role, func(t *testing.T) {
+				mockRepository := NewMockRepository(mockController)
+				unavailable := customError.CustomError{Code: fiber.StatusServiceUnavailable, Message: "unavailable"}
+				mockRepository.EXPECT().GetInvoices(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, unavailable).AnyTimes()
+				mockRepository.EXPECT().GetInvoiceById(gomock.Any(), gomock.Any()).Return(nil, unavailable).AnyTimes()
+				mockRepository.EXPECT().VerifyChain(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, unavailable).AnyTimes()
+				mockRepository.EXPECT().GetInvoiceEvents(gomock.Any(), gomock.Any()).Return(nil, unavailable).AnyTimes()
+				mockRepository.EXPECT().UpdateInvoiceById(gomock.Any(), gomock.Any(), gomock.Any()).Return(unavailable).AnyTimes()
+				mockRepository.EXPECT().DeleteInvoiceById(gomock.Any(), gomock.Any()).Return(unavailable).AnyTimes()
+				mockRepository.EXPECT().RestoreInvoiceById(gomock.Any(), gomock.Any()).Return(unavailable).AnyTimes()
+
+				server, validate := SetupServer(t)
+				server.Use(func(ctx *fiber.Ctx) error {
+					ctx.Locals(auth.ContextKeyRoles, []string{role})
+					return ctx.Next()
+				})
+				h := NewHandler(server, validate, mockRepository, nil, auth.NewAuthorizer(policy))
+				h.RegisterRoutes()
+
+				var body io.Reader
+				if route.body != "" {
+					body = strings.NewReader(route.body)
+				}
+				req := httptest.NewRequest(route.method, route.path, body)
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+				res, err := server.Test(req, -1)
+				require.NoError(t, err)
+
+				if slices.Contains(route.allowed, role) {
+					assert.NotEqual(t, fiber.StatusForbidden, res.StatusCode)
+					return
+				}
+
+				missing := route.permission
+				if slices.Contains(policy[role], route.permission) {
+					missing = route.check
+				}
+				assert.Equal(t, fiber.StatusForbidden, res.StatusCode)
+				responseBody, err := io.ReadAll(res.Body)
+				require.NoError(t, err)
+				assert.Contains(t, string(responseBody), `"permission":"`+missing+`"`)
+			})
+		}
+	}
+
+	t.Run("without roles every route is denied", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		server.Use(func(ctx *fiber.Ctx) error {
+			ctx.Locals(auth.ContextKeyRoles, nil)
+			return ctx.Next()
+		})
+		h := NewHandler(server, validate, nil, nil, auth.NewAuthorizer(policy))
+		h.RegisterRoutes()
+
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, "/invoices/not-a-uuid", nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusForbidden, res.StatusCode)
+	})
+
+	scopeRoutes := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		scopes []string
+		status int
+	}{
+		{"write key updates", http.MethodPut, "/invoices/" + id, `{"serviceName":"DMP","amount":10,"status":"UNPAID","date":"2024-03-01T00:00:00Z"}`, []string{"invoices:write"}, fiber.StatusServiceUnavailable},
+		{"write key cannot mark paid", http.MethodPut, "/invoices/" + id, `{"serviceName":"DMP","amount":10,"status":"PAID","date":"2024-03-01T00:00:00Z"}`, []string{"invoices:write"}, fiber.StatusForbidden},
+		{"mark-paid key marks paid", http.MethodPut, "/invoices/" + id, `{"serviceName":"DMP","amount":10,"status":"PAID","date":"2024-03-01T00:00:00Z"}`, []string{"invoices:write", "invoices:mark-paid"}, fiber.StatusServiceUnavailable},
+		{"write key cannot delete", http.MethodDelete, "/invoices/" + id, "", []string{"invoices:write"}, fiber.StatusForbidden},
+		{"delete key deletes", http.MethodDelete, "/invoices/" + id, "", []string{"invoices:delete"}, fiber.StatusServiceUnavailable},
+		{"read key cannot create", http.MethodPost, "/invoices", "{}", []string{"invoices:read"}, fiber.StatusForbidden},
+		{"key ignores roles", http.MethodGet, "/invoices/" + id, "", []string{}, fiber.StatusForbidden},
+	}
+	for _, route := range scopeRoutes {
+		t.Run("api key: "+route.name, func(t *testing.T) {
+			mockRepository := NewMockRepository(mockController)
+			unavailable := customError.CustomError{Code: fiber.StatusServiceUnavailable, Message: "unavailable"}
+			mockRepository.EXPECT().GetInvoiceById(gomock.Any(), gomock.Any()).Return(nil, unavailable).AnyTimes()
+			mockRepository.EXPECT().UpdateInvoiceById(gomock.Any(), gomock.Any(), gomock.Any()).Return(unavailable).AnyTimes()
+			mockRepository.EXPECT().DeleteInvoiceById(gomock.Any(), gomock.Any()).Return(unavailable).AnyTimes()
+
+			server, validate := SetupServer(t)
+			server.Use(func(ctx *fiber.Ctx) error {
+				ctx.Locals(auth.ContextKeyScopes, route.scopes)
+				return ctx.Next()
+			})
+			h := NewHandler(server, validate, mockRepository, nil, auth.NewAuthorizer(policy))
+			h.RegisterRoutes()
+
+			var body io.Reader
+			if route.body != "" {
+				body = strings.NewReader(route.body)
+			}
+			req := httptest.NewRequest(route.method, route.path, body)
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+			res, err := server.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, route.status, res.StatusCode)
+		})
+	}
+}
@@ -9,18 +9,36 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/zap"
 
 	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/payment"
+	"invoice-api/pkg/requestcontext"
+	"invoice-api/pkg/tenant"
+	"invoice-api/pkg/tracing"
+)
+
+const (
+	pgUniqueViolation = "23505"
+	invoiceColumns    = "id, service_name, amount, status, date, direction, series, sequence_number, hash, previous_hash, finalized_at, payment_reference, deleted_at"
 )
 
 type Repository interface {
 	CreateInvoice(ctx context.Context, invoice *InvoiceDTO) error
-	GetInvoices(ctx context.Context, page int, pageSize int, search string) (*[]InvoiceDTO, error)
+	GetInvoices(ctx context.Context, page int, pageSize int, search string, deleted string) (*[]InvoiceDTO, error)
 	GetInvoiceById(ctx context.Context, id string) (*InvoiceDTO, error)
 	UpdateInvoiceById(ctx context.Context, id string, invoice *InvoiceDTO) error
 	DeleteInvoiceById(ctx context.Context, id string) error
+	RestoreInvoiceById(ctx context.Context, id string) error
+	PurgeDeletedInvoices(ctx context.Context, deletedBefore time.Time) (*PurgeResult, error)
+	GetInvoiceEvents(ctx context.Context, invoiceId string) (*[]InvoiceEventDTO, error)
+	GetLatestInvoiceEventId(ctx context.Context) (int64, error)
+	GetInvoiceStreamEvents(ctx context.Context, afterId int64, limit int) ([]StreamEventDTO, error)
+	ListenInvoiceEvents(ctx context.Context, notifications chan<- struct{}) error
+	VerifyChain(ctx context.Context, series string, from, to time.Time) (*ChainVerification, error)
+	GetUnpaidTotals(ctx context.Context) ([]UnpaidTotalDTO, error)
 }
 
 type PgRepository struct {
@@ -33,6 +51,8 @@ func NewPgRepository(log *zap.Logger, host, port, username, password, database s
 	if err != nil {
 		log.Fatal("failed to parse database config", zap.Error(err))
 	}
+	pgConfig.AfterConnect = tenant.AfterConnect
+	pgConfig.ConnConfig.Tracer = tracing.QueryTracer{}
 
 	var pgConnectionPool *pgxpool.Pool
 	pgConnectionPool, err = pgxpool.NewWithConfig(context.Background(), pgConfig)
@@ -57,7 +77,19 @@ func NewPgRepository(log *zap.Logger, host, port, username, password, database s
 	}
 }
 
+func (r *PgRepository) Stat() *pgxpool.Stat {
+	return r.connectionPool.Stat()
+}
+
+func (r *PgRepository) Ping(ctx context.Context) error {
+	return r.connectionPool.Ping(ctx)
+}
+
 func (r *PgRepository) CreateInvoice(ctx context.Context, invoice *InvoiceDTO) error {
+	if invoice.Direction == "" {
+		invoice.Direction = DirectionOutbound
+	}
+
 	connection, err := r.connectionPool.Acquire(ctx)
 	if err != nil {
 		return customError.CustomError{
@@ -69,15 +101,69 @@ func (r *PgRepository) CreateInvoice(ctx context.Context, invoice *InvoiceDTO) e
 	}
 	defer connection.Release()
 
-	if _, err = connection.Exec(
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	if invoice.Direction == DirectionOutbound {
+		if err = r.linkToChain(ctx, tx, invoice); err != nil {
+			return err
+		}
+
+		if invoice.PaymentReference == nil {
+			var reference string
+			reference, err = payment.NewReference(invoice.Id)
+			if err != nil {
+				return customError.CustomError{
+					Code:     fiber.StatusInternalServerError,
+					Message:  "failed to generate payment reference",
+					Severity: zap.ErrorLevel,
+					Fields:   []zap.Field{zap.Error(err)},
+				}
+			}
+			invoice.PaymentReference = &reference
+		}
+	}
+
+	if _, err = tx.Exec(
 		ctx,
-		"insert into invoices (id, service_name, amount, status, date) values ($1, $2, $3, $4, $5)",
+		"insert into invoices ("+invoiceColumns+") values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)",
 		invoice.Id,
 		invoice.ServiceName,
 		invoice.Amount,
 		invoice.Status,
-		time.Now().UTC(),
+		invoice.Date.UTC(),
+		invoice.Direction,
+		invoice.Series,
+		invoice.SequenceNumber,
+		invoice.Hash,
+		invoice.PreviousHash,
+		invoice.FinalizedAt,
+		invoice.PaymentReference,
+		invoice.DeletedAt,
 	); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+			return customError.CustomError{
+				Code:     fiber.StatusConflict,
+				Message:  "invoice already exists",
+				Severity: zap.WarnLevel,
+				Fields:   []zap.Field{zap.String("id", invoice.Id)},
+			}
+		}
+
 		return customError.CustomError{
 			Code:     fiber.StatusInternalServerError,
 			Message:  "failed to create invoice",
@@ -86,24 +172,97 @@ func (r *PgRepository) CreateInvoice(ctx context.Context, invoice *InvoiceDTO) e
 		}
 	}
 
+	if err = r.insertEvent(ctx, tx, EventCreated, invoice.Id, nil, invoice); err != nil {
+		return err
+	}
+
+	if err = r.writeOutbox(ctx, tx, EventCreated, nil, invoice); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return nil
+}
+
+func (r *PgRepository) linkToChain(ctx context.Context, tx pgx.Tx, invoice *InvoiceDTO) error {
+	if _, err := tx.Exec(ctx, "select pg_advisory_xact_lock(hashtext('invoice_chain:' || current_tenant() || ':' || $1))", invoice.ServiceName); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to lock invoice chain",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	// The last link may be the tombstone of a purged invoice.
+	var previous InvoiceDTO
+	err := tx.QueryRow(
+		ctx,
+		`select sequence_number, hash from invoices where tenant_id = current_tenant() and series = $1
+		union all
+		select sequence_number, hash from invoice_chain_tombstones where tenant_id = current_tenant() and series = $1
+		order by sequence_number desc limit 1`,
+		invoice.ServiceName,
+	).Scan(&previous.SequenceNumber, &previous.Hash)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		linkToChain(invoice, nil, time.Now())
+	case err != nil:
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get last chained invoice",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	default:
+		linkToChain(invoice, &previous, time.Now())
+	}
+
 	return nil
 }
 
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// searchPattern turns a search into an ilike pattern matching it as a
+// case-insensitive substring of an invoice's id and service name, the same
+// rule StreamFilter.Match applies to streamed events.
+func searchPattern(search string) string {
+	return "%" + likeEscaper.Replace(search) + "%"
+}
+
 func (r *PgRepository) GetInvoices(
 	ctx context.Context,
 	page,
 	pageSize int,
 	search string,
+	deleted string,
 ) (*[]InvoiceDTO, error) {
 	var query strings.Builder
-	query.WriteString("select * from invoices")
+	query.WriteString("select " + invoiceColumns + " from invoices")
 
 	args := make([]interface{}, 0, 3)
 	argIndex := 1
 
+	switch deleted {
+	case DeletedOnly:
+		query.WriteString(" where deleted_at is not null")
+	case DeletedInclude:
+		query.WriteString(" where true")
+	default:
+		query.WriteString(" where deleted_at is null")
+	}
+
 	if search != "" {
-		query.WriteString(" where to_tsvector(id || ' ' || service_name) @@ to_tsquery($1)")
-		args = append(args, search)
+		query.WriteString(" and (id || ' ' || service_name) ilike $1")
+		args = append(args, searchPattern(search))
 		argIndex++
 	}
 
@@ -121,8 +280,24 @@ func (r *PgRepository) GetInvoices(
 	}
 	defer connection.Release()
 
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
 	var rows pgx.Rows
-	rows, err = connection.Query(ctx, query.String(), args...)
+	rows, err = tx.Query(ctx, query.String(), args...)
 	if err != nil {
 		return nil, customError.CustomError{
 			Code:     fiber.StatusInternalServerError,
@@ -158,8 +333,24 @@ func (r *PgRepository) GetInvoiceById(ctx context.Context, id string) (*InvoiceD
 	}
 	defer connection.Release()
 
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
 	var row pgx.Rows
-	row, err = connection.Query(ctx, "select * from invoices where id = $1", id)
+	row, err = tx.Query(ctx, "select "+invoiceColumns+" from invoices where id = $1 and deleted_at is null", id)
 	if err != nil {
 		return nil, customError.CustomError{
 			Code:     fiber.StatusInternalServerError,
@@ -203,7 +394,46 @@ func (r *PgRepository) UpdateInvoiceById(ctx context.Context, id string, invoice
 	}
 	defer connection.Release()
 
-	if _, err = connection.Exec(
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	var before *InvoiceDTO
+	before, err = r.selectInvoiceForUpdate(ctx, tx, id, false)
+	if err != nil {
+		return err
+	}
+
+	if before == nil {
+		return customError.CustomError{
+			Code:     fiber.StatusNotFound,
+			Message:  "invoice not found",
+			Severity: zap.WarnLevel,
+		}
+	}
+
+	if changesChainedFields(before, invoice) {
+		return customError.CustomError{
+			Code:     fiber.StatusConflict,
+			Message:  "finalized invoice can only change its status",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.String("id", id)},
+		}
+	}
+
+	if _, err = tx.Exec(
 		ctx,
 		"update invoices set service_name = $1, amount = $2, status = $3 where id = $4",
 		invoice.ServiceName,
@@ -211,13 +441,6 @@ func (r *PgRepository) UpdateInvoiceById(ctx context.Context, id string, invoice
 		invoice.Status,
 		id,
 	); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return customError.CustomError{
-				Code:     fiber.StatusNotFound,
-				Message:  "invoice not found",
-				Severity: zap.WarnLevel,
-			}
-		}
 		return customError.CustomError{
 			Code:     fiber.StatusInternalServerError,
 			Message:  "failed to update invoice by id",
@@ -226,6 +449,27 @@ func (r *PgRepository) UpdateInvoiceById(ctx context.Context, id string, invoice
 		}
 	}
 
+	after := *before
+	after.ServiceName = invoice.ServiceName
+	after.Amount = invoice.Amount
+	after.Status = invoice.Status
+	if err = r.insertEvent(ctx, tx, EventUpdated, id, before, &after); err != nil {
+		return err
+	}
+
+	if err = r.writeOutbox(ctx, tx, EventUpdated, before, &after); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
 	return nil
 }
 
@@ -241,15 +485,38 @@ func (r *PgRepository) DeleteInvoiceById(ctx context.Context, id string) error {
 	}
 	defer connection.Release()
 
-	if _, err = connection.Exec(ctx, "delete from invoices where id = $1", id); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return customError.CustomError{
-				Code:     fiber.StatusNotFound,
-				Message:  "invoice not found",
-				Severity: zap.WarnLevel,
-			}
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	var before *InvoiceDTO
+	before, err = r.selectInvoiceForUpdate(ctx, tx, id, false)
+	if err != nil {
+		return err
+	}
+
+	if before == nil {
+		return customError.CustomError{
+			Code:     fiber.StatusNotFound,
+			Message:  "invoice not found",
+			Severity: zap.WarnLevel,
 		}
+	}
 
+	deletedAt := time.Now().UTC().Truncate(time.Microsecond)
+	if _, err = tx.Exec(ctx, "update invoices set deleted_at = $1 where id = $2", deletedAt, id); err != nil {
 		return customError.CustomError{
 			Code:     fiber.StatusInternalServerError,
 			Message:  "failed to delete an invoice",
@@ -258,5 +525,484 @@ func (r *PgRepository) DeleteInvoiceById(ctx context.Context, id string) error {
 		}
 	}
 
+	after := *before
+	after.DeletedAt = &deletedAt
+	if err = r.insertEvent(ctx, tx, EventDeleted, id, before, &after); err != nil {
+		return err
+	}
+
+	if err = r.writeOutbox(ctx, tx, EventDeleted, before, &after); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return nil
+}
+
+func (r *PgRepository) RestoreInvoiceById(ctx context.Context, id string) error {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	var before *InvoiceDTO
+	before, err = r.selectInvoiceForUpdate(ctx, tx, id, true)
+	if err != nil {
+		return err
+	}
+
+	if before == nil {
+		return customError.CustomError{
+			Code:     fiber.StatusNotFound,
+			Message:  "deleted invoice not found",
+			Severity: zap.WarnLevel,
+		}
+	}
+
+	if _, err = tx.Exec(ctx, "update invoices set deleted_at = null where id = $1", id); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to restore an invoice",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	after := *before
+	after.DeletedAt = nil
+	if err = r.insertEvent(ctx, tx, EventRestored, id, before, &after); err != nil {
+		return err
+	}
+
+	if err = r.writeOutbox(ctx, tx, EventRestored, before, &after); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
 	return nil
 }
+
+// Finalized invoices leave a tombstone with their chain position and hash, so
+// the chain still verifies once they are gone. Attachment storage keys are
+// returned so the blobs can be deleted after the commit.
+func (r *PgRepository) PurgeDeletedInvoices(ctx context.Context, deletedBefore time.Time) (*PurgeResult, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	var rows pgx.Rows
+	rows, err = tx.Query(
+		ctx,
+		"select id from invoices where deleted_at < $1 for update",
+		deletedBefore.UTC(),
+	)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to select deleted invoices",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var ids []string
+	ids, err = pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect deleted invoices",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	if len(ids) == 0 {
+		return &PurgeResult{}, nil
+	}
+
+	rows, err = tx.Query(ctx, "delete from invoice_attachments where invoice_id = any($1) returning storage_key", ids)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to purge invoice attachments",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	result := &PurgeResult{}
+	result.StorageKeys, err = pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect purged attachments",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	for _, statement := range []string{
+		"update usage_events set invoice_id = null where invoice_id = any($1)",
+		"delete from billing_periods where invoice_id = any($1)",
+		"delete from recurring_runs where invoice_id = any($1)",
+	} {
+		if _, err = tx.Exec(ctx, statement, ids); err != nil {
+			return nil, customError.CustomError{
+				Code:     fiber.StatusInternalServerError,
+				Message:  "failed to release references to purged invoices",
+				Severity: zap.ErrorLevel,
+				Fields:   []zap.Field{zap.Error(err), zap.String("statement", statement)},
+			}
+		}
+	}
+
+	if _, err = tx.Exec(
+		ctx,
+		`insert into invoice_chain_tombstones (tenant_id, series, sequence_number, invoice_id, hash, previous_hash, finalized_at, purged_at)
+		select tenant_id, series, sequence_number, id, hash, previous_hash, finalized_at, $2
+		from invoices where id = any($1) and finalized_at is not null`,
+		ids,
+		time.Now().UTC(),
+	); err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to keep chain tombstones of purged invoices",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	rows, err = tx.Query(ctx, "delete from invoices where id = any($1) returning "+invoiceColumns, ids)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to purge deleted invoices",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var purged []InvoiceDTO
+	purged, err = pgx.CollectRows(rows, pgx.RowToStructByPos[InvoiceDTO])
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect purged invoices",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	for i := range purged {
+		if err = r.insertEvent(ctx, tx, EventPurged, purged[i].Id, &purged[i], nil); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	result.Purged = int64(len(purged))
+	return result, nil
+}
+
+// GetUnpaidTotals is a cross-tenant admin query for the metrics collector: it
+// aggregates the invoices of every tenant and must run as system, since
+// row-level security would otherwise limit it to a single tenant.
+func (r *PgRepository) GetUnpaidTotals(ctx context.Context) ([]UnpaidTotalDTO, error) {
+	if !tenant.IsSystem(ctx) {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "unpaid invoice totals must be queried as system",
+			Severity: zap.ErrorLevel,
+		}
+	}
+
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	var rows pgx.Rows
+	rows, err = tx.Query(
+		ctx,
+		`select tenant_id, service_name, status, count(*), sum(amount)
+		from invoices
+		where status in ('UNPAID', 'PENDING') and deleted_at is null
+		group by tenant_id, service_name, status
+		order by tenant_id, service_name, status`,
+	)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get unpaid invoice totals",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var totals []UnpaidTotalDTO
+	totals, err = pgx.CollectRows(rows, pgx.RowToStructByPos[UnpaidTotalDTO])
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect unpaid invoice totals",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return totals, nil
+}
+
+func (r *PgRepository) GetInvoiceEvents(ctx context.Context, invoiceId string) (*[]InvoiceEventDTO, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	var rows pgx.Rows
+	rows, err = tx.Query(
+		ctx,
+		"select id, invoice_id, type, actor, request_id, changes, occurred_at from invoice_events where invoice_id = $1 order by id",
+		invoiceId,
+	)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get invoice events",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var events []InvoiceEventDTO
+	events, err = pgx.CollectRows(rows, pgx.RowToStructByName[InvoiceEventDTO])
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect invoice events",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return &events, nil
+}
+
+func (r *PgRepository) VerifyChain(ctx context.Context, series string, from, to time.Time) (*ChainVerification, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	var links []chainLink
+	links, err = r.selectChainLinks(ctx, tx, "finalized_at >= $2 and finalized_at < $3", series, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(links) == 0 || *links[0].SequenceNumber == 1 {
+		return verifyChain(series, nil, links), nil
+	}
+
+	var preceding []chainLink
+	preceding, err = r.selectChainLinks(ctx, tx, "sequence_number = $2", series, *links[0].SequenceNumber-1)
+	if err != nil {
+		return nil, err
+	}
+	if len(preceding) == 0 {
+		return verifyChain(series, nil, links), nil
+	}
+
+	return verifyChain(series, &preceding[0], links), nil
+}
+
+// selectChainLinks returns the invoices and tombstones of the series in $1
+// that match condition, ordered by sequence number.
+func (r *PgRepository) selectChainLinks(ctx context.Context, tx pgx.Tx, condition string, args ...any) ([]chainLink, error) {
+	rows, err := tx.Query(
+		ctx,
+		"select "+invoiceColumns+" from invoices where tenant_id = current_tenant() and series = $1 and "+condition,
+		args...,
+	)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get chained invoices",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var invoices []InvoiceDTO
+	invoices, err = pgx.CollectRows(rows, pgx.RowToStructByPos[InvoiceDTO])
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect chained invoices",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	rows, err = tx.Query(
+		ctx,
+		`select invoice_id, series, sequence_number, hash, previous_hash, finalized_at
+		from invoice_chain_tombstones where tenant_id = current_tenant() and series = $1 and `+condition,
+		args...,
+	)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get chain tombstones",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var tombstones []chainTombstone
+	tombstones, err = pgx.CollectRows(rows, pgx.RowToStructByPos[chainTombstone])
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect chain tombstones",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return mergeChainLinks(invoices, tombstones), nil
+}
@@ -12,6 +12,7 @@ package invoice
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -83,19 +84,122 @@ func (mr *MockRepositoryMockRecorder) GetInvoiceById(ctx, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInvoiceById", reflect.TypeOf((*MockRepository)(nil).GetInvoiceById), ctx, id)
 }
 
+// GetInvoiceEvents mocks base method.
+func (m *MockRepository) GetInvoiceEvents(ctx context.Context, invoiceId string) (*[]InvoiceEventDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInvoiceEvents", ctx, invoiceId)
+	ret0, _ := ret[0].(*[]InvoiceEventDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInvoiceEvents indicates an expected call of GetInvoiceEvents.
+func (mr *MockRepositoryMockRecorder) GetInvoiceEvents(ctx, invoiceId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInvoiceEvents", reflect.TypeOf((*MockRepository)(nil).GetInvoiceEvents), ctx, invoiceId)
+}
+
+// GetInvoiceStreamEvents mocks base method.
+func (m *MockRepository) GetInvoiceStreamEvents(ctx context.Context, afterId int64, limit int) ([]StreamEventDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInvoiceStreamEvents", ctx, afterId, limit)
+	ret0, _ := ret[0].([]StreamEventDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInvoiceStreamEvents indicates an expected call of GetInvoiceStreamEvents.
+func (mr *MockRepositoryMockRecorder) GetInvoiceStreamEvents(ctx, afterId, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInvoiceStreamEvents", reflect.TypeOf((*MockRepository)(nil).GetInvoiceStreamEvents), ctx, afterId, limit)
+}
+
 // GetInvoices mocks base method.
-func (m *MockRepository) GetInvoices(ctx context.Context, page, pageSize int, search string) (*[]InvoiceDTO, error) {
+func (m *MockRepository) GetInvoices(ctx context.Context, page, pageSize int, search, deleted string) (*[]InvoiceDTO, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetInvoices", ctx, page, pageSize, search)
+	ret := m.ctrl.Call(m, "GetInvoices", ctx, page, pageSize, search, deleted)
 	ret0, _ := ret[0].(*[]InvoiceDTO)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetInvoices indicates an expected call of GetInvoices.
-func (mr *MockRepositoryMockRecorder) GetInvoices(ctx, page, pageSize, search any) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetInvoices(ctx, page, pageSize, search, deleted any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInvoices", reflect.TypeOf((*MockRepository)(nil).GetInvoices), ctx, page, pageSize, search, deleted)
+}
+
+// GetLatestInvoiceEventId mocks base method.
+func (m *MockRepository) GetLatestInvoiceEventId(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatestInvoiceEventId", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatestInvoiceEventId indicates an expected call of GetLatestInvoiceEventId.
+func (mr *MockRepositoryMockRecorder) GetLatestInvoiceEventId(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestInvoiceEventId", reflect.TypeOf((*MockRepository)(nil).GetLatestInvoiceEventId), ctx)
+}
+
+// GetUnpaidTotals mocks base method.
+func (m *MockRepository) GetUnpaidTotals(ctx context.Context) ([]UnpaidTotalDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUnpaidTotals", ctx)
+	ret0, _ := ret[0].([]UnpaidTotalDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUnpaidTotals indicates an expected call of GetUnpaidTotals.
+func (mr *MockRepositoryMockRecorder) GetUnpaidTotals(ctx any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInvoices", reflect.TypeOf((*MockRepository)(nil).GetInvoices), ctx, page, pageSize, search)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUnpaidTotals", reflect.TypeOf((*MockRepository)(nil).GetUnpaidTotals), ctx)
+}
+
+// ListenInvoiceEvents mocks base method.
+func (m *MockRepository) ListenInvoiceEvents(ctx context.Context, notifications chan<- struct{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListenInvoiceEvents", ctx, notifications)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ListenInvoiceEvents indicates an expected call of ListenInvoiceEvents.
+func (mr *MockRepositoryMockRecorder) ListenInvoiceEvents(ctx, notifications any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListenInvoiceEvents", reflect.TypeOf((*MockRepository)(nil).ListenInvoiceEvents), ctx, notifications)
+}
+
+// PurgeDeletedInvoices mocks base method.
+func (m *MockRepository) PurgeDeletedInvoices(ctx context.Context, deletedBefore time.Time) (*PurgeResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeletedInvoices", ctx, deletedBefore)
+	ret0, _ := ret[0].(*PurgeResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeletedInvoices indicates an expected call of PurgeDeletedInvoices.
+func (mr *MockRepositoryMockRecorder) PurgeDeletedInvoices(ctx, deletedBefore any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeletedInvoices", reflect.TypeOf((*MockRepository)(nil).PurgeDeletedInvoices), ctx, deletedBefore)
+}
+
+// RestoreInvoiceById mocks base method.
+func (m *MockRepository) RestoreInvoiceById(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreInvoiceById", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreInvoiceById indicates an expected call of RestoreInvoiceById.
+func (mr *MockRepositoryMockRecorder) RestoreInvoiceById(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreInvoiceById", reflect.TypeOf((*MockRepository)(nil).RestoreInvoiceById), ctx, id)
 }
 
 // UpdateInvoiceById mocks base method.
@@ -111,3 +215,18 @@ func (mr *MockRepositoryMockRecorder) UpdateInvoiceById(ctx, id, invoice any) *g
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateInvoiceById", reflect.TypeOf((*MockRepository)(nil).UpdateInvoiceById), ctx, id, invoice)
 }
+
+// VerifyChain mocks base method.
+func (m *MockRepository) VerifyChain(ctx context.Context, series string, from, to time.Time) (*ChainVerification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyChain", ctx, series, from, to)
+	ret0, _ := ret[0].(*ChainVerification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyChain indicates an expected call of VerifyChain.
+func (mr *MockRepositoryMockRecorder) VerifyChain(ctx, series, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyChain", reflect.TypeOf((*MockRepository)(nil).VerifyChain), ctx, series, from, to)
+}
@@ -0,0 +1,66 @@
+package invoice
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type BlobDeleter interface {
+	Delete(ctx context.Context, key string) error
+}
+
+type RetentionJob struct {
+	log        *zap.Logger
+	repository Repository
+	blobs      BlobDeleter
+	retention  time.Duration
+	interval   time.Duration
+	now        func() time.Time
+}
+
+func NewRetentionJob(log *zap.Logger, repository Repository, blobs BlobDeleter, retention, interval time.Duration) *RetentionJob {
+	return &RetentionJob{
+		log:        log,
+		repository: repository,
+		blobs:      blobs,
+		retention:  retention,
+		interval:   interval,
+		now:        time.Now,
+	}
+}
+
+func (j *RetentionJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.Purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (j *RetentionJob) Purge(ctx context.Context) {
+	deletedBefore := j.now().Add(-j.retention)
+	result, err := j.repository.PurgeDeletedInvoices(ctx, deletedBefore)
+	if err != nil {
+		j.log.Error("failed to purge deleted invoices", zap.Error(err))
+		return
+	}
+
+	for _, key := range result.StorageKeys {
+		if err = j.blobs.Delete(ctx, key); err != nil {
+			j.log.Warn("failed to delete attachment of purged invoice", zap.String("key", key), zap.Error(err))
+		}
+	}
+
+	if result.Purged > 0 {
+		j.log.Info("purged deleted invoices", zap.Int64("count", result.Purged), zap.Time("deletedBefore", deletedBefore))
+	}
+}
@@ -0,0 +1,80 @@
+package invoice
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func TestRetentionJob_Purge(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("happy path", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().PurgeDeletedInvoices(gomock.Any(), now.Add(-30*24*time.Hour)).
+			Return(&PurgeResult{Purged: 2, StorageKeys: []string{"a", "b"}}, nil)
+		blobs := &fakeBlobs{failing: "a"}
+
+		job := NewRetentionJob(zap.NewNop(), mockRepository, blobs, 30*24*time.Hour, time.Hour)
+		job.now = func() time.Time { return now }
+
+		assert.NotPanics(t, func() { job.Purge(context.TODO()) })
+		assert.Equal(t, []string{"a", "b"}, blobs.deleted)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().PurgeDeletedInvoices(gomock.Any(), gomock.Any()).Return(nil, errors.New("unavailable"))
+
+		job := NewRetentionJob(zap.NewNop(), mockRepository, &fakeBlobs{}, time.Hour, time.Hour)
+
+		assert.NotPanics(t, func() { job.Purge(context.TODO()) })
+	})
+}
+
+func TestRetentionJob_Run(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	mockRepository := NewMockRepository(mockController)
+	mockRepository.EXPECT().
+		PurgeDeletedInvoices(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(context.Context, time.Time) (*PurgeResult, error) {
+			cancel()
+			return &PurgeResult{}, nil
+		})
+
+	done := make(chan struct{})
+	go func() {
+		NewRetentionJob(zap.NewNop(), mockRepository, &fakeBlobs{}, time.Hour, time.Hour).Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("retention job did not stop")
+	}
+}
+
+type fakeBlobs struct {
+	failing string
+	deleted []string
+}
+
+func (b *fakeBlobs) Delete(_ context.Context, key string) error {
+	b.deleted = append(b.deleted, key)
+	if key == b.failing {
+		return errors.New("unavailable")
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package invoice
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/requestcontext"
+	"invoice-api/pkg/tenant"
+)
+
+const invoiceEventsChannel = "invoice_events"
+
+type StreamEventDTO struct {
+	Id         int64       `json:"id" db:"id"`
+	Type       string      `json:"type" db:"type"`
+	InvoiceId  string      `json:"invoiceId" db:"invoice_id"`
+	OccurredAt time.Time   `json:"occurredAt" db:"occurred_at"`
+	TenantId   string      `json:"-" db:"tenant_id"`
+	Invoice    *InvoiceDTO `json:"invoice,omitempty" db:"-"`
+}
+
+type StreamFilter struct {
+	Tenant  string
+	Search  string
+	Deleted string
+}
+
+// Match reports whether an event passes the filter. Search matches the same
+// case-insensitive substring of the invoice id and service name as
+// GetInvoices, so a stream shows the invoices its list query returns.
+func (f StreamFilter) Match(event StreamEventDTO) bool {
+	if f.Tenant != "" && event.TenantId != f.Tenant {
+		return false
+	}
+
+	invoice := event.Invoice
+	if f.Search != "" {
+		subject := event.InvoiceId
+		if invoice != nil {
+			subject += " " + invoice.ServiceName
+		}
+		if !strings.Contains(strings.ToLower(subject), strings.ToLower(f.Search)) {
+			return false
+		}
+	}
+
+	deleted := invoice == nil || invoice.DeletedAt != nil
+	switch f.Deleted {
+	case DeletedInclude:
+		return true
+	case DeletedOnly:
+		return deleted || event.Type == EventRestored
+	default:
+		return !deleted || event.Type == EventDeleted
+	}
+}
+
+func (r *PgRepository) GetLatestInvoiceEventId(ctx context.Context) (int64, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return 0, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return 0, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	var id int64
+	if err = tx.QueryRow(ctx, "select coalesce(max(id), 0) from invoice_events").Scan(&id); err != nil {
+		return 0, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get latest invoice event",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return id, nil
+}
+
+func (r *PgRepository) GetInvoiceStreamEvents(ctx context.Context, afterId int64, limit int) ([]StreamEventDTO, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	var rows pgx.Rows
+	rows, err = tx.Query(
+		ctx,
+		"select id, type, invoice_id, occurred_at, tenant_id from invoice_events where id > $1 order by id limit $2",
+		afterId,
+		limit,
+	)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get invoice events",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var events []StreamEventDTO
+	events, err = pgx.CollectRows(rows, pgx.RowToStructByPos[StreamEventDTO])
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect invoice events",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if len(events) == 0 {
+		return events, nil
+	}
+
+	invoiceIds := make([]string, 0, len(events))
+	for _, event := range events {
+		invoiceIds = append(invoiceIds, event.InvoiceId)
+	}
+
+	rows, err = tx.Query(ctx, "select "+invoiceColumns+" from invoices where id = any($1)", invoiceIds)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get invoices",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var invoices []InvoiceDTO
+	invoices, err = pgx.CollectRows(rows, pgx.RowToStructByPos[InvoiceDTO])
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect invoices",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	invoicesById := make(map[string]*InvoiceDTO, len(invoices))
+	for i := range invoices {
+		invoicesById[invoices[i].Id] = &invoices[i]
+	}
+	for i := range events {
+		events[i].Invoice = invoicesById[events[i].InvoiceId]
+	}
+
+	return events, nil
+}
+
+func (r *PgRepository) ListenInvoiceEvents(ctx context.Context, notifications chan<- struct{}) error {
+	pooled, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	connection := pooled.Hijack()
+	defer func() { _ = connection.Close(context.Background()) }()
+
+	if _, err = connection.Exec(ctx, "listen "+invoiceEventsChannel); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to listen for invoice events",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	for {
+		if _, err = connection.WaitForNotification(ctx); err != nil {
+			return err
+		}
+
+		select {
+		case notifications <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package invoice
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamFilter_Match(t *testing.T) {
+	deletedAt := time.Now()
+	active := &InvoiceDTO{Id: "dda97bce-ac2a-4431-9c7b-3b6bcdfe8a23", ServiceName: "DMP"}
+	deleted := &InvoiceDTO{Id: "dc874c3f-2773-413e-a3c8-e9f24b04079c", ServiceName: "SSP", DeletedAt: &deletedAt}
+
+	created := StreamEventDTO{Type: EventCreated, InvoiceId: active.Id, Invoice: active}
+	removed := StreamEventDTO{Type: EventDeleted, InvoiceId: deleted.Id, Invoice: deleted}
+	updatedInTrash := StreamEventDTO{Type: EventUpdated, InvoiceId: deleted.Id, Invoice: deleted}
+	restored := StreamEventDTO{Type: EventRestored, InvoiceId: active.Id, Invoice: active}
+	purged := StreamEventDTO{Type: EventPurged, InvoiceId: "550e8400-e29b-41d4-a716-446655440000"}
+
+	t.Run("active invoices", func(t *testing.T) {
+		filter := StreamFilter{}
+
+		assert.True(t, filter.Match(created))
+		assert.True(t, filter.Match(removed))
+		assert.True(t, filter.Match(restored))
+		assert.False(t, filter.Match(updatedInTrash))
+		assert.False(t, filter.Match(purged))
+	})
+
+	t.Run("deleted invoices", func(t *testing.T) {
+		filter := StreamFilter{Deleted: DeletedOnly}
+
+		assert.False(t, filter.Match(created))
+		assert.True(t, filter.Match(removed))
+		assert.True(t, filter.Match(restored))
+		assert.True(t, filter.Match(updatedInTrash))
+		assert.True(t, filter.Match(purged))
+	})
+
+	t.Run("all invoices", func(t *testing.T) {
+		filter := StreamFilter{Deleted: DeletedInclude}
+
+		for _, event := range []StreamEventDTO{created, removed, updatedInTrash, restored, purged} {
+			assert.True(t, filter.Match(event))
+		}
+	})
+
+	t.Run("search", func(t *testing.T) {
+		assert.True(t, StreamFilter{Search: "dmp"}.Match(created))
+		assert.True(t, StreamFilter{Search: "dda97bce"}.Match(created))
+		assert.False(t, StreamFilter{Search: "ssp"}.Match(created))
+		assert.True(t, StreamFilter{Search: "550e8400", Deleted: DeletedOnly}.Match(purged))
+		assert.True(t, StreamFilter{Search: "8a23 dm"}.Match(created))
+		assert.False(t, StreamFilter{Search: "d%p"}.Match(created))
+	})
+
+	t.Run("tenant", func(t *testing.T) {
+		event := StreamEventDTO{Type: EventCreated, InvoiceId: active.Id, Invoice: active, TenantId: "acme"}
+
+		assert.True(t, StreamFilter{Tenant: "acme"}.Match(event))
+		assert.False(t, StreamFilter{Tenant: "globex"}.Match(event))
+		assert.True(t, StreamFilter{}.Match(event))
+	})
+}
@@ -0,0 +1,326 @@
+package invoice
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ublInvoiceNamespace = "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"
+	ublCbcNamespace     = "urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2"
+	ublCacNamespace     = "urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2"
+	ublVersion          = "2.1"
+	ublTRCustomization  = "TR"
+)
+
+var ublNamespacePrefixes = map[string]string{
+	ublCbcNamespace: "cbc",
+	ublCacNamespace: "cac",
+}
+
+type UBLValidationError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e UBLValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+type ublElement struct {
+	name     xml.Name
+	path     string
+	attrs    []xml.Attr
+	text     strings.Builder
+	children []*ublElement
+}
+
+func ParseUBLInvoice(r io.Reader) (*InvoiceDTO, []UBLValidationError) {
+	root, err := decodeUBLDocument(r)
+	if err != nil {
+		var validationError UBLValidationError
+		if errors.As(err, &validationError) {
+			return nil, []UBLValidationError{validationError}
+		}
+
+		return nil, []UBLValidationError{{Path: "/", Message: err.Error()}}
+	}
+
+	return mapUBLInvoice(root)
+}
+
+func decodeUBLDocument(r io.Reader) (*ublElement, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = true
+
+	var root *ublElement
+	var stack []*ublElement
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			path := "/"
+			if len(stack) > 0 {
+				path = stack[len(stack)-1].path
+			}
+
+			return nil, UBLValidationError{Path: path, Message: fmt.Sprintf("malformed xml: %s", err)}
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			element := &ublElement{name: t.Name, attrs: t.Attr}
+			if len(stack) == 0 {
+				if root != nil {
+					return nil, UBLValidationError{Path: "/", Message: "document must have a single root element"}
+				}
+				root = element
+				element.path = "/" + ublQualifiedName(t.Name)
+			} else {
+				parent := stack[len(stack)-1]
+				element.path = parent.path + "/" + ublQualifiedName(t.Name)
+				if position := parent.countChildren(t.Name) + 1; position > 1 {
+					element.path = fmt.Sprintf("%s[%d]", element.path, position)
+				}
+				parent.children = append(parent.children, element)
+			}
+			stack = append(stack, element)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, UBLValidationError{Path: "/", Message: "document is empty"}
+	}
+
+	return root, nil
+}
+
+func mapUBLInvoice(root *ublElement) (*InvoiceDTO, []UBLValidationError) {
+	var errs []UBLValidationError
+	fail := func(path, format string, args ...any) {
+		errs = append(errs, UBLValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if root.name.Space != ublInvoiceNamespace || root.name.Local != "Invoice" {
+		fail(root.path, "root element must be Invoice in namespace %s", ublInvoiceNamespace)
+		return nil, errs
+	}
+
+	if version := root.value(ublCbcNamespace, "UBLVersionID"); version != ublVersion {
+		fail(root.childPath(ublCbcNamespace, "UBLVersionID"), "must be %q, got %q", ublVersion, version)
+	}
+
+	if root.value(ublCbcNamespace, "ID") == "" {
+		fail(root.childPath(ublCbcNamespace, "ID"), "is required")
+	}
+
+	invoice := &InvoiceDTO{
+		Status:    "UNPAID",
+		Direction: DirectionInbound,
+	}
+
+	documentUUID := root.value(ublCbcNamespace, "UUID")
+	if strings.HasPrefix(root.value(ublCbcNamespace, "CustomizationID"), ublTRCustomization) {
+		if documentUUID == "" {
+			fail(root.childPath(ublCbcNamespace, "UUID"), "is required for UBL-TR documents")
+		}
+		if root.value(ublCbcNamespace, "ProfileID") == "" {
+			fail(root.childPath(ublCbcNamespace, "ProfileID"), "is required for UBL-TR documents")
+		}
+	}
+	if documentUUID != "" {
+		parsed, err := uuid.Parse(documentUUID)
+		if err != nil || parsed.Version() != 4 {
+			fail(root.childPath(ublCbcNamespace, "UUID"), "must be a version 4 uuid, got %q", documentUUID)
+		} else {
+			invoice.Id = parsed.String()
+		}
+	}
+
+	issueDate := root.value(ublCbcNamespace, "IssueDate")
+	date, err := time.Parse(time.DateOnly, issueDate)
+	if err != nil {
+		fail(root.childPath(ublCbcNamespace, "IssueDate"), "must be a date in YYYY-MM-DD format, got %q", issueDate)
+	} else {
+		if issueTime := root.value(ublCbcNamespace, "IssueTime"); issueTime != "" {
+			clock, err := parseUBLTime(issueTime)
+			if err != nil {
+				fail(root.childPath(ublCbcNamespace, "IssueTime"), "must be a time in hh:mm:ss format, got %q", issueTime)
+			} else {
+				date = date.Add(clock)
+			}
+		}
+		invoice.Date = date.UTC()
+	}
+
+	currency := root.value(ublCbcNamespace, "DocumentCurrencyCode")
+	if len(currency) != 3 {
+		fail(root.childPath(ublCbcNamespace, "DocumentCurrencyCode"), "must be a three letter ISO 4217 code, got %q", currency)
+	}
+
+	supplier := root.child(ublCacNamespace, "AccountingSupplierParty")
+	if supplier == nil || supplier.child(ublCacNamespace, "Party") == nil {
+		fail(root.childPath(ublCacNamespace, "AccountingSupplierParty")+"/cac:Party", "is required")
+	}
+
+	monetaryTotal := root.child(ublCacNamespace, "LegalMonetaryTotal")
+	if monetaryTotal == nil {
+		fail(root.childPath(ublCacNamespace, "LegalMonetaryTotal"), "is required")
+	} else {
+		payableAmount := monetaryTotal.child(ublCbcNamespace, "PayableAmount")
+		if payableAmount == nil {
+			fail(monetaryTotal.childPath(ublCbcNamespace, "PayableAmount"), "is required")
+		} else {
+			amount, err := strconv.ParseFloat(payableAmount.textValue(), 32)
+			if err != nil || amount <= 0 {
+				fail(payableAmount.path, "must be a positive decimal, got %q", payableAmount.textValue())
+			} else {
+				invoice.Amount = float32(amount)
+			}
+
+			if currencyID := payableAmount.attr("currencyID"); currencyID != currency {
+				fail(payableAmount.path+"/@currencyID", "must match DocumentCurrencyCode %q, got %q", currency, currencyID)
+			}
+		}
+	}
+
+	lines := root.childrenNamed(ublCacNamespace, "InvoiceLine")
+	if len(lines) == 0 {
+		fail(root.childPath(ublCacNamespace, "InvoiceLine"), "at least one invoice line is required")
+	}
+	for _, line := range lines {
+		if line.value(ublCbcNamespace, "ID") == "" {
+			fail(line.childPath(ublCbcNamespace, "ID"), "is required")
+		}
+
+		item := line.child(ublCacNamespace, "Item")
+		if item == nil {
+			fail(line.childPath(ublCacNamespace, "Item"), "is required")
+			continue
+		}
+
+		itemName := item.child(ublCbcNamespace, "Name")
+		if itemName == nil {
+			fail(item.childPath(ublCbcNamespace, "Name"), "is required")
+			continue
+		}
+
+		serviceName := itemName.textValue()
+		switch {
+		case serviceName != "DMP" && serviceName != "SSP":
+			fail(itemName.path, "must be one of DMP, SSP, got %q", serviceName)
+		case invoice.ServiceName == "":
+			invoice.ServiceName = serviceName
+		case invoice.ServiceName != serviceName:
+			fail(itemName.path, "all invoice lines must reference the same service, expected %q, got %q", invoice.ServiceName, serviceName)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return invoice, nil
+}
+
+func parseUBLTime(value string) (time.Duration, error) {
+	for _, layout := range []string{"15:04:05Z07:00", "15:04:05.999999999", "15:04:05"} {
+		clock, err := time.Parse(layout, value)
+		if err == nil {
+			hour, minute, second := clock.Clock()
+			_, offset := clock.Zone()
+			return time.Duration(hour)*time.Hour +
+				time.Duration(minute)*time.Minute +
+				time.Duration(second)*time.Second +
+				time.Duration(clock.Nanosecond()) -
+				time.Duration(offset)*time.Second, nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid time %q", value)
+}
+
+func ublQualifiedName(name xml.Name) string {
+	if prefix, ok := ublNamespacePrefixes[name.Space]; ok {
+		return prefix + ":" + name.Local
+	}
+
+	return name.Local
+}
+
+func (e *ublElement) countChildren(name xml.Name) int {
+	count := 0
+	for _, child := range e.children {
+		if child.name == name {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (e *ublElement) child(space, local string) *ublElement {
+	for _, child := range e.children {
+		if child.name.Space == space && child.name.Local == local {
+			return child
+		}
+	}
+
+	return nil
+}
+
+func (e *ublElement) childrenNamed(space, local string) []*ublElement {
+	var children []*ublElement
+	for _, child := range e.children {
+		if child.name.Space == space && child.name.Local == local {
+			children = append(children, child)
+		}
+	}
+
+	return children
+}
+
+func (e *ublElement) childPath(space, local string) string {
+	if child := e.child(space, local); child != nil {
+		return child.path
+	}
+
+	return e.path + "/" + ublQualifiedName(xml.Name{Space: space, Local: local})
+}
+
+func (e *ublElement) value(space, local string) string {
+	if child := e.child(space, local); child != nil {
+		return child.textValue()
+	}
+
+	return ""
+}
+
+func (e *ublElement) textValue() string {
+	return strings.TrimSpace(e.text.String())
+}
+
+func (e *ublElement) attr(local string) string {
+	for _, attr := range e.attrs {
+		if attr.Name.Local == local {
+			return attr.Value
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,106 @@
+package invoice
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testUBLInvoice = `<?xml version="1.0" encoding="UTF-8"?>
+<Invoice xmlns="urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"
+         xmlns:cac="urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2"
+         xmlns:cbc="urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2">
+  <cbc:UBLVersionID>2.1</cbc:UBLVersionID>
+  <cbc:CustomizationID>TR1.2</cbc:CustomizationID>
+  <cbc:ProfileID>TICARIFATURA</cbc:ProfileID>
+  <cbc:ID>ABC2025000000001</cbc:ID>
+  <cbc:UUID>3f1c8a4e-5b7d-4c2a-9e1f-0a2b3c4d5e6f</cbc:UUID>
+  <cbc:IssueDate>2025-03-18</cbc:IssueDate>
+  <cbc:IssueTime>12:34:56</cbc:IssueTime>
+  <cbc:DocumentCurrencyCode>TRY</cbc:DocumentCurrencyCode>
+  <cac:AccountingSupplierParty>
+    <cac:Party>
+      <cac:PartyName><cbc:Name>Supplier Ltd.</cbc:Name></cac:PartyName>
+    </cac:Party>
+  </cac:AccountingSupplierParty>
+  <cac:LegalMonetaryTotal>
+    <cbc:PayableAmount currencyID="TRY">120.30</cbc:PayableAmount>
+  </cac:LegalMonetaryTotal>
+  <cac:InvoiceLine>
+    <cbc:ID>1</cbc:ID>
+    <cac:Item><cbc:Name>DMP</cbc:Name></cac:Item>
+  </cac:InvoiceLine>
+  <cac:InvoiceLine>
+    <cbc:ID>2</cbc:ID>
+    <cac:Item><cbc:Name>DMP</cbc:Name></cac:Item>
+  </cac:InvoiceLine>
+</Invoice>`
+
+func TestParseUBLInvoice(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		invoice, errs := ParseUBLInvoice(strings.NewReader(testUBLInvoice))
+
+		require.Empty(t, errs)
+		assert.Equal(t, "3f1c8a4e-5b7d-4c2a-9e1f-0a2b3c4d5e6f", invoice.Id)
+		assert.Equal(t, "DMP", invoice.ServiceName)
+		assert.Equal(t, float32(120.30), invoice.Amount)
+		assert.Equal(t, "UNPAID", invoice.Status)
+		assert.Equal(t, DirectionInbound, invoice.Direction)
+		assert.Equal(t, time.Date(2025, 3, 18, 12, 34, 56, 0, time.UTC), invoice.Date)
+	})
+
+	t.Run("malformed xml", func(t *testing.T) {
+		document := strings.Replace(testUBLInvoice, "</cac:LegalMonetaryTotal>", "", 1)
+
+		invoice, errs := ParseUBLInvoice(strings.NewReader(document))
+
+		assert.Nil(t, invoice)
+		require.Len(t, errs, 1)
+		assert.Equal(t, "/Invoice/cac:LegalMonetaryTotal", errs[0].Path)
+	})
+
+	t.Run("invalid root element", func(t *testing.T) {
+		invoice, errs := ParseUBLInvoice(strings.NewReader(`<CreditNote/>`))
+
+		assert.Nil(t, invoice)
+		require.Len(t, errs, 1)
+		assert.Equal(t, "/CreditNote", errs[0].Path)
+	})
+
+	t.Run("validation errors", func(t *testing.T) {
+		document := strings.NewReplacer(
+			"<cbc:UBLVersionID>2.1</cbc:UBLVersionID>", "<cbc:UBLVersionID>2.0</cbc:UBLVersionID>",
+			"<cbc:UUID>3f1c8a4e-5b7d-4c2a-9e1f-0a2b3c4d5e6f</cbc:UUID>", "",
+			`currencyID="TRY">120.30`, `currencyID="EUR">-1`,
+			"<cac:Item><cbc:Name>DMP</cbc:Name></cac:Item>\n  </cac:InvoiceLine>\n</Invoice>", "<cac:Item><cbc:Name>SSP</cbc:Name></cac:Item>\n  </cac:InvoiceLine>\n</Invoice>",
+		).Replace(testUBLInvoice)
+
+		invoice, errs := ParseUBLInvoice(strings.NewReader(document))
+
+		assert.Nil(t, invoice)
+		paths := make([]string, 0, len(errs))
+		for _, err := range errs {
+			paths = append(paths, err.Path)
+		}
+		assert.ElementsMatch(t, []string{
+			"/Invoice/cbc:UBLVersionID",
+			"/Invoice/cbc:UUID",
+			"/Invoice/cac:LegalMonetaryTotal/cbc:PayableAmount",
+			"/Invoice/cac:LegalMonetaryTotal/cbc:PayableAmount/@currencyID",
+			"/Invoice/cac:InvoiceLine[2]/cac:Item/cbc:Name",
+		}, paths)
+	})
+
+	t.Run("missing invoice lines", func(t *testing.T) {
+		document := testUBLInvoice[:strings.Index(testUBLInvoice, "<cac:InvoiceLine>")] + "</Invoice>"
+
+		invoice, errs := ParseUBLInvoice(strings.NewReader(document))
+
+		assert.Nil(t, invoice)
+		require.Len(t, errs, 1)
+		assert.Equal(t, "/Invoice/cac:InvoiceLine", errs[0].Path)
+	})
+}
@@ -0,0 +1,51 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"invoice-api/pkg/tenant"
+)
+
+var failedEvents = prometheus.NewDesc(
+	"outbox_events_failed",
+	"Number of outbox events that exhausted their publish attempts by tenant and type.",
+	[]string{"tenant", "type"},
+	nil,
+)
+
+type Collector struct {
+	log        *zap.Logger
+	repository Repository
+	timeout    time.Duration
+}
+
+func NewCollector(log *zap.Logger, repository Repository, timeout time.Duration) *Collector {
+	return &Collector{
+		log:        log,
+		repository: repository,
+		timeout:    timeout,
+	}
+}
+
+func (c *Collector) Describe(descriptions chan<- *prometheus.Desc) {
+	descriptions <- failedEvents
+}
+
+func (c *Collector) Collect(metrics chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(tenant.WithSystem(context.Background()), c.timeout)
+	defer cancel()
+
+	totals, err := c.repository.GetFailedTotals(ctx)
+	if err != nil {
+		c.log.Warn("failed to collect failed outbox event totals", zap.Error(err))
+		return
+	}
+
+	for _, total := range totals {
+		metrics <- prometheus.MustNewConstMetric(failedEvents, prometheus.GaugeValue, float64(total.Count), total.TenantId, total.Type)
+	}
+}
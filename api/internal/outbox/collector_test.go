@@ -0,0 +1,43 @@
+package outbox
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func TestCollector_Collect(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("happy path", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetFailedTotals(gomock.Any()).Return([]FailedTotal{
+			{TenantId: "default", Type: "invoice.created", Count: 2},
+			{TenantId: "default", Type: "invoice.paid", Count: 1},
+		}, nil)
+
+		expected := `
+# HELP outbox_events_failed Number of outbox events that exhausted their publish attempts by tenant and type.
+# TYPE outbox_events_failed gauge
+outbox_events_failed{tenant="default",type="invoice.created"} 2
+outbox_events_failed{tenant="default",type="invoice.paid"} 1
+`
+		collector := NewCollector(zap.NewNop(), mockRepository, time.Second)
+		assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected)))
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetFailedTotals(gomock.Any()).Return(nil, errors.New("connection reset"))
+
+		collector := NewCollector(zap.NewNop(), mockRepository, time.Second)
+		assert.Zero(t, testutil.CollectAndCount(collector))
+	})
+}
@@ -0,0 +1,47 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"invoice-api/pkg/metrics"
+	"invoice-api/pkg/tracing"
+)
+
+const repositoryName = "outbox"
+
+type InstrumentedRepository struct {
+	repository Repository
+}
+
+func NewInstrumentedRepository(repository Repository) *InstrumentedRepository {
+	return &InstrumentedRepository{repository: repository}
+}
+
+func (r *InstrumentedRepository) GetPendingEvents(ctx context.Context, limit int) (_ []Event, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "GetPendingEvents")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "GetPendingEvents", time.Now(), &err)
+	return r.repository.GetPendingEvents(ctx, limit)
+}
+
+func (r *InstrumentedRepository) MarkPublished(ctx context.Context, id int64) (err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "MarkPublished")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "MarkPublished", time.Now(), &err)
+	return r.repository.MarkPublished(ctx, id)
+}
+
+func (r *InstrumentedRepository) MarkFailed(ctx context.Context, id int64, nextAttemptAt *time.Time, reason string) (err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "MarkFailed")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "MarkFailed", time.Now(), &err)
+	return r.repository.MarkFailed(ctx, id, nextAttemptAt, reason)
+}
+
+func (r *InstrumentedRepository) GetFailedTotals(ctx context.Context) (_ []FailedTotal, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "GetFailedTotals")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "GetFailedTotals", time.Now(), &err)
+	return r.repository.GetFailedTotals(ctx)
+}
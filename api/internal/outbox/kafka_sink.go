@@ -0,0 +1,46 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type KafkaSink struct {
+	writer *kafka.Writer
+}
+
+func NewKafkaSink(brokers []string, topic string) *KafkaSink {
+	return &KafkaSink{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Topic:        topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			MaxAttempts:  1,
+		},
+	}
+}
+
+func (s *KafkaSink) Publish(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return s.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(event.AggregateId),
+		Value: data,
+		Headers: []kafka.Header{
+			{Key: "Event-Id", Value: []byte(strconv.FormatInt(event.Id, 10))},
+			{Key: "Event-Type", Value: []byte(event.Type)},
+		},
+		Time: event.CreatedAt,
+	})
+}
+
+func (s *KafkaSink) Close() error {
+	return s.writer.Close()
+}
@@ -0,0 +1,24 @@
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type Event struct {
+	Id            int64           `json:"id" db:"id"`
+	AggregateType string          `json:"aggregateType" db:"aggregate_type"`
+	AggregateId   string          `json:"aggregateId" db:"aggregate_id"`
+	Type          string          `json:"type" db:"type"`
+	Payload       json.RawMessage `json:"payload" db:"payload"`
+	RequestId     *string         `json:"requestId,omitempty" db:"request_id"`
+	CreatedAt     time.Time       `json:"createdAt" db:"created_at"`
+	Attempts      int             `json:"-" db:"attempts"`
+	TenantId      string          `json:"tenantId" db:"tenant_id"`
+}
+
+type FailedTotal struct {
+	TenantId string `db:"tenant_id"`
+	Type     string `db:"type"`
+	Count    int64  `db:"count"`
+}
@@ -0,0 +1,55 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/nats-io/nats.go"
+)
+
+type NATSSink struct {
+	connection    *nats.Conn
+	jetStream     nats.JetStreamContext
+	subjectPrefix string
+}
+
+func NewNATSSink(url, subjectPrefix string) (*NATSSink, error) {
+	connection, err := nats.Connect(url, nats.Name("invoice-api outbox relay"))
+	if err != nil {
+		return nil, err
+	}
+
+	var jetStream nats.JetStreamContext
+	jetStream, err = connection.JetStream()
+	if err != nil {
+		connection.Close()
+		return nil, err
+	}
+
+	return &NATSSink{
+		connection:    connection,
+		jetStream:     jetStream,
+		subjectPrefix: subjectPrefix,
+	}, nil
+}
+
+func (s *NATSSink) Publish(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	message := nats.NewMsg(s.subjectPrefix + "." + event.Type)
+	message.Data = data
+	message.Header.Set("Event-Id", strconv.FormatInt(event.Id, 10))
+	message.Header.Set("Event-Type", event.Type)
+	message.Header.Set("Aggregate-Id", event.AggregateId)
+
+	_, err = s.jetStream.PublishMsg(message, nats.Context(ctx), nats.MsgId(strconv.FormatInt(event.Id, 10)))
+	return err
+}
+
+func (s *NATSSink) Close() error {
+	return s.connection.Drain()
+}
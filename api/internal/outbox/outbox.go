@@ -0,0 +1,52 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/requestcontext"
+)
+
+func Write(ctx context.Context, tx pgx.Tx, aggregateType, aggregateId, eventType string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to marshal outbox event",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var requestId *string
+	if id := requestcontext.RequestId(ctx); id != "" {
+		requestId = &id
+	}
+
+	now := time.Now().UTC()
+	if _, err = tx.Exec(
+		ctx,
+		"insert into outbox_events (aggregate_type, aggregate_id, type, payload, request_id, created_at, next_attempt_at) values ($1, $2, $3, $4, $5, $6, $6)",
+		aggregateType,
+		aggregateId,
+		eventType,
+		data,
+		requestId,
+		now,
+	); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to write outbox event",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return nil
+}
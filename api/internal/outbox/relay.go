@@ -0,0 +1,116 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type Relay struct {
+	log         *zap.Logger
+	repository  Repository
+	sink        Sink
+	batchSize   int
+	interval    time.Duration
+	maxBackoff  time.Duration
+	maxAttempts int
+	now         func() time.Time
+}
+
+func NewRelay(
+	log *zap.Logger,
+	repository Repository,
+	sink Sink,
+	batchSize int,
+	interval time.Duration,
+	maxBackoff time.Duration,
+	maxAttempts int,
+) *Relay {
+	return &Relay{
+		log:         log,
+		repository:  repository,
+		sink:        sink,
+		batchSize:   batchSize,
+		interval:    interval,
+		maxBackoff:  maxBackoff,
+		maxAttempts: maxAttempts,
+		now:         time.Now,
+	}
+}
+
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if r.Publish(ctx) < r.batchSize {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func (r *Relay) Publish(ctx context.Context) int {
+	events, err := r.repository.GetPendingEvents(ctx, r.batchSize)
+	if err != nil {
+		r.log.Error("failed to get pending outbox events", zap.Error(err))
+		return 0
+	}
+
+	published := 0
+	for _, event := range events {
+		if err = r.sink.Publish(ctx, event); err != nil {
+			var nextAttemptAt *time.Time
+			if event.Attempts+1 < r.maxAttempts {
+				retryAt := r.now().Add(r.backoff(event.Attempts))
+				nextAttemptAt = &retryAt
+				r.log.Warn(
+					"failed to publish outbox event",
+					zap.Int64("id", event.Id),
+					zap.String("type", event.Type),
+					zap.Int("attempts", event.Attempts+1),
+					zap.Time("nextAttemptAt", retryAt),
+					zap.Error(err),
+				)
+			} else {
+				r.log.Error(
+					"giving up on outbox event",
+					zap.Int64("id", event.Id),
+					zap.String("type", event.Type),
+					zap.Int("attempts", event.Attempts+1),
+					zap.Error(err),
+				)
+			}
+
+			if err = r.repository.MarkFailed(ctx, event.Id, nextAttemptAt, err.Error()); err != nil {
+				r.log.Error("failed to mark outbox event as failed", zap.Int64("id", event.Id), zap.Error(err))
+			}
+			continue
+		}
+
+		if err = r.repository.MarkPublished(ctx, event.Id); err != nil {
+			r.log.Error("failed to mark outbox event as published", zap.Int64("id", event.Id), zap.Error(err))
+			continue
+		}
+		published++
+	}
+
+	return published
+}
+
+func (r *Relay) backoff(attempts int) time.Duration {
+	backoff := r.interval
+	for i := 0; i < attempts && backoff < r.maxBackoff; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, r.maxBackoff)
+}
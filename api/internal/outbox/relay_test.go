@@ -0,0 +1,138 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+type sinkFunc func(ctx context.Context, event Event) error
+
+func (f sinkFunc) Publish(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+func (f sinkFunc) Close() error {
+	return nil
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
+
+func TestRelay_Publish(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	events := []Event{
+		{Id: 1, AggregateId: "a", Type: "invoice.created"},
+		{Id: 2, AggregateId: "b", Type: "invoice.paid", Attempts: 3},
+	}
+
+	t.Run("happy path", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetPendingEvents(gomock.Any(), 10).Return(events, nil)
+		mockRepository.EXPECT().MarkPublished(gomock.Any(), int64(1)).Return(nil)
+		mockRepository.EXPECT().MarkPublished(gomock.Any(), int64(2)).Return(nil)
+
+		var published []int64
+		sink := sinkFunc(func(_ context.Context, event Event) error {
+			published = append(published, event.Id)
+			return nil
+		})
+
+		relay := NewRelay(zap.NewNop(), mockRepository, sink, 10, time.Second, time.Minute, 5)
+
+		assert.Equal(t, 2, relay.Publish(context.TODO()))
+		assert.Equal(t, []int64{1, 2}, published)
+	})
+
+	t.Run("sink error schedules a retry", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetPendingEvents(gomock.Any(), 10).Return(events, nil)
+		mockRepository.EXPECT().MarkPublished(gomock.Any(), int64(1)).Return(nil)
+		mockRepository.EXPECT().MarkFailed(gomock.Any(), int64(2), gomock.Eq(ptr(now.Add(8*time.Second))), "unavailable").Return(nil)
+
+		sink := sinkFunc(func(_ context.Context, event Event) error {
+			if event.Id == 2 {
+				return errors.New("unavailable")
+			}
+			return nil
+		})
+
+		relay := NewRelay(zap.NewNop(), mockRepository, sink, 10, time.Second, time.Minute, 5)
+		relay.now = func() time.Time { return now }
+
+		assert.Equal(t, 1, relay.Publish(context.TODO()))
+	})
+
+	t.Run("sink error after the last attempt marks the event as failed", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetPendingEvents(gomock.Any(), 10).Return(events, nil)
+		mockRepository.EXPECT().MarkPublished(gomock.Any(), int64(1)).Return(nil)
+		mockRepository.EXPECT().MarkFailed(gomock.Any(), int64(2), (*time.Time)(nil), "rejected").Return(nil)
+
+		sink := sinkFunc(func(_ context.Context, event Event) error {
+			if event.Id == 2 {
+				return errors.New("rejected")
+			}
+			return nil
+		})
+
+		relay := NewRelay(zap.NewNop(), mockRepository, sink, 10, time.Second, time.Minute, 4)
+		relay.now = func() time.Time { return now }
+
+		assert.Equal(t, 1, relay.Publish(context.TODO()))
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetPendingEvents(gomock.Any(), gomock.Any()).Return(nil, errors.New("unavailable"))
+
+		relay := NewRelay(zap.NewNop(), mockRepository, nil, 10, time.Second, time.Minute, 5)
+
+		assert.Zero(t, relay.Publish(context.TODO()))
+	})
+}
+
+func TestRelay_Backoff(t *testing.T) {
+	relay := NewRelay(zap.NewNop(), nil, nil, 10, time.Second, time.Minute, 5)
+
+	assert.Equal(t, time.Second, relay.backoff(0))
+	assert.Equal(t, 2*time.Second, relay.backoff(1))
+	assert.Equal(t, 32*time.Second, relay.backoff(5))
+	assert.Equal(t, time.Minute, relay.backoff(6))
+	assert.Equal(t, time.Minute, relay.backoff(100))
+}
+
+func TestRelay_Run(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	mockRepository := NewMockRepository(mockController)
+	mockRepository.EXPECT().
+		GetPendingEvents(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(context.Context, int) ([]Event, error) {
+			cancel()
+			return nil, nil
+		})
+
+	done := make(chan struct{})
+	go func() {
+		NewRelay(zap.NewNop(), mockRepository, nil, 10, time.Hour, time.Hour, 5).Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("relay did not stop")
+	}
+}
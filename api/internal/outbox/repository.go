@@ -0,0 +1,353 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/requestcontext"
+	"invoice-api/pkg/tenant"
+	"invoice-api/pkg/tracing"
+)
+
+const (
+	eventColumns = "id, aggregate_type, aggregate_id, type, payload, request_id, created_at, attempts, tenant_id"
+
+	// claimTimeout is how long a claimed event stays hidden from other relays
+	// before it is considered abandoned and picked up again.
+	claimTimeout = 5 * time.Minute
+)
+
+type Repository interface {
+	GetPendingEvents(ctx context.Context, limit int) ([]Event, error)
+	MarkPublished(ctx context.Context, id int64) error
+	MarkFailed(ctx context.Context, id int64, nextAttemptAt *time.Time, reason string) error
+	GetFailedTotals(ctx context.Context) ([]FailedTotal, error)
+}
+
+type PgRepository struct {
+	connectionPool *pgxpool.Pool
+}
+
+func NewPgRepository(log *zap.Logger, host, port, username, password, database string) *PgRepository {
+	credentials := fmt.Sprintf("user=%s password=%s host=%s port=%s dbname=%s sslmode=disable", username, password, host, port, database)
+	pgConfig, err := pgxpool.ParseConfig(credentials)
+	if err != nil {
+		log.Fatal("failed to parse database config", zap.Error(err))
+	}
+	pgConfig.AfterConnect = tenant.AfterConnect
+	pgConfig.ConnConfig.Tracer = tracing.QueryTracer{}
+
+	var pgConnectionPool *pgxpool.Pool
+	pgConnectionPool, err = pgxpool.NewWithConfig(context.Background(), pgConfig)
+	if err != nil {
+		log.Fatal("failed to connect database", zap.Error(err))
+	}
+
+	var connection *pgxpool.Conn
+	connection, err = pgConnectionPool.Acquire(context.Background())
+	if err != nil {
+		log.Fatal("failed to acquire connection", zap.Error(err))
+	}
+	defer connection.Release()
+
+	err = connection.Ping(context.Background())
+	if err != nil {
+		log.Fatal("failed to ping database", zap.Error(err))
+	}
+
+	return &PgRepository{
+		connectionPool: pgConnectionPool,
+	}
+}
+
+func (r *PgRepository) Stat() *pgxpool.Stat {
+	return r.connectionPool.Stat()
+}
+
+func (r *PgRepository) Ping(ctx context.Context) error {
+	return r.connectionPool.Ping(ctx)
+}
+
+// GetPendingEvents claims the next events to publish. Rows locked by another
+// relay are skipped and the claimed rows are pushed past claimTimeout, so
+// concurrent relays never publish the same event until MarkPublished or
+// MarkFailed settles it. Failed events are neither claimed nor hold back the
+// later events of their aggregate.
+func (r *PgRepository) GetPendingEvents(ctx context.Context, limit int) ([]Event, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	now := time.Now().UTC()
+	var rows pgx.Rows
+	rows, err = tx.Query(
+		ctx,
+		`with pending as (
+			select `+eventColumns+` from outbox_events o
+			where published_at is null and failed_at is null and next_attempt_at <= $1
+			and not exists (
+				select 1 from outbox_events p
+				where p.aggregate_id = o.aggregate_id and p.published_at is null and p.failed_at is null and p.id < o.id
+			)
+			order by id limit $2
+			for update skip locked
+		), claimed as (
+			update outbox_events set next_attempt_at = $3 where id in (select id from pending)
+		)
+		select `+eventColumns+` from pending order by id`,
+		now,
+		limit,
+		now.Add(claimTimeout),
+	)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get pending outbox events",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var events []Event
+	events, err = pgx.CollectRows(rows, pgx.RowToStructByPos[Event])
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect pending outbox events",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return events, nil
+}
+
+func (r *PgRepository) MarkPublished(ctx context.Context, id int64) error {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	if _, err = tx.Exec(
+		ctx,
+		"update outbox_events set published_at = $1, attempts = attempts + 1, last_error = null where id = $2",
+		time.Now().UTC(),
+		id,
+	); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to mark outbox event as published",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return nil
+}
+
+// MarkFailed records a failed publish attempt. The event is retried at
+// nextAttemptAt, or marked as failed for good when nextAttemptAt is nil.
+func (r *PgRepository) MarkFailed(ctx context.Context, id int64, nextAttemptAt *time.Time, reason string) error {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	now := time.Now().UTC()
+	retryAt, failedAt := now, &now
+	if nextAttemptAt != nil {
+		retryAt, failedAt = nextAttemptAt.UTC(), nil
+	}
+
+	if _, err = tx.Exec(
+		ctx,
+		"update outbox_events set attempts = attempts + 1, next_attempt_at = $1, failed_at = $2, last_error = $3 where id = $4",
+		retryAt,
+		failedAt,
+		reason,
+		id,
+	); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to mark outbox event as failed",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return nil
+}
+
+// GetFailedTotals is a cross-tenant admin query for the metrics collector: it
+// counts the events that exhausted their attempts in every tenant and must run
+// as system, since row-level security would otherwise limit it to a single
+// tenant.
+func (r *PgRepository) GetFailedTotals(ctx context.Context) ([]FailedTotal, error) {
+	if !tenant.IsSystem(ctx) {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed outbox event totals must be queried as system",
+			Severity: zap.ErrorLevel,
+		}
+	}
+
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	var rows pgx.Rows
+	rows, err = tx.Query(
+		ctx,
+		`select tenant_id, type, count(*)
+		from outbox_events
+		where failed_at is not null
+		group by tenant_id, type
+		order by tenant_id, type`,
+	)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get failed outbox event totals",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var totals []FailedTotal
+	totals, err = pgx.CollectRows(rows, pgx.RowToStructByPos[FailedTotal])
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect failed outbox event totals",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return totals, nil
+}
@@ -0,0 +1,100 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/outbox/repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/outbox/repository.go -destination=internal/outbox/repository_mock.go -package=outbox
+//
+
+// Package outbox is a generated GoMock package.
+package outbox
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetFailedTotals mocks base method.
+func (m *MockRepository) GetFailedTotals(ctx context.Context) ([]FailedTotal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFailedTotals", ctx)
+	ret0, _ := ret[0].([]FailedTotal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFailedTotals indicates an expected call of GetFailedTotals.
+func (mr *MockRepositoryMockRecorder) GetFailedTotals(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFailedTotals", reflect.TypeOf((*MockRepository)(nil).GetFailedTotals), ctx)
+}
+
+// GetPendingEvents mocks base method.
+func (m *MockRepository) GetPendingEvents(ctx context.Context, limit int) ([]Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPendingEvents", ctx, limit)
+	ret0, _ := ret[0].([]Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPendingEvents indicates an expected call of GetPendingEvents.
+func (mr *MockRepositoryMockRecorder) GetPendingEvents(ctx, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingEvents", reflect.TypeOf((*MockRepository)(nil).GetPendingEvents), ctx, limit)
+}
+
+// MarkFailed mocks base method.
+func (m *MockRepository) MarkFailed(ctx context.Context, id int64, nextAttemptAt *time.Time, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkFailed", ctx, id, nextAttemptAt, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkFailed indicates an expected call of MarkFailed.
+func (mr *MockRepositoryMockRecorder) MarkFailed(ctx, id, nextAttemptAt, reason any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkFailed", reflect.TypeOf((*MockRepository)(nil).MarkFailed), ctx, id, nextAttemptAt, reason)
+}
+
+// MarkPublished mocks base method.
+func (m *MockRepository) MarkPublished(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkPublished", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkPublished indicates an expected call of MarkPublished.
+func (mr *MockRepositoryMockRecorder) MarkPublished(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkPublished", reflect.TypeOf((*MockRepository)(nil).MarkPublished), ctx, id)
+}
@@ -0,0 +1,105 @@
+package outbox
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+
+	"invoice-api/pkg/requestcontext"
+	"invoice-api/pkg/tenant"
+)
+
+var tenantContext = requestcontext.WithTenant(context.Background(), tenant.DefaultTenant)
+
+func TestPgRepository_Events(t *testing.T) {
+	pgContainer := setupContainer(t)
+	pgHost, err := pgContainer.Host(context.Background())
+	require.NoError(t, err)
+
+	pgPort, err := pgContainer.MappedPort(context.Background(), "5432/tcp")
+	require.NoError(t, err)
+
+	pgRepository := NewPgRepository(nil, pgHost, pgPort.Port(), "root", "root", "test")
+	aggregateId, otherAggregateId := uuid.NewString(), uuid.NewString()
+	require.NoError(t, pgx.BeginFunc(tenantContext, pgRepository.connectionPool, func(tx pgx.Tx) error {
+		for _, event := range []struct{ aggregateId, eventType string }{
+			{aggregateId, "invoice.created"},
+			{aggregateId, "invoice.paid"},
+			{otherAggregateId, "invoice.created"},
+		} {
+			if err := Write(tenantContext, tx, "invoice", event.aggregateId, event.eventType, map[string]string{"id": event.aggregateId}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	events, err := pgRepository.GetPendingEvents(tenantContext, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "invoice.created", events[0].Type)
+	assert.Equal(t, aggregateId, events[0].AggregateId)
+	assert.JSONEq(t, `{"id":"`+aggregateId+`"}`, string(events[0].Payload))
+	assert.Equal(t, otherAggregateId, events[1].AggregateId)
+
+	claimed, err := pgRepository.GetPendingEvents(tenantContext, 10)
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	failedId := events[0].Id
+	require.NoError(t, pgRepository.MarkFailed(tenantContext, failedId, ptr(time.Now().Add(time.Hour)), "unavailable"))
+	require.NoError(t, pgRepository.MarkPublished(tenantContext, events[1].Id))
+
+	events, err = pgRepository.GetPendingEvents(tenantContext, 10)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	require.NoError(t, pgRepository.MarkFailed(tenantContext, failedId, ptr(time.Now().Add(-time.Second)), "unavailable"))
+	events, err = pgRepository.GetPendingEvents(tenantContext, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, failedId, events[0].Id)
+	assert.Equal(t, 2, events[0].Attempts)
+
+	require.NoError(t, pgRepository.MarkFailed(tenantContext, failedId, nil, "rejected"))
+	events, err = pgRepository.GetPendingEvents(tenantContext, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1, "a failed event no longer holds back its aggregate")
+	assert.Equal(t, aggregateId, events[0].AggregateId)
+	assert.Equal(t, "invoice.paid", events[0].Type)
+
+	totals, err := pgRepository.GetFailedTotals(tenant.WithSystem(context.Background()))
+	require.NoError(t, err)
+	assert.Equal(t, []FailedTotal{{TenantId: tenant.DefaultTenant, Type: "invoice.created", Count: 1}}, totals)
+
+	_, err = pgRepository.GetFailedTotals(tenantContext)
+	assert.Error(t, err)
+}
+
+func setupContainer(t *testing.T) *postgres.PostgresContainer {
+	ctx := context.Background()
+	postgresContainer, err := postgres.Run(
+		ctx,
+		"postgres",
+		postgres.WithDatabase("test"),
+		postgres.WithUsername("root"),
+		postgres.WithPassword("root"),
+		postgres.BasicWaitStrategies(),
+		postgres.WithSQLDriver("pgx"),
+		postgres.WithInitScripts("../../.scripts/init.sql"),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		err = postgresContainer.Terminate(ctx)
+		require.NoError(t, err)
+	})
+
+	return postgresContainer
+}
@@ -0,0 +1,84 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriterSink_Publish(t *testing.T) {
+	event := Event{
+		Id:            1,
+		AggregateType: "invoice",
+		AggregateId:   "dda97bce-ac2a-4431-9c7b-3b6bcdfe8a23",
+		Type:          "invoice.created",
+		Payload:       json.RawMessage(`{"amount":120.3}`),
+		CreatedAt:     time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+		TenantId:      "acme",
+	}
+
+	t.Run("writer", func(t *testing.T) {
+		var buffer bytes.Buffer
+		sink := NewWriterSink(&buffer)
+
+		require.NoError(t, sink.Publish(context.TODO(), event))
+		require.NoError(t, sink.Publish(context.TODO(), event))
+		require.NoError(t, sink.Close())
+
+		lines := bytes.Split(bytes.TrimSpace(buffer.Bytes()), []byte("\n"))
+		require.Len(t, lines, 2)
+
+		var decoded Event
+		require.NoError(t, json.Unmarshal(lines[0], &decoded))
+		assert.Equal(t, event, decoded)
+	})
+
+	t.Run("file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "events.jsonl")
+		sink, err := NewFileSink(path)
+		require.NoError(t, err)
+
+		require.NoError(t, sink.Publish(context.TODO(), event))
+		require.NoError(t, sink.Close())
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"id": 1,
+			"aggregateType": "invoice",
+			"aggregateId": "dda97bce-ac2a-4431-9c7b-3b6bcdfe8a23",
+			"type": "invoice.created",
+			"payload": {"amount": 120.3},
+			"createdAt": "2025-06-01T00:00:00Z",
+			"tenantId": "acme"
+		}`, string(content))
+	})
+
+	t.Run("invalid file path", func(t *testing.T) {
+		_, err := NewFileSink(filepath.Join(t.TempDir(), "missing", "events.jsonl"))
+		assert.Error(t, err)
+	})
+}
+
+func TestMultiSink_Publish(t *testing.T) {
+	var first, second bytes.Buffer
+	failing := sinkFunc(func(context.Context, Event) error {
+		return errors.New("unavailable")
+	})
+
+	sink := NewMultiSink(NewWriterSink(&first), failing, NewWriterSink(&second))
+
+	err := sink.Publish(context.TODO(), Event{Id: 1, Type: "invoice.created"})
+	assert.EqualError(t, err, "unavailable")
+	assert.NotEmpty(t, first.String())
+	assert.Equal(t, first.String(), second.String())
+	assert.NoError(t, sink.Close())
+}
@@ -0,0 +1,34 @@
+package recurring
+
+import (
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+func ParseCadence(cadence string) (cron.Schedule, error) {
+	return cron.ParseStandard(cadence)
+}
+
+func NextRun(cadence string, after time.Time, endAt *time.Time) (*time.Time, error) {
+	schedule, err := ParseCadence(cadence)
+	if err != nil {
+		return nil, err
+	}
+
+	next := schedule.Next(after).UTC()
+	if next.IsZero() || (endAt != nil && next.After(*endAt)) {
+		return nil, nil
+	}
+
+	return &next, nil
+}
+
+func FirstRun(template *TemplateDTO) (*time.Time, error) {
+	after := template.StartAt.Add(-time.Second)
+	if template.LastRunAt != nil && !template.LastRunAt.Before(template.StartAt) {
+		after = *template.LastRunAt
+	}
+
+	return NextRun(template.Cadence, after, template.EndAt)
+}
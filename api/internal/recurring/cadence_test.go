@@ -0,0 +1,41 @@
+package recurring
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextRun(t *testing.T) {
+	next, err := NextRun("0 0 1 * *", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), nil)
+	require.NoError(t, err)
+	require.NotNil(t, next)
+	assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), *next)
+
+	next, err = NextRun("@monthly", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), nil)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), *next)
+
+	endAt := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	next, err = NextRun("0 0 1 * *", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), &endAt)
+	require.NoError(t, err)
+	assert.Nil(t, next)
+
+	_, err = NextRun("every month", time.Now(), nil)
+	assert.Error(t, err)
+}
+
+func TestFirstRun(t *testing.T) {
+	template := &TemplateDTO{Cadence: "0 0 1 * *", StartAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	next, err := FirstRun(template)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), *next)
+
+	lastRunAt := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	template.LastRunAt = &lastRunAt
+	next, err = FirstRun(template)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), *next)
+}
@@ -0,0 +1,246 @@
+package recurring
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"invoice-api/pkg/auth"
+	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/requestcontext"
+	"invoice-api/pkg/tracing"
+)
+
+type Handler struct {
+	server     *fiber.App
+	validator  *validator.Validate
+	repository Repository
+	authorizer *auth.Authorizer
+}
+
+func NewHandler(server *fiber.App, validator *validator.Validate, repository Repository, authorizer *auth.Authorizer) *Handler {
+	return &Handler{
+		server:     server,
+		validator:  validator,
+		repository: repository,
+		authorizer: authorizer,
+	}
+}
+
+func (h *Handler) RegisterRoutes() {
+	h.server.Post("/recurring-invoices", h.authorizer.Require(PermissionWrite), h.CreateTemplate)
+	h.server.Get("/recurring-invoices", h.authorizer.Require(PermissionRead), h.GetTemplates)
+	h.server.Get("/recurring-invoices/:id", h.authorizer.Require(PermissionRead), h.GetTemplateById)
+	h.server.Put("/recurring-invoices/:id", h.authorizer.Require(PermissionWrite), h.UpdateTemplateById)
+	h.server.Delete("/recurring-invoices/:id", h.authorizer.Require(PermissionWrite), h.DeleteTemplateById)
+	h.server.Get("/recurring-invoices/:id/runs", h.authorizer.Require(PermissionRead), h.GetRuns)
+}
+
+func (h *Handler) CreateTemplate(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "CreateTemplate"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "recurring.Handler.CreateTemplate")()
+
+	var reqBody CreateTemplateRequest
+	if err := h.parseBody(ctx, &reqBody); err != nil {
+		return err
+	}
+
+	template := &TemplateDTO{
+		Id:        uuid.NewString(),
+		Enabled:   true,
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if err := h.apply(template, &reqBody, time.Time{}); err != nil {
+		return err
+	}
+
+	if err := h.repository.CreateTemplate(ctx.UserContext(), template); err != nil {
+		return err
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	ctx.Location(fmt.Sprintf("/recurring-invoices/%s", template.Id))
+	return ctx.Status(fiber.StatusCreated).JSON(template)
+}
+
+func (h *Handler) GetTemplates(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "GetTemplates"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "recurring.Handler.GetTemplates")()
+
+	templates, err := h.repository.GetTemplates(ctx.UserContext())
+	if err != nil {
+		return err
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	return ctx.JSON(templates)
+}
+
+func (h *Handler) GetTemplateById(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "GetTemplateById"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "recurring.Handler.GetTemplateById")()
+
+	id, err := h.templateId(ctx)
+	if err != nil {
+		return err
+	}
+
+	template, err := h.repository.GetTemplateById(ctx.UserContext(), id)
+	if err != nil {
+		return err
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	return ctx.JSON(template)
+}
+
+func (h *Handler) UpdateTemplateById(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "UpdateTemplateById"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "recurring.Handler.UpdateTemplateById")()
+
+	id, err := h.templateId(ctx)
+	if err != nil {
+		return err
+	}
+
+	var reqBody UpdateTemplateRequest
+	if err = h.parseBody(ctx, &reqBody); err != nil {
+		return err
+	}
+
+	template, err := h.repository.GetTemplateById(ctx.UserContext(), id)
+	if err != nil {
+		return err
+	}
+
+	var resumeAt time.Time
+	if reqBody.Enabled && !template.Enabled {
+		resumeAt = time.Now().UTC().Truncate(time.Second)
+	}
+	template.Enabled = reqBody.Enabled
+	if err = h.apply(template, &reqBody.CreateTemplateRequest, resumeAt); err != nil {
+		return err
+	}
+
+	if err = h.repository.UpdateTemplate(ctx.UserContext(), template); err != nil {
+		return err
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	return ctx.JSON(template)
+}
+
+func (h *Handler) DeleteTemplateById(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "DeleteTemplateById"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "recurring.Handler.DeleteTemplateById")()
+
+	id, err := h.templateId(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = h.repository.DeleteTemplateById(ctx.UserContext(), id); err != nil {
+		return err
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) GetRuns(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "GetRuns"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "recurring.Handler.GetRuns")()
+
+	id, err := h.templateId(ctx)
+	if err != nil {
+		return err
+	}
+
+	runs, err := h.repository.GetRuns(ctx.UserContext(), id)
+	if err != nil {
+		return err
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	return ctx.JSON(runs)
+}
+
+func (h *Handler) parseBody(ctx *fiber.Ctx, reqBody any) error {
+	if err := ctx.BodyParser(reqBody); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid request body",
+			Severity: zap.WarnLevel,
+		}
+	}
+
+	if err := h.validator.StructCtx(ctx.UserContext(), reqBody); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid request body",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return nil
+}
+
+func (h *Handler) apply(template *TemplateDTO, reqBody *CreateTemplateRequest, resumeAt time.Time) error {
+	template.ServiceName = reqBody.ServiceName
+	template.Amount = reqBody.Amount
+	template.Cadence = reqBody.Cadence
+	template.StartAt = reqBody.StartAt.UTC().Truncate(time.Second)
+	template.EndAt = nil
+	if reqBody.EndAt != nil {
+		endAt := reqBody.EndAt.UTC()
+		template.EndAt = &endAt
+	}
+
+	next, err := FirstRun(template)
+	if err == nil && next != nil && next.Before(resumeAt) {
+		next, err = NextRun(template.Cadence, resumeAt.Add(-time.Second), template.EndAt)
+	}
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid request body",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+			Details:  "cadence must be a cron expression such as \"0 0 1 * *\" or \"@monthly\"",
+		}
+	}
+	template.NextRunAt = next
+
+	return nil
+}
+
+func (h *Handler) templateId(ctx *fiber.Ctx) (string, error) {
+	id := ctx.Params("id")
+	if err := h.validator.VarCtx(ctx.UserContext(), id, "required,uuid4"); err != nil {
+		return "", customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid recurring template id",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return id, nil
+}
@@ -0,0 +1,294 @@
+package recurring
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	json "github.com/bytedance/sonic"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+
+	"invoice-api/pkg/auth"
+	customError "invoice-api/pkg/error"
+)
+
+const templateId = "0d4f7e3a-8b1c-4c2d-9e5f-1a2b3c4d5e6f"
+
+func TestHandler_CreateTemplate(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("happy path", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().CreateTemplate(gomock.Any(), gomock.Any()).Return(nil)
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, testAuthorizer)
+		h.RegisterRoutes()
+
+		req := httptest.NewRequest(
+			http.MethodPost,
+			"/recurring-invoices",
+			strings.NewReader(`{"serviceName":"DMP","amount":99.5,"cadence":"0 0 1 * *","startAt":"2024-01-15T00:00:00Z"}`),
+		)
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusCreated, res.StatusCode)
+
+		var template TemplateDTO
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &template))
+		assert.True(t, template.Enabled)
+		require.NotNil(t, template.NextRunAt)
+		assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), *template.NextRunAt)
+		assert.Equal(t, fmt.Sprintf("/recurring-invoices/%s", template.Id), res.Header.Get(fiber.HeaderLocation))
+	})
+
+	t.Run("invalid request body", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		bodies := []string{
+			`{"serviceName":"XYZ","amount":99.5,"cadence":"@monthly","startAt":"2024-01-15T00:00:00Z"}`,
+			`{"serviceName":"DMP","amount":0,"cadence":"@monthly","startAt":"2024-01-15T00:00:00Z"}`,
+			`{"serviceName":"DMP","amount":99.5,"cadence":"every month","startAt":"2024-01-15T00:00:00Z"}`,
+			`{"serviceName":"DMP","amount":99.5,"cadence":"@monthly"}`,
+			`{"serviceName":"DMP","amount":99.5,"cadence":"@monthly","startAt":"2024-01-15T00:00:00Z","endAt":"2024-01-01T00:00:00Z"}`,
+			`{`,
+		}
+		for _, body := range bodies {
+			req := httptest.NewRequest(http.MethodPost, "/recurring-invoices", strings.NewReader(body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+			res, err := server.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, fiber.StatusBadRequest, res.StatusCode, body)
+		}
+	})
+}
+
+func TestHandler_GetTemplates(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	mockRepository := NewMockRepository(mockController)
+	mockRepository.EXPECT().GetTemplates(gomock.Any()).Return(&[]TemplateDTO{{Id: templateId, Cadence: "@monthly"}}, nil)
+
+	server, validate := SetupServer(t)
+	h := NewHandler(server, validate, mockRepository, testAuthorizer)
+	h.RegisterRoutes()
+
+	res, err := server.Test(httptest.NewRequest(http.MethodGet, "/recurring-invoices", nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, res.StatusCode)
+
+	var templates []TemplateDTO
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(body, &templates))
+	assert.Len(t, templates, 1)
+}
+
+func TestHandler_GetTemplateById(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("happy path", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetTemplateById(gomock.Any(), templateId).Return(&TemplateDTO{Id: templateId}, nil)
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, testAuthorizer)
+		h.RegisterRoutes()
+
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, "/recurring-invoices/"+templateId, nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, "/recurring-invoices/invalid", nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetTemplateById(gomock.Any(), templateId).Return(nil, customError.CustomError{
+			Code:     fiber.StatusNotFound,
+			Message:  "recurring template not found",
+			Severity: zap.WarnLevel,
+		})
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, testAuthorizer)
+		h.RegisterRoutes()
+
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, "/recurring-invoices/"+templateId, nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, res.StatusCode)
+	})
+}
+
+func TestHandler_UpdateTemplateById(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	lastRunAt := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	existing := func(enabled bool) *TemplateDTO {
+		return &TemplateDTO{
+			Id:          templateId,
+			ServiceName: "DMP",
+			Amount:      10,
+			Cadence:     "@monthly",
+			StartAt:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			LastRunAt:   &lastRunAt,
+			Enabled:     enabled,
+		}
+	}
+
+	t.Run("continues after the last run", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetTemplateById(gomock.Any(), templateId).Return(existing(true), nil)
+		mockRepository.EXPECT().UpdateTemplate(gomock.Any(), gomock.Any()).DoAndReturn(func(_ any, template *TemplateDTO) error {
+			assert.Equal(t, float32(20), template.Amount)
+			assert.Equal(t, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), *template.NextRunAt)
+			return nil
+		})
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, testAuthorizer)
+		h.RegisterRoutes()
+
+		req := httptest.NewRequest(
+			http.MethodPut,
+			"/recurring-invoices/"+templateId,
+			strings.NewReader(`{"serviceName":"DMP","amount":20,"cadence":"@monthly","startAt":"2024-01-01T00:00:00Z","enabled":true}`),
+		)
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	})
+
+	t.Run("re-enabling skips paused runs", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetTemplateById(gomock.Any(), templateId).Return(existing(false), nil)
+		mockRepository.EXPECT().UpdateTemplate(gomock.Any(), gomock.Any()).DoAndReturn(func(_ any, template *TemplateDTO) error {
+			assert.True(t, template.Enabled)
+			assert.True(t, template.NextRunAt.After(time.Now()))
+			return nil
+		})
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, testAuthorizer)
+		h.RegisterRoutes()
+
+		req := httptest.NewRequest(
+			http.MethodPut,
+			"/recurring-invoices/"+templateId,
+			strings.NewReader(`{"serviceName":"DMP","amount":20,"cadence":"@monthly","startAt":"2024-01-01T00:00:00Z","enabled":true}`),
+		)
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	})
+
+	t.Run("invalid request body", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		req := httptest.NewRequest(http.MethodPut, "/recurring-invoices/"+templateId, strings.NewReader(`{"serviceName":"DMP"}`))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+	})
+}
+
+func TestHandler_DeleteTemplateById(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	mockRepository := NewMockRepository(mockController)
+	mockRepository.EXPECT().DeleteTemplateById(gomock.Any(), templateId).Return(nil)
+
+	server, validate := SetupServer(t)
+	h := NewHandler(server, validate, mockRepository, testAuthorizer)
+	h.RegisterRoutes()
+
+	res, err := server.Test(httptest.NewRequest(http.MethodDelete, "/recurring-invoices/"+templateId, nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusNoContent, res.StatusCode)
+}
+
+func TestHandler_GetRuns(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	mockRepository := NewMockRepository(mockController)
+	mockRepository.EXPECT().GetRuns(gomock.Any(), templateId).Return(&[]RunDTO{{TemplateId: templateId, InvoiceId: "a"}}, nil)
+
+	server, validate := SetupServer(t)
+	h := NewHandler(server, validate, mockRepository, testAuthorizer)
+	h.RegisterRoutes()
+
+	res, err := server.Test(httptest.NewRequest(http.MethodGet, "/recurring-invoices/"+templateId+"/runs", nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, res.StatusCode)
+
+	var runs []RunDTO
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(body, &runs))
+	assert.Len(t, runs, 1)
+}
+
+var testAuthorizer = auth.NewAuthorizer(auth.Policy{"admin": {auth.AllPermissions}})
+
+func SetupServer(t *testing.T) (*fiber.App, *validator.Validate) {
+	server := fiber.New(fiber.Config{
+		JSONDecoder:           json.Unmarshal,
+		JSONEncoder:           json.Marshal,
+		ErrorHandler:          customError.ErrorHandler,
+		DisableStartupMessage: true,
+	})
+
+	log, _ := zap.NewProduction()
+	defer func(log *zap.Logger) {
+		err := log.Sync()
+		if err != nil {
+			assert.FailNow(t, err.Error())
+		}
+	}(log)
+
+	server.Use(func(c *fiber.Ctx) error {
+		c.Locals("log", log)
+		c.Locals(auth.ContextKeyRoles, []string{"admin"})
+		return c.Next()
+	})
+
+	return server, validator.New()
+}
@@ -0,0 +1,89 @@
+package recurring
+
+import (
+	"context"
+	"time"
+
+	"invoice-api/pkg/metrics"
+	"invoice-api/pkg/tracing"
+)
+
+const repositoryName = "recurring"
+
+type InstrumentedRepository struct {
+	repository Repository
+}
+
+func NewInstrumentedRepository(repository Repository) *InstrumentedRepository {
+	return &InstrumentedRepository{repository: repository}
+}
+
+func (r *InstrumentedRepository) CreateTemplate(ctx context.Context, template *TemplateDTO) (err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "CreateTemplate")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "CreateTemplate", time.Now(), &err)
+	return r.repository.CreateTemplate(ctx, template)
+}
+
+func (r *InstrumentedRepository) GetTemplates(ctx context.Context) (_ *[]TemplateDTO, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "GetTemplates")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "GetTemplates", time.Now(), &err)
+	return r.repository.GetTemplates(ctx)
+}
+
+func (r *InstrumentedRepository) GetTemplateById(ctx context.Context, id string) (_ *TemplateDTO, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "GetTemplateById")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "GetTemplateById", time.Now(), &err)
+	return r.repository.GetTemplateById(ctx, id)
+}
+
+func (r *InstrumentedRepository) UpdateTemplate(ctx context.Context, template *TemplateDTO) (err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "UpdateTemplate")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "UpdateTemplate", time.Now(), &err)
+	return r.repository.UpdateTemplate(ctx, template)
+}
+
+func (r *InstrumentedRepository) DeleteTemplateById(ctx context.Context, id string) (err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "DeleteTemplateById")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "DeleteTemplateById", time.Now(), &err)
+	return r.repository.DeleteTemplateById(ctx, id)
+}
+
+func (r *InstrumentedRepository) GetRuns(ctx context.Context, templateId string) (_ *[]RunDTO, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "GetRuns")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "GetRuns", time.Now(), &err)
+	return r.repository.GetRuns(ctx, templateId)
+}
+
+func (r *InstrumentedRepository) GetDueTemplates(ctx context.Context, now time.Time, limit int) (_ []TemplateDTO, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "GetDueTemplates")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "GetDueTemplates", time.Now(), &err)
+	return r.repository.GetDueTemplates(ctx, now, limit)
+}
+
+func (r *InstrumentedRepository) ReserveRun(ctx context.Context, templateId string, scheduledAt time.Time) (_ string, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "ReserveRun")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "ReserveRun", time.Now(), &err)
+	return r.repository.ReserveRun(ctx, templateId, scheduledAt)
+}
+
+func (r *InstrumentedRepository) AdvanceTemplate(ctx context.Context, id string, scheduledAt time.Time, nextRunAt *time.Time) (err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "AdvanceTemplate")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "AdvanceTemplate", time.Now(), &err)
+	return r.repository.AdvanceTemplate(ctx, id, scheduledAt, nextRunAt)
+}
+
+func (r *InstrumentedRepository) WithLock(ctx context.Context, key int64, fn func(ctx context.Context) error) (_ bool, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "WithLock")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "WithLock", time.Now(), &err)
+	return r.repository.WithLock(ctx, key, fn)
+}
@@ -0,0 +1,39 @@
+package recurring
+
+import (
+	"time"
+)
+
+type CreateTemplateRequest struct {
+	ServiceName string     `json:"serviceName" validate:"required,oneof=DMP SSP"`
+	Amount      float32    `json:"amount" validate:"required,min=1"`
+	Cadence     string     `json:"cadence" validate:"required,max=128"`
+	StartAt     time.Time  `json:"startAt" validate:"required"`
+	EndAt       *time.Time `json:"endAt" validate:"omitempty,gtfield=StartAt"`
+}
+
+type UpdateTemplateRequest struct {
+	CreateTemplateRequest
+	Enabled bool `json:"enabled"`
+}
+
+type TemplateDTO struct {
+	Id          string     `json:"id" db:"id"`
+	ServiceName string     `json:"serviceName" db:"service_name"`
+	Amount      float32    `json:"amount" db:"amount"`
+	Cadence     string     `json:"cadence" db:"cadence"`
+	StartAt     time.Time  `json:"startAt" db:"start_at"`
+	EndAt       *time.Time `json:"endAt,omitempty" db:"end_at"`
+	NextRunAt   *time.Time `json:"nextRunAt,omitempty" db:"next_run_at"`
+	LastRunAt   *time.Time `json:"lastRunAt,omitempty" db:"last_run_at"`
+	Enabled     bool       `json:"enabled" db:"enabled"`
+	CreatedAt   time.Time  `json:"createdAt" db:"created_at"`
+	TenantId    string     `json:"-" db:"tenant_id"`
+}
+
+type RunDTO struct {
+	TemplateId  string    `json:"templateId" db:"template_id"`
+	ScheduledAt time.Time `json:"scheduledAt" db:"scheduled_at"`
+	InvoiceId   string    `json:"invoiceId" db:"invoice_id"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+}
@@ -0,0 +1,6 @@
+package recurring
+
+const (
+	PermissionRead  = "recurring-invoices:read"
+	PermissionWrite = "recurring-invoices:write"
+)
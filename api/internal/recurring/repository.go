@@ -0,0 +1,646 @@
+package recurring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/requestcontext"
+	"invoice-api/pkg/tenant"
+	"invoice-api/pkg/tracing"
+)
+
+const (
+	templateColumns = "id, service_name, amount, cadence, start_at, end_at, next_run_at, last_run_at, enabled, created_at, tenant_id"
+	runColumns      = "template_id, scheduled_at, invoice_id, created_at"
+)
+
+type Repository interface {
+	CreateTemplate(ctx context.Context, template *TemplateDTO) error
+	GetTemplates(ctx context.Context) (*[]TemplateDTO, error)
+	GetTemplateById(ctx context.Context, id string) (*TemplateDTO, error)
+	UpdateTemplate(ctx context.Context, template *TemplateDTO) error
+	DeleteTemplateById(ctx context.Context, id string) error
+	GetRuns(ctx context.Context, templateId string) (*[]RunDTO, error)
+	GetDueTemplates(ctx context.Context, now time.Time, limit int) ([]TemplateDTO, error)
+	ReserveRun(ctx context.Context, templateId string, scheduledAt time.Time) (string, error)
+	AdvanceTemplate(ctx context.Context, id string, scheduledAt time.Time, nextRunAt *time.Time) error
+	WithLock(ctx context.Context, key int64, fn func(ctx context.Context) error) (bool, error)
+}
+
+type PgRepository struct {
+	connectionPool *pgxpool.Pool
+}
+
+func NewPgRepository(log *zap.Logger, host, port, username, password, database string) *PgRepository {
+	credentials := fmt.Sprintf("user=%s password=%s host=%s port=%s dbname=%s sslmode=disable", username, password, host, port, database)
+	pgConfig, err := pgxpool.ParseConfig(credentials)
+	if err != nil {
+		log.Fatal("failed to parse database config", zap.Error(err))
+	}
+	pgConfig.AfterConnect = tenant.AfterConnect
+	pgConfig.ConnConfig.Tracer = tracing.QueryTracer{}
+
+	var pgConnectionPool *pgxpool.Pool
+	pgConnectionPool, err = pgxpool.NewWithConfig(context.Background(), pgConfig)
+	if err != nil {
+		log.Fatal("failed to connect database", zap.Error(err))
+	}
+
+	var connection *pgxpool.Conn
+	connection, err = pgConnectionPool.Acquire(context.Background())
+	if err != nil {
+		log.Fatal("failed to acquire connection", zap.Error(err))
+	}
+	defer connection.Release()
+
+	err = connection.Ping(context.Background())
+	if err != nil {
+		log.Fatal("failed to ping database", zap.Error(err))
+	}
+
+	return &PgRepository{
+		connectionPool: pgConnectionPool,
+	}
+}
+
+func (r *PgRepository) Stat() *pgxpool.Stat {
+	return r.connectionPool.Stat()
+}
+
+func (r *PgRepository) Ping(ctx context.Context) error {
+	return r.connectionPool.Ping(ctx)
+}
+
+func (r *PgRepository) CreateTemplate(ctx context.Context, template *TemplateDTO) error {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	if _, err = tx.Exec(
+		ctx,
+		"insert into recurring_templates ("+templateColumns+") values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, current_tenant())",
+		template.Id,
+		template.ServiceName,
+		template.Amount,
+		template.Cadence,
+		template.StartAt.UTC(),
+		template.EndAt,
+		template.NextRunAt,
+		template.LastRunAt,
+		template.Enabled,
+		template.CreatedAt,
+	); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to create recurring template",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return nil
+}
+
+func (r *PgRepository) GetTemplates(ctx context.Context) (*[]TemplateDTO, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	var rows pgx.Rows
+	rows, err = tx.Query(ctx, "select "+templateColumns+" from recurring_templates order by created_at")
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get recurring templates",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var templates []TemplateDTO
+	templates, err = pgx.CollectRows(rows, pgx.RowToStructByPos[TemplateDTO])
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect recurring templates",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return &templates, nil
+}
+
+func (r *PgRepository) GetTemplateById(ctx context.Context, id string) (*TemplateDTO, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	var rows pgx.Rows
+	rows, err = tx.Query(ctx, "select "+templateColumns+" from recurring_templates where id = $1", id)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get recurring template",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	template, err := pgx.CollectOneRow(rows, pgx.RowToStructByPos[TemplateDTO])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, customError.CustomError{
+				Code:     fiber.StatusNotFound,
+				Message:  "recurring template not found",
+				Severity: zap.WarnLevel,
+			}
+		}
+
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect recurring template",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return &template, nil
+}
+
+func (r *PgRepository) UpdateTemplate(ctx context.Context, template *TemplateDTO) error {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	tag, err := tx.Exec(
+		ctx,
+		`update recurring_templates set service_name = $1, amount = $2, cadence = $3, start_at = $4, end_at = $5,
+		next_run_at = $6, enabled = $7 where id = $8`,
+		template.ServiceName,
+		template.Amount,
+		template.Cadence,
+		template.StartAt.UTC(),
+		template.EndAt,
+		template.NextRunAt,
+		template.Enabled,
+		template.Id,
+	)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to update recurring template",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if tag.RowsAffected() == 0 {
+		return customError.CustomError{
+			Code:     fiber.StatusNotFound,
+			Message:  "recurring template not found",
+			Severity: zap.WarnLevel,
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return nil
+}
+
+func (r *PgRepository) DeleteTemplateById(ctx context.Context, id string) error {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	tag, err := tx.Exec(ctx, "delete from recurring_templates where id = $1", id)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to delete recurring template",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if tag.RowsAffected() == 0 {
+		return customError.CustomError{
+			Code:     fiber.StatusNotFound,
+			Message:  "recurring template not found",
+			Severity: zap.WarnLevel,
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return nil
+}
+
+func (r *PgRepository) GetRuns(ctx context.Context, templateId string) (*[]RunDTO, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	var rows pgx.Rows
+	rows, err = tx.Query(
+		ctx,
+		"select "+runColumns+" from recurring_runs where template_id = $1 order by scheduled_at desc",
+		templateId,
+	)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get recurring runs",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var runs []RunDTO
+	runs, err = pgx.CollectRows(rows, pgx.RowToStructByPos[RunDTO])
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect recurring runs",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return &runs, nil
+}
+
+func (r *PgRepository) GetDueTemplates(ctx context.Context, now time.Time, limit int) ([]TemplateDTO, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	var rows pgx.Rows
+	rows, err = tx.Query(
+		ctx,
+		"select "+templateColumns+" from recurring_templates where enabled and next_run_at <= $1 order by next_run_at limit $2",
+		now,
+		limit,
+	)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get due recurring templates",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var templates []TemplateDTO
+	templates, err = pgx.CollectRows(rows, pgx.RowToStructByPos[TemplateDTO])
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect due recurring templates",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return templates, nil
+}
+
+func (r *PgRepository) ReserveRun(ctx context.Context, templateId string, scheduledAt time.Time) (string, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return "", customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return "", customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	var invoiceId string
+	if err = tx.QueryRow(
+		ctx,
+		`insert into recurring_runs (`+runColumns+`) values ($1, $2, $3, $4)
+		on conflict (template_id, scheduled_at) do update set template_id = excluded.template_id
+		returning invoice_id`,
+		templateId,
+		scheduledAt.UTC(),
+		uuid.NewString(),
+		time.Now().UTC(),
+	).Scan(&invoiceId); err != nil {
+		return "", customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to reserve recurring run",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return "", customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return invoiceId, nil
+}
+
+func (r *PgRepository) AdvanceTemplate(ctx context.Context, id string, scheduledAt time.Time, nextRunAt *time.Time) error {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	if _, err = tx.Exec(
+		ctx,
+		"update recurring_templates set last_run_at = $1, next_run_at = $2 where id = $3 and next_run_at = $1",
+		scheduledAt.UTC(),
+		nextRunAt,
+		id,
+	); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to advance recurring template",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return nil
+}
+
+func (r *PgRepository) WithLock(ctx context.Context, key int64, fn func(ctx context.Context) error) (bool, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return false, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var acquired bool
+	if err = connection.QueryRow(ctx, "select pg_try_advisory_lock($1)", key).Scan(&acquired); err != nil {
+		return false, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire advisory lock",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	if !acquired {
+		return false, nil
+	}
+	defer func() {
+		if _, err := connection.Exec(context.Background(), "select pg_advisory_unlock($1)", key); err != nil {
+			_ = connection.Conn().Close(context.Background())
+		}
+	}()
+
+	return true, fn(ctx)
+}
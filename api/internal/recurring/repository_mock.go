@@ -0,0 +1,188 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/recurring/repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/recurring/repository.go -destination=internal/recurring/repository_mock.go -package=recurring
+//
+
+// Package recurring is a generated GoMock package.
+package recurring
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AdvanceTemplate mocks base method.
+func (m *MockRepository) AdvanceTemplate(ctx context.Context, id string, scheduledAt time.Time, nextRunAt *time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdvanceTemplate", ctx, id, scheduledAt, nextRunAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AdvanceTemplate indicates an expected call of AdvanceTemplate.
+func (mr *MockRepositoryMockRecorder) AdvanceTemplate(ctx, id, scheduledAt, nextRunAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdvanceTemplate", reflect.TypeOf((*MockRepository)(nil).AdvanceTemplate), ctx, id, scheduledAt, nextRunAt)
+}
+
+// CreateTemplate mocks base method.
+func (m *MockRepository) CreateTemplate(ctx context.Context, template *TemplateDTO) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTemplate", ctx, template)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateTemplate indicates an expected call of CreateTemplate.
+func (mr *MockRepositoryMockRecorder) CreateTemplate(ctx, template any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTemplate", reflect.TypeOf((*MockRepository)(nil).CreateTemplate), ctx, template)
+}
+
+// DeleteTemplateById mocks base method.
+func (m *MockRepository) DeleteTemplateById(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTemplateById", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTemplateById indicates an expected call of DeleteTemplateById.
+func (mr *MockRepositoryMockRecorder) DeleteTemplateById(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTemplateById", reflect.TypeOf((*MockRepository)(nil).DeleteTemplateById), ctx, id)
+}
+
+// GetDueTemplates mocks base method.
+func (m *MockRepository) GetDueTemplates(ctx context.Context, now time.Time, limit int) ([]TemplateDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDueTemplates", ctx, now, limit)
+	ret0, _ := ret[0].([]TemplateDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDueTemplates indicates an expected call of GetDueTemplates.
+func (mr *MockRepositoryMockRecorder) GetDueTemplates(ctx, now, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDueTemplates", reflect.TypeOf((*MockRepository)(nil).GetDueTemplates), ctx, now, limit)
+}
+
+// GetRuns mocks base method.
+func (m *MockRepository) GetRuns(ctx context.Context, templateId string) (*[]RunDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRuns", ctx, templateId)
+	ret0, _ := ret[0].(*[]RunDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRuns indicates an expected call of GetRuns.
+func (mr *MockRepositoryMockRecorder) GetRuns(ctx, templateId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRuns", reflect.TypeOf((*MockRepository)(nil).GetRuns), ctx, templateId)
+}
+
+// GetTemplateById mocks base method.
+func (m *MockRepository) GetTemplateById(ctx context.Context, id string) (*TemplateDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTemplateById", ctx, id)
+	ret0, _ := ret[0].(*TemplateDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTemplateById indicates an expected call of GetTemplateById.
+func (mr *MockRepositoryMockRecorder) GetTemplateById(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTemplateById", reflect.TypeOf((*MockRepository)(nil).GetTemplateById), ctx, id)
+}
+
+// GetTemplates mocks base method.
+func (m *MockRepository) GetTemplates(ctx context.Context) (*[]TemplateDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTemplates", ctx)
+	ret0, _ := ret[0].(*[]TemplateDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTemplates indicates an expected call of GetTemplates.
+func (mr *MockRepositoryMockRecorder) GetTemplates(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTemplates", reflect.TypeOf((*MockRepository)(nil).GetTemplates), ctx)
+}
+
+// ReserveRun mocks base method.
+func (m *MockRepository) ReserveRun(ctx context.Context, templateId string, scheduledAt time.Time) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReserveRun", ctx, templateId, scheduledAt)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReserveRun indicates an expected call of ReserveRun.
+func (mr *MockRepositoryMockRecorder) ReserveRun(ctx, templateId, scheduledAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReserveRun", reflect.TypeOf((*MockRepository)(nil).ReserveRun), ctx, templateId, scheduledAt)
+}
+
+// UpdateTemplate mocks base method.
+func (m *MockRepository) UpdateTemplate(ctx context.Context, template *TemplateDTO) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTemplate", ctx, template)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateTemplate indicates an expected call of UpdateTemplate.
+func (mr *MockRepositoryMockRecorder) UpdateTemplate(ctx, template any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTemplate", reflect.TypeOf((*MockRepository)(nil).UpdateTemplate), ctx, template)
+}
+
+// WithLock mocks base method.
+func (m *MockRepository) WithLock(ctx context.Context, key int64, fn func(context.Context) error) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithLock", ctx, key, fn)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WithLock indicates an expected call of WithLock.
+func (mr *MockRepositoryMockRecorder) WithLock(ctx, key, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithLock", reflect.TypeOf((*MockRepository)(nil).WithLock), ctx, key, fn)
+}
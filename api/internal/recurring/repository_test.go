@@ -0,0 +1,128 @@
+package recurring
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+
+	"invoice-api/pkg/requestcontext"
+	"invoice-api/pkg/tenant"
+)
+
+var tenantContext = requestcontext.WithTenant(context.Background(), tenant.DefaultTenant)
+
+func TestPgRepository_Templates(t *testing.T) {
+	pgContainer := setupContainer(t)
+	pgHost, err := pgContainer.Host(context.Background())
+	require.NoError(t, err)
+
+	pgPort, err := pgContainer.MappedPort(context.Background(), "5432/tcp")
+	require.NoError(t, err)
+
+	pgRepository := NewPgRepository(nil, pgHost, pgPort.Port(), "root", "root", "test")
+	january := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	february := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	template := &TemplateDTO{
+		Id:          uuid.NewString(),
+		ServiceName: "SSP",
+		Amount:      250,
+		Cadence:     "@monthly",
+		StartAt:     january,
+		NextRunAt:   &january,
+		Enabled:     true,
+		CreatedAt:   time.Now().UTC().Truncate(time.Microsecond),
+	}
+	require.NoError(t, pgRepository.CreateTemplate(tenantContext, template))
+
+	due, err := pgRepository.GetDueTemplates(tenantContext, january.Add(time.Hour), 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, *template, due[0])
+
+	invoiceId, err := pgRepository.ReserveRun(tenantContext, template.Id, january)
+	require.NoError(t, err)
+	sameInvoiceId, err := pgRepository.ReserveRun(tenantContext, template.Id, january)
+	require.NoError(t, err)
+	assert.Equal(t, invoiceId, sameInvoiceId)
+
+	require.NoError(t, pgRepository.AdvanceTemplate(tenantContext, template.Id, january, &february))
+	require.NoError(t, pgRepository.AdvanceTemplate(tenantContext, template.Id, january, nil))
+	stored, err := pgRepository.GetTemplateById(tenantContext, template.Id)
+	require.NoError(t, err)
+	assert.Equal(t, february, *stored.NextRunAt)
+	assert.Equal(t, january, *stored.LastRunAt)
+
+	runs, err := pgRepository.GetRuns(tenantContext, template.Id)
+	require.NoError(t, err)
+	require.Len(t, *runs, 1)
+	assert.Equal(t, invoiceId, (*runs)[0].InvoiceId)
+
+	stored.Enabled = false
+	require.NoError(t, pgRepository.UpdateTemplate(tenantContext, stored))
+	due, err = pgRepository.GetDueTemplates(tenantContext, february, 10)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+
+	require.NoError(t, pgRepository.DeleteTemplateById(tenantContext, template.Id))
+	_, err = pgRepository.GetTemplateById(tenantContext, template.Id)
+	assert.Error(t, err)
+}
+
+func TestPgRepository_WithLock(t *testing.T) {
+	pgContainer := setupContainer(t)
+	pgHost, err := pgContainer.Host(context.Background())
+	require.NoError(t, err)
+
+	pgPort, err := pgContainer.MappedPort(context.Background(), "5432/tcp")
+	require.NoError(t, err)
+
+	leader := NewPgRepository(nil, pgHost, pgPort.Port(), "root", "root", "test")
+	follower := NewPgRepository(nil, pgHost, pgPort.Port(), "root", "root", "test")
+
+	var runs atomic.Int32
+	acquired, err := leader.WithLock(tenantContext, schedulerLockKey, func(ctx context.Context) error {
+		runs.Add(1)
+		acquired, err := follower.WithLock(ctx, schedulerLockKey, func(context.Context) error {
+			runs.Add(1)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.False(t, acquired)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.True(t, acquired)
+	assert.Equal(t, int32(1), runs.Load())
+
+	acquired, err = follower.WithLock(tenantContext, schedulerLockKey, func(context.Context) error { return nil })
+	require.NoError(t, err)
+	assert.True(t, acquired)
+}
+
+func setupContainer(t *testing.T) *postgres.PostgresContainer {
+	ctx := context.Background()
+	postgresContainer, err := postgres.Run(
+		ctx,
+		"postgres",
+		postgres.WithDatabase("test"),
+		postgres.WithUsername("root"),
+		postgres.WithPassword("root"),
+		postgres.BasicWaitStrategies(),
+		postgres.WithSQLDriver("pgx"),
+		postgres.WithInitScripts("../../.scripts/init.sql"),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		err = postgresContainer.Terminate(ctx)
+		require.NoError(t, err)
+	})
+
+	return postgresContainer
+}
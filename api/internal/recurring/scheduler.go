@@ -0,0 +1,145 @@
+package recurring
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+
+	"invoice-api/internal/invoice"
+	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/requestcontext"
+)
+
+const (
+	schedulerLockKey = 0x7265637572
+	invoiceStatus    = "UNPAID"
+)
+
+type Scheduler struct {
+	log        *zap.Logger
+	repository Repository
+	invoices   invoice.Repository
+	batchSize  int
+	interval   time.Duration
+	now        func() time.Time
+}
+
+func NewScheduler(log *zap.Logger, repository Repository, invoices invoice.Repository, batchSize int, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		log:        log,
+		repository: repository,
+		invoices:   invoices,
+		batchSize:  batchSize,
+		interval:   interval,
+		now:        time.Now,
+	}
+}
+
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if s.Schedule(ctx) < s.batchSize {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func (s *Scheduler) Schedule(ctx context.Context) int {
+	var generated int
+	acquired, err := s.repository.WithLock(ctx, schedulerLockKey, func(ctx context.Context) error {
+		templates, err := s.repository.GetDueTemplates(ctx, s.now().UTC(), s.batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, template := range templates {
+			if generated >= s.batchSize {
+				break
+			}
+
+			var count int
+			count, err = s.catchUp(ctx, template, s.batchSize-generated)
+			generated += count
+			if err != nil {
+				s.log.Error("failed to generate recurring invoice", zap.String("templateId", template.Id), zap.Error(err))
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		s.log.Error("failed to schedule recurring invoices", zap.Error(err))
+	}
+	if !acquired {
+		return 0
+	}
+
+	if generated > 0 {
+		s.log.Info("generated recurring invoices", zap.Int("count", generated))
+	}
+
+	return generated
+}
+
+func (s *Scheduler) catchUp(ctx context.Context, template TemplateDTO, limit int) (int, error) {
+	ctx = requestcontext.WithTenant(requestcontext.WithActor(ctx, "recurring:"+template.Id), template.TenantId)
+	now := s.now().UTC()
+
+	var generated int
+	for template.NextRunAt != nil && !template.NextRunAt.After(now) && generated < limit {
+		scheduledAt := *template.NextRunAt
+		if err := s.generate(ctx, template, scheduledAt); err != nil {
+			return generated, err
+		}
+
+		next, err := NextRun(template.Cadence, scheduledAt, template.EndAt)
+		if err != nil {
+			next = nil
+			s.log.Error("invalid recurring template cadence", zap.String("templateId", template.Id), zap.Error(err))
+		}
+
+		if err = s.repository.AdvanceTemplate(ctx, template.Id, scheduledAt, next); err != nil {
+			return generated, err
+		}
+
+		template.NextRunAt = next
+		generated++
+	}
+
+	return generated, nil
+}
+
+func (s *Scheduler) generate(ctx context.Context, template TemplateDTO, scheduledAt time.Time) error {
+	invoiceId, err := s.repository.ReserveRun(ctx, template.Id, scheduledAt)
+	if err != nil {
+		return err
+	}
+
+	err = s.invoices.CreateInvoice(ctx, &invoice.InvoiceDTO{
+		Id:          invoiceId,
+		ServiceName: template.ServiceName,
+		Amount:      template.Amount,
+		Status:      invoiceStatus,
+		Date:        scheduledAt,
+	})
+
+	var cerr customError.CustomError
+	if errors.As(err, &cerr) && cerr.Code == fiber.StatusConflict {
+		return nil
+	}
+
+	return err
+}
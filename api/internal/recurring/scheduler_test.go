@@ -0,0 +1,116 @@
+package recurring
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+
+	"invoice-api/internal/invoice"
+	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/requestcontext"
+)
+
+func TestScheduler_Schedule(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	january := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	february := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	march := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	april := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	template := TemplateDTO{
+		Id:          "0d4f7e3a-8b1c-4c2d-9e5f-1a2b3c4d5e6f",
+		ServiceName: "DMP",
+		Amount:      99.5,
+		Cadence:     "0 0 1 * *",
+		StartAt:     january,
+		NextRunAt:   &january,
+		Enabled:     true,
+		TenantId:    "acme",
+	}
+	withLock := func(_ context.Context, key int64, fn func(ctx context.Context) error) (bool, error) {
+		assert.Equal(t, int64(schedulerLockKey), key)
+		return true, fn(context.TODO())
+	}
+
+	t.Run("catches up missed runs", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockInvoices := invoice.NewMockRepository(mockController)
+		mockRepository.EXPECT().WithLock(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(withLock)
+		mockRepository.EXPECT().GetDueTemplates(gomock.Any(), now, 10).Return([]TemplateDTO{template}, nil)
+		for i, scheduledAt := range []time.Time{january, february, march} {
+			invoiceId := []string{"a", "b", "c"}[i]
+			next := []time.Time{february, march, april}[i]
+			mockRepository.EXPECT().ReserveRun(gomock.Any(), template.Id, scheduledAt).Return(invoiceId, nil)
+			mockInvoices.EXPECT().CreateInvoice(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, dto *invoice.InvoiceDTO) error {
+				assert.Equal(t, "recurring:"+template.Id, requestcontext.Actor(ctx))
+				assert.Equal(t, template.TenantId, requestcontext.Tenant(ctx))
+				assert.Equal(t, invoice.InvoiceDTO{Id: invoiceId, ServiceName: "DMP", Amount: 99.5, Status: "UNPAID", Date: scheduledAt}, *dto)
+				return nil
+			})
+			mockRepository.EXPECT().AdvanceTemplate(gomock.Any(), template.Id, scheduledAt, &next).Return(nil)
+		}
+
+		scheduler := NewScheduler(zap.NewNop(), mockRepository, mockInvoices, 10, time.Minute)
+		scheduler.now = func() time.Time { return now }
+		assert.Equal(t, 3, scheduler.Schedule(context.TODO()))
+	})
+
+	t.Run("already generated invoice is skipped", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockInvoices := invoice.NewMockRepository(mockController)
+		templateInMarch := template
+		templateInMarch.NextRunAt = &march
+		mockRepository.EXPECT().WithLock(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(withLock)
+		mockRepository.EXPECT().GetDueTemplates(gomock.Any(), now, 10).Return([]TemplateDTO{templateInMarch}, nil)
+		mockRepository.EXPECT().ReserveRun(gomock.Any(), template.Id, march).Return("c", nil)
+		mockInvoices.EXPECT().CreateInvoice(gomock.Any(), gomock.Any()).Return(customError.CustomError{Code: fiber.StatusConflict, Message: "invoice already exists"})
+		mockRepository.EXPECT().AdvanceTemplate(gomock.Any(), template.Id, march, &april).Return(nil)
+
+		scheduler := NewScheduler(zap.NewNop(), mockRepository, mockInvoices, 10, time.Minute)
+		scheduler.now = func() time.Time { return now }
+		assert.Equal(t, 1, scheduler.Schedule(context.TODO()))
+	})
+
+	t.Run("stops at batch size", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockInvoices := invoice.NewMockRepository(mockController)
+		mockRepository.EXPECT().WithLock(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(withLock)
+		mockRepository.EXPECT().GetDueTemplates(gomock.Any(), now, 2).Return([]TemplateDTO{template, template}, nil)
+		mockRepository.EXPECT().ReserveRun(gomock.Any(), template.Id, gomock.Any()).Return("a", nil).Times(2)
+		mockInvoices.EXPECT().CreateInvoice(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+		mockRepository.EXPECT().AdvanceTemplate(gomock.Any(), template.Id, gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+		scheduler := NewScheduler(zap.NewNop(), mockRepository, mockInvoices, 2, time.Minute)
+		scheduler.now = func() time.Time { return now }
+		assert.Equal(t, 2, scheduler.Schedule(context.TODO()))
+	})
+
+	t.Run("failed invoice is retried on the next run", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockInvoices := invoice.NewMockRepository(mockController)
+		mockRepository.EXPECT().WithLock(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(withLock)
+		mockRepository.EXPECT().GetDueTemplates(gomock.Any(), now, 10).Return([]TemplateDTO{template}, nil)
+		mockRepository.EXPECT().ReserveRun(gomock.Any(), template.Id, january).Return("a", nil)
+		mockInvoices.EXPECT().CreateInvoice(gomock.Any(), gomock.Any()).Return(errors.New("connection refused"))
+
+		scheduler := NewScheduler(zap.NewNop(), mockRepository, mockInvoices, 10, time.Minute)
+		scheduler.now = func() time.Time { return now }
+		assert.Equal(t, 0, scheduler.Schedule(context.TODO()))
+	})
+
+	t.Run("another replica is the leader", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().WithLock(gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil)
+
+		scheduler := NewScheduler(zap.NewNop(), mockRepository, nil, 10, time.Minute)
+		assert.Equal(t, 0, scheduler.Schedule(context.TODO()))
+	})
+}
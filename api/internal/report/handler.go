@@ -0,0 +1,166 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+
+	"invoice-api/pkg/auth"
+	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/requestcontext"
+	"invoice-api/pkg/tracing"
+)
+
+type Handler struct {
+	server         *fiber.App
+	validator      *validator.Validate
+	repository     Repository
+	defaultBuckets []int
+	authorizer     *auth.Authorizer
+}
+
+func NewHandler(server *fiber.App, validator *validator.Validate, repository Repository, defaultBuckets []int, authorizer *auth.Authorizer) *Handler {
+	return &Handler{
+		server:         server,
+		validator:      validator,
+		repository:     repository,
+		defaultBuckets: defaultBuckets,
+		authorizer:     authorizer,
+	}
+}
+
+func (h *Handler) RegisterRoutes() {
+	h.server.Get("/reports/aging", h.authorizer.Require(PermissionRead), h.GetAgingReport)
+	h.server.Get("/reports/revenue", h.authorizer.Require(PermissionRead), h.GetRevenueReport)
+}
+
+func (h *Handler) GetAgingReport(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "GetAgingReport"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "report.Handler.GetAgingReport")()
+
+	var reqQuery GetAgingReportRequest
+	if err := ctx.QueryParser(&reqQuery); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid request query",
+			Severity: zap.WarnLevel,
+		}
+	}
+
+	if err := h.validator.StructCtx(ctx.UserContext(), &reqQuery); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid request query",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	asOf := time.Now().UTC().Truncate(24 * time.Hour)
+	if reqQuery.AsOf != "" {
+		asOf, _ = time.Parse(time.DateOnly, reqQuery.AsOf)
+	}
+
+	bounds := h.defaultBuckets
+	if reqQuery.Buckets != "" {
+		var err error
+		bounds, err = ParseAgingBuckets(reqQuery.Buckets)
+		if err != nil {
+			return customError.CustomError{
+				Code:     fiber.StatusBadRequest,
+				Message:  "invalid request query",
+				Severity: zap.WarnLevel,
+				Fields:   []zap.Field{zap.Error(err)},
+			}
+		}
+	}
+
+	rows, err := h.repository.GetAgingReport(ctx.UserContext(), asOf, bounds)
+	if err != nil {
+		return err
+	}
+
+	report := NewAgingReport(asOf, bounds, rows)
+
+	if reqQuery.Format == FormatCSV {
+		var body bytes.Buffer
+		if err = report.WriteCSV(&body); err != nil {
+			return customError.CustomError{
+				Code:     fiber.StatusInternalServerError,
+				Message:  "failed to write aging report",
+				Severity: zap.ErrorLevel,
+				Fields:   []zap.Field{zap.Error(err)},
+			}
+		}
+
+		ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+		ctx.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+		ctx.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="aging-%s.csv"`, report.AsOf))
+		return ctx.Send(body.Bytes())
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	return ctx.JSON(report)
+}
+
+func (h *Handler) GetRevenueReport(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "GetRevenueReport"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "report.Handler.GetRevenueReport")()
+
+	var reqQuery GetRevenueReportRequest
+	if err := ctx.QueryParser(&reqQuery); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid request query",
+			Severity: zap.WarnLevel,
+		}
+	}
+
+	if err := h.validator.StructCtx(ctx.UserContext(), &reqQuery); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid request query",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	from, _ := time.Parse(time.DateOnly, reqQuery.From)
+	to, _ := time.Parse(time.DateOnly, reqQuery.To)
+	if to.Before(from) || to.Sub(from) > maxRevenueRange {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid request query",
+			Severity: zap.WarnLevel,
+			Details:  "to must not be before from and the range must not exceed 5 years",
+		}
+	}
+
+	interval := reqQuery.Interval
+	if interval == "" {
+		interval = IntervalMonth
+	}
+
+	rows, err := h.repository.GetRevenue(ctx.UserContext(), interval, from, to)
+	if err != nil {
+		return err
+	}
+
+	previousFrom, previousTo := PreviousPeriod(from, to)
+	var previousRows []RevenueRow
+	previousRows, err = h.repository.GetRevenue(ctx.UserContext(), interval, previousFrom, previousTo)
+	if err != nil {
+		return err
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	return ctx.JSON(NewRevenueReport(interval, from, to, rows, previousRows))
+}
@@ -0,0 +1,214 @@
+package report
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	json "github.com/bytedance/sonic"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+
+	"invoice-api/pkg/auth"
+	customError "invoice-api/pkg/error"
+)
+
+func TestHandler_NewHandler(t *testing.T) {
+	server, validate := SetupServer(t)
+	h := NewHandler(server, validate, nil, []int{30, 60, 90}, testAuthorizer)
+	assert.NotNil(t, h)
+}
+
+func TestHandler_GetAgingReport(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("happy path", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().
+			GetAgingReport(gomock.Any(), time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC), []int{30, 60, 90}).
+			Return([]AgingBucketRow{{ServiceName: "DMP", Bucket: 2, Count: 1, Amount: 42}}, nil)
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, []int{30, 60, 90}, testAuthorizer)
+		h.RegisterRoutes()
+
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, "/reports/aging?asOf=2024-06-30", nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+
+		var report AgingReport
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &report))
+		assert.Equal(t, "2024-06-30", report.AsOf)
+		assert.Len(t, report.Buckets, 4)
+		assert.Equal(t, []float64{0, 0, 42, 0}, report.Totals.Amounts)
+	})
+
+	t.Run("custom buckets as csv", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().
+			GetAgingReport(gomock.Any(), gomock.Any(), []int{15, 45}).
+			Return([]AgingBucketRow{{ServiceName: "SSP", Bucket: 0, Count: 2, Amount: 10}}, nil)
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, []int{30, 60, 90}, testAuthorizer)
+		h.RegisterRoutes()
+
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, "/reports/aging?asOf=2024-06-30&buckets=15,45&format=csv", nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+		assert.True(t, strings.HasPrefix(res.Header.Get(fiber.HeaderContentType), "text/csv"))
+		assert.Equal(t, `attachment; filename="aging-2024-06-30.csv"`, res.Header.Get(fiber.HeaderContentDisposition))
+
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "service_name,0-15,16-45,45+,total\nSSP,10.00,0.00,0.00,10.00\nTOTAL,10.00,0.00,0.00,10.00\n", string(body))
+	})
+
+	t.Run("invalid request queries", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, nil, []int{30, 60, 90}, testAuthorizer)
+		h.RegisterRoutes()
+
+		for _, query := range []string{"asOf=30-06-2024", "buckets=60,30", "buckets=0", "format=xml"} {
+			res, err := server.Test(httptest.NewRequest(http.MethodGet, "/reports/aging?"+query, nil), -1)
+			require.NoError(t, err)
+			assert.Equal(t, fiber.StatusBadRequest, res.StatusCode, query)
+		}
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().
+			GetAgingReport(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(nil, customError.CustomError{Code: fiber.StatusInternalServerError, Message: "failed to get aging report", Severity: zap.ErrorLevel})
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, []int{30, 60, 90}, testAuthorizer)
+		h.RegisterRoutes()
+
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, "/reports/aging", nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusInternalServerError, res.StatusCode)
+	})
+}
+
+func TestHandler_GetRevenueReport(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("happy path", func(t *testing.T) {
+		from := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+		to := time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)
+
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().
+			GetRevenue(gomock.Any(), IntervalQuarter, from, to).
+			Return([]RevenueRow{{Period: from, ServiceName: "DMP", Status: "PAID", Count: 3, Amount: 300}}, nil)
+		mockRepository.EXPECT().
+			GetRevenue(gomock.Any(), IntervalQuarter, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)).
+			Return([]RevenueRow{{Period: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), ServiceName: "DMP", Status: "PAID", Count: 2, Amount: 200}}, nil)
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, []int{30, 60, 90}, testAuthorizer)
+		h.RegisterRoutes()
+
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, "/reports/revenue?from=2024-04-01&to=2024-06-30&interval=quarter", nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+
+		var report RevenueReport
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &report))
+		assert.Equal(t, IntervalQuarter, report.Interval)
+		assert.Equal(t, []RevenuePoint{{Period: "2024-04-01", ServiceName: "DMP", Status: "PAID", Count: 3, Amount: 300}}, report.Series)
+		assert.Equal(t, "2024-01-01", report.Previous.From)
+		assert.Equal(t, 100.0, report.Change.Amount)
+		require.NotNil(t, report.Change.AmountPercent)
+		assert.Equal(t, 50.0, *report.Change.AmountPercent)
+	})
+
+	t.Run("defaults to monthly interval", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetRevenue(gomock.Any(), IntervalMonth, gomock.Any(), gomock.Any()).Return(nil, nil).Times(2)
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, []int{30, 60, 90}, testAuthorizer)
+		h.RegisterRoutes()
+
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, "/reports/revenue?from=2024-01-01&to=2024-12-31", nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	})
+
+	t.Run("invalid request queries", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, nil, []int{30, 60, 90}, testAuthorizer)
+		h.RegisterRoutes()
+
+		for _, query := range []string{
+			"",
+			"from=2024-01-01",
+			"from=2024-01-01&to=2024-13-01",
+			"from=2024-02-01&to=2024-01-01",
+			"from=2014-01-01&to=2024-01-01",
+			"from=2024-01-01&to=2024-02-01&interval=year",
+		} {
+			res, err := server.Test(httptest.NewRequest(http.MethodGet, "/reports/revenue?"+query, nil), -1)
+			require.NoError(t, err)
+			assert.Equal(t, fiber.StatusBadRequest, res.StatusCode, query)
+		}
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().
+			GetRevenue(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(nil, customError.CustomError{Code: fiber.StatusInternalServerError, Message: "failed to get revenue", Severity: zap.ErrorLevel})
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, []int{30, 60, 90}, testAuthorizer)
+		h.RegisterRoutes()
+
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, "/reports/revenue?from=2024-01-01&to=2024-01-31", nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusInternalServerError, res.StatusCode)
+	})
+}
+
+var testAuthorizer = auth.NewAuthorizer(auth.Policy{"admin": {auth.AllPermissions}})
+
+func SetupServer(t *testing.T) (*fiber.App, *validator.Validate) {
+	server := fiber.New(fiber.Config{
+		JSONDecoder:           json.Unmarshal,
+		JSONEncoder:           json.Marshal,
+		ErrorHandler:          customError.ErrorHandler,
+		DisableStartupMessage: true,
+	})
+
+	log, _ := zap.NewProduction()
+	defer func(log *zap.Logger) {
+		err := log.Sync()
+		if err != nil {
+			assert.FailNow(t, err.Error())
+		}
+	}(log)
+
+	server.Use(func(c *fiber.Ctx) error {
+		c.Locals("log", log)
+		c.Locals(auth.ContextKeyRoles, []string{"admin"})
+		return c.Next()
+	})
+
+	return server, validator.New()
+}
@@ -0,0 +1,33 @@
+package report
+
+import (
+	"context"
+	"time"
+
+	"invoice-api/pkg/metrics"
+	"invoice-api/pkg/tracing"
+)
+
+const repositoryName = "report"
+
+type InstrumentedRepository struct {
+	repository Repository
+}
+
+func NewInstrumentedRepository(repository Repository) *InstrumentedRepository {
+	return &InstrumentedRepository{repository: repository}
+}
+
+func (r *InstrumentedRepository) GetAgingReport(ctx context.Context, asOf time.Time, bounds []int) (_ []AgingBucketRow, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "GetAgingReport")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "GetAgingReport", time.Now(), &err)
+	return r.repository.GetAgingReport(ctx, asOf, bounds)
+}
+
+func (r *InstrumentedRepository) GetRevenue(ctx context.Context, interval string, from, to time.Time) (_ []RevenueRow, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "GetRevenue")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "GetRevenue", time.Now(), &err)
+	return r.repository.GetRevenue(ctx, interval, from, to)
+}
@@ -0,0 +1,94 @@
+package report
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+type GetAgingReportRequest struct {
+	AsOf    string `query:"asOf" validate:"omitempty,datetime=2006-01-02"`
+	Buckets string `query:"buckets" validate:"omitempty,max=64"`
+	Format  string `query:"format" validate:"omitempty,oneof=json csv"`
+}
+
+type AgingBucketRow struct {
+	ServiceName string  `db:"service_name"`
+	Bucket      int     `db:"bucket"`
+	Count       int64   `db:"count"`
+	Amount      float64 `db:"amount"`
+}
+
+type AgingBucket struct {
+	Label string `json:"label"`
+	From  int    `json:"from"`
+	To    *int   `json:"to,omitempty"`
+}
+
+type AgingLine struct {
+	ServiceName string    `json:"serviceName,omitempty"`
+	Amounts     []float64 `json:"amounts"`
+	Counts      []int64   `json:"counts"`
+	Total       float64   `json:"total"`
+	Count       int64     `json:"count"`
+}
+
+type AgingReport struct {
+	AsOf    string        `json:"asOf"`
+	Buckets []AgingBucket `json:"buckets"`
+	Rows    []AgingLine   `json:"rows"`
+	Totals  AgingLine     `json:"totals"`
+}
+
+func agingBuckets(bounds []int) []AgingBucket {
+	buckets := make([]AgingBucket, 0, len(bounds)+1)
+	from := 0
+	for _, bound := range bounds {
+		to := bound
+		buckets = append(buckets, AgingBucket{Label: fmt.Sprintf("%d-%d", from, to), From: from, To: &to})
+		from = bound + 1
+	}
+
+	return append(buckets, AgingBucket{Label: fmt.Sprintf("%d+", from-1), From: from})
+}
+
+func NewAgingReport(asOf time.Time, bounds []int, rows []AgingBucketRow) *AgingReport {
+	buckets := agingBuckets(bounds)
+	report := &AgingReport{
+		AsOf:    asOf.Format(time.DateOnly),
+		Buckets: buckets,
+		Rows:    []AgingLine{},
+		Totals:  newAgingLine("", len(buckets)),
+	}
+
+	lines := make(map[string]int)
+	for _, row := range rows {
+		index, ok := lines[row.ServiceName]
+		if !ok {
+			index = len(report.Rows)
+			lines[row.ServiceName] = index
+			report.Rows = append(report.Rows, newAgingLine(row.ServiceName, len(buckets)))
+		}
+
+		for _, line := range []*AgingLine{&report.Rows[index], &report.Totals} {
+			line.Amounts[row.Bucket] = roundAmount(line.Amounts[row.Bucket] + row.Amount)
+			line.Counts[row.Bucket] += row.Count
+			line.Total = roundAmount(line.Total + row.Amount)
+			line.Count += row.Count
+		}
+	}
+
+	return report
+}
+
+func newAgingLine(serviceName string, buckets int) AgingLine {
+	return AgingLine{
+		ServiceName: serviceName,
+		Amounts:     make([]float64, buckets),
+		Counts:      make([]int64, buckets),
+	}
+}
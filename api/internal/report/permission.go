@@ -0,0 +1,5 @@
+package report
+
+const (
+	PermissionRead = "reports:read"
+)
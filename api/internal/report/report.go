@@ -0,0 +1,73 @@
+package report
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxAgingBuckets = 10
+	maxRevenueRange = 5 * 366 * 24 * time.Hour
+)
+
+var errInvalidBuckets = errors.New("buckets must be ascending positive day counts")
+
+func ParseAgingBuckets(value string) ([]int, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) > maxAgingBuckets {
+		return nil, errInvalidBuckets
+	}
+
+	bounds := make([]int, 0, len(parts))
+	for _, part := range parts {
+		bound, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || bound <= 0 || (len(bounds) > 0 && bound <= bounds[len(bounds)-1]) {
+			return nil, errInvalidBuckets
+		}
+		bounds = append(bounds, bound)
+	}
+
+	return bounds, nil
+}
+
+func (r *AgingReport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	header := []string{"service_name"}
+	for _, bucket := range r.Buckets {
+		header = append(header, bucket.Label)
+	}
+	if err := writer.Write(append(header, "total")); err != nil {
+		return err
+	}
+
+	for _, line := range append(slices.Clone(r.Rows), r.Totals) {
+		record := []string{line.ServiceName}
+		if record[0] == "" {
+			record[0] = "TOTAL"
+		}
+		for _, amount := range line.Amounts {
+			record = append(record, formatAmount(amount))
+		}
+		if err := writer.Write(append(record, formatAmount(line.Total))); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
@@ -0,0 +1,57 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAgingBuckets(t *testing.T) {
+	bounds, err := ParseAgingBuckets("15, 45,120")
+	require.NoError(t, err)
+	assert.Equal(t, []int{15, 45, 120}, bounds)
+
+	for _, value := range []string{"", "30,30", "60,30", "0,30", "-5", "a,b", "1,2,3,4,5,6,7,8,9,10,11"} {
+		_, err = ParseAgingBuckets(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestNewAgingReport(t *testing.T) {
+	asOf := time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)
+	report := NewAgingReport(asOf, []int{30, 60, 90}, []AgingBucketRow{
+		{ServiceName: "DMP", Bucket: 0, Count: 2, Amount: 100.10},
+		{ServiceName: "DMP", Bucket: 3, Count: 1, Amount: 50.25},
+		{ServiceName: "SSP", Bucket: 1, Count: 1, Amount: 20.20},
+	})
+
+	assert.Equal(t, "2024-06-30", report.AsOf)
+	require.Len(t, report.Buckets, 4)
+	assert.Equal(t, []string{"0-30", "31-60", "61-90", "90+"}, []string{
+		report.Buckets[0].Label, report.Buckets[1].Label, report.Buckets[2].Label, report.Buckets[3].Label,
+	})
+	assert.Equal(t, 91, report.Buckets[3].From)
+	assert.Nil(t, report.Buckets[3].To)
+
+	require.Len(t, report.Rows, 2)
+	assert.Equal(t, []float64{100.10, 0, 0, 50.25}, report.Rows[0].Amounts)
+	assert.Equal(t, 150.35, report.Rows[0].Total)
+	assert.Equal(t, int64(3), report.Rows[0].Count)
+	assert.Equal(t, []float64{100.10, 20.20, 0, 50.25}, report.Totals.Amounts)
+	assert.Equal(t, 170.55, report.Totals.Total)
+	assert.Equal(t, int64(4), report.Totals.Count)
+}
+
+func TestAgingReport_WriteCSV(t *testing.T) {
+	report := NewAgingReport(time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC), []int{30}, []AgingBucketRow{
+		{ServiceName: "DMP", Bucket: 0, Count: 1, Amount: 10},
+		{ServiceName: "SSP", Bucket: 1, Count: 1, Amount: 2.5},
+	})
+
+	var body bytes.Buffer
+	require.NoError(t, report.WriteCSV(&body))
+	assert.Equal(t, "service_name,0-30,30+,total\nDMP,10.00,0.00,10.00\nSSP,0.00,2.50,2.50\nTOTAL,10.00,2.50,12.50\n", body.String())
+}
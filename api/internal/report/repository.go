@@ -0,0 +1,214 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/requestcontext"
+	"invoice-api/pkg/tenant"
+	"invoice-api/pkg/tracing"
+)
+
+const statusAt = `coalesce(
+	(select e.changes->'status'->>'after' from invoice_events e
+		where e.invoice_id = i.id and e.occurred_at < $2 and e.changes ? 'status' order by e.id desc limit 1),
+	(select e.changes->'status'->>'before' from invoice_events e
+		where e.invoice_id = i.id and e.occurred_at >= $2 and e.changes ? 'status' order by e.id limit 1),
+	i.status::text
+)`
+
+type Repository interface {
+	GetAgingReport(ctx context.Context, asOf time.Time, bounds []int) ([]AgingBucketRow, error)
+	GetRevenue(ctx context.Context, interval string, from, to time.Time) ([]RevenueRow, error)
+}
+
+type PgRepository struct {
+	connectionPool *pgxpool.Pool
+}
+
+func NewPgRepository(log *zap.Logger, host, port, username, password, database string) *PgRepository {
+	credentials := fmt.Sprintf("user=%s password=%s host=%s port=%s dbname=%s sslmode=disable", username, password, host, port, database)
+	pgConfig, err := pgxpool.ParseConfig(credentials)
+	if err != nil {
+		log.Fatal("failed to parse database config", zap.Error(err))
+	}
+	pgConfig.AfterConnect = tenant.AfterConnect
+	pgConfig.ConnConfig.Tracer = tracing.QueryTracer{}
+
+	var pgConnectionPool *pgxpool.Pool
+	pgConnectionPool, err = pgxpool.NewWithConfig(context.Background(), pgConfig)
+	if err != nil {
+		log.Fatal("failed to connect database", zap.Error(err))
+	}
+
+	var connection *pgxpool.Conn
+	connection, err = pgConnectionPool.Acquire(context.Background())
+	if err != nil {
+		log.Fatal("failed to acquire connection", zap.Error(err))
+	}
+	defer connection.Release()
+
+	err = connection.Ping(context.Background())
+	if err != nil {
+		log.Fatal("failed to ping database", zap.Error(err))
+	}
+
+	return &PgRepository{
+		connectionPool: pgConnectionPool,
+	}
+}
+
+func (r *PgRepository) Stat() *pgxpool.Stat {
+	return r.connectionPool.Stat()
+}
+
+func (r *PgRepository) Ping(ctx context.Context) error {
+	return r.connectionPool.Ping(ctx)
+}
+
+func (r *PgRepository) GetAgingReport(ctx context.Context, asOf time.Time, bounds []int) ([]AgingBucketRow, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	thresholds := make([]int, 0, len(bounds))
+	for _, bound := range bounds {
+		thresholds = append(thresholds, bound+1)
+	}
+
+	var rows pgx.Rows
+	rows, err = tx.Query(
+		ctx,
+		`with outstanding as (
+			select i.service_name::text as service_name, i.amount, $1::date - i.date::date as age
+			from invoices i
+			where i.direction = 'OUTBOUND'
+			and i.date::date <= $1::date
+			and (i.deleted_at is null or i.deleted_at >= $2)
+			and `+statusAt+` in ('UNPAID', 'PENDING')
+		)
+		select service_name, width_bucket(age, $3::int[]) as bucket, count(*) as count, round(sum(amount)::numeric, 2)::float8 as amount
+		from outstanding
+		group by service_name, bucket
+		order by service_name, bucket`,
+		asOf.Format(time.DateOnly),
+		asOf.AddDate(0, 0, 1),
+		thresholds,
+	)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get aging report",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var buckets []AgingBucketRow
+	buckets, err = pgx.CollectRows(rows, pgx.RowToStructByName[AgingBucketRow])
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect aging report",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return buckets, nil
+}
+
+func (r *PgRepository) GetRevenue(ctx context.Context, interval string, from, to time.Time) ([]RevenueRow, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	var rows pgx.Rows
+	rows, err = tx.Query(
+		ctx,
+		`select date_trunc($1, date) as period, service_name::text as service_name, status::text as status,
+			count(*) as count, round(sum(amount)::numeric, 2)::float8 as amount
+		from invoices
+		where direction = 'OUTBOUND' and deleted_at is null and date >= $2 and date < $3
+		group by period, service_name, status
+		order by period, service_name, status`,
+		interval,
+		from,
+		to.AddDate(0, 0, 1),
+	)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get revenue",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var revenue []RevenueRow
+	revenue, err = pgx.CollectRows(rows, pgx.RowToStructByName[RevenueRow])
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect revenue",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return revenue, nil
+}
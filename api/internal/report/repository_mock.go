@@ -0,0 +1,72 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/report/repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/report/repository.go -destination=internal/report/repository_mock.go -package=report
+//
+
+// Package report is a generated GoMock package.
+package report
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetAgingReport mocks base method.
+func (m *MockRepository) GetAgingReport(ctx context.Context, asOf time.Time, bounds []int) ([]AgingBucketRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAgingReport", ctx, asOf, bounds)
+	ret0, _ := ret[0].([]AgingBucketRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAgingReport indicates an expected call of GetAgingReport.
+func (mr *MockRepositoryMockRecorder) GetAgingReport(ctx, asOf, bounds any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAgingReport", reflect.TypeOf((*MockRepository)(nil).GetAgingReport), ctx, asOf, bounds)
+}
+
+// GetRevenue mocks base method.
+func (m *MockRepository) GetRevenue(ctx context.Context, interval string, from, to time.Time) ([]RevenueRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRevenue", ctx, interval, from, to)
+	ret0, _ := ret[0].([]RevenueRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRevenue indicates an expected call of GetRevenue.
+func (mr *MockRepositoryMockRecorder) GetRevenue(ctx, interval, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRevenue", reflect.TypeOf((*MockRepository)(nil).GetRevenue), ctx, interval, from, to)
+}
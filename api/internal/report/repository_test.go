@@ -0,0 +1,121 @@
+package report
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+
+	"invoice-api/pkg/requestcontext"
+	"invoice-api/pkg/tenant"
+)
+
+var tenantContext = requestcontext.WithTenant(context.Background(), tenant.DefaultTenant)
+
+func TestPgRepository_GetAgingReport(t *testing.T) {
+	pgContainer := setupContainer(t)
+	pgHost, err := pgContainer.Host(context.Background())
+	require.NoError(t, err)
+
+	pgPort, err := pgContainer.MappedPort(context.Background(), "5432/tcp")
+	require.NoError(t, err)
+
+	pgRepository := NewPgRepository(nil, pgHost, pgPort.Port(), "root", "root", "test")
+	asOf := time.Date(2023, 6, 30, 0, 0, 0, 0, time.UTC)
+	paidLater := uuid.NewString()
+	invoices := []struct {
+		id          string
+		serviceName string
+		amount      float64
+		status      string
+		age         int
+	}{
+		{uuid.NewString(), "DMP", 10, "UNPAID", 5},
+		{uuid.NewString(), "DMP", 20, "PENDING", 45},
+		{uuid.NewString(), "SSP", 30, "UNPAID", 120},
+		{uuid.NewString(), "SSP", 40, "PAID", 10},
+		{paidLater, "SSP", 50, "PAID", 70},
+	}
+	for _, invoice := range invoices {
+		_, err = pgRepository.connectionPool.Exec(
+			tenantContext,
+			"insert into invoices (id, service_name, amount, status, date) values ($1, $2, $3, $4, $5)",
+			invoice.id, invoice.serviceName, invoice.amount, invoice.status, asOf.AddDate(0, 0, -invoice.age),
+		)
+		require.NoError(t, err)
+	}
+	_, err = pgRepository.connectionPool.Exec(
+		tenantContext,
+		`insert into invoice_events (invoice_id, type, actor, changes, occurred_at) values ($1, 'updated', 'test', '{"status":{"before":"UNPAID","after":"PAID"}}', $2)`,
+		paidLater, asOf.AddDate(0, 0, 3),
+	)
+	require.NoError(t, err)
+
+	rows, err := pgRepository.GetAgingReport(tenantContext, asOf, []int{30, 60, 90})
+	require.NoError(t, err)
+	assert.Equal(t, []AgingBucketRow{
+		{ServiceName: "DMP", Bucket: 0, Count: 1, Amount: 10},
+		{ServiceName: "DMP", Bucket: 1, Count: 1, Amount: 20},
+		{ServiceName: "SSP", Bucket: 2, Count: 1, Amount: 50},
+		{ServiceName: "SSP", Bucket: 3, Count: 1, Amount: 30},
+	}, rows)
+
+	rows, err = pgRepository.GetAgingReport(tenantContext, asOf.AddDate(0, 0, 5), []int{30, 60, 90})
+	require.NoError(t, err)
+	assert.Len(t, rows, 3)
+}
+
+func TestPgRepository_GetRevenue(t *testing.T) {
+	pgContainer := setupContainer(t)
+	pgHost, err := pgContainer.Host(context.Background())
+	require.NoError(t, err)
+
+	pgPort, err := pgContainer.MappedPort(context.Background(), "5432/tcp")
+	require.NoError(t, err)
+
+	pgRepository := NewPgRepository(nil, pgHost, pgPort.Port(), "root", "root", "test")
+
+	rows, err := pgRepository.GetRevenue(
+		tenantContext,
+		IntervalMonth,
+		time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC),
+	)
+	require.NoError(t, err)
+	march := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	april := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, []RevenueRow{
+		{Period: march, ServiceName: "DMP", Status: "PAID", Count: 1, Amount: 180.45},
+		{Period: march, ServiceName: "DMP", Status: "PENDING", Count: 1, Amount: 175.9},
+		{Period: march, ServiceName: "SSP", Status: "PAID", Count: 2, Amount: 750.25},
+		{Period: march, ServiceName: "SSP", Status: "PENDING", Count: 2, Amount: 506.1},
+		{Period: april, ServiceName: "DMP", Status: "UNPAID", Count: 2, Amount: 351.55},
+		{Period: april, ServiceName: "SSP", Status: "UNPAID", Count: 1, Amount: 325.9},
+	}, rows)
+}
+
+func setupContainer(t *testing.T) *postgres.PostgresContainer {
+	ctx := context.Background()
+	postgresContainer, err := postgres.Run(
+		ctx,
+		"postgres",
+		postgres.WithDatabase("test"),
+		postgres.WithUsername("root"),
+		postgres.WithPassword("root"),
+		postgres.BasicWaitStrategies(),
+		postgres.WithSQLDriver("pgx"),
+		postgres.WithInitScripts("../../.scripts/init.sql"),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		err = postgresContainer.Terminate(ctx)
+		require.NoError(t, err)
+	})
+
+	return postgresContainer
+}
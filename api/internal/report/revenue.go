@@ -0,0 +1,133 @@
+package report
+
+import (
+	"time"
+)
+
+const (
+	IntervalDay     = "day"
+	IntervalWeek    = "week"
+	IntervalMonth   = "month"
+	IntervalQuarter = "quarter"
+)
+
+type GetRevenueReportRequest struct {
+	From     string `query:"from" validate:"required,datetime=2006-01-02"`
+	To       string `query:"to" validate:"required,datetime=2006-01-02"`
+	Interval string `query:"interval" validate:"omitempty,oneof=day week month quarter"`
+}
+
+type RevenueRow struct {
+	Period      time.Time `db:"period"`
+	ServiceName string    `db:"service_name"`
+	Status      string    `db:"status"`
+	Count       int64     `db:"count"`
+	Amount      float64   `db:"amount"`
+}
+
+type RevenuePoint struct {
+	Period      string  `json:"period"`
+	ServiceName string  `json:"serviceName"`
+	Status      string  `json:"status"`
+	Count       int64   `json:"count"`
+	Amount      float64 `json:"amount"`
+}
+
+type RevenueTotal struct {
+	Count  int64   `json:"count"`
+	Amount float64 `json:"amount"`
+}
+
+type RevenueTotals struct {
+	RevenueTotal
+	ByServiceName map[string]RevenueTotal `json:"byServiceName"`
+	ByStatus      map[string]RevenueTotal `json:"byStatus"`
+}
+
+type RevenuePeriod struct {
+	From   string        `json:"from"`
+	To     string        `json:"to"`
+	Totals RevenueTotals `json:"totals"`
+}
+
+type RevenueChange struct {
+	Count         int64    `json:"count"`
+	Amount        float64  `json:"amount"`
+	AmountPercent *float64 `json:"amountPercent"`
+}
+
+type RevenueReport struct {
+	RevenuePeriod
+	Interval string         `json:"interval"`
+	Series   []RevenuePoint `json:"series"`
+	Previous RevenuePeriod  `json:"previous"`
+	Change   RevenueChange  `json:"change"`
+}
+
+func PreviousPeriod(from, to time.Time) (time.Time, time.Time) {
+	days := int(to.Sub(from).Hours()/24) + 1
+	previousTo := from.AddDate(0, 0, -1)
+	return previousTo.AddDate(0, 0, 1-days), previousTo
+}
+
+func NewRevenueReport(interval string, from, to time.Time, rows []RevenueRow, previousRows []RevenueRow) *RevenueReport {
+	previousFrom, previousTo := PreviousPeriod(from, to)
+	report := &RevenueReport{
+		RevenuePeriod: newRevenuePeriod(from, to, rows),
+		Interval:      interval,
+		Series:        make([]RevenuePoint, 0, len(rows)),
+		Previous:      newRevenuePeriod(previousFrom, previousTo, previousRows),
+	}
+
+	for _, row := range rows {
+		report.Series = append(report.Series, RevenuePoint{
+			Period:      row.Period.Format(time.DateOnly),
+			ServiceName: row.ServiceName,
+			Status:      row.Status,
+			Count:       row.Count,
+			Amount:      row.Amount,
+		})
+	}
+
+	report.Change = RevenueChange{
+		Count:  report.Totals.Count - report.Previous.Totals.Count,
+		Amount: roundAmount(report.Totals.Amount - report.Previous.Totals.Amount),
+	}
+	if report.Previous.Totals.Amount != 0 {
+		percent := roundAmount(report.Change.Amount / report.Previous.Totals.Amount * 100)
+		report.Change.AmountPercent = &percent
+	}
+
+	return report
+}
+
+func newRevenuePeriod(from, to time.Time, rows []RevenueRow) RevenuePeriod {
+	period := RevenuePeriod{
+		From: from.Format(time.DateOnly),
+		To:   to.Format(time.DateOnly),
+		Totals: RevenueTotals{
+			ByServiceName: make(map[string]RevenueTotal),
+			ByStatus:      make(map[string]RevenueTotal),
+		},
+	}
+
+	for _, row := range rows {
+		period.Totals.add(row)
+	}
+
+	return period
+}
+
+func (t *RevenueTotals) add(row RevenueRow) {
+	t.RevenueTotal = t.RevenueTotal.add(row)
+
+	t.ByServiceName[row.ServiceName] = t.ByServiceName[row.ServiceName].add(row)
+	t.ByStatus[row.Status] = t.ByStatus[row.Status].add(row)
+}
+
+func (t RevenueTotal) add(row RevenueRow) RevenueTotal {
+	return RevenueTotal{
+		Count:  t.Count + row.Count,
+		Amount: roundAmount(t.Amount + row.Amount),
+	}
+}
@@ -0,0 +1,57 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviousPeriod(t *testing.T) {
+	from, to := PreviousPeriod(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), from)
+	assert.Equal(t, time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC), to)
+
+	from, to = PreviousPeriod(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), from)
+	assert.Equal(t, from, to)
+}
+
+func TestNewRevenueReport(t *testing.T) {
+	march := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	april := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	report := NewRevenueReport(
+		IntervalMonth,
+		march,
+		time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC),
+		[]RevenueRow{
+			{Period: march, ServiceName: "DMP", Status: "PAID", Count: 2, Amount: 100.10},
+			{Period: march, ServiceName: "SSP", Status: "UNPAID", Count: 1, Amount: 50},
+			{Period: april, ServiceName: "DMP", Status: "PAID", Count: 1, Amount: 49.90},
+		},
+		[]RevenueRow{
+			{Period: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), ServiceName: "DMP", Status: "PAID", Count: 2, Amount: 160},
+		},
+	)
+
+	assert.Equal(t, "2024-03-01", report.From)
+	assert.Equal(t, "2024-04-30", report.To)
+	require.Len(t, report.Series, 3)
+	assert.Equal(t, "2024-04-01", report.Series[2].Period)
+	assert.Equal(t, RevenueTotal{Count: 4, Amount: 200}, report.Totals.RevenueTotal)
+	assert.Equal(t, RevenueTotal{Count: 3, Amount: 150}, report.Totals.ByServiceName["DMP"])
+	assert.Equal(t, RevenueTotal{Count: 1, Amount: 50}, report.Totals.ByStatus["UNPAID"])
+
+	assert.Equal(t, "2023-12-31", report.Previous.From)
+	assert.Equal(t, "2024-02-29", report.Previous.To)
+	assert.Equal(t, RevenueTotal{Count: 2, Amount: 160}, report.Previous.Totals.RevenueTotal)
+	assert.Equal(t, int64(2), report.Change.Count)
+	assert.Equal(t, 40.0, report.Change.Amount)
+	require.NotNil(t, report.Change.AmountPercent)
+	assert.Equal(t, 25.0, *report.Change.AmountPercent)
+
+	report = NewRevenueReport(IntervalDay, march, march, nil, nil)
+	assert.Empty(t, report.Series)
+	assert.Nil(t, report.Change.AmountPercent)
+}
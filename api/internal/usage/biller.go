@@ -0,0 +1,154 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"invoice-api/internal/invoice"
+	customError "invoice-api/pkg/error"
+)
+
+const invoiceStatus = "UNPAID"
+
+type Biller struct {
+	repository Repository
+	invoices   invoice.Repository
+	now        func() time.Time
+}
+
+func NewBiller(repository Repository, invoices invoice.Repository) *Biller {
+	return &Biller{
+		repository: repository,
+		invoices:   invoices,
+		now:        time.Now,
+	}
+}
+
+func (b *Biller) Close(ctx context.Context, request *CloseBillingPeriodRequest) (*BillingResult, bool, error) {
+	period, err := b.repository.ReserveBillingPeriod(ctx, &BillingPeriodDTO{
+		InvoiceId:   uuid.NewString(),
+		CustomerId:  request.CustomerId,
+		ServiceName: request.ServiceName,
+		PeriodStart: request.From.UTC(),
+		PeriodEnd:   request.To.UTC(),
+		CreatedAt:   b.now().UTC().Truncate(time.Microsecond),
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	if period.CompletedAt != nil {
+		lines, err := b.repository.GetInvoiceLines(ctx, period.InvoiceId)
+		if err != nil {
+			return nil, false, err
+		}
+
+		return newBillingResult(period, *lines), false, nil
+	}
+
+	usage, err := b.repository.ClaimUsage(ctx, period)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(usage) == 0 {
+		if err = b.repository.ReleaseBillingPeriod(ctx, period.InvoiceId); err != nil {
+			return nil, false, err
+		}
+
+		return nil, false, customError.CustomError{
+			Code:     fiber.StatusUnprocessableEntity,
+			Message:  "no usage to bill in billing period",
+			Severity: zap.WarnLevel,
+		}
+	}
+
+	lines, err := b.price(ctx, period, usage)
+	if err != nil {
+		return nil, false, err
+	}
+	result := newBillingResult(period, lines)
+
+	err = b.invoices.CreateInvoice(ctx, &invoice.InvoiceDTO{
+		Id:          period.InvoiceId,
+		ServiceName: period.ServiceName,
+		Amount:      float32(result.Amount),
+		Status:      invoiceStatus,
+		Date:        b.now().UTC(),
+	})
+	var cerr customError.CustomError
+	if err != nil && !(errors.As(err, &cerr) && cerr.Code == fiber.StatusConflict) {
+		return nil, false, err
+	}
+
+	if err = b.repository.CompleteBillingPeriod(ctx, period.InvoiceId, lines); err != nil {
+		return nil, false, err
+	}
+
+	completedAt := b.now().UTC()
+	result.CompletedAt = &completedAt
+
+	return result, true, nil
+}
+
+func (b *Biller) price(ctx context.Context, period *BillingPeriodDTO, usage []MetricUsage) ([]InvoiceLineDTO, error) {
+	plans, err := b.repository.GetServicePricePlans(ctx, period.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+
+	plansByMetric := make(map[string]PricePlanDTO, len(plans))
+	for _, plan := range plans {
+		plansByMetric[plan.Metric] = plan
+	}
+
+	lines := make([]InvoiceLineDTO, 0, len(usage))
+	for _, metric := range usage {
+		plan, ok := plansByMetric[metric.Metric]
+		if !ok {
+			return nil, customError.CustomError{
+				Code:     fiber.StatusUnprocessableEntity,
+				Message:  "no price plan for metric",
+				Severity: zap.WarnLevel,
+				Details:  fmt.Sprintf("%s %s", period.ServiceName, metric.Metric),
+			}
+		}
+
+		amount := Price(plan.Model, plan.Tiers, metric.Quantity)
+		lines = append(lines, InvoiceLineDTO{
+			InvoiceId: period.InvoiceId,
+			Metric:    metric.Metric,
+			Description: fmt.Sprintf(
+				"%s %s from %s to %s",
+				period.ServiceName,
+				metric.Metric,
+				period.PeriodStart.Format(time.DateOnly),
+				period.PeriodEnd.Format(time.DateOnly),
+			),
+			Quantity:  metric.Quantity,
+			UnitPrice: math.Round(amount/metric.Quantity*10000) / 10000,
+			Amount:    amount,
+		})
+	}
+
+	return lines, nil
+}
+
+func newBillingResult(period *BillingPeriodDTO, lines []InvoiceLineDTO) *BillingResult {
+	result := &BillingResult{
+		BillingPeriodDTO: *period,
+		Lines:            lines,
+	}
+	for _, line := range lines {
+		result.Amount = roundAmount(result.Amount + line.Amount)
+	}
+
+	return result
+}
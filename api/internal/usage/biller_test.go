@@ -0,0 +1,132 @@
+package usage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"invoice-api/internal/invoice"
+	customError "invoice-api/pkg/error"
+)
+
+func TestBiller_Close(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	now := time.Date(2024, 2, 1, 6, 0, 0, 0, time.UTC)
+	request := &CloseBillingPeriodRequest{
+		CustomerId:  "acme",
+		ServiceName: "DMP",
+		From:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:          time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+	}
+	period := &BillingPeriodDTO{
+		InvoiceId:   "5f0c2b8e-3f0a-4f5e-9d1a-6c1f3f1f8b11",
+		CustomerId:  "acme",
+		ServiceName: "DMP",
+		PeriodStart: request.From,
+		PeriodEnd:   request.To,
+		CreatedAt:   now,
+	}
+	plans := []PricePlanDTO{
+		{ServiceName: "DMP", Metric: "api_calls", Model: PricingTiered, Tiers: []Tier{{UpTo: upTo(1000), UnitPrice: 0.01}, {UnitPrice: 0.005}}},
+		{ServiceName: "DMP", Metric: "segments", Model: PricingVolume, Tiers: []Tier{{UnitPrice: 2}}},
+	}
+
+	t.Run("creates invoice with lines", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockInvoices := invoice.NewMockRepository(mockController)
+		mockRepository.EXPECT().ReserveBillingPeriod(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, reserved *BillingPeriodDTO) (*BillingPeriodDTO, error) {
+			assert.Equal(t, "acme", reserved.CustomerId)
+			assert.Equal(t, request.From, reserved.PeriodStart)
+			return period, nil
+		})
+		mockRepository.EXPECT().ClaimUsage(gomock.Any(), period).Return([]MetricUsage{{Metric: "api_calls", Quantity: 3000}, {Metric: "segments", Quantity: 4}}, nil)
+		mockRepository.EXPECT().GetServicePricePlans(gomock.Any(), "DMP").Return(plans, nil)
+		mockInvoices.EXPECT().CreateInvoice(gomock.Any(), &invoice.InvoiceDTO{
+			Id:          period.InvoiceId,
+			ServiceName: "DMP",
+			Amount:      28,
+			Status:      "UNPAID",
+			Date:        now,
+		}).Return(nil)
+		mockRepository.EXPECT().CompleteBillingPeriod(gomock.Any(), period.InvoiceId, gomock.Len(2)).Return(nil)
+
+		biller := NewBiller(mockRepository, mockInvoices)
+		biller.now = func() time.Time { return now }
+
+		result, created, err := biller.Close(context.TODO(), request)
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.Equal(t, 28.0, result.Amount)
+		require.Len(t, result.Lines, 2)
+		assert.Equal(t, InvoiceLineDTO{
+			InvoiceId:   period.InvoiceId,
+			Metric:      "api_calls",
+			Description: "DMP api_calls from 2024-01-01 to 2024-02-01",
+			Quantity:    3000,
+			UnitPrice:   0.0067,
+			Amount:      20,
+		}, result.Lines[0])
+		assert.Equal(t, 8.0, result.Lines[1].Amount)
+	})
+
+	t.Run("retry after the invoice was created", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockInvoices := invoice.NewMockRepository(mockController)
+		mockRepository.EXPECT().ReserveBillingPeriod(gomock.Any(), gomock.Any()).Return(period, nil)
+		mockRepository.EXPECT().ClaimUsage(gomock.Any(), period).Return([]MetricUsage{{Metric: "segments", Quantity: 4}}, nil)
+		mockRepository.EXPECT().GetServicePricePlans(gomock.Any(), "DMP").Return(plans, nil)
+		mockInvoices.EXPECT().CreateInvoice(gomock.Any(), gomock.Any()).Return(customError.CustomError{Code: fiber.StatusConflict})
+		mockRepository.EXPECT().CompleteBillingPeriod(gomock.Any(), period.InvoiceId, gomock.Len(1)).Return(nil)
+
+		_, created, err := NewBiller(mockRepository, mockInvoices).Close(context.TODO(), request)
+		require.NoError(t, err)
+		assert.True(t, created)
+	})
+
+	t.Run("already closed period", func(t *testing.T) {
+		completedAt := now
+		closed := *period
+		closed.CompletedAt = &completedAt
+
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().ReserveBillingPeriod(gomock.Any(), gomock.Any()).Return(&closed, nil)
+		mockRepository.EXPECT().GetInvoiceLines(gomock.Any(), period.InvoiceId).Return(&[]InvoiceLineDTO{{Metric: "segments", Amount: 8}}, nil)
+
+		result, created, err := NewBiller(mockRepository, nil).Close(context.TODO(), request)
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, 8.0, result.Amount)
+	})
+
+	t.Run("no usage", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().ReserveBillingPeriod(gomock.Any(), gomock.Any()).Return(period, nil)
+		mockRepository.EXPECT().ClaimUsage(gomock.Any(), period).Return(nil, nil)
+		mockRepository.EXPECT().ReleaseBillingPeriod(gomock.Any(), period.InvoiceId).Return(nil)
+
+		_, _, err := NewBiller(mockRepository, nil).Close(context.TODO(), request)
+		var cerr customError.CustomError
+		require.ErrorAs(t, err, &cerr)
+		assert.Equal(t, fiber.StatusUnprocessableEntity, cerr.Code)
+	})
+
+	t.Run("missing price plan", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().ReserveBillingPeriod(gomock.Any(), gomock.Any()).Return(period, nil)
+		mockRepository.EXPECT().ClaimUsage(gomock.Any(), period).Return([]MetricUsage{{Metric: "storage_gb", Quantity: 1}}, nil)
+		mockRepository.EXPECT().GetServicePricePlans(gomock.Any(), "DMP").Return(plans, nil)
+
+		_, _, err := NewBiller(mockRepository, nil).Close(context.TODO(), request)
+		var cerr customError.CustomError
+		require.ErrorAs(t, err, &cerr)
+		assert.Equal(t, fiber.StatusUnprocessableEntity, cerr.Code)
+		assert.Equal(t, "DMP storage_gb", cerr.Details)
+	})
+}
@@ -0,0 +1,280 @@
+package usage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"invoice-api/internal/invoice"
+	"invoice-api/pkg/auth"
+	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/requestcontext"
+	"invoice-api/pkg/tracing"
+)
+
+type Handler struct {
+	server     *fiber.App
+	validator  *validator.Validate
+	repository Repository
+	biller     *Biller
+	authorizer *auth.Authorizer
+}
+
+func NewHandler(server *fiber.App, validator *validator.Validate, repository Repository, biller *Biller, authorizer *auth.Authorizer) *Handler {
+	return &Handler{
+		server:     server,
+		validator:  validator,
+		repository: repository,
+		biller:     biller,
+		authorizer: authorizer,
+	}
+}
+
+func (h *Handler) RegisterRoutes() {
+	h.server.Post("/usage/events", h.authorizer.Require(PermissionIngest), h.IngestEvents)
+	h.server.Post("/billing-periods", h.authorizer.Require(PermissionCloseBilling), h.CloseBillingPeriod)
+	h.server.Post("/price-plans", h.authorizer.Require(PermissionWritePricePlans), h.CreatePricePlan)
+	h.server.Get("/price-plans", h.authorizer.Require(PermissionReadPricePlans), h.GetPricePlans)
+	h.server.Get("/price-plans/:id", h.authorizer.Require(PermissionReadPricePlans), h.GetPricePlanById)
+	h.server.Put("/price-plans/:id", h.authorizer.Require(PermissionWritePricePlans), h.UpdatePricePlanById)
+	h.server.Delete("/price-plans/:id", h.authorizer.Require(PermissionWritePricePlans), h.DeletePricePlanById)
+	h.server.Get("/invoices/:id/lines", h.authorizer.Require(invoice.PermissionRead), h.GetInvoiceLines)
+}
+
+func (h *Handler) IngestEvents(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "IngestEvents"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "usage.Handler.IngestEvents")()
+
+	var reqBody IngestEventsRequest
+	if err := h.parseBody(ctx, &reqBody); err != nil {
+		return err
+	}
+
+	accepted, err := h.repository.IngestEvents(ctx.UserContext(), reqBody.Events, time.Now().UTC().Truncate(time.Microsecond))
+	if err != nil {
+		return err
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	return ctx.Status(fiber.StatusAccepted).JSON(IngestEventsResponse{
+		Received: len(reqBody.Events),
+		Accepted: accepted,
+	})
+}
+
+func (h *Handler) CloseBillingPeriod(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "CloseBillingPeriod"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "usage.Handler.CloseBillingPeriod")()
+
+	var reqBody CloseBillingPeriodRequest
+	if err := h.parseBody(ctx, &reqBody); err != nil {
+		return err
+	}
+
+	result, created, err := h.biller.Close(ctx.UserContext(), &reqBody)
+	if err != nil {
+		return err
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	ctx.Location(fmt.Sprintf("/invoices/%s", result.InvoiceId))
+	if created {
+		return ctx.Status(fiber.StatusCreated).JSON(result)
+	}
+	return ctx.JSON(result)
+}
+
+func (h *Handler) CreatePricePlan(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "CreatePricePlan"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "usage.Handler.CreatePricePlan")()
+
+	var reqBody PricePlanRequest
+	if err := h.parsePricePlan(ctx, &reqBody); err != nil {
+		return err
+	}
+
+	plan := &PricePlanDTO{
+		Id:          uuid.NewString(),
+		ServiceName: reqBody.ServiceName,
+		Metric:      reqBody.Metric,
+		Model:       reqBody.Model,
+		Tiers:       reqBody.Tiers,
+		CreatedAt:   time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if err := h.repository.CreatePricePlan(ctx.UserContext(), plan); err != nil {
+		return err
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	ctx.Location(fmt.Sprintf("/price-plans/%s", plan.Id))
+	return ctx.Status(fiber.StatusCreated).JSON(plan)
+}
+
+func (h *Handler) GetPricePlans(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "GetPricePlans"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "usage.Handler.GetPricePlans")()
+
+	plans, err := h.repository.GetPricePlans(ctx.UserContext())
+	if err != nil {
+		return err
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	return ctx.JSON(plans)
+}
+
+func (h *Handler) GetPricePlanById(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "GetPricePlanById"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "usage.Handler.GetPricePlanById")()
+
+	id, err := h.id(ctx, "invalid price plan id")
+	if err != nil {
+		return err
+	}
+
+	plan, err := h.repository.GetPricePlanById(ctx.UserContext(), id)
+	if err != nil {
+		return err
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	return ctx.JSON(plan)
+}
+
+func (h *Handler) UpdatePricePlanById(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "UpdatePricePlanById"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "usage.Handler.UpdatePricePlanById")()
+
+	id, err := h.id(ctx, "invalid price plan id")
+	if err != nil {
+		return err
+	}
+
+	var reqBody PricePlanRequest
+	if err = h.parsePricePlan(ctx, &reqBody); err != nil {
+		return err
+	}
+
+	plan, err := h.repository.GetPricePlanById(ctx.UserContext(), id)
+	if err != nil {
+		return err
+	}
+	plan.ServiceName = reqBody.ServiceName
+	plan.Metric = reqBody.Metric
+	plan.Model = reqBody.Model
+	plan.Tiers = reqBody.Tiers
+
+	if err = h.repository.UpdatePricePlan(ctx.UserContext(), plan); err != nil {
+		return err
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	return ctx.JSON(plan)
+}
+
+func (h *Handler) DeletePricePlanById(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "DeletePricePlanById"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "usage.Handler.DeletePricePlanById")()
+
+	id, err := h.id(ctx, "invalid price plan id")
+	if err != nil {
+		return err
+	}
+
+	if err = h.repository.DeletePricePlanById(ctx.UserContext(), id); err != nil {
+		return err
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) GetInvoiceLines(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "GetInvoiceLines"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "usage.Handler.GetInvoiceLines")()
+
+	id, err := h.id(ctx, "invalid invoice id")
+	if err != nil {
+		return err
+	}
+
+	lines, err := h.repository.GetInvoiceLines(ctx.UserContext(), id)
+	if err != nil {
+		return err
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	return ctx.JSON(lines)
+}
+
+func (h *Handler) parsePricePlan(ctx *fiber.Ctx, reqBody *PricePlanRequest) error {
+	if err := h.parseBody(ctx, reqBody); err != nil {
+		return err
+	}
+
+	if err := ValidateTiers(reqBody.Tiers); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid request body",
+			Severity: zap.WarnLevel,
+			Details:  err.Error(),
+		}
+	}
+
+	return nil
+}
+
+func (h *Handler) parseBody(ctx *fiber.Ctx, reqBody any) error {
+	if err := ctx.BodyParser(reqBody); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid request body",
+			Severity: zap.WarnLevel,
+		}
+	}
+
+	if err := h.validator.StructCtx(ctx.UserContext(), reqBody); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid request body",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return nil
+}
+
+func (h *Handler) id(ctx *fiber.Ctx, message string) (string, error) {
+	id := ctx.Params("id")
+	if err := h.validator.VarCtx(ctx.UserContext(), id, "required,uuid4"); err != nil {
+		return "", customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  message,
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return id, nil
+}
@@ -0,0 +1,333 @@
+package usage
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	json "github.com/bytedance/sonic"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+
+	"invoice-api/internal/invoice"
+	"invoice-api/pkg/auth"
+	customError "invoice-api/pkg/error"
+)
+
+const (
+	planId    = "0d4f7e3a-8b1c-4c2d-9e5f-1a2b3c4d5e6f"
+	invoiceId = "5f0c2b8e-3f0a-4f5e-9d1a-6c1f3f1f8b11"
+)
+
+func TestHandler_IngestEvents(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("happy path", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().IngestEvents(gomock.Any(), gomock.Len(2), gomock.Any()).Return(int64(1), nil)
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		req := httptest.NewRequest(
+			http.MethodPost,
+			"/usage/events",
+			strings.NewReader(`{"events":[
+				{"id":"evt-1","customerId":"acme","serviceName":"DMP","metric":"api_calls","quantity":120,"timestamp":"2024-01-15T10:00:00Z"},
+				{"id":"evt-1","customerId":"acme","serviceName":"DMP","metric":"api_calls","quantity":120,"timestamp":"2024-01-15T10:00:00Z"}
+			]}`),
+		)
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusAccepted, res.StatusCode)
+
+		var response IngestEventsResponse
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &response))
+		assert.Equal(t, IngestEventsResponse{Received: 2, Accepted: 1}, response)
+	})
+
+	t.Run("invalid request body", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, nil, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		bodies := []string{
+			`{"events":[]}`,
+			`{"events":[{"customerId":"acme","serviceName":"DMP","metric":"api_calls","quantity":1,"timestamp":"2024-01-15T10:00:00Z"}]}`,
+			`{"events":[{"id":"evt-1","customerId":"acme","serviceName":"XYZ","metric":"api_calls","quantity":1,"timestamp":"2024-01-15T10:00:00Z"}]}`,
+			`{"events":[{"id":"evt-1","customerId":"acme","serviceName":"DMP","metric":"api_calls","quantity":0,"timestamp":"2024-01-15T10:00:00Z"}]}`,
+			`{"events":[{"id":"evt-1","customerId":"acme","serviceName":"DMP","metric":"api_calls","quantity":1}]}`,
+			`{`,
+		}
+		for _, body := range bodies {
+			req := httptest.NewRequest(http.MethodPost, "/usage/events", strings.NewReader(body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+			res, err := server.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, fiber.StatusBadRequest, res.StatusCode, body)
+		}
+	})
+}
+
+func TestHandler_CloseBillingPeriod(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	period := &BillingPeriodDTO{
+		InvoiceId:   invoiceId,
+		CustomerId:  "acme",
+		ServiceName: "SSP",
+		PeriodStart: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		PeriodEnd:   time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+	}
+	body := `{"customerId":"acme","serviceName":"SSP","from":"2024-01-01T00:00:00Z","to":"2024-02-01T00:00:00Z"}`
+
+	t.Run("happy path", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockInvoices := invoice.NewMockRepository(mockController)
+		mockRepository.EXPECT().ReserveBillingPeriod(gomock.Any(), gomock.Any()).Return(period, nil)
+		mockRepository.EXPECT().ClaimUsage(gomock.Any(), period).Return([]MetricUsage{{Metric: "impressions", Quantity: 10}}, nil)
+		mockRepository.EXPECT().GetServicePricePlans(gomock.Any(), "SSP").Return([]PricePlanDTO{
+			{Metric: "impressions", Model: PricingVolume, Tiers: []Tier{{UnitPrice: 1.5}}},
+		}, nil)
+		mockInvoices.EXPECT().CreateInvoice(gomock.Any(), gomock.Any()).Return(nil)
+		mockRepository.EXPECT().CompleteBillingPeriod(gomock.Any(), invoiceId, gomock.Any()).Return(nil)
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, NewBiller(mockRepository, mockInvoices), testAuthorizer)
+		h.RegisterRoutes()
+
+		req := httptest.NewRequest(http.MethodPost, "/billing-periods", strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusCreated, res.StatusCode)
+		assert.Equal(t, "/invoices/"+invoiceId, res.Header.Get(fiber.HeaderLocation))
+
+		var result BillingResult
+		responseBody, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(responseBody, &result))
+		assert.Equal(t, 15.0, result.Amount)
+		assert.NotNil(t, result.CompletedAt)
+	})
+
+	t.Run("overlapping period", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().ReserveBillingPeriod(gomock.Any(), gomock.Any()).Return(nil, customError.CustomError{
+			Code:     fiber.StatusConflict,
+			Message:  "billing period overlaps an existing billing period",
+			Severity: zap.WarnLevel,
+		})
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, NewBiller(mockRepository, nil), testAuthorizer)
+		h.RegisterRoutes()
+
+		req := httptest.NewRequest(http.MethodPost, "/billing-periods", strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusConflict, res.StatusCode)
+	})
+
+	t.Run("invalid request body", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, nil, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		req := httptest.NewRequest(
+			http.MethodPost,
+			"/billing-periods",
+			strings.NewReader(`{"customerId":"acme","serviceName":"SSP","from":"2024-02-01T00:00:00Z","to":"2024-01-01T00:00:00Z"}`),
+		)
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+	})
+}
+
+func TestHandler_CreatePricePlan(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("happy path", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().CreatePricePlan(gomock.Any(), gomock.Any()).Return(nil)
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		req := httptest.NewRequest(
+			http.MethodPost,
+			"/price-plans",
+			strings.NewReader(`{"serviceName":"DMP","metric":"api_calls","model":"TIERED","tiers":[{"upTo":1000,"unitPrice":0.01},{"unitPrice":0.005}]}`),
+		)
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusCreated, res.StatusCode)
+
+		var plan PricePlanDTO
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &plan))
+		assert.Len(t, plan.Tiers, 2)
+		assert.Equal(t, "/price-plans/"+plan.Id, res.Header.Get(fiber.HeaderLocation))
+	})
+
+	t.Run("invalid request body", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, nil, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		bodies := []string{
+			`{"serviceName":"DMP","metric":"api_calls","model":"FLAT","tiers":[{"unitPrice":1}]}`,
+			`{"serviceName":"DMP","metric":"api_calls","model":"TIERED","tiers":[]}`,
+			`{"serviceName":"DMP","metric":"api_calls","model":"TIERED","tiers":[{"upTo":1000,"unitPrice":0.01}]}`,
+			`{"serviceName":"DMP","metric":"api_calls","model":"TIERED","tiers":[{"unitPrice":-1}]}`,
+		}
+		for _, body := range bodies {
+			req := httptest.NewRequest(http.MethodPost, "/price-plans", strings.NewReader(body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+			res, err := server.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, fiber.StatusBadRequest, res.StatusCode, body)
+		}
+	})
+}
+
+func TestHandler_PricePlanById(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("get", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetPricePlanById(gomock.Any(), planId).Return(&PricePlanDTO{Id: planId}, nil)
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, "/price-plans/"+planId, nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetPricePlanById(gomock.Any(), planId).Return(&PricePlanDTO{Id: planId, Model: PricingTiered}, nil)
+		mockRepository.EXPECT().UpdatePricePlan(gomock.Any(), gomock.Any()).DoAndReturn(func(_ any, plan *PricePlanDTO) error {
+			assert.Equal(t, PricingVolume, plan.Model)
+			return nil
+		})
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		req := httptest.NewRequest(
+			http.MethodPut,
+			"/price-plans/"+planId,
+			strings.NewReader(`{"serviceName":"DMP","metric":"api_calls","model":"VOLUME","tiers":[{"unitPrice":0.01}]}`),
+		)
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().DeletePricePlanById(gomock.Any(), planId).Return(nil)
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		res, err := server.Test(httptest.NewRequest(http.MethodDelete, "/price-plans/"+planId, nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusNoContent, res.StatusCode)
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, nil, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, "/price-plans/invalid", nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+	})
+}
+
+func TestHandler_GetInvoiceLines(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	mockRepository := NewMockRepository(mockController)
+	mockRepository.EXPECT().GetInvoiceLines(gomock.Any(), invoiceId).Return(&[]InvoiceLineDTO{{InvoiceId: invoiceId, Metric: "api_calls"}}, nil)
+
+	server, validate := SetupServer(t)
+	h := NewHandler(server, validate, mockRepository, nil, testAuthorizer)
+	h.RegisterRoutes()
+
+	res, err := server.Test(httptest.NewRequest(http.MethodGet, "/invoices/"+invoiceId+"/lines", nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, res.StatusCode)
+
+	var lines []InvoiceLineDTO
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(body, &lines))
+	assert.Len(t, lines, 1)
+}
+
+var testAuthorizer = auth.NewAuthorizer(auth.Policy{"admin": {auth.AllPermissions}})
+
+func SetupServer(t *testing.T) (*fiber.App, *validator.Validate) {
+	server := fiber.New(fiber.Config{
+		JSONDecoder:           json.Unmarshal,
+		JSONEncoder:           json.Marshal,
+		ErrorHandler:          customError.ErrorHandler,
+		DisableStartupMessage: true,
+	})
+
+	log, _ := zap.NewProduction()
+	defer func(log *zap.Logger) {
+		err := log.Sync()
+		if err != nil {
+			assert.FailNow(t, err.Error())
+		}
+	}(log)
+
+	server.Use(func(c *fiber.Ctx) error {
+		c.Locals("log", log)
+		c.Locals(auth.ContextKeyRoles, []string{"admin"})
+		return c.Next()
+	})
+
+	return server, validator.New()
+}
@@ -0,0 +1,103 @@
+package usage
+
+import (
+	"context"
+	"time"
+
+	"invoice-api/pkg/metrics"
+	"invoice-api/pkg/tracing"
+)
+
+const repositoryName = "usage"
+
+type InstrumentedRepository struct {
+	repository Repository
+}
+
+func NewInstrumentedRepository(repository Repository) *InstrumentedRepository {
+	return &InstrumentedRepository{repository: repository}
+}
+
+func (r *InstrumentedRepository) IngestEvents(ctx context.Context, events []EventRequest, receivedAt time.Time) (_ int64, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "IngestEvents")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "IngestEvents", time.Now(), &err)
+	return r.repository.IngestEvents(ctx, events, receivedAt)
+}
+
+func (r *InstrumentedRepository) CreatePricePlan(ctx context.Context, plan *PricePlanDTO) (err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "CreatePricePlan")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "CreatePricePlan", time.Now(), &err)
+	return r.repository.CreatePricePlan(ctx, plan)
+}
+
+func (r *InstrumentedRepository) GetPricePlans(ctx context.Context) (_ *[]PricePlanDTO, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "GetPricePlans")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "GetPricePlans", time.Now(), &err)
+	return r.repository.GetPricePlans(ctx)
+}
+
+func (r *InstrumentedRepository) GetPricePlanById(ctx context.Context, id string) (_ *PricePlanDTO, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "GetPricePlanById")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "GetPricePlanById", time.Now(), &err)
+	return r.repository.GetPricePlanById(ctx, id)
+}
+
+func (r *InstrumentedRepository) UpdatePricePlan(ctx context.Context, plan *PricePlanDTO) (err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "UpdatePricePlan")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "UpdatePricePlan", time.Now(), &err)
+	return r.repository.UpdatePricePlan(ctx, plan)
+}
+
+func (r *InstrumentedRepository) DeletePricePlanById(ctx context.Context, id string) (err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "DeletePricePlanById")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "DeletePricePlanById", time.Now(), &err)
+	return r.repository.DeletePricePlanById(ctx, id)
+}
+
+func (r *InstrumentedRepository) GetServicePricePlans(ctx context.Context, serviceName string) (_ []PricePlanDTO, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "GetServicePricePlans")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "GetServicePricePlans", time.Now(), &err)
+	return r.repository.GetServicePricePlans(ctx, serviceName)
+}
+
+func (r *InstrumentedRepository) ReserveBillingPeriod(ctx context.Context, period *BillingPeriodDTO) (_ *BillingPeriodDTO, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "ReserveBillingPeriod")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "ReserveBillingPeriod", time.Now(), &err)
+	return r.repository.ReserveBillingPeriod(ctx, period)
+}
+
+func (r *InstrumentedRepository) ClaimUsage(ctx context.Context, period *BillingPeriodDTO) (_ []MetricUsage, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "ClaimUsage")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "ClaimUsage", time.Now(), &err)
+	return r.repository.ClaimUsage(ctx, period)
+}
+
+func (r *InstrumentedRepository) ReleaseBillingPeriod(ctx context.Context, invoiceId string) (err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "ReleaseBillingPeriod")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "ReleaseBillingPeriod", time.Now(), &err)
+	return r.repository.ReleaseBillingPeriod(ctx, invoiceId)
+}
+
+func (r *InstrumentedRepository) CompleteBillingPeriod(ctx context.Context, invoiceId string, lines []InvoiceLineDTO) (err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "CompleteBillingPeriod")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "CompleteBillingPeriod", time.Now(), &err)
+	return r.repository.CompleteBillingPeriod(ctx, invoiceId, lines)
+}
+
+func (r *InstrumentedRepository) GetInvoiceLines(ctx context.Context, invoiceId string) (_ *[]InvoiceLineDTO, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "GetInvoiceLines")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "GetInvoiceLines", time.Now(), &err)
+	return r.repository.GetInvoiceLines(ctx, invoiceId)
+}
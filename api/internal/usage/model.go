@@ -0,0 +1,87 @@
+package usage
+
+import (
+	"time"
+)
+
+const (
+	PricingTiered = "TIERED"
+	PricingVolume = "VOLUME"
+)
+
+type EventRequest struct {
+	Id          string    `json:"id" validate:"required,max=128"`
+	CustomerId  string    `json:"customerId" validate:"required,max=128"`
+	ServiceName string    `json:"serviceName" validate:"required,oneof=DMP SSP"`
+	Metric      string    `json:"metric" validate:"required,max=64"`
+	Quantity    float64   `json:"quantity" validate:"gt=0"`
+	Timestamp   time.Time `json:"timestamp" validate:"required"`
+}
+
+type IngestEventsRequest struct {
+	Events []EventRequest `json:"events" validate:"required,min=1,max=1000,dive"`
+}
+
+type IngestEventsResponse struct {
+	Received int   `json:"received"`
+	Accepted int64 `json:"accepted"`
+}
+
+type Tier struct {
+	UpTo      *float64 `json:"upTo,omitempty" validate:"omitempty,gt=0"`
+	UnitPrice float64  `json:"unitPrice" validate:"gte=0"`
+	FlatFee   float64  `json:"flatFee,omitempty" validate:"gte=0"`
+}
+
+type PricePlanRequest struct {
+	ServiceName string `json:"serviceName" validate:"required,oneof=DMP SSP"`
+	Metric      string `json:"metric" validate:"required,max=64"`
+	Model       string `json:"model" validate:"required,oneof=TIERED VOLUME"`
+	Tiers       []Tier `json:"tiers" validate:"required,min=1,max=20,dive"`
+}
+
+type PricePlanDTO struct {
+	Id          string    `json:"id" db:"id"`
+	ServiceName string    `json:"serviceName" db:"service_name"`
+	Metric      string    `json:"metric" db:"metric"`
+	Model       string    `json:"model" db:"model"`
+	Tiers       []Tier    `json:"tiers" db:"tiers"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+}
+
+type CloseBillingPeriodRequest struct {
+	CustomerId  string    `json:"customerId" validate:"required,max=128"`
+	ServiceName string    `json:"serviceName" validate:"required,oneof=DMP SSP"`
+	From        time.Time `json:"from" validate:"required"`
+	To          time.Time `json:"to" validate:"required,gtfield=From"`
+}
+
+type BillingPeriodDTO struct {
+	InvoiceId   string     `json:"invoiceId" db:"invoice_id"`
+	CustomerId  string     `json:"customerId" db:"customer_id"`
+	ServiceName string     `json:"serviceName" db:"service_name"`
+	PeriodStart time.Time  `json:"periodStart" db:"period_start"`
+	PeriodEnd   time.Time  `json:"periodEnd" db:"period_end"`
+	CompletedAt *time.Time `json:"completedAt,omitempty" db:"completed_at"`
+	CreatedAt   time.Time  `json:"createdAt" db:"created_at"`
+}
+
+type MetricUsage struct {
+	Metric   string  `db:"metric"`
+	Quantity float64 `db:"quantity"`
+}
+
+type InvoiceLineDTO struct {
+	InvoiceId   string  `json:"invoiceId" db:"invoice_id"`
+	Metric      string  `json:"metric" db:"metric"`
+	Description string  `json:"description" db:"description"`
+	Quantity    float64 `json:"quantity" db:"quantity"`
+	UnitPrice   float64 `json:"unitPrice" db:"unit_price"`
+	Amount      float64 `json:"amount" db:"amount"`
+}
+
+type BillingResult struct {
+	BillingPeriodDTO
+	Amount float64          `json:"amount"`
+	Lines  []InvoiceLineDTO `json:"lines"`
+}
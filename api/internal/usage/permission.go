@@ -0,0 +1,8 @@
+package usage
+
+const (
+	PermissionIngest          = "usage:write"
+	PermissionCloseBilling    = "billing-periods:write"
+	PermissionReadPricePlans  = "price-plans:read"
+	PermissionWritePricePlans = "price-plans:write"
+)
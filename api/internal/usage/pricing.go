@@ -0,0 +1,55 @@
+package usage
+
+import (
+	"errors"
+	"math"
+)
+
+var errInvalidTiers = errors.New("tiers must have ascending upTo limits and end with a tier without upTo")
+
+func ValidateTiers(tiers []Tier) error {
+	for i, tier := range tiers {
+		if (tier.UpTo == nil) != (i == len(tiers)-1) {
+			return errInvalidTiers
+		}
+		if tier.UpTo != nil && i > 0 && *tier.UpTo <= *tiers[i-1].UpTo {
+			return errInvalidTiers
+		}
+	}
+
+	return nil
+}
+
+func Price(model string, tiers []Tier, quantity float64) float64 {
+	if model == PricingVolume {
+		for _, tier := range tiers {
+			if tier.UpTo == nil || quantity <= *tier.UpTo {
+				return roundAmount(quantity*tier.UnitPrice + tier.FlatFee)
+			}
+		}
+		return 0
+	}
+
+	var amount, lower float64
+	for _, tier := range tiers {
+		if quantity <= lower {
+			break
+		}
+
+		upper := quantity
+		if tier.UpTo != nil {
+			upper = math.Min(quantity, *tier.UpTo)
+		}
+		amount += (upper-lower)*tier.UnitPrice + tier.FlatFee
+		if tier.UpTo == nil {
+			break
+		}
+		lower = *tier.UpTo
+	}
+
+	return roundAmount(amount)
+}
+
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
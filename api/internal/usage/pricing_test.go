@@ -0,0 +1,39 @@
+package usage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func upTo(value float64) *float64 {
+	return &value
+}
+
+func TestValidateTiers(t *testing.T) {
+	assert.NoError(t, ValidateTiers([]Tier{{UnitPrice: 1}}))
+	assert.NoError(t, ValidateTiers([]Tier{{UpTo: upTo(10), UnitPrice: 1}, {UnitPrice: 0.5}}))
+	assert.Error(t, ValidateTiers([]Tier{{UpTo: upTo(10), UnitPrice: 1}}))
+	assert.Error(t, ValidateTiers([]Tier{{UnitPrice: 1}, {UnitPrice: 0.5}}))
+	assert.Error(t, ValidateTiers([]Tier{{UpTo: upTo(10), UnitPrice: 1}, {UpTo: upTo(10), UnitPrice: 0.5}, {UnitPrice: 0.1}}))
+}
+
+func TestPrice(t *testing.T) {
+	tiers := []Tier{
+		{UpTo: upTo(1000), UnitPrice: 0.01},
+		{UpTo: upTo(10000), UnitPrice: 0.008},
+		{UnitPrice: 0.005},
+	}
+
+	assert.Equal(t, 5.0, Price(PricingTiered, tiers, 500))
+	assert.Equal(t, 92.0, Price(PricingTiered, tiers, 12000))
+	assert.Equal(t, 5.0, Price(PricingVolume, tiers, 500))
+	assert.Equal(t, 40.0, Price(PricingVolume, tiers, 5000))
+	assert.Equal(t, 60.0, Price(PricingVolume, tiers, 12000))
+
+	withFee := []Tier{{UpTo: upTo(100), FlatFee: 10}, {UnitPrice: 0.5}}
+	assert.Equal(t, 10.0, Price(PricingTiered, withFee, 50))
+	assert.Equal(t, 35.0, Price(PricingTiered, withFee, 150))
+	assert.Equal(t, 75.0, Price(PricingVolume, withFee, 150))
+	assert.Equal(t, 0.0, Price(PricingTiered, withFee, 0))
+}
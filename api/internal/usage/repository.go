@@ -0,0 +1,903 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/requestcontext"
+	"invoice-api/pkg/tenant"
+	"invoice-api/pkg/tracing"
+)
+
+const (
+	pgUniqueViolation = "23505"
+
+	pricePlanColumns     = "id, service_name, metric, model, tiers, created_at"
+	billingPeriodColumns = "invoice_id, customer_id, service_name, period_start, period_end, completed_at, created_at"
+	invoiceLineColumns   = "invoice_id, metric, description, quantity, unit_price, amount"
+)
+
+type Repository interface {
+	IngestEvents(ctx context.Context, events []EventRequest, receivedAt time.Time) (int64, error)
+	CreatePricePlan(ctx context.Context, plan *PricePlanDTO) error
+	GetPricePlans(ctx context.Context) (*[]PricePlanDTO, error)
+	GetPricePlanById(ctx context.Context, id string) (*PricePlanDTO, error)
+	UpdatePricePlan(ctx context.Context, plan *PricePlanDTO) error
+	DeletePricePlanById(ctx context.Context, id string) error
+	GetServicePricePlans(ctx context.Context, serviceName string) ([]PricePlanDTO, error)
+	ReserveBillingPeriod(ctx context.Context, period *BillingPeriodDTO) (*BillingPeriodDTO, error)
+	ClaimUsage(ctx context.Context, period *BillingPeriodDTO) ([]MetricUsage, error)
+	ReleaseBillingPeriod(ctx context.Context, invoiceId string) error
+	CompleteBillingPeriod(ctx context.Context, invoiceId string, lines []InvoiceLineDTO) error
+	GetInvoiceLines(ctx context.Context, invoiceId string) (*[]InvoiceLineDTO, error)
+}
+
+type PgRepository struct {
+	connectionPool *pgxpool.Pool
+}
+
+func NewPgRepository(log *zap.Logger, host, port, username, password, database string) *PgRepository {
+	credentials := fmt.Sprintf("user=%s password=%s host=%s port=%s dbname=%s sslmode=disable", username, password, host, port, database)
+	pgConfig, err := pgxpool.ParseConfig(credentials)
+	if err != nil {
+		log.Fatal("failed to parse database config", zap.Error(err))
+	}
+	pgConfig.AfterConnect = tenant.AfterConnect
+	pgConfig.ConnConfig.Tracer = tracing.QueryTracer{}
+
+	var pgConnectionPool *pgxpool.Pool
+	pgConnectionPool, err = pgxpool.NewWithConfig(context.Background(), pgConfig)
+	if err != nil {
+		log.Fatal("failed to connect database", zap.Error(err))
+	}
+
+	var connection *pgxpool.Conn
+	connection, err = pgConnectionPool.Acquire(context.Background())
+	if err != nil {
+		log.Fatal("failed to acquire connection", zap.Error(err))
+	}
+	defer connection.Release()
+
+	err = connection.Ping(context.Background())
+	if err != nil {
+		log.Fatal("failed to ping database", zap.Error(err))
+	}
+
+	return &PgRepository{
+		connectionPool: pgConnectionPool,
+	}
+}
+
+func (r *PgRepository) Stat() *pgxpool.Stat {
+	return r.connectionPool.Stat()
+}
+
+func (r *PgRepository) Ping(ctx context.Context) error {
+	return r.connectionPool.Ping(ctx)
+}
+
+func (r *PgRepository) IngestEvents(ctx context.Context, events []EventRequest, receivedAt time.Time) (int64, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return 0, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	batch := &pgx.Batch{}
+	for _, event := range events {
+		batch.Queue(
+			`insert into usage_events (id, customer_id, service_name, metric, quantity, occurred_at, received_at)
+			select $1, $2, $3::invoice_service_name, $4, $5, $6::timestamp, $7
+			where not exists (
+				select 1 from billing_periods
+				where customer_id = $2 and service_name = $3::invoice_service_name and period_start <= $6::timestamp and period_end > $6::timestamp
+			)
+			on conflict (tenant_id, customer_id, id) do nothing`,
+			event.Id,
+			event.CustomerId,
+			event.ServiceName,
+			event.Metric,
+			event.Quantity,
+			event.Timestamp.UTC(),
+			receivedAt,
+		)
+	}
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return 0, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	results := tx.SendBatch(ctx, batch)
+	var accepted int64
+	for range events {
+		tag, err := results.Exec()
+		if err != nil {
+			_ = results.Close()
+			return 0, customError.CustomError{
+				Code:     fiber.StatusInternalServerError,
+				Message:  "failed to ingest usage events",
+				Severity: zap.ErrorLevel,
+				Fields:   []zap.Field{zap.Error(err)},
+			}
+		}
+		accepted += tag.RowsAffected()
+	}
+	if err = results.Close(); err != nil {
+		return 0, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to ingest usage events",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return accepted, nil
+}
+
+func (r *PgRepository) CreatePricePlan(ctx context.Context, plan *PricePlanDTO) error {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	if _, err = tx.Exec(
+		ctx,
+		"insert into price_plans ("+pricePlanColumns+") values ($1, $2, $3, $4, $5, $6)",
+		plan.Id,
+		plan.ServiceName,
+		plan.Metric,
+		plan.Model,
+		plan.Tiers,
+		plan.CreatedAt,
+	); err != nil {
+		return pricePlanWriteError(err, "failed to create price plan")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return nil
+}
+
+func (r *PgRepository) GetPricePlans(ctx context.Context) (*[]PricePlanDTO, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	var rows pgx.Rows
+	rows, err = tx.Query(ctx, "select "+pricePlanColumns+" from price_plans order by service_name, metric")
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get price plans",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var plans []PricePlanDTO
+	plans, err = pgx.CollectRows(rows, pgx.RowToStructByPos[PricePlanDTO])
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect price plans",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return &plans, nil
+}
+
+func (r *PgRepository) GetPricePlanById(ctx context.Context, id string) (*PricePlanDTO, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	var rows pgx.Rows
+	rows, err = tx.Query(ctx, "select "+pricePlanColumns+" from price_plans where id = $1", id)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get price plan",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	plan, err := pgx.CollectOneRow(rows, pgx.RowToStructByPos[PricePlanDTO])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, customError.CustomError{
+				Code:     fiber.StatusNotFound,
+				Message:  "price plan not found",
+				Severity: zap.WarnLevel,
+			}
+		}
+
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect price plan",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return &plan, nil
+}
+
+func (r *PgRepository) UpdatePricePlan(ctx context.Context, plan *PricePlanDTO) error {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	tag, err := tx.Exec(
+		ctx,
+		"update price_plans set service_name = $1, metric = $2, model = $3, tiers = $4 where id = $5",
+		plan.ServiceName,
+		plan.Metric,
+		plan.Model,
+		plan.Tiers,
+		plan.Id,
+	)
+	if err != nil {
+		return pricePlanWriteError(err, "failed to update price plan")
+	}
+
+	if tag.RowsAffected() == 0 {
+		return customError.CustomError{
+			Code:     fiber.StatusNotFound,
+			Message:  "price plan not found",
+			Severity: zap.WarnLevel,
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return nil
+}
+
+func (r *PgRepository) DeletePricePlanById(ctx context.Context, id string) error {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	tag, err := tx.Exec(ctx, "delete from price_plans where id = $1", id)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to delete price plan",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if tag.RowsAffected() == 0 {
+		return customError.CustomError{
+			Code:     fiber.StatusNotFound,
+			Message:  "price plan not found",
+			Severity: zap.WarnLevel,
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return nil
+}
+
+func (r *PgRepository) GetServicePricePlans(ctx context.Context, serviceName string) ([]PricePlanDTO, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	var rows pgx.Rows
+	rows, err = tx.Query(ctx, "select "+pricePlanColumns+" from price_plans where service_name = $1", serviceName)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get price plans",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var plans []PricePlanDTO
+	plans, err = pgx.CollectRows(rows, pgx.RowToStructByPos[PricePlanDTO])
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect price plans",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return plans, nil
+}
+
+func (r *PgRepository) ReserveBillingPeriod(ctx context.Context, period *BillingPeriodDTO) (*BillingPeriodDTO, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	if _, err = tx.Exec(ctx, "select pg_advisory_xact_lock(hashtext(current_tenant() || '/' || $1 || '/' || $2))", period.CustomerId, period.ServiceName); err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to lock billing periods",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var rows pgx.Rows
+	rows, err = tx.Query(
+		ctx,
+		`select `+billingPeriodColumns+` from billing_periods
+		where customer_id = $1 and service_name = $2 and period_start < $4 and period_end > $3`,
+		period.CustomerId,
+		period.ServiceName,
+		period.PeriodStart.UTC(),
+		period.PeriodEnd.UTC(),
+	)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get billing periods",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var existing []BillingPeriodDTO
+	existing, err = pgx.CollectRows(rows, pgx.RowToStructByPos[BillingPeriodDTO])
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect billing periods",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	for _, other := range existing {
+		if other.PeriodStart.Equal(period.PeriodStart.UTC()) && other.PeriodEnd.Equal(period.PeriodEnd.UTC()) {
+			return &other, nil
+		}
+	}
+	if len(existing) > 0 {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusConflict,
+			Message:  "billing period overlaps an existing billing period",
+			Severity: zap.WarnLevel,
+			Details:  existing,
+		}
+	}
+
+	if _, err = tx.Exec(
+		ctx,
+		"insert into billing_periods ("+billingPeriodColumns+") values ($1, $2, $3, $4, $5, $6, $7)",
+		period.InvoiceId,
+		period.CustomerId,
+		period.ServiceName,
+		period.PeriodStart.UTC(),
+		period.PeriodEnd.UTC(),
+		period.CompletedAt,
+		period.CreatedAt,
+	); err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to create billing period",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return period, nil
+}
+
+func (r *PgRepository) ClaimUsage(ctx context.Context, period *BillingPeriodDTO) ([]MetricUsage, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	if _, err = tx.Exec(
+		ctx,
+		`update usage_events set invoice_id = $1
+		where customer_id = $2 and service_name = $3 and occurred_at >= $4 and occurred_at < $5 and invoice_id is null`,
+		period.InvoiceId,
+		period.CustomerId,
+		period.ServiceName,
+		period.PeriodStart.UTC(),
+		period.PeriodEnd.UTC(),
+	); err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to claim usage events",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var rows pgx.Rows
+	rows, err = tx.Query(
+		ctx,
+		"select metric, sum(quantity) as quantity from usage_events where invoice_id = $1 group by metric order by metric",
+		period.InvoiceId,
+	)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to aggregate usage events",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var usage []MetricUsage
+	usage, err = pgx.CollectRows(rows, pgx.RowToStructByPos[MetricUsage])
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect usage",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return usage, nil
+}
+
+func (r *PgRepository) ReleaseBillingPeriod(ctx context.Context, invoiceId string) error {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	if _, err = tx.Exec(
+		ctx,
+		`delete from billing_periods where invoice_id = $1 and completed_at is null
+		and not exists (select 1 from usage_events where invoice_id = $1)`,
+		invoiceId,
+	); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to release billing period",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return nil
+}
+
+func (r *PgRepository) CompleteBillingPeriod(ctx context.Context, invoiceId string, lines []InvoiceLineDTO) error {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	for _, line := range lines {
+		if _, err = tx.Exec(
+			ctx,
+			"insert into invoice_lines ("+invoiceLineColumns+") values ($1, $2, $3, $4, $5, $6) on conflict (invoice_id, metric) do nothing",
+			invoiceId,
+			line.Metric,
+			line.Description,
+			line.Quantity,
+			line.UnitPrice,
+			line.Amount,
+		); err != nil {
+			return customError.CustomError{
+				Code:     fiber.StatusInternalServerError,
+				Message:  "failed to create invoice line",
+				Severity: zap.ErrorLevel,
+				Fields:   []zap.Field{zap.Error(err)},
+			}
+		}
+	}
+
+	if _, err = tx.Exec(
+		ctx,
+		"update billing_periods set completed_at = $1 where invoice_id = $2 and completed_at is null",
+		time.Now().UTC(),
+		invoiceId,
+	); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to complete billing period",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return nil
+}
+
+func (r *PgRepository) GetInvoiceLines(ctx context.Context, invoiceId string) (*[]InvoiceLineDTO, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	var rows pgx.Rows
+	rows, err = tx.Query(ctx, "select "+invoiceLineColumns+" from invoice_lines where invoice_id = $1 order by metric", invoiceId)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get invoice lines",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var lines []InvoiceLineDTO
+	lines, err = pgx.CollectRows(rows, pgx.RowToStructByPos[InvoiceLineDTO])
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect invoice lines",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return &lines, nil
+}
+
+func pricePlanWriteError(err error, message string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+		return customError.CustomError{
+			Code:     fiber.StatusConflict,
+			Message:  "price plan already exists for this service and metric",
+			Severity: zap.WarnLevel,
+		}
+	}
+
+	return customError.CustomError{
+		Code:     fiber.StatusInternalServerError,
+		Message:  message,
+		Severity: zap.ErrorLevel,
+		Fields:   []zap.Field{zap.Error(err)},
+	}
+}
@@ -0,0 +1,217 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/usage/repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/usage/repository.go -destination=internal/usage/repository_mock.go -package=usage
+//
+
+// Package usage is a generated GoMock package.
+package usage
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ClaimUsage mocks base method.
+func (m *MockRepository) ClaimUsage(ctx context.Context, period *BillingPeriodDTO) ([]MetricUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimUsage", ctx, period)
+	ret0, _ := ret[0].([]MetricUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimUsage indicates an expected call of ClaimUsage.
+func (mr *MockRepositoryMockRecorder) ClaimUsage(ctx, period any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimUsage", reflect.TypeOf((*MockRepository)(nil).ClaimUsage), ctx, period)
+}
+
+// CompleteBillingPeriod mocks base method.
+func (m *MockRepository) CompleteBillingPeriod(ctx context.Context, invoiceId string, lines []InvoiceLineDTO) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteBillingPeriod", ctx, invoiceId, lines)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteBillingPeriod indicates an expected call of CompleteBillingPeriod.
+func (mr *MockRepositoryMockRecorder) CompleteBillingPeriod(ctx, invoiceId, lines any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteBillingPeriod", reflect.TypeOf((*MockRepository)(nil).CompleteBillingPeriod), ctx, invoiceId, lines)
+}
+
+// CreatePricePlan mocks base method.
+func (m *MockRepository) CreatePricePlan(ctx context.Context, plan *PricePlanDTO) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePricePlan", ctx, plan)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePricePlan indicates an expected call of CreatePricePlan.
+func (mr *MockRepositoryMockRecorder) CreatePricePlan(ctx, plan any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePricePlan", reflect.TypeOf((*MockRepository)(nil).CreatePricePlan), ctx, plan)
+}
+
+// DeletePricePlanById mocks base method.
+func (m *MockRepository) DeletePricePlanById(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePricePlanById", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePricePlanById indicates an expected call of DeletePricePlanById.
+func (mr *MockRepositoryMockRecorder) DeletePricePlanById(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePricePlanById", reflect.TypeOf((*MockRepository)(nil).DeletePricePlanById), ctx, id)
+}
+
+// GetInvoiceLines mocks base method.
+func (m *MockRepository) GetInvoiceLines(ctx context.Context, invoiceId string) (*[]InvoiceLineDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInvoiceLines", ctx, invoiceId)
+	ret0, _ := ret[0].(*[]InvoiceLineDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInvoiceLines indicates an expected call of GetInvoiceLines.
+func (mr *MockRepositoryMockRecorder) GetInvoiceLines(ctx, invoiceId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInvoiceLines", reflect.TypeOf((*MockRepository)(nil).GetInvoiceLines), ctx, invoiceId)
+}
+
+// GetPricePlanById mocks base method.
+func (m *MockRepository) GetPricePlanById(ctx context.Context, id string) (*PricePlanDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPricePlanById", ctx, id)
+	ret0, _ := ret[0].(*PricePlanDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPricePlanById indicates an expected call of GetPricePlanById.
+func (mr *MockRepositoryMockRecorder) GetPricePlanById(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPricePlanById", reflect.TypeOf((*MockRepository)(nil).GetPricePlanById), ctx, id)
+}
+
+// GetPricePlans mocks base method.
+func (m *MockRepository) GetPricePlans(ctx context.Context) (*[]PricePlanDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPricePlans", ctx)
+	ret0, _ := ret[0].(*[]PricePlanDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPricePlans indicates an expected call of GetPricePlans.
+func (mr *MockRepositoryMockRecorder) GetPricePlans(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPricePlans", reflect.TypeOf((*MockRepository)(nil).GetPricePlans), ctx)
+}
+
+// GetServicePricePlans mocks base method.
+func (m *MockRepository) GetServicePricePlans(ctx context.Context, serviceName string) ([]PricePlanDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetServicePricePlans", ctx, serviceName)
+	ret0, _ := ret[0].([]PricePlanDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetServicePricePlans indicates an expected call of GetServicePricePlans.
+func (mr *MockRepositoryMockRecorder) GetServicePricePlans(ctx, serviceName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetServicePricePlans", reflect.TypeOf((*MockRepository)(nil).GetServicePricePlans), ctx, serviceName)
+}
+
+// IngestEvents mocks base method.
+func (m *MockRepository) IngestEvents(ctx context.Context, events []EventRequest, receivedAt time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IngestEvents", ctx, events, receivedAt)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IngestEvents indicates an expected call of IngestEvents.
+func (mr *MockRepositoryMockRecorder) IngestEvents(ctx, events, receivedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IngestEvents", reflect.TypeOf((*MockRepository)(nil).IngestEvents), ctx, events, receivedAt)
+}
+
+// ReleaseBillingPeriod mocks base method.
+func (m *MockRepository) ReleaseBillingPeriod(ctx context.Context, invoiceId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseBillingPeriod", ctx, invoiceId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseBillingPeriod indicates an expected call of ReleaseBillingPeriod.
+func (mr *MockRepositoryMockRecorder) ReleaseBillingPeriod(ctx, invoiceId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseBillingPeriod", reflect.TypeOf((*MockRepository)(nil).ReleaseBillingPeriod), ctx, invoiceId)
+}
+
+// ReserveBillingPeriod mocks base method.
+func (m *MockRepository) ReserveBillingPeriod(ctx context.Context, period *BillingPeriodDTO) (*BillingPeriodDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReserveBillingPeriod", ctx, period)
+	ret0, _ := ret[0].(*BillingPeriodDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReserveBillingPeriod indicates an expected call of ReserveBillingPeriod.
+func (mr *MockRepositoryMockRecorder) ReserveBillingPeriod(ctx, period any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReserveBillingPeriod", reflect.TypeOf((*MockRepository)(nil).ReserveBillingPeriod), ctx, period)
+}
+
+// UpdatePricePlan mocks base method.
+func (m *MockRepository) UpdatePricePlan(ctx context.Context, plan *PricePlanDTO) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePricePlan", ctx, plan)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePricePlan indicates an expected call of UpdatePricePlan.
+func (mr *MockRepositoryMockRecorder) UpdatePricePlan(ctx, plan any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePricePlan", reflect.TypeOf((*MockRepository)(nil).UpdatePricePlan), ctx, plan)
+}
@@ -0,0 +1,187 @@
+package usage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+
+	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/requestcontext"
+	"invoice-api/pkg/tenant"
+)
+
+var tenantContext = requestcontext.WithTenant(context.Background(), tenant.DefaultTenant)
+
+func TestPgRepository_PricePlans(t *testing.T) {
+	pgContainer := setupContainer(t)
+	pgHost, err := pgContainer.Host(context.Background())
+	require.NoError(t, err)
+
+	pgPort, err := pgContainer.MappedPort(context.Background(), "5432/tcp")
+	require.NoError(t, err)
+
+	pgRepository := NewPgRepository(nil, pgHost, pgPort.Port(), "root", "root", "test")
+	plan := &PricePlanDTO{
+		Id:          uuid.NewString(),
+		ServiceName: "DMP",
+		Metric:      "api_calls",
+		Model:       PricingTiered,
+		Tiers:       []Tier{{UpTo: upTo(1000), UnitPrice: 0.01}, {UnitPrice: 0.005}},
+		CreatedAt:   time.Now().UTC().Truncate(time.Microsecond),
+	}
+	require.NoError(t, pgRepository.CreatePricePlan(tenantContext, plan))
+
+	duplicate := *plan
+	duplicate.Id = uuid.NewString()
+	var cerr customError.CustomError
+	require.ErrorAs(t, pgRepository.CreatePricePlan(tenantContext, &duplicate), &cerr)
+	assert.Equal(t, fiber.StatusConflict, cerr.Code)
+
+	stored, err := pgRepository.GetPricePlanById(tenantContext, plan.Id)
+	require.NoError(t, err)
+	assert.Equal(t, plan, stored)
+
+	plan.Model = PricingVolume
+	require.NoError(t, pgRepository.UpdatePricePlan(tenantContext, plan))
+	plans, err := pgRepository.GetServicePricePlans(tenantContext, "DMP")
+	require.NoError(t, err)
+	require.Len(t, plans, 1)
+	assert.Equal(t, PricingVolume, plans[0].Model)
+
+	require.NoError(t, pgRepository.DeletePricePlanById(tenantContext, plan.Id))
+	_, err = pgRepository.GetPricePlanById(tenantContext, plan.Id)
+	assert.Error(t, err)
+}
+
+func TestPgRepository_BillingPeriod(t *testing.T) {
+	pgContainer := setupContainer(t)
+	pgHost, err := pgContainer.Host(context.Background())
+	require.NoError(t, err)
+
+	pgPort, err := pgContainer.MappedPort(context.Background(), "5432/tcp")
+	require.NoError(t, err)
+
+	pgRepository := NewPgRepository(nil, pgHost, pgPort.Port(), "root", "root", "test")
+	january := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	february := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	event := func(id, metric string, quantity float64, timestamp time.Time) EventRequest {
+		return EventRequest{Id: id, CustomerId: "acme", ServiceName: "DMP", Metric: metric, Quantity: quantity, Timestamp: timestamp}
+	}
+
+	accepted, err := pgRepository.IngestEvents(tenantContext, []EventRequest{
+		event("evt-1", "api_calls", 100, january.Add(time.Hour)),
+		event("evt-1", "api_calls", 100, january.Add(time.Hour)),
+		event("evt-2", "api_calls", 50, january.Add(48*time.Hour)),
+		event("evt-3", "segments", 2, january.Add(72*time.Hour)),
+		event("evt-4", "api_calls", 10, february.Add(time.Hour)),
+	}, time.Now().UTC())
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), accepted)
+
+	period := &BillingPeriodDTO{
+		InvoiceId:   uuid.NewString(),
+		CustomerId:  "acme",
+		ServiceName: "DMP",
+		PeriodStart: january,
+		PeriodEnd:   february,
+		CreatedAt:   time.Now().UTC().Truncate(time.Microsecond),
+	}
+	reserved, err := pgRepository.ReserveBillingPeriod(tenantContext, period)
+	require.NoError(t, err)
+	assert.Equal(t, period.InvoiceId, reserved.InvoiceId)
+
+	again := *period
+	again.InvoiceId = uuid.NewString()
+	reserved, err = pgRepository.ReserveBillingPeriod(tenantContext, &again)
+	require.NoError(t, err)
+	assert.Equal(t, period.InvoiceId, reserved.InvoiceId)
+
+	overlapping := *period
+	overlapping.InvoiceId = uuid.NewString()
+	overlapping.PeriodStart = january.Add(15 * 24 * time.Hour)
+	overlapping.PeriodEnd = february.Add(15 * 24 * time.Hour)
+	var cerr customError.CustomError
+	_, err = pgRepository.ReserveBillingPeriod(tenantContext, &overlapping)
+	require.ErrorAs(t, err, &cerr)
+	assert.Equal(t, fiber.StatusConflict, cerr.Code)
+
+	usage, err := pgRepository.ClaimUsage(tenantContext, period)
+	require.NoError(t, err)
+	assert.Equal(t, []MetricUsage{{Metric: "api_calls", Quantity: 150}, {Metric: "segments", Quantity: 2}}, usage)
+
+	accepted, err = pgRepository.IngestEvents(tenantContext, []EventRequest{event("evt-5", "api_calls", 1, january.Add(time.Hour))}, time.Now().UTC())
+	require.NoError(t, err)
+	assert.Zero(t, accepted)
+
+	_, err = pgRepository.connectionPool.Exec(
+		tenantContext,
+		"insert into invoices (id, service_name, amount, status, date) values ($1, 'DMP', 10, 'UNPAID', $2)",
+		period.InvoiceId,
+		february,
+	)
+	require.NoError(t, err)
+	lines := []InvoiceLineDTO{
+		{Metric: "api_calls", Description: "DMP api_calls", Quantity: 150, UnitPrice: 0.01, Amount: 1.5},
+		{Metric: "segments", Description: "DMP segments", Quantity: 2, UnitPrice: 4.25, Amount: 8.5},
+	}
+	require.NoError(t, pgRepository.CompleteBillingPeriod(tenantContext, period.InvoiceId, lines))
+	require.NoError(t, pgRepository.CompleteBillingPeriod(tenantContext, period.InvoiceId, lines))
+
+	stored, err := pgRepository.GetInvoiceLines(tenantContext, period.InvoiceId)
+	require.NoError(t, err)
+	require.Len(t, *stored, 2)
+	assert.Equal(t, period.InvoiceId, (*stored)[0].InvoiceId)
+
+	reserved, err = pgRepository.ReserveBillingPeriod(tenantContext, &again)
+	require.NoError(t, err)
+	assert.NotNil(t, reserved.CompletedAt)
+
+	empty := &BillingPeriodDTO{
+		InvoiceId:   uuid.NewString(),
+		CustomerId:  "acme",
+		ServiceName: "SSP",
+		PeriodStart: january,
+		PeriodEnd:   february,
+		CreatedAt:   time.Now().UTC().Truncate(time.Microsecond),
+	}
+	_, err = pgRepository.ReserveBillingPeriod(tenantContext, empty)
+	require.NoError(t, err)
+	usage, err = pgRepository.ClaimUsage(tenantContext, empty)
+	require.NoError(t, err)
+	assert.Empty(t, usage)
+	require.NoError(t, pgRepository.ReleaseBillingPeriod(tenantContext, empty.InvoiceId))
+
+	accepted, err = pgRepository.IngestEvents(tenantContext, []EventRequest{
+		{Id: "evt-6", CustomerId: "acme", ServiceName: "SSP", Metric: "impressions", Quantity: 1, Timestamp: january.Add(time.Hour)},
+	}, time.Now().UTC())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), accepted)
+}
+
+func setupContainer(t *testing.T) *postgres.PostgresContainer {
+	ctx := context.Background()
+	postgresContainer, err := postgres.Run(
+		ctx,
+		"postgres",
+		postgres.WithDatabase("test"),
+		postgres.WithUsername("root"),
+		postgres.WithPassword("root"),
+		postgres.BasicWaitStrategies(),
+		postgres.WithSQLDriver("pgx"),
+		postgres.WithInitScripts("../../.scripts/init.sql"),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		err = postgresContainer.Terminate(ctx)
+		require.NoError(t, err)
+	})
+
+	return postgresContainer
+}
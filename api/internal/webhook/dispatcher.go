@@ -0,0 +1,195 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const maxResponseBodySize = 64 << 10
+
+type Dispatcher struct {
+	log          *zap.Logger
+	repository   Repository
+	client       *http.Client
+	batchSize    int
+	interval     time.Duration
+	maxBackoff   time.Duration
+	maxAttempts  int
+	disableAfter int
+	now          func() time.Time
+}
+
+// NewClient returns the HTTP client deliveries are sent with. It refuses to
+// connect to non-public addresses, which also covers host names resolving to
+// them and redirects to them.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !isPublicAddress(addrPort.Addr()) {
+				return fmt.Errorf("destination %s is not a public address", addrPort.Addr())
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+func NewDispatcher(
+	log *zap.Logger,
+	repository Repository,
+	client *http.Client,
+	batchSize int,
+	interval time.Duration,
+	maxBackoff time.Duration,
+	maxAttempts int,
+	disableAfter int,
+) *Dispatcher {
+	return &Dispatcher{
+		log:          log,
+		repository:   repository,
+		client:       client,
+		batchSize:    batchSize,
+		interval:     interval,
+		maxBackoff:   maxBackoff,
+		maxAttempts:  maxAttempts,
+		disableAfter: disableAfter,
+		now:          time.Now,
+	}
+}
+
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		if d.Dispatch(ctx) < d.batchSize {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func (d *Dispatcher) Dispatch(ctx context.Context) int {
+	deliveries, err := d.repository.GetDueDeliveries(ctx, d.batchSize)
+	if err != nil {
+		d.log.Error("failed to get due webhook deliveries", zap.Error(err))
+		return 0
+	}
+
+	for _, delivery := range deliveries {
+		attempt := d.deliver(ctx, delivery)
+
+		var disabled bool
+		disabled, err = d.repository.RecordAttempt(ctx, attempt, d.disableAfter)
+		if err != nil {
+			d.log.Error("failed to record webhook delivery attempt", zap.String("id", delivery.Id), zap.Error(err))
+			continue
+		}
+
+		if !attempt.Succeeded {
+			d.log.Warn(
+				"webhook delivery failed",
+				zap.String("id", delivery.Id),
+				zap.String("subscriptionId", delivery.SubscriptionId),
+				zap.Int("attempts", delivery.Attempts+1),
+				zap.String("error", attempt.Error),
+			)
+		}
+
+		if disabled {
+			d.log.Warn("webhook subscription disabled after repeated failures", zap.String("subscriptionId", delivery.SubscriptionId))
+		}
+	}
+
+	return len(deliveries)
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery PendingDelivery) DeliveryAttempt {
+	now := d.now().UTC()
+	attempt := DeliveryAttempt{
+		DeliveryId:     delivery.Id,
+		SubscriptionId: delivery.SubscriptionId,
+		AttemptedAt:    now,
+	}
+
+	statusCode, err := d.send(ctx, delivery, now)
+	if statusCode != 0 {
+		attempt.ResponseStatus = &statusCode
+	}
+	if err == nil {
+		attempt.Succeeded = true
+		return attempt
+	}
+
+	attempt.Error = err.Error()
+	if delivery.Attempts+1 < d.maxAttempts {
+		nextAttemptAt := now.Add(d.backoff(delivery.Attempts))
+		attempt.NextAttemptAt = &nextAttemptAt
+	}
+
+	return attempt
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery PendingDelivery, now time.Time) (int, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := now.Unix()
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "invoice-api-webhooks")
+	request.Header.Set(HeaderId, delivery.Id)
+	request.Header.Set(HeaderEvent, delivery.EventType)
+	request.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	request.Header.Set(HeaderSignature, Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	var response *http.Response
+	response, err = d.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, maxResponseBodySize))
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("unexpected response status %d", response.StatusCode)
+	}
+
+	return response.StatusCode, nil
+}
+
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	backoff := d.interval
+	for i := 0; i < attempts && backoff < d.maxBackoff; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, d.maxBackoff)
+}
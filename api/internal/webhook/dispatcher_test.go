@@ -0,0 +1,149 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	now := time.Unix(1718000000, 0).UTC()
+	newDelivery := func(url string, attempts int) PendingDelivery {
+		return PendingDelivery{
+			DeliveryDTO: DeliveryDTO{
+				Id:             "5f0c2b8e-3f0a-4f5e-9d1a-6c1f3f1f8b11",
+				SubscriptionId: "0d4f7e3a-8b1c-4c2d-9e5f-1a2b3c4d5e6f",
+				EventId:        42,
+				EventType:      "invoice.paid",
+				Payload:        []byte(`{"id":42,"type":"invoice.paid"}`),
+				Attempts:       attempts,
+			},
+			URL:    url,
+			Secret: "whsec_test",
+		}
+	}
+
+	t.Run("signed delivery", func(t *testing.T) {
+		var received atomic.Int32
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+
+			assert.NoError(t, Verify("whsec_test", r.Header, body, time.Minute, now))
+			assert.Equal(t, "invoice.paid", r.Header.Get(HeaderEvent))
+			assert.Equal(t, "5f0c2b8e-3f0a-4f5e-9d1a-6c1f3f1f8b11", r.Header.Get(HeaderId))
+			assert.JSONEq(t, `{"id":42,"type":"invoice.paid"}`, string(body))
+			received.Add(1)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer receiver.Close()
+
+		statusCode := http.StatusNoContent
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetDueDeliveries(gomock.Any(), 10).Return([]PendingDelivery{newDelivery(receiver.URL, 0)}, nil)
+		mockRepository.EXPECT().RecordAttempt(gomock.Any(), DeliveryAttempt{
+			DeliveryId:     "5f0c2b8e-3f0a-4f5e-9d1a-6c1f3f1f8b11",
+			SubscriptionId: "0d4f7e3a-8b1c-4c2d-9e5f-1a2b3c4d5e6f",
+			Succeeded:      true,
+			ResponseStatus: &statusCode,
+			AttemptedAt:    now,
+		}, 5).Return(false, nil)
+
+		dispatcher := NewDispatcher(zap.NewNop(), mockRepository, receiver.Client(), 10, time.Second, time.Minute, 3, 5)
+		dispatcher.now = func() time.Time { return now }
+
+		assert.Equal(t, 1, dispatcher.Dispatch(context.TODO()))
+		assert.Equal(t, int32(1), received.Load())
+	})
+
+	t.Run("failed delivery is retried with backoff", func(t *testing.T) {
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer receiver.Close()
+
+		statusCode := http.StatusServiceUnavailable
+		nextAttemptAt := now.Add(4 * time.Second)
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetDueDeliveries(gomock.Any(), gomock.Any()).Return([]PendingDelivery{newDelivery(receiver.URL, 2)}, nil)
+		mockRepository.EXPECT().RecordAttempt(gomock.Any(), DeliveryAttempt{
+			DeliveryId:     "5f0c2b8e-3f0a-4f5e-9d1a-6c1f3f1f8b11",
+			SubscriptionId: "0d4f7e3a-8b1c-4c2d-9e5f-1a2b3c4d5e6f",
+			ResponseStatus: &statusCode,
+			Error:          "unexpected response status 503",
+			NextAttemptAt:  &nextAttemptAt,
+			AttemptedAt:    now,
+		}, 5).Return(false, nil)
+
+		dispatcher := NewDispatcher(zap.NewNop(), mockRepository, receiver.Client(), 10, time.Second, time.Minute, 5, 5)
+		dispatcher.now = func() time.Time { return now }
+
+		assert.Equal(t, 1, dispatcher.Dispatch(context.TODO()))
+	})
+
+	t.Run("last attempt gives up and disables the subscription", func(t *testing.T) {
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		receiverURL := receiver.URL
+		receiver.Close()
+
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetDueDeliveries(gomock.Any(), gomock.Any()).Return([]PendingDelivery{newDelivery(receiverURL, 2)}, nil)
+		mockRepository.EXPECT().
+			RecordAttempt(gomock.Any(), gomock.Any(), 5).
+			DoAndReturn(func(_ context.Context, attempt DeliveryAttempt, _ int) (bool, error) {
+				assert.False(t, attempt.Succeeded)
+				assert.Nil(t, attempt.ResponseStatus)
+				assert.Nil(t, attempt.NextAttemptAt)
+				assert.NotEmpty(t, attempt.Error)
+				return true, nil
+			})
+
+		dispatcher := NewDispatcher(zap.NewNop(), mockRepository, http.DefaultClient, 10, time.Second, time.Minute, 3, 5)
+
+		assert.Equal(t, 1, dispatcher.Dispatch(context.TODO()))
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetDueDeliveries(gomock.Any(), gomock.Any()).Return(nil, errors.New("unavailable"))
+
+		dispatcher := NewDispatcher(zap.NewNop(), mockRepository, http.DefaultClient, 10, time.Second, time.Minute, 3, 5)
+
+		assert.Zero(t, dispatcher.Dispatch(context.TODO()))
+	})
+}
+
+func TestDispatcher_Backoff(t *testing.T) {
+	dispatcher := NewDispatcher(zap.NewNop(), nil, nil, 10, time.Second, time.Minute, 3, 5)
+
+	assert.Equal(t, time.Second, dispatcher.backoff(0))
+	assert.Equal(t, 4*time.Second, dispatcher.backoff(2))
+	assert.Equal(t, time.Minute, dispatcher.backoff(10))
+}
+
+func TestNewClient(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	_, err := NewClient(time.Second).Get(receiver.URL)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is not a public address")
+}
@@ -0,0 +1,275 @@
+package webhook
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"invoice-api/pkg/auth"
+	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/requestcontext"
+	"invoice-api/pkg/tracing"
+)
+
+type Handler struct {
+	server     *fiber.App
+	validator  *validator.Validate
+	repository Repository
+	authorizer *auth.Authorizer
+}
+
+func NewHandler(server *fiber.App, validator *validator.Validate, repository Repository, authorizer *auth.Authorizer) *Handler {
+	return &Handler{
+		server:     server,
+		validator:  validator,
+		repository: repository,
+		authorizer: authorizer,
+	}
+}
+
+func (h *Handler) RegisterRoutes() {
+	h.server.Post("/webhooks", h.authorizer.Require(PermissionWrite), h.CreateSubscription)
+	h.server.Get("/webhooks", h.authorizer.Require(PermissionRead), h.GetSubscriptions)
+	h.server.Get("/webhooks/:id", h.authorizer.Require(PermissionRead), h.GetSubscriptionById)
+	h.server.Put("/webhooks/:id", h.authorizer.Require(PermissionWrite), h.UpdateSubscriptionById)
+	h.server.Delete("/webhooks/:id", h.authorizer.Require(PermissionWrite), h.DeleteSubscriptionById)
+	h.server.Get("/webhooks/:id/deliveries", h.authorizer.Require(PermissionRead), h.GetDeliveries)
+	h.server.Post("/webhooks/:id/deliveries/:deliveryId/replay", h.authorizer.Require(PermissionWrite), h.ReplayDelivery)
+}
+
+func (h *Handler) CreateSubscription(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "CreateSubscription"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "webhook.Handler.CreateSubscription")()
+
+	var reqBody CreateSubscriptionRequest
+	if err := ctx.BodyParser(&reqBody); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid request body",
+			Severity: zap.WarnLevel,
+		}
+	}
+
+	if err := h.validator.StructCtx(ctx.UserContext(), &reqBody); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid request body",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if err := validateDestination(reqBody.URL); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid webhook url",
+			Severity: zap.WarnLevel,
+			Details:  err.Error(),
+		}
+	}
+
+	if reqBody.Secret == "" {
+		secret, err := NewSecret()
+		if err != nil {
+			return customError.CustomError{
+				Code:     fiber.StatusInternalServerError,
+				Message:  "failed to generate webhook secret",
+				Severity: zap.ErrorLevel,
+				Fields:   []zap.Field{zap.Error(err)},
+			}
+		}
+		reqBody.Secret = secret
+	}
+
+	subscription := &SubscriptionDTO{
+		Id:         uuid.NewString(),
+		URL:        reqBody.URL,
+		EventTypes: reqBody.EventTypes,
+		Secret:     reqBody.Secret,
+		Enabled:    true,
+		CreatedAt:  time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if err := h.repository.CreateSubscription(ctx.UserContext(), subscription); err != nil {
+		return err
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	ctx.Location(fmt.Sprintf("/webhooks/%s", subscription.Id))
+	return ctx.Status(fiber.StatusCreated).JSON(subscription)
+}
+
+func (h *Handler) GetSubscriptions(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "GetSubscriptions"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "webhook.Handler.GetSubscriptions")()
+
+	subscriptions, err := h.repository.GetSubscriptions(ctx.UserContext())
+	if err != nil {
+		return err
+	}
+
+	for i := range *subscriptions {
+		(*subscriptions)[i].Secret = ""
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	return ctx.JSON(subscriptions)
+}
+
+func (h *Handler) GetSubscriptionById(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "GetSubscriptionById"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "webhook.Handler.GetSubscriptionById")()
+
+	id, err := h.subscriptionId(ctx)
+	if err != nil {
+		return err
+	}
+
+	subscription, err := h.repository.GetSubscriptionById(ctx.UserContext(), id)
+	if err != nil {
+		return err
+	}
+	subscription.Secret = ""
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	return ctx.JSON(subscription)
+}
+
+func (h *Handler) UpdateSubscriptionById(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "UpdateSubscriptionById"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "webhook.Handler.UpdateSubscriptionById")()
+
+	id, err := h.subscriptionId(ctx)
+	if err != nil {
+		return err
+	}
+
+	var reqBody UpdateSubscriptionRequest
+	if err = ctx.BodyParser(&reqBody); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid request body",
+			Severity: zap.WarnLevel,
+		}
+	}
+
+	if err = h.validator.StructCtx(ctx.UserContext(), &reqBody); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid request body",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if err = validateDestination(reqBody.URL); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid webhook url",
+			Severity: zap.WarnLevel,
+			Details:  err.Error(),
+		}
+	}
+
+	subscription, err := h.repository.UpdateSubscriptionById(ctx.UserContext(), id, &reqBody)
+	if err != nil {
+		return err
+	}
+	subscription.Secret = ""
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	return ctx.JSON(subscription)
+}
+
+func (h *Handler) DeleteSubscriptionById(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "DeleteSubscriptionById"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "webhook.Handler.DeleteSubscriptionById")()
+
+	id, err := h.subscriptionId(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = h.repository.DeleteSubscriptionById(ctx.UserContext(), id); err != nil {
+		return err
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) GetDeliveries(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "GetDeliveries"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "webhook.Handler.GetDeliveries")()
+
+	id, err := h.subscriptionId(ctx)
+	if err != nil {
+		return err
+	}
+
+	deliveries, err := h.repository.GetDeliveries(ctx.UserContext(), id)
+	if err != nil {
+		return err
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	return ctx.JSON(deliveries)
+}
+
+func (h *Handler) ReplayDelivery(ctx *fiber.Ctx) error {
+	log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+	log = log.With(zap.String("route", ctx.Route().Path), zap.String("method", "ReplayDelivery"))
+	requestcontext.SetLogger(ctx, log)
+	defer tracing.StartSpan(ctx, "webhook.Handler.ReplayDelivery")()
+
+	id, err := h.subscriptionId(ctx)
+	if err != nil {
+		return err
+	}
+
+	deliveryId := ctx.Params("deliveryId")
+	if err = h.validator.VarCtx(ctx.UserContext(), deliveryId, "required,uuid4"); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid delivery id",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if err = h.repository.ReplayDelivery(ctx.UserContext(), id, deliveryId); err != nil {
+		return err
+	}
+
+	ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("successfully finished")
+	return ctx.SendStatus(fiber.StatusAccepted)
+}
+
+func (h *Handler) subscriptionId(ctx *fiber.Ctx) (string, error) {
+	id := ctx.Params("id")
+	if err := h.validator.VarCtx(ctx.UserContext(), id, "required,uuid4"); err != nil {
+		return "", customError.CustomError{
+			Code:     fiber.StatusBadRequest,
+			Message:  "invalid webhook subscription id",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return id, nil
+}
@@ -0,0 +1,297 @@
+package webhook
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	json "github.com/bytedance/sonic"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+
+	"invoice-api/pkg/auth"
+	customError "invoice-api/pkg/error"
+)
+
+func TestHandler_CreateSubscription(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("happy path", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().CreateSubscription(gomock.Any(), gomock.Any()).Return(nil)
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, testAuthorizer)
+		h.RegisterRoutes()
+
+		req := httptest.NewRequest(
+			http.MethodPost,
+			"/webhooks",
+			strings.NewReader(`{"url":"https://partner.example.com/hooks","eventTypes":["invoice.created","invoice.paid"]}`),
+		)
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusCreated, res.StatusCode)
+
+		var subscription SubscriptionDTO
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &subscription))
+		assert.True(t, subscription.Enabled)
+		assert.True(t, strings.HasPrefix(subscription.Secret, "whsec_"))
+		assert.Equal(t, []string{"invoice.created", "invoice.paid"}, subscription.EventTypes)
+		assert.Equal(t, fmt.Sprintf("/webhooks/%s", subscription.Id), res.Header.Get(fiber.HeaderLocation))
+	})
+
+	t.Run("invalid request body", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		bodies := []string{
+			`{"url":"ftp://partner.example.com","eventTypes":["invoice.created"]}`,
+			`{"url":"https://partner.example.com/hooks","eventTypes":[]}`,
+			`{"url":"https://partner.example.com/hooks","eventTypes":["invoice.unknown"]}`,
+			`{"url":"https://partner.example.com/hooks","eventTypes":["invoice.created"],"secret":"short"}`,
+			`{"url":"http://127.0.0.1:8080/hooks","eventTypes":["invoice.created"]}`,
+			`{"url":"http://169.254.169.254/latest/meta-data","eventTypes":["invoice.created"]}`,
+			`{"url":"http://10.0.0.5/hooks","eventTypes":["invoice.created"]}`,
+			`{"url":"http://[::1]/hooks","eventTypes":["invoice.created"]}`,
+			`{"url":"http://localhost/hooks","eventTypes":["invoice.created"]}`,
+			`{`,
+		}
+		for _, body := range bodies {
+			req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+			res, err := server.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, fiber.StatusBadRequest, res.StatusCode, body)
+		}
+	})
+
+	t.Run("missing permission", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		server.Use(func(c *fiber.Ctx) error {
+			c.Locals(auth.ContextKeyRoles, []string{"viewer"})
+			return c.Next()
+		})
+		authorizer := auth.NewAuthorizer(auth.Policy{"viewer": {PermissionRead}})
+		h := NewHandler(server, validate, nil, authorizer)
+		h.RegisterRoutes()
+
+		req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(`{"url":"https://partner.example.com/hooks","eventTypes":["invoice.created"]}`))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusForbidden, res.StatusCode)
+	})
+}
+
+func TestHandler_GetSubscriptions(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	mockRepository := NewMockRepository(mockController)
+	mockRepository.EXPECT().GetSubscriptions(gomock.Any()).Return(&[]SubscriptionDTO{
+		{Id: uuid.NewString(), URL: "https://partner.example.com/hooks", Secret: "whsec_test", Enabled: true},
+	}, nil)
+
+	server, validate := SetupServer(t)
+	h := NewHandler(server, validate, mockRepository, testAuthorizer)
+	h.RegisterRoutes()
+
+	res, err := server.Test(httptest.NewRequest(http.MethodGet, "/webhooks", nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, res.StatusCode)
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.NotContains(t, string(body), "whsec_test")
+}
+
+func TestHandler_GetSubscriptionById(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("happy path", func(t *testing.T) {
+		id := uuid.NewString()
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetSubscriptionById(gomock.Any(), id).Return(&SubscriptionDTO{Id: id, Secret: "whsec_test"}, nil)
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, testAuthorizer)
+		h.RegisterRoutes()
+
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, "/webhooks/"+id, nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.NotContains(t, string(body), "whsec_test")
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetSubscriptionById(gomock.Any(), gomock.Any()).Return(nil, customError.CustomError{
+			Code:     fiber.StatusNotFound,
+			Message:  "webhook subscription not found",
+			Severity: zap.WarnLevel,
+		})
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, testAuthorizer)
+		h.RegisterRoutes()
+
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, "/webhooks/"+uuid.NewString(), nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, "/webhooks/123", nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+	})
+}
+
+func TestHandler_UpdateSubscriptionById(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	id := uuid.NewString()
+	mockRepository := NewMockRepository(mockController)
+	mockRepository.EXPECT().
+		UpdateSubscriptionById(gomock.Any(), id, &UpdateSubscriptionRequest{
+			URL:        "https://partner.example.com/v2/hooks",
+			EventTypes: []string{"invoice.voided"},
+			Enabled:    true,
+		}).
+		Return(&SubscriptionDTO{Id: id, Enabled: true}, nil)
+
+	server, validate := SetupServer(t)
+	h := NewHandler(server, validate, mockRepository, testAuthorizer)
+	h.RegisterRoutes()
+
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/webhooks/"+id,
+		strings.NewReader(`{"url":"https://partner.example.com/v2/hooks","eventTypes":["invoice.voided"],"enabled":true}`),
+	)
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+	res, err := server.Test(req, -1)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, res.StatusCode)
+}
+
+func TestHandler_DeleteSubscriptionById(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	id := uuid.NewString()
+	mockRepository := NewMockRepository(mockController)
+	mockRepository.EXPECT().DeleteSubscriptionById(gomock.Any(), id).Return(nil)
+
+	server, validate := SetupServer(t)
+	h := NewHandler(server, validate, mockRepository, testAuthorizer)
+	h.RegisterRoutes()
+
+	res, err := server.Test(httptest.NewRequest(http.MethodDelete, "/webhooks/"+id, nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusNoContent, res.StatusCode)
+}
+
+func TestHandler_GetDeliveries(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	id := uuid.NewString()
+	mockRepository := NewMockRepository(mockController)
+	mockRepository.EXPECT().GetDeliveries(gomock.Any(), id).Return(&[]DeliveryDTO{
+		{Id: uuid.NewString(), SubscriptionId: id, EventId: 1, Status: DeliveryFailed, Payload: []byte(`{}`)},
+	}, nil)
+
+	server, validate := SetupServer(t)
+	h := NewHandler(server, validate, mockRepository, testAuthorizer)
+	h.RegisterRoutes()
+
+	res, err := server.Test(httptest.NewRequest(http.MethodGet, "/webhooks/"+id+"/deliveries", nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, res.StatusCode)
+}
+
+func TestHandler_ReplayDelivery(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("happy path", func(t *testing.T) {
+		id, deliveryId := uuid.NewString(), uuid.NewString()
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().ReplayDelivery(gomock.Any(), id, deliveryId).Return(nil)
+
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, mockRepository, testAuthorizer)
+		h.RegisterRoutes()
+
+		url := fmt.Sprintf("/webhooks/%s/deliveries/%s/replay", id, deliveryId)
+		res, err := server.Test(httptest.NewRequest(http.MethodPost, url, nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusAccepted, res.StatusCode)
+	})
+
+	t.Run("invalid delivery id", func(t *testing.T) {
+		server, validate := SetupServer(t)
+		h := NewHandler(server, validate, nil, testAuthorizer)
+		h.RegisterRoutes()
+
+		url := fmt.Sprintf("/webhooks/%s/deliveries/123/replay", uuid.NewString())
+		res, err := server.Test(httptest.NewRequest(http.MethodPost, url, nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+	})
+}
+
+var testAuthorizer = auth.NewAuthorizer(auth.Policy{"admin": {auth.AllPermissions}})
+
+func SetupServer(t *testing.T) (*fiber.App, *validator.Validate) {
+	server := fiber.New(fiber.Config{
+		JSONDecoder:           json.Unmarshal,
+		JSONEncoder:           json.Marshal,
+		ErrorHandler:          customError.ErrorHandler,
+		DisableStartupMessage: true,
+	})
+
+	log, _ := zap.NewProduction()
+	defer func(log *zap.Logger) {
+		err := log.Sync()
+		if err != nil {
+			assert.FailNow(t, err.Error())
+		}
+	}(log)
+
+	server.Use(func(c *fiber.Ctx) error {
+		c.Locals("log", log)
+		c.Locals(auth.ContextKeyRoles, []string{"admin"})
+		return c.Next()
+	})
+
+	return server, validator.New()
+}
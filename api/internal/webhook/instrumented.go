@@ -0,0 +1,90 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"invoice-api/internal/outbox"
+	"invoice-api/pkg/metrics"
+	"invoice-api/pkg/tracing"
+)
+
+const repositoryName = "webhook"
+
+type InstrumentedRepository struct {
+	repository Repository
+}
+
+func NewInstrumentedRepository(repository Repository) *InstrumentedRepository {
+	return &InstrumentedRepository{repository: repository}
+}
+
+func (r *InstrumentedRepository) CreateSubscription(ctx context.Context, subscription *SubscriptionDTO) (err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "CreateSubscription")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "CreateSubscription", time.Now(), &err)
+	return r.repository.CreateSubscription(ctx, subscription)
+}
+
+func (r *InstrumentedRepository) GetSubscriptions(ctx context.Context) (_ *[]SubscriptionDTO, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "GetSubscriptions")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "GetSubscriptions", time.Now(), &err)
+	return r.repository.GetSubscriptions(ctx)
+}
+
+func (r *InstrumentedRepository) GetSubscriptionById(ctx context.Context, id string) (_ *SubscriptionDTO, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "GetSubscriptionById")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "GetSubscriptionById", time.Now(), &err)
+	return r.repository.GetSubscriptionById(ctx, id)
+}
+
+func (r *InstrumentedRepository) UpdateSubscriptionById(ctx context.Context, id string, request *UpdateSubscriptionRequest) (_ *SubscriptionDTO, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "UpdateSubscriptionById")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "UpdateSubscriptionById", time.Now(), &err)
+	return r.repository.UpdateSubscriptionById(ctx, id, request)
+}
+
+func (r *InstrumentedRepository) DeleteSubscriptionById(ctx context.Context, id string) (err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "DeleteSubscriptionById")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "DeleteSubscriptionById", time.Now(), &err)
+	return r.repository.DeleteSubscriptionById(ctx, id)
+}
+
+func (r *InstrumentedRepository) GetDeliveries(ctx context.Context, subscriptionId string) (_ *[]DeliveryDTO, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "GetDeliveries")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "GetDeliveries", time.Now(), &err)
+	return r.repository.GetDeliveries(ctx, subscriptionId)
+}
+
+func (r *InstrumentedRepository) ReplayDelivery(ctx context.Context, subscriptionId, deliveryId string) (err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "ReplayDelivery")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "ReplayDelivery", time.Now(), &err)
+	return r.repository.ReplayDelivery(ctx, subscriptionId, deliveryId)
+}
+
+func (r *InstrumentedRepository) EnqueueDeliveries(ctx context.Context, event outbox.Event) (_ int64, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "EnqueueDeliveries")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "EnqueueDeliveries", time.Now(), &err)
+	return r.repository.EnqueueDeliveries(ctx, event)
+}
+
+func (r *InstrumentedRepository) GetDueDeliveries(ctx context.Context, limit int) (_ []PendingDelivery, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "GetDueDeliveries")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "GetDueDeliveries", time.Now(), &err)
+	return r.repository.GetDueDeliveries(ctx, limit)
+}
+
+func (r *InstrumentedRepository) RecordAttempt(ctx context.Context, attempt DeliveryAttempt, disableAfter int) (_ bool, err error) {
+	ctx, endSpan := tracing.StartRepository(ctx, repositoryName, "RecordAttempt")
+	defer endSpan(&err)
+	defer metrics.ObserveRepository(repositoryName, "RecordAttempt", time.Now(), &err)
+	return r.repository.RecordAttempt(ctx, attempt, disableAfter)
+}
@@ -0,0 +1,98 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	DeliveryPending   = "PENDING"
+	DeliverySucceeded = "SUCCEEDED"
+	DeliveryFailed    = "FAILED"
+)
+
+type CreateSubscriptionRequest struct {
+	URL        string   `json:"url" validate:"required,http_url"`
+	EventTypes []string `json:"eventTypes" validate:"required,min=1,dive,oneof=invoice.created invoice.updated invoice.paid invoice.voided invoice.restored"`
+	Secret     string   `json:"secret" validate:"omitempty,min=16,max=128"`
+}
+
+type UpdateSubscriptionRequest struct {
+	URL        string   `json:"url" validate:"required,http_url"`
+	EventTypes []string `json:"eventTypes" validate:"required,min=1,dive,oneof=invoice.created invoice.updated invoice.paid invoice.voided invoice.restored"`
+	Enabled    bool     `json:"enabled"`
+}
+
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// validateDestination rejects webhook URLs that point at the service's own
+// network: loopback, link-local, private and unspecified addresses, and
+// localhost names. Host names are checked again when the dispatcher dials
+// them, since they may resolve to such an address.
+func validateDestination(rawURL string) error {
+	destination, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(destination.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errors.New("webhook url must not point to localhost")
+	}
+	if address, err := netip.ParseAddr(host); err == nil && !isPublicAddress(address) {
+		return errors.New("webhook url must not point to a private address")
+	}
+
+	return nil
+}
+
+func isPublicAddress(address netip.Addr) bool {
+	address = address.Unmap()
+	return address.IsGlobalUnicast() && !address.IsPrivate() && !sharedAddressSpace.Contains(address)
+}
+
+type SubscriptionDTO struct {
+	Id                  string     `json:"id" db:"id"`
+	URL                 string     `json:"url" db:"url"`
+	EventTypes          []string   `json:"eventTypes" db:"event_types"`
+	Secret              string     `json:"secret,omitempty" db:"secret"`
+	Enabled             bool       `json:"enabled" db:"enabled"`
+	ConsecutiveFailures int        `json:"consecutiveFailures" db:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabledAt,omitempty" db:"disabled_at"`
+	CreatedAt           time.Time  `json:"createdAt" db:"created_at"`
+}
+
+type DeliveryDTO struct {
+	Id             string          `json:"id" db:"id"`
+	SubscriptionId string          `json:"subscriptionId" db:"subscription_id"`
+	EventId        int64           `json:"eventId" db:"event_id"`
+	EventType      string          `json:"eventType" db:"event_type"`
+	Payload        json.RawMessage `json:"payload" db:"payload"`
+	Status         string          `json:"status" db:"status"`
+	Attempts       int             `json:"attempts" db:"attempts"`
+	ResponseStatus *int            `json:"responseStatus,omitempty" db:"response_status"`
+	LastError      *string         `json:"lastError,omitempty" db:"last_error"`
+	NextAttemptAt  time.Time       `json:"nextAttemptAt" db:"next_attempt_at"`
+	CreatedAt      time.Time       `json:"createdAt" db:"created_at"`
+	DeliveredAt    *time.Time      `json:"deliveredAt,omitempty" db:"delivered_at"`
+}
+
+type PendingDelivery struct {
+	DeliveryDTO
+	URL    string `db:"url"`
+	Secret string `db:"secret"`
+}
+
+type DeliveryAttempt struct {
+	DeliveryId     string
+	SubscriptionId string
+	Succeeded      bool
+	ResponseStatus *int
+	Error          string
+	NextAttemptAt  *time.Time
+	AttemptedAt    time.Time
+}
@@ -0,0 +1,6 @@
+package webhook
+
+const (
+	PermissionRead  = "webhooks:read"
+	PermissionWrite = "webhooks:write"
+)
@@ -0,0 +1,753 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	"invoice-api/internal/outbox"
+	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/requestcontext"
+	"invoice-api/pkg/tenant"
+	"invoice-api/pkg/tracing"
+)
+
+const (
+	subscriptionColumns = "id, url, event_types, secret, enabled, consecutive_failures, disabled_at, created_at"
+	deliveryColumns     = "id, subscription_id, event_id, event_type, payload, status, attempts, response_status, last_error, next_attempt_at, created_at, delivered_at"
+
+	// claimTimeout is how long a claimed delivery stays hidden from other
+	// dispatchers before it is considered abandoned and picked up again.
+	claimTimeout = 5 * time.Minute
+)
+
+type Repository interface {
+	CreateSubscription(ctx context.Context, subscription *SubscriptionDTO) error
+	GetSubscriptions(ctx context.Context) (*[]SubscriptionDTO, error)
+	GetSubscriptionById(ctx context.Context, id string) (*SubscriptionDTO, error)
+	UpdateSubscriptionById(ctx context.Context, id string, request *UpdateSubscriptionRequest) (*SubscriptionDTO, error)
+	DeleteSubscriptionById(ctx context.Context, id string) error
+	GetDeliveries(ctx context.Context, subscriptionId string) (*[]DeliveryDTO, error)
+	ReplayDelivery(ctx context.Context, subscriptionId, deliveryId string) error
+	EnqueueDeliveries(ctx context.Context, event outbox.Event) (int64, error)
+	GetDueDeliveries(ctx context.Context, limit int) ([]PendingDelivery, error)
+	RecordAttempt(ctx context.Context, attempt DeliveryAttempt, disableAfter int) (bool, error)
+}
+
+type PgRepository struct {
+	connectionPool *pgxpool.Pool
+}
+
+func NewPgRepository(log *zap.Logger, host, port, username, password, database string) *PgRepository {
+	credentials := fmt.Sprintf("user=%s password=%s host=%s port=%s dbname=%s sslmode=disable", username, password, host, port, database)
+	pgConfig, err := pgxpool.ParseConfig(credentials)
+	if err != nil {
+		log.Fatal("failed to parse database config", zap.Error(err))
+	}
+	pgConfig.AfterConnect = tenant.AfterConnect
+	pgConfig.ConnConfig.Tracer = tracing.QueryTracer{}
+
+	var pgConnectionPool *pgxpool.Pool
+	pgConnectionPool, err = pgxpool.NewWithConfig(context.Background(), pgConfig)
+	if err != nil {
+		log.Fatal("failed to connect database", zap.Error(err))
+	}
+
+	var connection *pgxpool.Conn
+	connection, err = pgConnectionPool.Acquire(context.Background())
+	if err != nil {
+		log.Fatal("failed to acquire connection", zap.Error(err))
+	}
+	defer connection.Release()
+
+	err = connection.Ping(context.Background())
+	if err != nil {
+		log.Fatal("failed to ping database", zap.Error(err))
+	}
+
+	return &PgRepository{
+		connectionPool: pgConnectionPool,
+	}
+}
+
+func (r *PgRepository) Stat() *pgxpool.Stat {
+	return r.connectionPool.Stat()
+}
+
+func (r *PgRepository) Ping(ctx context.Context) error {
+	return r.connectionPool.Ping(ctx)
+}
+
+func (r *PgRepository) CreateSubscription(ctx context.Context, subscription *SubscriptionDTO) error {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	if _, err = tx.Exec(
+		ctx,
+		"insert into webhook_subscriptions ("+subscriptionColumns+") values ($1, $2, $3, $4, $5, $6, $7, $8)",
+		subscription.Id,
+		subscription.URL,
+		subscription.EventTypes,
+		subscription.Secret,
+		subscription.Enabled,
+		subscription.ConsecutiveFailures,
+		subscription.DisabledAt,
+		subscription.CreatedAt,
+	); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to create webhook subscription",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return nil
+}
+
+func (r *PgRepository) GetSubscriptions(ctx context.Context) (*[]SubscriptionDTO, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	var rows pgx.Rows
+	rows, err = tx.Query(ctx, "select "+subscriptionColumns+" from webhook_subscriptions order by created_at")
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get webhook subscriptions",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var subscriptions []SubscriptionDTO
+	subscriptions, err = pgx.CollectRows(rows, pgx.RowToStructByPos[SubscriptionDTO])
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect webhook subscriptions",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return &subscriptions, nil
+}
+
+func (r *PgRepository) GetSubscriptionById(ctx context.Context, id string) (*SubscriptionDTO, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	var rows pgx.Rows
+	rows, err = tx.Query(ctx, "select "+subscriptionColumns+" from webhook_subscriptions where id = $1", id)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get webhook subscription",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return collectSubscription(rows)
+}
+
+func (r *PgRepository) UpdateSubscriptionById(ctx context.Context, id string, request *UpdateSubscriptionRequest) (*SubscriptionDTO, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	var rows pgx.Rows
+	rows, err = tx.Query(
+		ctx,
+		`update webhook_subscriptions set url = $1, event_types = $2, enabled = $3,
+		consecutive_failures = case when $3 and not enabled then 0 else consecutive_failures end,
+		disabled_at = case when $3 then null when enabled then $4 else disabled_at end
+		where id = $5 returning `+subscriptionColumns,
+		request.URL,
+		request.EventTypes,
+		request.Enabled,
+		time.Now().UTC(),
+		id,
+	)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to update webhook subscription",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var subscription *SubscriptionDTO
+	subscription, err = collectSubscription(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return subscription, nil
+}
+
+func (r *PgRepository) DeleteSubscriptionById(ctx context.Context, id string) error {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	tag, err := tx.Exec(ctx, "delete from webhook_subscriptions where id = $1", id)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to delete webhook subscription",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if tag.RowsAffected() == 0 {
+		return customError.CustomError{
+			Code:     fiber.StatusNotFound,
+			Message:  "webhook subscription not found",
+			Severity: zap.WarnLevel,
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return nil
+}
+
+func (r *PgRepository) GetDeliveries(ctx context.Context, subscriptionId string) (*[]DeliveryDTO, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	var rows pgx.Rows
+	rows, err = tx.Query(
+		ctx,
+		"select "+deliveryColumns+" from webhook_deliveries where subscription_id = $1 order by created_at desc, event_id desc",
+		subscriptionId,
+	)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get webhook deliveries",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var deliveries []DeliveryDTO
+	deliveries, err = pgx.CollectRows(rows, pgx.RowToStructByPos[DeliveryDTO])
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect webhook deliveries",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return &deliveries, nil
+}
+
+func (r *PgRepository) ReplayDelivery(ctx context.Context, subscriptionId, deliveryId string) error {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	tag, err := tx.Exec(
+		ctx,
+		"update webhook_deliveries set status = $1, attempts = 0, next_attempt_at = $2 where id = $3 and subscription_id = $4",
+		DeliveryPending,
+		time.Now().UTC(),
+		deliveryId,
+		subscriptionId,
+	)
+	if err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to replay webhook delivery",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if tag.RowsAffected() == 0 {
+		return customError.CustomError{
+			Code:     fiber.StatusNotFound,
+			Message:  "webhook delivery not found",
+			Severity: zap.WarnLevel,
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return nil
+}
+
+func (r *PgRepository) EnqueueDeliveries(ctx context.Context, event outbox.Event) (int64, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return 0, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return 0, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	tag, err := tx.Exec(
+		ctx,
+		`insert into webhook_deliveries (`+deliveryColumns+`, tenant_id)
+		select gen_random_uuid(), id, $1, $2, $3, $4, 0, null, null, $5, $5, null, tenant_id
+		from webhook_subscriptions where enabled and $2 = any(event_types) and tenant_id = $6
+		on conflict (subscription_id, event_id) do nothing`,
+		event.Id,
+		event.Type,
+		event,
+		DeliveryPending,
+		time.Now().UTC(),
+		event.TenantId,
+	)
+	if err != nil {
+		return 0, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to enqueue webhook deliveries",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// GetDueDeliveries claims the next deliveries to send. Rows locked by another
+// dispatcher are skipped and the claimed rows are pushed past claimTimeout,
+// so concurrent dispatchers never send the same delivery until RecordAttempt
+// settles it.
+func (r *PgRepository) GetDueDeliveries(ctx context.Context, limit int) ([]PendingDelivery, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	now := time.Now().UTC()
+	var rows pgx.Rows
+	rows, err = tx.Query(
+		ctx,
+		`with due as (
+			select d.id, d.subscription_id, d.event_id, d.event_type, d.payload, d.status, d.attempts, d.response_status,
+			d.last_error, d.next_attempt_at, d.created_at, d.delivered_at, s.url, s.secret
+			from webhook_deliveries d join webhook_subscriptions s on s.id = d.subscription_id
+			where d.status = $1 and d.next_attempt_at <= $2 and s.enabled
+			order by d.next_attempt_at, d.event_id limit $3
+			for update of d skip locked
+		), claimed as (
+			update webhook_deliveries set next_attempt_at = $4 where id in (select id from due)
+		)
+		select `+deliveryColumns+`, url, secret from due order by next_attempt_at, event_id`,
+		DeliveryPending,
+		now,
+		limit,
+		now.Add(claimTimeout),
+	)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get due webhook deliveries",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var deliveries []PendingDelivery
+	deliveries, err = pgx.CollectRows(rows, pgx.RowToStructByPos[PendingDelivery])
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect due webhook deliveries",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return deliveries, nil
+}
+
+func (r *PgRepository) RecordAttempt(ctx context.Context, attempt DeliveryAttempt, disableAfter int) (bool, error) {
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return false, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	var tx pgx.Tx
+	tx, err = tenant.Begin(ctx, connection)
+	if err != nil {
+		return false, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			requestcontext.Logger(ctx).Warn("failed to rollback transaction", zap.Error(rollbackErr))
+		}
+	}()
+
+	status, nextAttemptAt := DeliveryPending, attempt.AttemptedAt
+	var lastError, deliveredAt any
+	switch {
+	case attempt.Succeeded:
+		status, deliveredAt = DeliverySucceeded, attempt.AttemptedAt
+	case attempt.NextAttemptAt != nil:
+		nextAttemptAt, lastError = *attempt.NextAttemptAt, attempt.Error
+	default:
+		status, lastError = DeliveryFailed, attempt.Error
+	}
+
+	if _, err = tx.Exec(
+		ctx,
+		`update webhook_deliveries set status = $1, attempts = attempts + 1, response_status = $2, last_error = $3,
+		next_attempt_at = $4, delivered_at = $5 where id = $6`,
+		status,
+		attempt.ResponseStatus,
+		lastError,
+		nextAttemptAt,
+		deliveredAt,
+		attempt.DeliveryId,
+	); err != nil {
+		return false, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to record webhook delivery attempt",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var disabled bool
+	if attempt.Succeeded {
+		_, err = tx.Exec(ctx, "update webhook_subscriptions set consecutive_failures = 0 where id = $1", attempt.SubscriptionId)
+	} else {
+		err = tx.QueryRow(
+			ctx,
+			`update webhook_subscriptions set consecutive_failures = consecutive_failures + 1,
+			enabled = enabled and consecutive_failures + 1 < $1,
+			disabled_at = case when enabled and consecutive_failures + 1 >= $1 then $2 else disabled_at end
+			where id = $3 returning coalesce(disabled_at = $2, false)`,
+			disableAfter,
+			attempt.AttemptedAt,
+			attempt.SubscriptionId,
+		).Scan(&disabled)
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = nil
+		}
+	}
+	if err != nil {
+		return false, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to update webhook subscription health",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return false, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return disabled, nil
+}
+
+func collectSubscription(rows pgx.Rows) (*SubscriptionDTO, error) {
+	subscription, err := pgx.CollectOneRow(rows, pgx.RowToStructByPos[SubscriptionDTO])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, customError.CustomError{
+				Code:     fiber.StatusNotFound,
+				Message:  "webhook subscription not found",
+				Severity: zap.WarnLevel,
+			}
+		}
+
+		return nil, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to collect webhook subscription",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return &subscription, nil
+}
@@ -0,0 +1,189 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/webhook/repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/webhook/repository.go -destination=internal/webhook/repository_mock.go -package=webhook
+//
+
+// Package webhook is a generated GoMock package.
+package webhook
+
+import (
+	context "context"
+	outbox "invoice-api/internal/outbox"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateSubscription mocks base method.
+func (m *MockRepository) CreateSubscription(ctx context.Context, subscription *SubscriptionDTO) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSubscription", ctx, subscription)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSubscription indicates an expected call of CreateSubscription.
+func (mr *MockRepositoryMockRecorder) CreateSubscription(ctx, subscription any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSubscription", reflect.TypeOf((*MockRepository)(nil).CreateSubscription), ctx, subscription)
+}
+
+// DeleteSubscriptionById mocks base method.
+func (m *MockRepository) DeleteSubscriptionById(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSubscriptionById", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSubscriptionById indicates an expected call of DeleteSubscriptionById.
+func (mr *MockRepositoryMockRecorder) DeleteSubscriptionById(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSubscriptionById", reflect.TypeOf((*MockRepository)(nil).DeleteSubscriptionById), ctx, id)
+}
+
+// EnqueueDeliveries mocks base method.
+func (m *MockRepository) EnqueueDeliveries(ctx context.Context, event outbox.Event) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnqueueDeliveries", ctx, event)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnqueueDeliveries indicates an expected call of EnqueueDeliveries.
+func (mr *MockRepositoryMockRecorder) EnqueueDeliveries(ctx, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnqueueDeliveries", reflect.TypeOf((*MockRepository)(nil).EnqueueDeliveries), ctx, event)
+}
+
+// GetDeliveries mocks base method.
+func (m *MockRepository) GetDeliveries(ctx context.Context, subscriptionId string) (*[]DeliveryDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeliveries", ctx, subscriptionId)
+	ret0, _ := ret[0].(*[]DeliveryDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeliveries indicates an expected call of GetDeliveries.
+func (mr *MockRepositoryMockRecorder) GetDeliveries(ctx, subscriptionId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeliveries", reflect.TypeOf((*MockRepository)(nil).GetDeliveries), ctx, subscriptionId)
+}
+
+// GetDueDeliveries mocks base method.
+func (m *MockRepository) GetDueDeliveries(ctx context.Context, limit int) ([]PendingDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDueDeliveries", ctx, limit)
+	ret0, _ := ret[0].([]PendingDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDueDeliveries indicates an expected call of GetDueDeliveries.
+func (mr *MockRepositoryMockRecorder) GetDueDeliveries(ctx, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDueDeliveries", reflect.TypeOf((*MockRepository)(nil).GetDueDeliveries), ctx, limit)
+}
+
+// GetSubscriptionById mocks base method.
+func (m *MockRepository) GetSubscriptionById(ctx context.Context, id string) (*SubscriptionDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSubscriptionById", ctx, id)
+	ret0, _ := ret[0].(*SubscriptionDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSubscriptionById indicates an expected call of GetSubscriptionById.
+func (mr *MockRepositoryMockRecorder) GetSubscriptionById(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubscriptionById", reflect.TypeOf((*MockRepository)(nil).GetSubscriptionById), ctx, id)
+}
+
+// GetSubscriptions mocks base method.
+func (m *MockRepository) GetSubscriptions(ctx context.Context) (*[]SubscriptionDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSubscriptions", ctx)
+	ret0, _ := ret[0].(*[]SubscriptionDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSubscriptions indicates an expected call of GetSubscriptions.
+func (mr *MockRepositoryMockRecorder) GetSubscriptions(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubscriptions", reflect.TypeOf((*MockRepository)(nil).GetSubscriptions), ctx)
+}
+
+// RecordAttempt mocks base method.
+func (m *MockRepository) RecordAttempt(ctx context.Context, attempt DeliveryAttempt, disableAfter int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordAttempt", ctx, attempt, disableAfter)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordAttempt indicates an expected call of RecordAttempt.
+func (mr *MockRepositoryMockRecorder) RecordAttempt(ctx, attempt, disableAfter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordAttempt", reflect.TypeOf((*MockRepository)(nil).RecordAttempt), ctx, attempt, disableAfter)
+}
+
+// ReplayDelivery mocks base method.
+func (m *MockRepository) ReplayDelivery(ctx context.Context, subscriptionId, deliveryId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplayDelivery", ctx, subscriptionId, deliveryId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplayDelivery indicates an expected call of ReplayDelivery.
+func (mr *MockRepositoryMockRecorder) ReplayDelivery(ctx, subscriptionId, deliveryId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplayDelivery", reflect.TypeOf((*MockRepository)(nil).ReplayDelivery), ctx, subscriptionId, deliveryId)
+}
+
+// UpdateSubscriptionById mocks base method.
+func (m *MockRepository) UpdateSubscriptionById(ctx context.Context, id string, request *UpdateSubscriptionRequest) (*SubscriptionDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSubscriptionById", ctx, id, request)
+	ret0, _ := ret[0].(*SubscriptionDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateSubscriptionById indicates an expected call of UpdateSubscriptionById.
+func (mr *MockRepositoryMockRecorder) UpdateSubscriptionById(ctx, id, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSubscriptionById", reflect.TypeOf((*MockRepository)(nil).UpdateSubscriptionById), ctx, id, request)
+}
@@ -0,0 +1,146 @@
+package webhook
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+
+	"invoice-api/internal/outbox"
+	"invoice-api/pkg/requestcontext"
+	"invoice-api/pkg/tenant"
+)
+
+var tenantContext = requestcontext.WithTenant(context.Background(), tenant.DefaultTenant)
+
+func TestPgRepository_Webhooks(t *testing.T) {
+	pgContainer := setupContainer(t)
+	pgHost, err := pgContainer.Host(context.Background())
+	require.NoError(t, err)
+
+	pgPort, err := pgContainer.MappedPort(context.Background(), "5432/tcp")
+	require.NoError(t, err)
+
+	pgRepository := NewPgRepository(nil, pgHost, pgPort.Port(), "root", "root", "test")
+	subscription := &SubscriptionDTO{
+		Id:         uuid.NewString(),
+		URL:        "https://partner.example.com/hooks",
+		EventTypes: []string{"invoice.paid"},
+		Secret:     "whsec_test",
+		Enabled:    true,
+		CreatedAt:  time.Now().UTC().Truncate(time.Microsecond),
+	}
+	require.NoError(t, pgRepository.CreateSubscription(tenantContext, subscription))
+	require.NoError(t, pgRepository.CreateSubscription(requestcontext.WithTenant(context.Background(), "acme"), &SubscriptionDTO{
+		Id:         uuid.NewString(),
+		URL:        "https://acme.example.com/hooks",
+		EventTypes: []string{"invoice.paid"},
+		Secret:     "whsec_acme",
+		Enabled:    true,
+		CreatedAt:  time.Now().UTC().Truncate(time.Microsecond),
+	}))
+
+	event := outbox.Event{
+		Id:            7,
+		AggregateType: "invoice",
+		AggregateId:   uuid.NewString(),
+		Type:          "invoice.paid",
+		Payload:       []byte(`{}`),
+		TenantId:      tenant.DefaultTenant,
+	}
+	enqueued, err := pgRepository.EnqueueDeliveries(tenantContext, event)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), enqueued)
+
+	enqueued, err = pgRepository.EnqueueDeliveries(tenantContext, event)
+	require.NoError(t, err)
+	assert.Zero(t, enqueued)
+
+	enqueued, err = pgRepository.EnqueueDeliveries(tenantContext, outbox.Event{Id: 8, Type: "invoice.created", Payload: []byte(`{}`), TenantId: tenant.DefaultTenant})
+	require.NoError(t, err)
+	assert.Zero(t, enqueued)
+
+	due, err := pgRepository.GetDueDeliveries(tenantContext, 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, subscription.URL, due[0].URL)
+	assert.Equal(t, subscription.Secret, due[0].Secret)
+	assert.Equal(t, int64(7), due[0].EventId)
+
+	claimed, err := pgRepository.GetDueDeliveries(tenantContext, 10)
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	for i := 0; i < 2; i++ {
+		var disabled bool
+		disabled, err = pgRepository.RecordAttempt(tenantContext, DeliveryAttempt{
+			DeliveryId:     due[0].Id,
+			SubscriptionId: subscription.Id,
+			Error:          "unexpected response status 500",
+			AttemptedAt:    time.Now().UTC().Truncate(time.Microsecond),
+		}, 2)
+		require.NoError(t, err)
+		assert.Equal(t, i == 1, disabled)
+	}
+
+	stored, err := pgRepository.GetSubscriptionById(tenantContext, subscription.Id)
+	require.NoError(t, err)
+	assert.False(t, stored.Enabled)
+	assert.NotNil(t, stored.DisabledAt)
+	assert.Equal(t, 2, stored.ConsecutiveFailures)
+
+	deliveries, err := pgRepository.GetDeliveries(tenantContext, subscription.Id)
+	require.NoError(t, err)
+	require.Len(t, *deliveries, 1)
+	assert.Equal(t, DeliveryFailed, (*deliveries)[0].Status)
+	assert.Equal(t, 2, (*deliveries)[0].Attempts)
+
+	require.NoError(t, pgRepository.ReplayDelivery(tenantContext, subscription.Id, due[0].Id))
+	due, err = pgRepository.GetDueDeliveries(tenantContext, 10)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+
+	stored, err = pgRepository.UpdateSubscriptionById(tenantContext, subscription.Id, &UpdateSubscriptionRequest{
+		URL:        subscription.URL,
+		EventTypes: subscription.EventTypes,
+		Enabled:    true,
+	})
+	require.NoError(t, err)
+	assert.True(t, stored.Enabled)
+	assert.Nil(t, stored.DisabledAt)
+	assert.Zero(t, stored.ConsecutiveFailures)
+
+	due, err = pgRepository.GetDueDeliveries(tenantContext, 10)
+	require.NoError(t, err)
+	assert.Len(t, due, 1)
+
+	require.NoError(t, pgRepository.DeleteSubscriptionById(tenantContext, subscription.Id))
+	_, err = pgRepository.GetSubscriptionById(tenantContext, subscription.Id)
+	assert.Error(t, err)
+}
+
+func setupContainer(t *testing.T) *postgres.PostgresContainer {
+	ctx := context.Background()
+	postgresContainer, err := postgres.Run(
+		ctx,
+		"postgres",
+		postgres.WithDatabase("test"),
+		postgres.WithUsername("root"),
+		postgres.WithPassword("root"),
+		postgres.BasicWaitStrategies(),
+		postgres.WithSQLDriver("pgx"),
+		postgres.WithInitScripts("../../.scripts/init.sql"),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		err = postgresContainer.Terminate(ctx)
+		require.NoError(t, err)
+	})
+
+	return postgresContainer
+}
@@ -0,0 +1,67 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	HeaderId        = "Webhook-Id"
+	HeaderEvent     = "Webhook-Event"
+	HeaderTimestamp = "Webhook-Timestamp"
+	HeaderSignature = "Webhook-Signature"
+
+	signatureVersion = "v1"
+)
+
+var (
+	ErrMissingSignature = errors.New("missing webhook signature headers")
+	ErrExpiredTimestamp = errors.New("webhook timestamp is outside the tolerance")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+)
+
+func NewSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
+
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return signatureVersion + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func Verify(secret string, header http.Header, body []byte, tolerance time.Duration, now time.Time) error {
+	signatures := header.Get(HeaderSignature)
+	timestamp, err := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+	if signatures == "" || err != nil {
+		return ErrMissingSignature
+	}
+
+	if now.Sub(time.Unix(timestamp, 0)).Abs() > tolerance {
+		return ErrExpiredTimestamp
+	}
+
+	expected := Sign(secret, timestamp, body)
+	for _, signature := range strings.Split(signatures, ",") {
+		if hmac.Equal([]byte(strings.TrimSpace(signature)), []byte(expected)) {
+			return nil
+		}
+	}
+
+	return ErrInvalidSignature
+}
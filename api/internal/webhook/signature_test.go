@@ -0,0 +1,66 @@
+package webhook
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSign(t *testing.T) {
+	signature := Sign("whsec_test", 1718000000, []byte(`{"id":1}`))
+
+	assert.Equal(t, "v1=9909e4481a6c5d27d2ad4b309b9ad69c4a053a2b735e83929cfa6a4d72fa38da", signature)
+	assert.NotEqual(t, signature, Sign("whsec_other", 1718000000, []byte(`{"id":1}`)))
+	assert.NotEqual(t, signature, Sign("whsec_test", 1718000001, []byte(`{"id":1}`)))
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1718000000, 0)
+	body := []byte(`{"id":1}`)
+	headers := func(timestamp int64, signature string) http.Header {
+		header := http.Header{}
+		header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+		header.Set(HeaderSignature, signature)
+		return header
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		header := headers(now.Unix(), Sign("whsec_test", now.Unix(), body))
+		assert.NoError(t, Verify("whsec_test", header, body, 5*time.Minute, now.Add(time.Minute)))
+	})
+
+	t.Run("valid with rotated secrets", func(t *testing.T) {
+		header := headers(now.Unix(), Sign("whsec_old", now.Unix(), body)+", "+Sign("whsec_test", now.Unix(), body))
+		assert.NoError(t, Verify("whsec_test", header, body, 5*time.Minute, now))
+	})
+
+	t.Run("missing headers", func(t *testing.T) {
+		assert.ErrorIs(t, Verify("whsec_test", http.Header{}, body, 5*time.Minute, now), ErrMissingSignature)
+	})
+
+	t.Run("expired timestamp", func(t *testing.T) {
+		header := headers(now.Unix(), Sign("whsec_test", now.Unix(), body))
+		assert.ErrorIs(t, Verify("whsec_test", header, body, 5*time.Minute, now.Add(10*time.Minute)), ErrExpiredTimestamp)
+	})
+
+	t.Run("tampered body", func(t *testing.T) {
+		header := headers(now.Unix(), Sign("whsec_test", now.Unix(), body))
+		assert.ErrorIs(t, Verify("whsec_test", header, []byte(`{"id":2}`), 5*time.Minute, now), ErrInvalidSignature)
+	})
+}
+
+func TestNewSecret(t *testing.T) {
+	first, err := NewSecret()
+	require.NoError(t, err)
+	second, err := NewSecret()
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(first, "whsec_"))
+	assert.Len(t, first, len("whsec_")+64)
+	assert.NotEqual(t, first, second)
+}
@@ -0,0 +1,24 @@
+package webhook
+
+import (
+	"context"
+
+	"invoice-api/internal/outbox"
+)
+
+type Sink struct {
+	repository Repository
+}
+
+func NewSink(repository Repository) *Sink {
+	return &Sink{repository: repository}
+}
+
+func (s *Sink) Publish(ctx context.Context, event outbox.Event) error {
+	_, err := s.repository.EnqueueDeliveries(ctx, event)
+	return err
+}
+
+func (s *Sink) Close() error {
+	return nil
+}
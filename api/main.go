@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
@@ -11,14 +12,31 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/monitor"
-	"github.com/gofiber/fiber/v2/middleware/pprof"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"go.uber.org/zap"
 
+	"invoice-api/internal/apikey"
+	"invoice-api/internal/attachment"
+	"invoice-api/internal/document"
+	"invoice-api/internal/dunning"
 	"invoice-api/internal/invoice"
+	"invoice-api/internal/outbox"
+	"invoice-api/internal/recurring"
+	"invoice-api/internal/report"
+	"invoice-api/internal/usage"
+	"invoice-api/internal/webhook"
+	"invoice-api/pkg/admin"
+	"invoice-api/pkg/auth"
 	"invoice-api/pkg/config"
 	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/health"
+	"invoice-api/pkg/metrics"
+	"invoice-api/pkg/payment"
+	"invoice-api/pkg/ratelimit"
+	"invoice-api/pkg/requestcontext"
+	"invoice-api/pkg/signature"
+	"invoice-api/pkg/tenant"
+	"invoice-api/pkg/tracing"
 )
 
 type GlobalHandler interface {
@@ -33,6 +51,17 @@ func main() {
 	}
 	defer log.Sync()
 
+	shutdownTracing, err := tracing.NewProvider(context.Background(), tracing.Config{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		ServiceName: cfg.Tracing.ServiceName,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		log.Fatal("failed to initialize tracing", zap.Error(err))
+	}
+
 	invoicePgRepository := invoice.NewPgRepository(
 		log,
 		cfg.Postgresql.Host,
@@ -41,8 +70,119 @@ func main() {
 		cfg.Postgresql.Password,
 		cfg.Postgresql.Database,
 	)
+	metrics.RegisterPool("invoice", invoicePgRepository.Stat)
+	invoiceRepository := invoice.NewInstrumentedRepository(invoicePgRepository)
+
+	if len(os.Args) > 1 && os.Args[1] == "verify-chain" {
+		exitCode := runVerifyChain(invoicePgRepository, os.Args[2:], os.Stdout)
+		_ = log.Sync()
+		os.Exit(exitCode)
+	}
+
+	healthChecker := health.New(cfg.Health.Timeout)
+	healthChecker.Add("postgres:invoice", invoicePgRepository.Ping)
+
+	var signer *signature.Signer
+	if cfg.Signing.CertificatePath != "" {
+		signer, err = signature.NewSigner(
+			cfg.Signing.CertificatePath,
+			cfg.Signing.KeyPath,
+			cfg.Signing.TrustedCertificatesPath,
+		)
+		if err != nil {
+			log.Fatal("failed to load signing certificate", zap.Error(err))
+		}
+	}
+
+	var attachmentStorage attachment.Storage
+	switch cfg.Attachment.Storage {
+	case "s3":
+		attachmentStorage, err = attachment.NewS3Storage(
+			cfg.Attachment.S3.Endpoint,
+			cfg.Attachment.S3.AccessKey,
+			cfg.Attachment.S3.SecretKey,
+			cfg.Attachment.S3.Bucket,
+			cfg.Attachment.S3.Region,
+			cfg.Attachment.S3.UseSSL,
+			nil,
+		)
+	default:
+		attachmentStorage, err = attachment.NewLocalStorage(cfg.Attachment.LocalPath)
+	}
+	if err != nil {
+		log.Fatal("failed to initialize attachment storage", zap.Error(err))
+	}
+
+	var outboxSink outbox.Sink
+	switch cfg.Outbox.Sink {
+	case "nats":
+		outboxSink, err = outbox.NewNATSSink(cfg.Outbox.NATS.URL, cfg.Outbox.NATS.SubjectPrefix)
+	case "kafka":
+		outboxSink = outbox.NewKafkaSink(cfg.Outbox.Kafka.Brokers, cfg.Outbox.Kafka.Topic)
+	case "file":
+		outboxSink, err = outbox.NewFileSink(cfg.Outbox.FilePath)
+	default:
+		outboxSink = outbox.NewWriterSink(os.Stdout)
+	}
+	if err != nil {
+		log.Fatal("failed to initialize outbox sink", zap.Error(err))
+	}
+
+	webhookPgRepository := webhook.NewPgRepository(
+		log,
+		cfg.Postgresql.Host,
+		cfg.Postgresql.Port,
+		cfg.Postgresql.Username,
+		cfg.Postgresql.Password,
+		cfg.Postgresql.Database,
+	)
+	metrics.RegisterPool("webhook", webhookPgRepository.Stat)
+	healthChecker.Add("postgres:webhook", webhookPgRepository.Ping)
+	webhookRepository := webhook.NewInstrumentedRepository(webhookPgRepository)
+	outboxSink = outbox.NewMultiSink(outboxSink, webhook.NewSink(webhookRepository))
+	defer outboxSink.Close()
+
+	genericQRFormat, err := payment.NewGenericFormat(cfg.Payment.GenericTemplate)
+	if err != nil {
+		log.Fatal("failed to initialize qr payload format", zap.Error(err))
+	}
+
+	dunningRenderer, err := dunning.NewRenderer()
+	if err != nil {
+		log.Fatal("failed to load dunning templates", zap.Error(err))
+	}
+
+	dunningPolicy := dunning.Policy{
+		PaymentTermDays: cfg.Dunning.PaymentTermDays,
+		Recipients:      make(map[string]dunning.Recipient),
+		Escalation:      dunning.Recipient{Email: cfg.Dunning.Escalation.Email, Locale: cfg.Dunning.Escalation.Locale},
+		From:            cfg.Dunning.From,
+		Currency:        cfg.Document.Currency,
+	}
+	for _, step := range cfg.Dunning.Steps {
+		if !dunningRenderer.Has(step.Name) {
+			log.Fatal("missing dunning template", zap.String("step", step.Name))
+		}
+		dunningPolicy.Steps = append(dunningPolicy.Steps, dunning.Step{Name: step.Name, OffsetDays: step.OffsetDays, Escalate: step.Escalate})
+	}
+	for serviceName, recipient := range cfg.Dunning.Recipients {
+		dunningPolicy.Recipients[serviceName] = dunning.Recipient{Email: recipient.Email, Locale: recipient.Locale}
+	}
+
+	apikeyPgRepository := apikey.NewPgRepository(
+		log,
+		cfg.Postgresql.Host,
+		cfg.Postgresql.Port,
+		cfg.Postgresql.Username,
+		cfg.Postgresql.Password,
+		cfg.Postgresql.Database,
+	)
+	metrics.RegisterPool("apikey", apikeyPgRepository.Stat)
+	healthChecker.Add("postgres:apikey", apikeyPgRepository.Ping)
+	apikeyRepository := apikey.NewInstrumentedRepository(apikeyPgRepository)
 
 	server := fiber.New(fiber.Config{
+		BodyLimit:             max(fiber.DefaultBodyLimit, int(cfg.Attachment.MaxSizeBytes)+1<<20),
 		JSONDecoder:           json.Unmarshal,
 		JSONEncoder:           json.Marshal,
 		ErrorHandler:          customError.ErrorHandler,
@@ -52,17 +192,263 @@ func main() {
 		ctx.Locals(customError.ContextKeyLog, log)
 		return ctx.Next()
 	})
+	server.Use(tracing.New())
+	server.Use(metrics.New())
+	server.Use(requestcontext.New())
 	server.Use(recover.New())
-	server.Use(cors.New(cors.Config{AllowOrigins: cfg.CorsOrigins}))
-	server.Use(pprof.New())
-	server.Get("/metrics", monitor.New())
+	if cfg.CorsOrigins != "" {
+		server.Use(cors.New(cors.Config{AllowOrigins: cfg.CorsOrigins}))
+	}
+	var rateLimitBySubject fiber.Handler
+	if cfg.RateLimit.Enabled {
+		rateLimitConfig := ratelimit.Config{Default: ratelimit.Limit{Rate: cfg.RateLimit.Rate, Burst: cfg.RateLimit.Burst}}
+		for _, route := range cfg.RateLimit.Routes {
+			rateLimitConfig.Routes = append(rateLimitConfig.Routes, ratelimit.Route{
+				Method: route.Method,
+				Path:   route.Path,
+				Limit:  ratelimit.Limit{Rate: route.Rate, Burst: route.Burst},
+			})
+		}
+
+		var rateLimitStore ratelimit.Store = ratelimit.NewMemoryStore()
+		if cfg.RateLimit.Store == "postgres" {
+			rateLimitPgStore := ratelimit.NewPgStore(
+				log,
+				cfg.Postgresql.Host,
+				cfg.Postgresql.Port,
+				cfg.Postgresql.Username,
+				cfg.Postgresql.Password,
+				cfg.Postgresql.Database,
+			)
+			metrics.RegisterPool("ratelimit", rateLimitPgStore.Stat)
+			healthChecker.Add("ratelimit", rateLimitPgStore.Ping)
+			rateLimitStore = rateLimitPgStore
+		}
+		server.Use(ratelimit.New(rateLimitConfig, rateLimitStore, ratelimit.ByIP))
+		rateLimitBySubject = ratelimit.New(rateLimitConfig, rateLimitStore, ratelimit.BySubject)
+	}
+	server.Use(apikey.NewMiddleware(apikeyRepository))
+	if cfg.Auth.Enabled {
+		var authMiddleware fiber.Handler
+		authMiddleware, err = auth.New(context.Background(), auth.Config{
+			Secret:      cfg.Auth.Secret,
+			JWKSPath:    cfg.Auth.JWKSPath,
+			JWKSURL:     cfg.Auth.JWKSURL,
+			Issuer:      cfg.Auth.Issuer,
+			Audience:    cfg.Auth.Audience,
+			RolesClaim:  cfg.Auth.RolesClaim,
+			TenantClaim: cfg.Auth.TenantClaim,
+			PublicPaths: cfg.Auth.PublicPaths,
+		})
+		if err != nil {
+			log.Fatal("failed to initialize authentication", zap.Error(err))
+		}
+		server.Use(authMiddleware)
+	} else {
+		server.Use(auth.Anonymous(cfg.Auth.AnonymousRoles))
+	}
+	if rateLimitBySubject != nil {
+		server.Use(rateLimitBySubject)
+	}
+	server.Use(tenant.New(tenant.Config{
+		Header:          cfg.Tenant.Header,
+		Default:         cfg.Tenant.Default,
+		TrustedSubjects: cfg.Tenant.TrustedSubjects,
+	}))
+
+	recurringPgRepository := recurring.NewPgRepository(
+		log,
+		cfg.Postgresql.Host,
+		cfg.Postgresql.Port,
+		cfg.Postgresql.Username,
+		cfg.Postgresql.Password,
+		cfg.Postgresql.Database,
+	)
+	metrics.RegisterPool("recurring", recurringPgRepository.Stat)
+	healthChecker.Add("postgres:recurring", recurringPgRepository.Ping)
+	recurringRepository := recurring.NewInstrumentedRepository(recurringPgRepository)
+
+	usagePgRepository := usage.NewPgRepository(
+		log,
+		cfg.Postgresql.Host,
+		cfg.Postgresql.Port,
+		cfg.Postgresql.Username,
+		cfg.Postgresql.Password,
+		cfg.Postgresql.Database,
+	)
+	metrics.RegisterPool("usage", usagePgRepository.Stat)
+	healthChecker.Add("postgres:usage", usagePgRepository.Ping)
+	usageRepository := usage.NewInstrumentedRepository(usagePgRepository)
+
+	dunningPgRepository := dunning.NewPgRepository(
+		log,
+		cfg.Postgresql.Host,
+		cfg.Postgresql.Port,
+		cfg.Postgresql.Username,
+		cfg.Postgresql.Password,
+		cfg.Postgresql.Database,
+	)
+	metrics.RegisterPool("dunning", dunningPgRepository.Stat)
+	healthChecker.Add("postgres:dunning", dunningPgRepository.Ping)
+	dunningRepository := dunning.NewInstrumentedRepository(dunningPgRepository)
+
+	attachmentPgRepository := attachment.NewPgRepository(
+		log,
+		cfg.Postgresql.Host,
+		cfg.Postgresql.Port,
+		cfg.Postgresql.Username,
+		cfg.Postgresql.Password,
+		cfg.Postgresql.Database,
+	)
+	metrics.RegisterPool("attachment", attachmentPgRepository.Stat)
+	healthChecker.Add("postgres:attachment", attachmentPgRepository.Ping)
+	attachmentRepository := attachment.NewInstrumentedRepository(attachmentPgRepository)
+
+	reportPgRepository := report.NewPgRepository(
+		log,
+		cfg.Postgresql.Host,
+		cfg.Postgresql.Port,
+		cfg.Postgresql.Username,
+		cfg.Postgresql.Password,
+		cfg.Postgresql.Database,
+	)
+	metrics.RegisterPool("report", reportPgRepository.Stat)
+	healthChecker.Add("postgres:report", reportPgRepository.Ping)
+	reportRepository := report.NewInstrumentedRepository(reportPgRepository)
+
+	outboxPgRepository := outbox.NewPgRepository(
+		log,
+		cfg.Postgresql.Host,
+		cfg.Postgresql.Port,
+		cfg.Postgresql.Username,
+		cfg.Postgresql.Password,
+		cfg.Postgresql.Database,
+	)
+	metrics.RegisterPool("outbox", outboxPgRepository.Stat)
+	healthChecker.Add("postgres:outbox", outboxPgRepository.Ping)
+	outboxRepository := outbox.NewInstrumentedRepository(outboxPgRepository)
+
+	jobContext, cancelJobs := context.WithCancel(tenant.WithSystem(requestcontext.WithLogger(context.Background(), log)))
+	defer cancelJobs()
+
+	metrics.Registry.MustRegister(invoice.NewCollector(log, invoiceRepository, 5*time.Second))
+	metrics.Registry.MustRegister(outbox.NewCollector(log, outboxRepository, 5*time.Second))
+	invoiceBroker := invoice.NewBroker(log, invoiceRepository, cfg.Stream.PollInterval)
+	go invoiceBroker.Run(jobContext)
 
 	validate := validator.New()
-	handlers := []GlobalHandler{invoice.NewHandler(server, validate, invoicePgRepository)}
+	authorizer := auth.NewAuthorizer(cfg.Auth.Roles)
+	handlers := []GlobalHandler{
+		invoice.NewHandler(server, validate, invoiceRepository, invoiceBroker, authorizer),
+		document.NewHandler(server, validate, invoiceRepository, signer, document.Supplier{
+			Name:     cfg.Document.SupplierName,
+			TaxId:    cfg.Document.SupplierTaxId,
+			Currency: cfg.Document.Currency,
+			IBAN:     cfg.Payment.IBAN,
+			BIC:      cfg.Payment.BIC,
+		}, document.QRConfig{
+			DefaultFormat: cfg.Payment.QRFormat,
+			Formats: map[string]payment.Format{
+				payment.FormatEPC:     payment.EPCFormat{},
+				payment.FormatGeneric: genericQRFormat,
+			},
+		}, authorizer),
+		webhook.NewHandler(server, validate, webhookRepository, authorizer),
+		recurring.NewHandler(server, validate, recurringRepository, authorizer),
+		usage.NewHandler(server, validate, usageRepository, usage.NewBiller(usageRepository, invoiceRepository), authorizer),
+		dunning.NewHandler(server, validate, dunningRepository, authorizer),
+		apikey.NewHandler(server, validate, apikeyRepository, authorizer),
+		attachment.NewHandler(
+			server,
+			validate,
+			attachmentRepository,
+			attachmentStorage,
+			cfg.Attachment.MaxSizeBytes,
+			cfg.Attachment.AllowedContentTypes,
+			authorizer,
+		),
+		report.NewHandler(
+			server,
+			validate,
+			reportRepository,
+			cfg.Report.AgingBuckets,
+			authorizer,
+		),
+	}
 	for _, handler := range handlers {
 		handler.RegisterRoutes()
 	}
 
+	go invoice.NewRetentionJob(
+		log,
+		invoiceRepository,
+		attachmentStorage,
+		time.Duration(cfg.Retention.DeletedInvoicesDays)*24*time.Hour,
+		cfg.Retention.PurgeInterval,
+	).Run(jobContext)
+
+	go recurring.NewScheduler(
+		log,
+		recurringRepository,
+		invoiceRepository,
+		cfg.Recurring.BatchSize,
+		cfg.Recurring.PollInterval,
+	).Run(jobContext)
+
+	go dunning.NewWorker(
+		log,
+		dunningRepository,
+		dunning.NewSMTPSender(
+			cfg.Dunning.SMTP.Host,
+			cfg.Dunning.SMTP.Port,
+			cfg.Dunning.SMTP.Username,
+			cfg.Dunning.SMTP.Password,
+			cfg.Dunning.SMTP.Timeout,
+		),
+		dunningRenderer,
+		dunningPolicy,
+		cfg.Dunning.BatchSize,
+		cfg.Dunning.PollInterval,
+	).Run(jobContext)
+
+	go outbox.NewRelay(
+		log,
+		outboxRepository,
+		outboxSink,
+		cfg.Outbox.BatchSize,
+		cfg.Outbox.PollInterval,
+		cfg.Outbox.MaxBackoff,
+		cfg.Outbox.MaxAttempts,
+	).Run(jobContext)
+
+	go webhook.NewDispatcher(
+		log,
+		webhookRepository,
+		webhook.NewClient(cfg.Webhook.Timeout),
+		cfg.Webhook.BatchSize,
+		cfg.Webhook.PollInterval,
+		cfg.Webhook.MaxBackoff,
+		cfg.Webhook.MaxAttempts,
+		cfg.Webhook.DisableAfterFailures,
+	).Run(jobContext)
+
+	adminConfig := admin.Config{
+		Username:  cfg.Admin.Username,
+		Password:  cfg.Admin.Password,
+		Token:     cfg.Admin.Token,
+		Liveness:  healthChecker.Liveness,
+		Readiness: healthChecker.Readiness,
+	}
+	if err = admin.CheckAddress(cfg.Admin.Address, adminConfig); err != nil {
+		log.Fatal("refusing to start admin server", zap.Error(err))
+	}
+	adminServer := admin.New(log, adminConfig)
+	go func() {
+		if err := adminServer.Listen(cfg.Admin.Address); err != nil {
+			log.Fatal("failed to start admin server", zap.Error(err))
+		}
+	}()
+
 	go func() {
 		if err = server.Listen(fmt.Sprintf("0.0.0.0:%s", cfg.ServerPort)); err != nil {
 			log.Fatal("failed to start server", zap.Error(err))
@@ -70,12 +456,25 @@ func main() {
 	}()
 
 	log.Info("server started on port", zap.String("port", cfg.ServerPort))
+	log.Info("admin server started on address", zap.String("address", cfg.Admin.Address))
 	signalChannel := make(chan os.Signal, 1)
 	signal.Notify(signalChannel, os.Interrupt, syscall.SIGTERM)
 	<-signalChannel
 
 	log.Info("shutting down server...")
+	healthChecker.Shutdown()
+	time.Sleep(cfg.Health.ShutdownDelay)
+	cancelJobs()
 	if err = server.ShutdownWithTimeout(5 * time.Second); err != nil {
 		log.Fatal("error occurred while server shutdown", zap.Error(err))
 	}
+	if err = adminServer.ShutdownWithTimeout(5 * time.Second); err != nil {
+		log.Fatal("error occurred while admin server shutdown", zap.Error(err))
+	}
+
+	tracingContext, cancelTracing := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelTracing()
+	if err = shutdownTracing(tracingContext); err != nil {
+		log.Error("error occurred while tracing shutdown", zap.Error(err))
+	}
 }
@@ -0,0 +1,107 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/monitor"
+	"github.com/gofiber/fiber/v2/middleware/pprof"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	"go.uber.org/zap"
+
+	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/metrics"
+)
+
+type Config struct {
+	Username  string
+	Password  string
+	Token     string
+	Liveness  fiber.Handler
+	Readiness fiber.Handler
+}
+
+func New(log *zap.Logger, config Config) *fiber.App {
+	server := fiber.New(fiber.Config{
+		ErrorHandler:          customError.ErrorHandler,
+		DisableStartupMessage: true,
+	})
+	server.Use(func(ctx *fiber.Ctx) error {
+		ctx.Locals(customError.ContextKeyLog, log.With(zap.String("server", "admin")))
+		return ctx.Next()
+	})
+	server.Use(recover.New())
+	if config.Liveness != nil {
+		server.Get("/healthz", config.Liveness)
+	}
+	if config.Readiness != nil {
+		server.Get("/readyz", config.Readiness)
+	}
+	server.Use(protect(config))
+	server.Use(pprof.New())
+	server.Get("/monitor", monitor.New(monitor.Config{Title: "Invoice API"}))
+	server.Get("/metrics", metrics.Handler())
+
+	return server
+}
+
+// CheckAddress refuses to serve the admin endpoints beyond the loopback
+// interface without credentials.
+func CheckAddress(address string, config Config) error {
+	if (config.Username != "" && config.Password != "") || config.Token != "" {
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+		return nil
+	}
+
+	return fmt.Errorf("admin address %s is not a loopback address and no credentials are configured", address)
+}
+
+func protect(config Config) fiber.Handler {
+	basic := config.Username != "" || config.Password != ""
+	if !basic && config.Token == "" {
+		return func(ctx *fiber.Ctx) error {
+			return ctx.Next()
+		}
+	}
+
+	return func(ctx *fiber.Ctx) error {
+		scheme, credentials, _ := strings.Cut(ctx.Get(fiber.HeaderAuthorization), " ")
+		switch {
+		case config.Token != "" && strings.EqualFold(scheme, "Bearer") && equal(credentials, config.Token):
+			return ctx.Next()
+		case basic && strings.EqualFold(scheme, "Basic"):
+			decoded, err := base64.StdEncoding.DecodeString(credentials)
+			username, password, _ := strings.Cut(string(decoded), ":")
+			if err == nil && equal(username, config.Username) && equal(password, config.Password) {
+				return ctx.Next()
+			}
+		}
+
+		if basic {
+			ctx.Set(fiber.HeaderWWWAuthenticate, `Basic realm="admin"`)
+		} else {
+			ctx.Set(fiber.HeaderWWWAuthenticate, "Bearer")
+		}
+		return customError.CustomError{
+			Code:     fiber.StatusUnauthorized,
+			Message:  "unauthorized",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.String("path", ctx.Path())},
+		}
+	}
+}
+
+func equal(actual, expected string) bool {
+	return subtle.ConstantTimeCompare([]byte(actual), []byte(expected)) == 1
+}
@@ -0,0 +1,113 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCheckAddress(t *testing.T) {
+	assert.NoError(t, CheckAddress("127.0.0.1:9090", Config{}))
+	assert.NoError(t, CheckAddress("[::1]:9090", Config{}))
+	assert.NoError(t, CheckAddress("localhost:9090", Config{}))
+	assert.NoError(t, CheckAddress("0.0.0.0:9090", Config{Token: "secret-token"}))
+	assert.NoError(t, CheckAddress("0.0.0.0:9090", Config{Username: "admin", Password: "secret"}))
+	assert.Error(t, CheckAddress("0.0.0.0:9090", Config{}))
+	assert.Error(t, CheckAddress(":9090", Config{}))
+	assert.Error(t, CheckAddress("10.0.0.5:9090", Config{Username: "admin"}))
+	assert.Error(t, CheckAddress("9090", Config{}))
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        Config
+		authorization string
+		status        int
+		challenge     string
+	}{
+		{
+			name:   "unprotected",
+			status: fiber.StatusOK,
+		},
+		{
+			name:          "token",
+			config:        Config{Token: "secret-token"},
+			authorization: "Bearer secret-token",
+			status:        fiber.StatusOK,
+		},
+		{
+			name:          "wrong token",
+			config:        Config{Token: "secret-token"},
+			authorization: "Bearer another-token",
+			status:        fiber.StatusUnauthorized,
+			challenge:     "Bearer",
+		},
+		{
+			name:          "basic auth",
+			config:        Config{Username: "admin", Password: "secret"},
+			authorization: "Basic YWRtaW46c2VjcmV0",
+			status:        fiber.StatusOK,
+		},
+		{
+			name:          "wrong password",
+			config:        Config{Username: "admin", Password: "secret"},
+			authorization: "Basic YWRtaW46b3RoZXI=",
+			status:        fiber.StatusUnauthorized,
+			challenge:     `Basic realm="admin"`,
+		},
+		{
+			name:          "basic auth or token",
+			config:        Config{Username: "admin", Password: "secret", Token: "secret-token"},
+			authorization: "Bearer secret-token",
+			status:        fiber.StatusOK,
+		},
+		{
+			name:      "missing credentials",
+			config:    Config{Username: "admin", Password: "secret", Token: "secret-token"},
+			status:    fiber.StatusUnauthorized,
+			challenge: `Basic realm="admin"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := New(zap.NewNop(), test.config)
+
+			for _, path := range []string{"/metrics", "/monitor", "/debug/pprof/"} {
+				req := httptest.NewRequest(http.MethodGet, path, nil)
+				if test.authorization != "" {
+					req.Header.Set(fiber.HeaderAuthorization, test.authorization)
+				}
+
+				res, err := server.Test(req, -1)
+				require.NoError(t, err)
+				assert.Equal(t, test.status, res.StatusCode, path)
+				assert.Equal(t, test.challenge, res.Header.Get(fiber.HeaderWWWAuthenticate), path)
+			}
+		})
+	}
+}
+
+func TestNew_Health(t *testing.T) {
+	server := New(zap.NewNop(), Config{
+		Token:     "secret-token",
+		Liveness:  func(ctx *fiber.Ctx) error { return ctx.SendStatus(fiber.StatusOK) },
+		Readiness: func(ctx *fiber.Ctx) error { return ctx.SendStatus(fiber.StatusServiceUnavailable) },
+	})
+
+	for path, status := range map[string]int{
+		"/healthz": fiber.StatusOK,
+		"/readyz":  fiber.StatusServiceUnavailable,
+		"/metrics": fiber.StatusUnauthorized,
+	} {
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, path, nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, status, res.StatusCode, path)
+	}
+}
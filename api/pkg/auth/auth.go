@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/MicahParks/keyfunc/v3"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+
+	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/requestcontext"
+)
+
+const ContextKeySubject = "subject"
+
+type Config struct {
+	Secret      string
+	JWKSPath    string
+	JWKSURL     string
+	Issuer      string
+	Audience    string
+	RolesClaim  string
+	TenantClaim string
+	PublicPaths []string
+}
+
+type authenticator struct {
+	secret      []byte
+	jwks        keyfunc.Keyfunc
+	parser      *jwt.Parser
+	rolesClaim  string
+	tenantClaim string
+}
+
+func New(ctx context.Context, config Config) (fiber.Handler, error) {
+	a := &authenticator{rolesClaim: config.RolesClaim, tenantClaim: config.TenantClaim}
+	if a.rolesClaim == "" {
+		a.rolesClaim = "roles"
+	}
+	if a.tenantClaim == "" {
+		a.tenantClaim = "tenant"
+	}
+	var methods []string
+	if config.Secret != "" {
+		a.secret = []byte(config.Secret)
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+
+	var err error
+	switch {
+	case config.JWKSPath != "":
+		var raw []byte
+		raw, err = os.ReadFile(config.JWKSPath)
+		if err != nil {
+			return nil, err
+		}
+		a.jwks, err = keyfunc.NewJWKSetJSON(raw)
+	case config.JWKSURL != "":
+		a.jwks, err = keyfunc.NewDefaultCtx(ctx, []string{config.JWKSURL})
+	}
+	if err != nil {
+		return nil, err
+	}
+	if a.jwks != nil {
+		methods = append(methods, jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg())
+	}
+	if len(methods) == 0 {
+		return nil, errors.New("auth requires a secret or a jwks")
+	}
+
+	options := []jwt.ParserOption{jwt.WithValidMethods(methods), jwt.WithExpirationRequired()}
+	if config.Issuer != "" {
+		options = append(options, jwt.WithIssuer(config.Issuer))
+	}
+	if config.Audience != "" {
+		options = append(options, jwt.WithAudience(config.Audience))
+	}
+	a.parser = jwt.NewParser(options...)
+
+	return func(ctx *fiber.Ctx) error {
+		if Subject(ctx) != "" || slices.Contains(config.PublicPaths, ctx.Path()) {
+			return ctx.Next()
+		}
+
+		claims, err := a.authenticate(ctx)
+		if err != nil {
+			ctx.Set(fiber.HeaderWWWAuthenticate, "Bearer")
+			return err
+		}
+
+		subject, _ := claims.GetSubject()
+		log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger).With(zap.String("subject", subject))
+		ctx.Locals(customError.ContextKeyLog, log)
+		ctx.Locals(ContextKeySubject, subject)
+		ctx.Locals(ContextKeyRoles, rolesFromClaims(claims, a.rolesClaim))
+		userContext := requestcontext.WithActor(ctx.UserContext(), subject)
+		if tenant, ok := claims[a.tenantClaim].(string); ok && tenant != "" {
+			userContext = requestcontext.WithTenant(userContext, tenant)
+		}
+		ctx.SetUserContext(userContext)
+		return ctx.Next()
+	}, nil
+}
+
+func (a *authenticator) authenticate(ctx *fiber.Ctx) (jwt.MapClaims, error) {
+	scheme, token, ok := strings.Cut(ctx.Get(fiber.HeaderAuthorization), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusUnauthorized,
+			Message:  "missing bearer token",
+			Severity: zap.WarnLevel,
+		}
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := a.parser.ParseWithClaims(token, claims, a.key)
+	if err != nil {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusUnauthorized,
+			Message:  "invalid bearer token",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		return nil, customError.CustomError{
+			Code:     fiber.StatusUnauthorized,
+			Message:  "bearer token has no subject",
+			Severity: zap.WarnLevel,
+		}
+	}
+
+	return claims, nil
+}
+
+func (a *authenticator) key(token *jwt.Token) (any, error) {
+	if token.Method.Alg() == jwt.SigningMethodHS256.Alg() {
+		return a.secret, nil
+	}
+
+	return a.jwks.Keyfunc(token)
+}
+
+func Subject(ctx *fiber.Ctx) string {
+	subject, _ := ctx.Locals(ContextKeySubject).(string)
+	return subject
+}
@@ -0,0 +1,251 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/requestcontext"
+)
+
+const secret = "0123456789abcdef0123456789abcdef"
+
+func TestNew(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	jwks := fmt.Sprintf(
+		`{"keys":[{"kty":"RSA","kid":"rsa","alg":"RS256","use":"sig","n":"%s","e":"%s"},{"kty":"EC","kid":"ec","alg":"ES256","use":"sig","crv":"P-256","x":"%s","y":"%s"}]}`,
+		encode(rsaKey.N.Bytes()),
+		encode(big.NewInt(int64(rsaKey.E)).Bytes()),
+		encode(ecKey.X.FillBytes(make([]byte, 32))),
+		encode(ecKey.Y.FillBytes(make([]byte, 32))),
+	)
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(jwksPath, []byte(jwks), 0o600))
+
+	claims := func(subject string) jwt.MapClaims {
+		return jwt.MapClaims{
+			"sub": subject,
+			"iss": "https://issuer.example.com",
+			"aud": "invoice-api",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+	}
+	sign := func(method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(method, claims)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(key)
+		require.NoError(t, err)
+		return signed
+	}
+	config := Config{
+		Secret:      secret,
+		JWKSPath:    jwksPath,
+		Issuer:      "https://issuer.example.com",
+		Audience:    "invoice-api",
+		PublicPaths: []string{"/public"},
+	}
+
+	tests := []struct {
+		name          string
+		config        Config
+		path          string
+		authorization string
+		status        int
+		subject       string
+	}{
+		{
+			name:          "hs256",
+			config:        config,
+			authorization: "Bearer " + sign(jwt.SigningMethodHS256, "", []byte(secret), claims("jane")),
+			status:        fiber.StatusOK,
+			subject:       "jane",
+		},
+		{
+			name:          "rs256 from jwks",
+			config:        config,
+			authorization: "Bearer " + sign(jwt.SigningMethodRS256, "rsa", rsaKey, claims("john")),
+			status:        fiber.StatusOK,
+			subject:       "john",
+		},
+		{
+			name:          "es256 from jwks",
+			config:        config,
+			authorization: "bearer " + sign(jwt.SigningMethodES256, "ec", ecKey, claims("joe")),
+			status:        fiber.StatusOK,
+			subject:       "joe",
+		},
+		{
+			name:   "missing token",
+			config: config,
+			status: fiber.StatusUnauthorized,
+		},
+		{
+			name:          "wrong scheme",
+			config:        config,
+			authorization: "Basic amFuZTpzZWNyZXQ=",
+			status:        fiber.StatusUnauthorized,
+		},
+		{
+			name:          "wrong secret",
+			config:        config,
+			authorization: "Bearer " + sign(jwt.SigningMethodHS256, "", []byte("another secret"), claims("jane")),
+			status:        fiber.StatusUnauthorized,
+		},
+		{
+			name:          "wrong issuer",
+			config:        config,
+			authorization: "Bearer " + sign(jwt.SigningMethodHS256, "", []byte(secret), jwt.MapClaims{"sub": "jane", "iss": "other", "aud": "invoice-api", "exp": time.Now().Add(time.Hour).Unix()}),
+			status:        fiber.StatusUnauthorized,
+		},
+		{
+			name:          "wrong audience",
+			config:        config,
+			authorization: "Bearer " + sign(jwt.SigningMethodHS256, "", []byte(secret), jwt.MapClaims{"sub": "jane", "iss": "https://issuer.example.com", "aud": "other", "exp": time.Now().Add(time.Hour).Unix()}),
+			status:        fiber.StatusUnauthorized,
+		},
+		{
+			name:          "expired",
+			config:        config,
+			authorization: "Bearer " + sign(jwt.SigningMethodHS256, "", []byte(secret), jwt.MapClaims{"sub": "jane", "iss": "https://issuer.example.com", "aud": "invoice-api", "exp": time.Now().Add(-time.Hour).Unix()}),
+			status:        fiber.StatusUnauthorized,
+		},
+		{
+			name:          "without expiration",
+			config:        config,
+			authorization: "Bearer " + sign(jwt.SigningMethodHS256, "", []byte(secret), jwt.MapClaims{"sub": "jane", "iss": "https://issuer.example.com", "aud": "invoice-api"}),
+			status:        fiber.StatusUnauthorized,
+		},
+		{
+			name:          "without subject",
+			config:        config,
+			authorization: "Bearer " + sign(jwt.SigningMethodHS256, "", []byte(secret), claims("")),
+			status:        fiber.StatusUnauthorized,
+		},
+		{
+			name:          "hs256 disabled without secret",
+			config:        Config{JWKSPath: jwksPath},
+			authorization: "Bearer " + sign(jwt.SigningMethodHS256, "", []byte(secret), claims("jane")),
+			status:        fiber.StatusUnauthorized,
+		},
+		{
+			name:   "public path",
+			config: config,
+			path:   "/public",
+			status: fiber.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := setupServer(t, test.config)
+
+			path := test.path
+			if path == "" {
+				path = "/"
+			}
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			if test.authorization != "" {
+				req.Header.Set(fiber.HeaderAuthorization, test.authorization)
+			}
+
+			res, err := server.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, test.status, res.StatusCode)
+			if test.status == fiber.StatusUnauthorized {
+				assert.Equal(t, "Bearer", res.Header.Get(fiber.HeaderWWWAuthenticate))
+			}
+			if test.subject != "" {
+				assert.Equal(t, test.subject, res.Header.Get("X-Subject"))
+				assert.Equal(t, test.subject, res.Header.Get("X-Actor"))
+			}
+		})
+	}
+
+	t.Run("tenant claim", func(t *testing.T) {
+		tenantClaims := claims("jane")
+		tenantClaims["org"] = "acme"
+
+		server := setupServer(t, Config{Secret: secret, TenantClaim: "org"})
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+sign(jwt.SigningMethodHS256, "", []byte(secret), tenantClaims))
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+		assert.Equal(t, "acme", res.Header.Get("X-Tenant"))
+	})
+
+	t.Run("jwks url", func(t *testing.T) {
+		jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			_, _ = w.Write([]byte(jwks))
+		}))
+		defer jwksServer.Close()
+
+		server := setupServer(t, Config{JWKSURL: jwksServer.URL})
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+sign(jwt.SigningMethodRS256, "rsa", rsaKey, claims("jane")))
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	})
+
+	t.Run("no key source", func(t *testing.T) {
+		_, err := New(context.TODO(), Config{})
+		assert.Error(t, err)
+	})
+}
+
+func setupServer(t *testing.T, config Config) *fiber.App {
+	middleware, err := New(t.Context(), config)
+	require.NoError(t, err)
+
+	server := fiber.New(fiber.Config{
+		ErrorHandler:          customError.ErrorHandler,
+		DisableStartupMessage: true,
+	})
+	server.Use(func(ctx *fiber.Ctx) error {
+		ctx.Locals(customError.ContextKeyLog, zap.NewNop())
+		return ctx.Next()
+	})
+	server.Use(middleware)
+	handler := func(ctx *fiber.Ctx) error {
+		ctx.Set("X-Subject", Subject(ctx))
+		ctx.Set("X-Actor", requestcontext.Actor(ctx.UserContext()))
+		ctx.Set("X-Tenant", requestcontext.Tenant(ctx.UserContext()))
+		return ctx.SendStatus(fiber.StatusOK)
+	}
+	server.Get("/", handler)
+	server.Get("/public", handler)
+
+	return server
+}
+
+func encode(value []byte) string {
+	return base64.RawURLEncoding.EncodeToString(value)
+}
@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+
+	customError "invoice-api/pkg/error"
+)
+
+const (
+	ContextKeyRoles  = "roles"
+	ContextKeyScopes = "scopes"
+
+	AllPermissions = "*"
+	ActionRead     = "read"
+	ActionWrite    = "write"
+)
+
+// writeActions are the permission actions that a "<resource>:write" grant
+// includes. Other actions, such as deleting an invoice or marking it as paid,
+// must be granted by name.
+var writeActions = []string{"create", "update"}
+
+type Policy map[string][]string
+
+type Authorizer struct {
+	policy Policy
+}
+
+func NewAuthorizer(policy Policy) *Authorizer {
+	return &Authorizer{policy: policy}
+}
+
+func (a *Authorizer) Require(permission string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if err := a.Check(ctx, permission); err != nil {
+			return err
+		}
+
+		return ctx.Next()
+	}
+}
+
+// Check grants API key requests the scopes of the key and other requests the
+// permissions of their roles. Requests with neither are denied.
+func (a *Authorizer) Check(ctx *fiber.Ctx, permission string) error {
+	scopes, isKey := ctx.Locals(ContextKeyScopes).([]string)
+	if isKey {
+		if grants(scopes, permission) {
+			return nil
+		}
+	} else {
+		for _, role := range Roles(ctx) {
+			if grants(a.policy[role], permission) {
+				return nil
+			}
+		}
+	}
+
+	return customError.CustomError{
+		Code:     fiber.StatusForbidden,
+		Message:  "missing permission",
+		Severity: zap.WarnLevel,
+		Fields: []zap.Field{
+			zap.String("permission", permission),
+			zap.Strings("roles", Roles(ctx)),
+			zap.Strings("scopes", scopes),
+		},
+		Details: fiber.Map{"permission": permission},
+	}
+}
+
+func grants(granted []string, permission string) bool {
+	if slices.Contains(granted, AllPermissions) || slices.Contains(granted, permission) {
+		return true
+	}
+
+	resource, action, _ := strings.Cut(permission, ":")
+	return slices.Contains(writeActions, action) && slices.Contains(granted, resource+":"+ActionWrite)
+}
+
+// Anonymous grants roles to requests without credentials. It is meant for
+// deployments that run without authentication.
+func Anonymous(roles []string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if Subject(ctx) == "" {
+			ctx.Locals(ContextKeyRoles, roles)
+		}
+
+		return ctx.Next()
+	}
+}
+
+func Roles(ctx *fiber.Ctx) []string {
+	roles, _ := ctx.Locals(ContextKeyRoles).([]string)
+	return roles
+}
+
+func rolesFromClaims(claims jwt.Claims, claim string) []string {
+	mapClaims, ok := claims.(jwt.MapClaims)
+	if !ok {
+		return []string{}
+	}
+
+	roles := []string{}
+	switch value := mapClaims[claim].(type) {
+	case string:
+		roles = append(roles, strings.Fields(value)...)
+	case []any:
+		for _, role := range value {
+			if name, ok := role.(string); ok {
+				roles = append(roles, name)
+			}
+		}
+	}
+
+	return roles
+}
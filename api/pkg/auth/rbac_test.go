@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	customError "invoice-api/pkg/error"
+)
+
+func TestAuthorizer_Require(t *testing.T) {
+	authorizer := NewAuthorizer(Policy{
+		"viewer": {"invoices:read"},
+		"admin":  {AllPermissions},
+	})
+
+	tests := []struct {
+		name   string
+		roles  []string
+		scopes []string
+		status int
+	}{
+		{name: "role with permission", roles: []string{"viewer"}, status: fiber.StatusOK},
+		{name: "wildcard role", roles: []string{"admin"}, status: fiber.StatusOK},
+		{name: "any of several roles", roles: []string{"unknown", "viewer"}, status: fiber.StatusOK},
+		{name: "unknown role", roles: []string{"unknown"}, status: fiber.StatusForbidden},
+		{name: "no roles", roles: []string{}, status: fiber.StatusForbidden},
+		{name: "roles not set", status: fiber.StatusForbidden},
+		{name: "api key with scope", scopes: []string{"invoices:read"}, status: fiber.StatusOK},
+		{name: "api key without scope", roles: []string{"admin"}, scopes: []string{"invoices:write"}, status: fiber.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := fiber.New(fiber.Config{
+				ErrorHandler:          customError.ErrorHandler,
+				DisableStartupMessage: true,
+			})
+			server.Use(func(ctx *fiber.Ctx) error {
+				ctx.Locals(customError.ContextKeyLog, zap.NewNop())
+				if test.roles != nil {
+					ctx.Locals(ContextKeyRoles, test.roles)
+				}
+				if test.scopes != nil {
+					ctx.Locals(ContextKeyScopes, test.scopes)
+				}
+				return ctx.Next()
+			})
+			server.Get("/", authorizer.Require("invoices:read"), func(ctx *fiber.Ctx) error {
+				return ctx.SendStatus(fiber.StatusOK)
+			})
+
+			res, err := server.Test(httptest.NewRequest(http.MethodGet, "/", nil), -1)
+			require.NoError(t, err)
+			assert.Equal(t, test.status, res.StatusCode)
+		})
+	}
+}
+
+func TestGrants(t *testing.T) {
+	assert.True(t, grants([]string{"invoices:read"}, "invoices:read"))
+	assert.True(t, grants([]string{AllPermissions}, "invoices:delete"))
+	assert.True(t, grants([]string{"invoices:write"}, "invoices:create"))
+	assert.True(t, grants([]string{"invoices:write"}, "invoices:update"))
+	assert.True(t, grants([]string{"invoices:write"}, "invoices:write"))
+	assert.False(t, grants([]string{"invoices:write"}, "invoices:delete"))
+	assert.False(t, grants([]string{"invoices:write"}, "invoices:mark-paid"))
+	assert.False(t, grants([]string{"invoices:write"}, "invoices:read"))
+	assert.False(t, grants([]string{"webhooks:write"}, "invoices:create"))
+	assert.False(t, grants(nil, "invoices:read"))
+}
+
+func TestAnonymous(t *testing.T) {
+	server := fiber.New(fiber.Config{DisableStartupMessage: true})
+	server.Use(func(ctx *fiber.Ctx) error {
+		if subject := ctx.Get("X-Subject"); subject != "" {
+			ctx.Locals(ContextKeySubject, subject)
+		}
+		return ctx.Next()
+	})
+	server.Use(Anonymous([]string{"viewer"}))
+	server.Get("/", func(ctx *fiber.Ctx) error {
+		return ctx.SendString(strings.Join(Roles(ctx), ","))
+	})
+
+	for subject, expected := range map[string]string{"": "viewer", "jane": ""} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Subject", subject)
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(body), subject)
+	}
+}
+
+func TestNew_Roles(t *testing.T) {
+	middleware, err := New(t.Context(), Config{Secret: secret, RolesClaim: "groups"})
+	require.NoError(t, err)
+
+	server := fiber.New(fiber.Config{
+		ErrorHandler:          customError.ErrorHandler,
+		DisableStartupMessage: true,
+	})
+	server.Use(func(ctx *fiber.Ctx) error {
+		ctx.Locals(customError.ContextKeyLog, zap.NewNop())
+		return ctx.Next()
+	})
+	server.Use(middleware)
+	server.Get("/", func(ctx *fiber.Ctx) error {
+		return ctx.SendString(strings.Join(Roles(ctx), ","))
+	})
+
+	for claim, expected := range map[string]any{
+		"viewer accountant": "viewer,accountant",
+		"list":              "admin",
+		"none":              "",
+	} {
+		claims := jwt.MapClaims{"sub": "jane", "exp": time.Now().Add(time.Hour).Unix()}
+		switch claim {
+		case "list":
+			claims["groups"] = []string{"admin"}
+		case "none":
+		default:
+			claims["groups"] = claim
+		}
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, res.StatusCode)
+
+		body := make([]byte, 64)
+		n, _ := res.Body.Read(body)
+		assert.Equal(t, expected, string(body[:n]), claim)
+	}
+}
@@ -2,8 +2,10 @@ package config
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"runtime"
+	"time"
 
 	"github.com/knadh/koanf/parsers/json"
 	"github.com/knadh/koanf/providers/file"
@@ -20,8 +22,154 @@ type Config struct {
 		Password string `koanf:"password"`
 		Database string `koanf:"database"`
 	} `koanf:"postgresql"`
+	Admin struct {
+		Address  string `koanf:"address"`
+		Username string `koanf:"username"`
+		Password string `koanf:"password"`
+		Token    string `koanf:"token"`
+	} `koanf:"admin"`
+	Tracing struct {
+		Exporter    string  `koanf:"exporter"`
+		Endpoint    string  `koanf:"endpoint"`
+		Insecure    bool    `koanf:"insecure"`
+		ServiceName string  `koanf:"serviceName"`
+		SampleRatio float64 `koanf:"sampleRatio"`
+	} `koanf:"tracing"`
+	Health struct {
+		Timeout       time.Duration `koanf:"timeout"`
+		ShutdownDelay time.Duration `koanf:"shutdownDelay"`
+	} `koanf:"health"`
+	Auth struct {
+		Enabled        bool                `koanf:"enabled"`
+		Secret         string              `koanf:"secret"`
+		JWKSPath       string              `koanf:"jwksPath"`
+		JWKSURL        string              `koanf:"jwksUrl"`
+		Issuer         string              `koanf:"issuer"`
+		Audience       string              `koanf:"audience"`
+		RolesClaim     string              `koanf:"rolesClaim"`
+		TenantClaim    string              `koanf:"tenantClaim"`
+		Roles          map[string][]string `koanf:"roles"`
+		AnonymousRoles []string            `koanf:"anonymousRoles"`
+		PublicPaths    []string            `koanf:"publicPaths"`
+	} `koanf:"auth"`
+	RateLimit struct {
+		Enabled bool    `koanf:"enabled"`
+		Store   string  `koanf:"store"`
+		Rate    float64 `koanf:"rate"`
+		Burst   int     `koanf:"burst"`
+		Routes  []struct {
+			Method string  `koanf:"method"`
+			Path   string  `koanf:"path"`
+			Rate   float64 `koanf:"rate"`
+			Burst  int     `koanf:"burst"`
+		} `koanf:"routes"`
+	} `koanf:"rateLimit"`
+	Tenant struct {
+		Header          string   `koanf:"header"`
+		Default         string   `koanf:"default"`
+		TrustedSubjects []string `koanf:"trustedSubjects"`
+	} `koanf:"tenant"`
+	Document struct {
+		Currency      string `koanf:"currency"`
+		SupplierName  string `koanf:"supplierName"`
+		SupplierTaxId string `koanf:"supplierTaxId"`
+	} `koanf:"document"`
+	Payment struct {
+		IBAN            string `koanf:"iban"`
+		BIC             string `koanf:"bic"`
+		QRFormat        string `koanf:"qrFormat"`
+		GenericTemplate string `koanf:"genericTemplate"`
+	} `koanf:"payment"`
+	Signing struct {
+		CertificatePath         string `koanf:"certificatePath"`
+		KeyPath                 string `koanf:"keyPath"`
+		TrustedCertificatesPath string `koanf:"trustedCertificatesPath"`
+	} `koanf:"signing"`
+	Attachment struct {
+		MaxSizeBytes        int64    `koanf:"maxSizeBytes"`
+		AllowedContentTypes []string `koanf:"allowedContentTypes"`
+		Storage             string   `koanf:"storage"`
+		LocalPath           string   `koanf:"localPath"`
+		S3                  struct {
+			Endpoint  string `koanf:"endpoint"`
+			AccessKey string `koanf:"accessKey"`
+			SecretKey string `koanf:"secretKey"`
+			Bucket    string `koanf:"bucket"`
+			Region    string `koanf:"region"`
+			UseSSL    bool   `koanf:"useSsl"`
+		} `koanf:"s3"`
+	} `koanf:"attachment"`
+	Retention struct {
+		DeletedInvoicesDays int           `koanf:"deletedInvoicesDays"`
+		PurgeInterval       time.Duration `koanf:"purgeInterval"`
+	} `koanf:"retention"`
+	Outbox struct {
+		Sink         string        `koanf:"sink"`
+		FilePath     string        `koanf:"filePath"`
+		BatchSize    int           `koanf:"batchSize"`
+		PollInterval time.Duration `koanf:"pollInterval"`
+		MaxBackoff   time.Duration `koanf:"maxBackoff"`
+		MaxAttempts  int           `koanf:"maxAttempts"`
+		NATS         struct {
+			URL           string `koanf:"url"`
+			SubjectPrefix string `koanf:"subjectPrefix"`
+		} `koanf:"nats"`
+		Kafka struct {
+			Brokers []string `koanf:"brokers"`
+			Topic   string   `koanf:"topic"`
+		} `koanf:"kafka"`
+	} `koanf:"outbox"`
+	Webhook struct {
+		BatchSize            int           `koanf:"batchSize"`
+		PollInterval         time.Duration `koanf:"pollInterval"`
+		Timeout              time.Duration `koanf:"timeout"`
+		MaxAttempts          int           `koanf:"maxAttempts"`
+		MaxBackoff           time.Duration `koanf:"maxBackoff"`
+		DisableAfterFailures int           `koanf:"disableAfterFailures"`
+	} `koanf:"webhook"`
+	Stream struct {
+		PollInterval time.Duration `koanf:"pollInterval"`
+	} `koanf:"stream"`
+	Recurring struct {
+		BatchSize    int           `koanf:"batchSize"`
+		PollInterval time.Duration `koanf:"pollInterval"`
+	} `koanf:"recurring"`
+	Report struct {
+		AgingBuckets []int `koanf:"agingBuckets"`
+	} `koanf:"report"`
+	Dunning struct {
+		PaymentTermDays int           `koanf:"paymentTermDays"`
+		BatchSize       int           `koanf:"batchSize"`
+		PollInterval    time.Duration `koanf:"pollInterval"`
+		From            string        `koanf:"from"`
+		Steps           []struct {
+			Name       string `koanf:"name"`
+			OffsetDays int    `koanf:"offsetDays"`
+			Escalate   bool   `koanf:"escalate"`
+		} `koanf:"steps"`
+		Recipients map[string]struct {
+			Email  string `koanf:"email"`
+			Locale string `koanf:"locale"`
+		} `koanf:"recipients"`
+		Escalation struct {
+			Email  string `koanf:"email"`
+			Locale string `koanf:"locale"`
+		} `koanf:"escalation"`
+		SMTP struct {
+			Host     string        `koanf:"host"`
+			Port     int           `koanf:"port"`
+			Username string        `koanf:"username"`
+			Password string        `koanf:"password"`
+			Timeout  time.Duration `koanf:"timeout"`
+		} `koanf:"smtp"`
+	} `koanf:"dunning"`
 }
 
+// OverlayEnv names a config file, relative to the api directory, whose values
+// override config/config.json. The compose setup uses it to load the
+// permissive config/config.dev.json.
+const OverlayEnv = "CONFIG_OVERLAY"
+
 func Read() *Config {
 	_, currentFile, _, _ := runtime.Caller(0)
 	rootDir := filepath.Join(filepath.Dir(currentFile), "../..")
@@ -32,6 +180,15 @@ func Read() *Config {
 		panic(fmt.Sprintf("error occurred while reading config: %s", err))
 	}
 
+	if overlay := os.Getenv(OverlayEnv); overlay != "" {
+		if !filepath.IsAbs(overlay) {
+			overlay = filepath.Join(rootDir, overlay)
+		}
+		if err := koanfInstance.Load(file.Provider(overlay), json.Parser()); err != nil {
+			panic(fmt.Sprintf("error occurred while reading config overlay: %s", err))
+		}
+	}
+
 	var config Config
 	if err := koanfInstance.Unmarshal("", &config); err != nil {
 		panic(fmt.Sprintf("error occurred while unmarshalling config: %s", err))
@@ -9,5 +9,19 @@ func TestConfig_Read(t *testing.T) {
 	assert.NotPanics(t, func() {
 		config := Read()
 		assert.NotNil(t, config)
+		assert.True(t, config.Auth.Enabled)
+		assert.Empty(t, config.Auth.AnonymousRoles)
+		assert.Empty(t, config.CorsOrigins)
 	})
 }
+
+func TestConfig_ReadOverlay(t *testing.T) {
+	t.Setenv(OverlayEnv, "config/config.dev.json")
+
+	config := Read()
+
+	assert.False(t, config.Auth.Enabled)
+	assert.Equal(t, []string{"admin"}, config.Auth.AnonymousRoles)
+	assert.Equal(t, "*", config.CorsOrigins)
+	assert.Equal(t, "roles", config.Auth.RolesClaim)
+}
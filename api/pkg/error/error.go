@@ -14,6 +14,7 @@ type CustomError struct {
 	Message  string
 	Severity zapcore.Level
 	Fields   []zapcore.Field
+	Details  any
 }
 
 const ContextKeyLog = "log"
@@ -39,5 +40,26 @@ func ErrorHandler(ctx *fiber.Ctx, err error) error {
 	}
 
 	log.Log(cerr.Severity, cerr.Message)
+	if cerr.Details != nil {
+		return ctx.Status(cerr.Code).JSON(fiber.Map{
+			"message": cerr.Message,
+			"details": cerr.Details,
+		})
+	}
+
 	return ctx.SendStatus(cerr.Code)
 }
+
+func StatusCode(err error) int {
+	var cerr CustomError
+	if errors.As(err, &cerr) {
+		return cerr.Code
+	}
+
+	var fiberError *fiber.Error
+	if errors.As(err, &fiberError) {
+		return fiberError.Code
+	}
+
+	return fiber.StatusInternalServerError
+}
@@ -0,0 +1,109 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+
+	customError "invoice-api/pkg/error"
+)
+
+const (
+	StatusOk           = "ok"
+	StatusReady        = "ready"
+	StatusNotReady     = "not ready"
+	StatusShuttingDown = "shutting down"
+	StatusUp           = "up"
+	StatusDown         = "down"
+)
+
+type Check func(ctx context.Context) error
+
+type CheckStatus struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+type Status struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckStatus `json:"checks,omitempty"`
+}
+
+type dependency struct {
+	name  string
+	check Check
+}
+
+type Checker struct {
+	timeout      time.Duration
+	dependencies []dependency
+	shuttingDown atomic.Bool
+}
+
+func New(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+func (c *Checker) Add(name string, check Check) {
+	c.dependencies = append(c.dependencies, dependency{name: name, check: check})
+}
+
+func (c *Checker) Shutdown() {
+	c.shuttingDown.Store(true)
+}
+
+func (c *Checker) Liveness(ctx *fiber.Ctx) error {
+	return ctx.JSON(Status{Status: StatusOk})
+}
+
+func (c *Checker) Readiness(ctx *fiber.Ctx) error {
+	if c.shuttingDown.Load() {
+		return ctx.Status(fiber.StatusServiceUnavailable).JSON(Status{Status: StatusShuttingDown})
+	}
+
+	status := c.check(ctx.UserContext())
+	if status.Status != StatusReady {
+		log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+		log.Warn("service is not ready", zap.Any("checks", status.Checks))
+		return ctx.Status(fiber.StatusServiceUnavailable).JSON(status)
+	}
+
+	return ctx.JSON(status)
+}
+
+func (c *Checker) check(ctx context.Context) Status {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	checks := make([]CheckStatus, len(c.dependencies))
+	var wg sync.WaitGroup
+	for i, dependency := range c.dependencies {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			start := time.Now()
+			checks[i] = CheckStatus{Status: StatusUp}
+			if err := dependency.check(ctx); err != nil {
+				checks[i] = CheckStatus{Status: StatusDown, Error: err.Error()}
+			}
+			checks[i].Duration = time.Since(start).String()
+		}()
+	}
+	wg.Wait()
+
+	status := Status{Status: StatusReady, Checks: make(map[string]CheckStatus, len(checks))}
+	for i, dependency := range c.dependencies {
+		status.Checks[dependency.name] = checks[i]
+		if checks[i].Status != StatusUp {
+			status.Status = StatusNotReady
+		}
+	}
+
+	return status
+}
@@ -0,0 +1,103 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	customError "invoice-api/pkg/error"
+)
+
+func TestChecker(t *testing.T) {
+	setupServer := func(checker *Checker) *fiber.App {
+		server := fiber.New(fiber.Config{
+			ErrorHandler:          customError.ErrorHandler,
+			DisableStartupMessage: true,
+		})
+		server.Use(func(ctx *fiber.Ctx) error {
+			ctx.Locals(customError.ContextKeyLog, zap.NewNop())
+			return ctx.Next()
+		})
+		server.Get("/healthz", checker.Liveness)
+		server.Get("/readyz", checker.Readiness)
+
+		return server
+	}
+	send := func(t *testing.T, server *fiber.App, path string) (int, Status) {
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, path, nil), -1)
+		require.NoError(t, err)
+
+		var status Status
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&status))
+		return res.StatusCode, status
+	}
+
+	t.Run("ready", func(t *testing.T) {
+		checker := New(time.Second)
+		checker.Add("postgres", func(context.Context) error { return nil })
+		server := setupServer(checker)
+
+		code, status := send(t, server, "/healthz")
+		assert.Equal(t, fiber.StatusOK, code)
+		assert.Equal(t, StatusOk, status.Status)
+
+		code, status = send(t, server, "/readyz")
+		assert.Equal(t, fiber.StatusOK, code)
+		assert.Equal(t, StatusReady, status.Status)
+		assert.Equal(t, StatusUp, status.Checks["postgres"].Status)
+	})
+
+	t.Run("dependency down", func(t *testing.T) {
+		checker := New(time.Second)
+		checker.Add("postgres", func(context.Context) error { return errors.New("connection refused") })
+		checker.Add("ratelimit", func(context.Context) error { return nil })
+		server := setupServer(checker)
+
+		code, status := send(t, server, "/readyz")
+		assert.Equal(t, fiber.StatusServiceUnavailable, code)
+		assert.Equal(t, StatusNotReady, status.Status)
+		assert.Equal(t, StatusDown, status.Checks["postgres"].Status)
+		assert.Equal(t, "connection refused", status.Checks["postgres"].Error)
+		assert.Equal(t, StatusUp, status.Checks["ratelimit"].Status)
+
+		code, _ = send(t, server, "/healthz")
+		assert.Equal(t, fiber.StatusOK, code)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		checker := New(10 * time.Millisecond)
+		checker.Add("postgres", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		server := setupServer(checker)
+
+		code, status := send(t, server, "/readyz")
+		assert.Equal(t, fiber.StatusServiceUnavailable, code)
+		assert.Equal(t, context.DeadlineExceeded.Error(), status.Checks["postgres"].Error)
+	})
+
+	t.Run("shutting down", func(t *testing.T) {
+		checker := New(time.Second)
+		checker.Add("postgres", func(context.Context) error { return nil })
+		checker.Shutdown()
+		server := setupServer(checker)
+
+		code, status := send(t, server, "/readyz")
+		assert.Equal(t, fiber.StatusServiceUnavailable, code)
+		assert.Equal(t, StatusShuttingDown, status.Status)
+		assert.Empty(t, status.Checks)
+
+		code, _ = send(t, server, "/healthz")
+		assert.Equal(t, fiber.StatusOK, code)
+	})
+}
@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	customError "invoice-api/pkg/error"
+)
+
+const unmatchedRoute = "unmatched"
+
+var (
+	Registry = prometheus.NewRegistry()
+
+	httpRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests by method, route and status.",
+		},
+		[]string{"method", "route", "status"},
+	)
+	httpDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by method, route and status.",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"method", "route", "status"},
+	)
+	repositoryDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "repository_operation_duration_seconds",
+			Help:    "Repository operation latency by repository and operation.",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"repository", "operation"},
+	)
+	repositoryErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "repository_operation_errors_total",
+			Help: "Repository operations failed with a server error by repository and operation.",
+		},
+		[]string{"repository", "operation"},
+	)
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		repositoryDuration,
+		repositoryErrors,
+	)
+}
+
+func Handler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+}
+
+func New() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		start := time.Now()
+		err := ctx.Next()
+
+		route := ctx.Route().Path
+		status := ctx.Response().StatusCode()
+		if err != nil {
+			status = customError.StatusCode(err)
+		}
+		if status == fiber.StatusNotFound && route == "/" && ctx.Path() != "/" {
+			route = unmatchedRoute
+		}
+
+		labels := prometheus.Labels{"method": ctx.Method(), "route": route, "status": strconv.Itoa(status)}
+		httpRequests.With(labels).Inc()
+		httpDuration.With(labels).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+func ObserveRepository(repository, operation string, start time.Time, err *error) {
+	repositoryDuration.WithLabelValues(repository, operation).Observe(time.Since(start).Seconds())
+	if *err != nil && customError.StatusCode(*err) >= fiber.StatusInternalServerError {
+		repositoryErrors.WithLabelValues(repository, operation).Inc()
+	}
+}
+
+func RegisterPool(name string, stat func() *pgxpool.Stat) {
+	Registry.MustRegister(&poolCollector{name: name, stat: stat})
+}
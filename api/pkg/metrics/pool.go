@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	poolAcquiredConns = prometheus.NewDesc(
+		"pgxpool_acquired_connections",
+		"Connections currently acquired from the pool.",
+		[]string{"pool"},
+		nil,
+	)
+	poolIdleConns = prometheus.NewDesc(
+		"pgxpool_idle_connections",
+		"Idle connections in the pool.",
+		[]string{"pool"},
+		nil,
+	)
+	poolTotalConns = prometheus.NewDesc(
+		"pgxpool_total_connections",
+		"Connections in the pool, including those being constructed.",
+		[]string{"pool"},
+		nil,
+	)
+	poolMaxConns = prometheus.NewDesc(
+		"pgxpool_max_connections",
+		"Maximum size of the pool.",
+		[]string{"pool"},
+		nil,
+	)
+	poolAcquires = prometheus.NewDesc(
+		"pgxpool_acquires_total",
+		"Successful acquires from the pool.",
+		[]string{"pool"},
+		nil,
+	)
+	poolEmptyAcquires = prometheus.NewDesc(
+		"pgxpool_empty_acquires_total",
+		"Successful acquires that had to wait for a connection.",
+		[]string{"pool"},
+		nil,
+	)
+	poolCanceledAcquires = prometheus.NewDesc(
+		"pgxpool_canceled_acquires_total",
+		"Acquires canceled by their context.",
+		[]string{"pool"},
+		nil,
+	)
+	poolAcquireDuration = prometheus.NewDesc(
+		"pgxpool_acquire_duration_seconds_total",
+		"Total time spent acquiring connections, including waiting for one.",
+		[]string{"pool"},
+		nil,
+	)
+)
+
+type poolCollector struct {
+	name string
+	stat func() *pgxpool.Stat
+}
+
+func (c *poolCollector) Describe(descriptions chan<- *prometheus.Desc) {
+	descriptions <- poolAcquiredConns
+	descriptions <- poolIdleConns
+	descriptions <- poolTotalConns
+	descriptions <- poolMaxConns
+	descriptions <- poolAcquires
+	descriptions <- poolEmptyAcquires
+	descriptions <- poolCanceledAcquires
+	descriptions <- poolAcquireDuration
+}
+
+func (c *poolCollector) Collect(metrics chan<- prometheus.Metric) {
+	stat := c.stat()
+	metrics <- prometheus.MustNewConstMetric(poolAcquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()), c.name)
+	metrics <- prometheus.MustNewConstMetric(poolIdleConns, prometheus.GaugeValue, float64(stat.IdleConns()), c.name)
+	metrics <- prometheus.MustNewConstMetric(poolTotalConns, prometheus.GaugeValue, float64(stat.TotalConns()), c.name)
+	metrics <- prometheus.MustNewConstMetric(poolMaxConns, prometheus.GaugeValue, float64(stat.MaxConns()), c.name)
+	metrics <- prometheus.MustNewConstMetric(poolAcquires, prometheus.CounterValue, float64(stat.AcquireCount()), c.name)
+	metrics <- prometheus.MustNewConstMetric(poolEmptyAcquires, prometheus.CounterValue, float64(stat.EmptyAcquireCount()), c.name)
+	metrics <- prometheus.MustNewConstMetric(poolCanceledAcquires, prometheus.CounterValue, float64(stat.CanceledAcquireCount()), c.name)
+	metrics <- prometheus.MustNewConstMetric(poolAcquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds(), c.name)
+}
@@ -0,0 +1,87 @@
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+const (
+	FormatEPC     = "epc"
+	FormatGeneric = "generic"
+)
+
+type Creditor struct {
+	Name     string
+	IBAN     string
+	BIC      string
+	Currency string
+}
+
+type PayloadData struct {
+	Creditor
+	InvoiceId     string
+	InvoiceNumber string
+	Reference     string
+	Amount        float64
+}
+
+type Format interface {
+	Payload(data PayloadData) (string, error)
+}
+
+type EPCFormat struct{}
+
+func (EPCFormat) Payload(data PayloadData) (string, error) {
+	switch {
+	case data.Currency != "EUR":
+		return "", fmt.Errorf("epc qr codes only support EUR, got %s", data.Currency)
+	case data.Name == "" || utf8.RuneCountInString(data.Name) > 70:
+		return "", errors.New("creditor name must be between 1 and 70 characters")
+	case data.IBAN == "":
+		return "", errors.New("creditor iban is required")
+	case data.Amount < 0.01 || data.Amount > 999999999.99:
+		return "", errors.New("amount must be between 0.01 and 999999999.99")
+	case data.Reference != "" && !ValidReference(data.Reference):
+		return "", fmt.Errorf("invalid payment reference %s", data.Reference)
+	}
+
+	return strings.Join([]string{
+		"BCD",
+		"002",
+		"1",
+		"SCT",
+		data.BIC,
+		data.Name,
+		strings.ReplaceAll(data.IBAN, " ", ""),
+		fmt.Sprintf("EUR%.2f", data.Amount),
+		"",
+		data.Reference,
+		"",
+		data.InvoiceNumber,
+	}, "\n"), nil
+}
+
+type GenericFormat struct {
+	template *template.Template
+}
+
+func NewGenericFormat(text string) (*GenericFormat, error) {
+	parsed, err := template.New("qr").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse qr payload template: %w", err)
+	}
+
+	return &GenericFormat{template: parsed}, nil
+}
+
+func (f *GenericFormat) Payload(data PayloadData) (string, error) {
+	var payload strings.Builder
+	if err := f.template.Execute(&payload, data); err != nil {
+		return "", fmt.Errorf("failed to render qr payload: %w", err)
+	}
+
+	return payload.String(), nil
+}
@@ -0,0 +1,78 @@
+package payment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPayloadData() PayloadData {
+	return PayloadData{
+		Creditor: Creditor{
+			Name:     "Invoice Manager",
+			IBAN:     "DE89 3704 0044 0532 0130 00",
+			BIC:      "COBADEFFXXX",
+			Currency: "EUR",
+		},
+		InvoiceId:     "dda97bce-ac2a-4431-9c7b-3b6bcdfe8a23",
+		InvoiceNumber: "DMP2025000000001",
+		Reference:     "RF18539007547034",
+		Amount:        120.3,
+	}
+}
+
+func TestEPCFormat_Payload(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		payload, err := EPCFormat{}.Payload(testPayloadData())
+
+		require.NoError(t, err)
+		assert.Equal(
+			t,
+			"BCD\n002\n1\nSCT\nCOBADEFFXXX\nInvoice Manager\nDE89370400440532013000\nEUR120.30\n\nRF18539007547034\n\nDMP2025000000001",
+			payload,
+		)
+	})
+
+	t.Run("invalid data", func(t *testing.T) {
+		for name, modify := range map[string]func(*PayloadData){
+			"currency":  func(d *PayloadData) { d.Currency = "TRY" },
+			"name":      func(d *PayloadData) { d.Name = "" },
+			"iban":      func(d *PayloadData) { d.IBAN = "" },
+			"amount":    func(d *PayloadData) { d.Amount = 0 },
+			"reference": func(d *PayloadData) { d.Reference = "RF00539007547034" },
+		} {
+			data := testPayloadData()
+			modify(&data)
+
+			_, err := EPCFormat{}.Payload(data)
+			assert.Error(t, err, name)
+		}
+	})
+}
+
+func TestGenericFormat_Payload(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		format, err := NewGenericFormat("{{.Name}}|{{.IBAN}}|{{.Amount}} {{.Currency}}|{{.Reference}}|{{.InvoiceNumber}}")
+		require.NoError(t, err)
+
+		payload, err := format.Payload(testPayloadData())
+
+		require.NoError(t, err)
+		assert.Equal(t, "Invoice Manager|DE89 3704 0044 0532 0130 00|120.3 EUR|RF18539007547034|DMP2025000000001", payload)
+	})
+
+	t.Run("invalid template", func(t *testing.T) {
+		_, err := NewGenericFormat("{{.Name")
+
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		format, err := NewGenericFormat("{{.Unknown}}")
+		require.NoError(t, err)
+
+		_, err = format.Payload(testPayloadData())
+		assert.Error(t, err)
+	})
+}
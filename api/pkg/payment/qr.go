@@ -0,0 +1,63 @@
+package payment
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+)
+
+func QRCode(payload string) (*qrcode.QRCode, error) {
+	code, err := qrcode.New(payload, qrcode.Medium)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode qr code: %w", err)
+	}
+
+	return code, nil
+}
+
+func Bitmap(payload string) ([][]bool, error) {
+	code, err := QRCode(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return code.Bitmap(), nil
+}
+
+func PNG(payload string, size int) ([]byte, error) {
+	code, err := QRCode(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return code.PNG(size)
+}
+
+func SVG(payload string, size int) ([]byte, error) {
+	bitmap, err := Bitmap(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var svg strings.Builder
+	_, _ = fmt.Fprintf(
+		&svg,
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		size,
+		size,
+		len(bitmap),
+		len(bitmap),
+	)
+	_, _ = fmt.Fprintf(&svg, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, len(bitmap), len(bitmap))
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				_, _ = fmt.Fprintf(&svg, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	svg.WriteString(`"/></svg>`)
+
+	return []byte(svg.String()), nil
+}
@@ -0,0 +1,43 @@
+package payment
+
+import (
+	"bytes"
+	"image/png"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPNG(t *testing.T) {
+	image, err := PNG("BCD\n002\n1\nSCT", 256)
+	require.NoError(t, err)
+
+	decoded, err := png.Decode(bytes.NewReader(image))
+	require.NoError(t, err)
+	assert.Equal(t, 256, decoded.Bounds().Dx())
+}
+
+func TestSVG(t *testing.T) {
+	bitmap, err := Bitmap("BCD\n002\n1\nSCT")
+	require.NoError(t, err)
+
+	image, err := SVG("BCD\n002\n1\nSCT", 256)
+	require.NoError(t, err)
+
+	svg := string(image)
+	assert.Contains(t, svg, `width="256" height="256"`)
+	assert.Contains(t, svg, `viewBox="0 0 `+strconv.Itoa(len(bitmap))+" "+strconv.Itoa(len(bitmap))+`"`)
+	assert.Contains(t, svg, "M")
+}
+
+func TestBitmap(t *testing.T) {
+	bitmap, err := Bitmap("RF18539007547034")
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, bitmap)
+	for _, row := range bitmap {
+		assert.Len(t, row, len(bitmap))
+	}
+}
@@ -0,0 +1,68 @@
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+const referenceMaxLength = 21
+
+var referencePattern = regexp.MustCompile(`^RF[0-9]{2}[0-9A-Z]{1,21}$`)
+
+func NewReference(seed string) (string, error) {
+	var body strings.Builder
+	for _, r := range strings.ToUpper(seed) {
+		if (r >= '0' && r <= '9') || (r >= 'A' && r <= 'Z') {
+			body.WriteRune(r)
+		}
+	}
+
+	if body.Len() == 0 {
+		return "", errors.New("payment reference seed has no alphanumeric characters")
+	}
+
+	reference := body.String()
+	if len(reference) > referenceMaxLength {
+		reference = reference[:referenceMaxLength]
+	}
+
+	return fmt.Sprintf("RF%02d%s", 98-referenceRemainder(reference+"RF00"), reference), nil
+}
+
+func ValidReference(reference string) bool {
+	reference = strings.ToUpper(strings.ReplaceAll(reference, " ", ""))
+	if !referencePattern.MatchString(reference) {
+		return false
+	}
+
+	return referenceRemainder(reference[4:]+reference[:4]) == 1
+}
+
+func FormatReference(reference string) string {
+	var formatted strings.Builder
+	for i, r := range reference {
+		if i > 0 && i%4 == 0 {
+			formatted.WriteByte(' ')
+		}
+		formatted.WriteRune(r)
+	}
+
+	return formatted.String()
+}
+
+func referenceRemainder(value string) int64 {
+	var digits strings.Builder
+	for _, r := range value {
+		if r >= 'A' && r <= 'Z' {
+			_, _ = fmt.Fprintf(&digits, "%d", r-'A'+10)
+			continue
+		}
+		digits.WriteRune(r)
+	}
+
+	number, _ := new(big.Int).SetString(digits.String(), 10)
+	return new(big.Int).Mod(number, big.NewInt(97)).Int64()
+}
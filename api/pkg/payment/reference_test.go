@@ -0,0 +1,47 @@
+package payment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewReference(t *testing.T) {
+	t.Run("known reference", func(t *testing.T) {
+		reference, err := NewReference("539007547034")
+
+		require.NoError(t, err)
+		assert.Equal(t, "RF18539007547034", reference)
+	})
+
+	t.Run("uuid seed", func(t *testing.T) {
+		reference, err := NewReference("dda97bce-ac2a-4431-9c7b-3b6bcdfe8a23")
+
+		require.NoError(t, err)
+		assert.Len(t, reference, 25)
+		assert.Equal(t, "DDA97BCEAC2A44319C7B3", reference[4:])
+		assert.True(t, ValidReference(reference))
+	})
+
+	t.Run("empty seed", func(t *testing.T) {
+		_, err := NewReference("--")
+
+		assert.Error(t, err)
+	})
+}
+
+func TestValidReference(t *testing.T) {
+	assert.True(t, ValidReference("RF18539007547034"))
+	assert.True(t, ValidReference("RF18 5390 0754 7034"))
+	assert.True(t, ValidReference("RF25A"))
+	assert.False(t, ValidReference("RF19539007547034"))
+	assert.False(t, ValidReference("XX18539007547034"))
+	assert.False(t, ValidReference("RF18"))
+	assert.False(t, ValidReference("RF181234567890123456789012"))
+}
+
+func TestFormatReference(t *testing.T) {
+	assert.Equal(t, "RF18 5390 0754 7034", FormatReference("RF18539007547034"))
+	assert.Equal(t, "RF25 A", FormatReference("RF25A"))
+}
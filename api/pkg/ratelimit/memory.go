@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const sweepInterval = time.Minute
+
+type MemoryStore struct {
+	mutex     sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+type memoryBucket struct {
+	Bucket
+	limit Limit
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*memoryBucket)}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit, now time.Time) (*Bucket, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+	}
+
+	stored, ok := s.buckets[key]
+	if !ok {
+		stored = &memoryBucket{Bucket: Bucket{Tokens: float64(limit.Burst), UpdatedAt: now}}
+		s.buckets[key] = stored
+	}
+	stored.limit = limit
+	stored.Bucket = refill(stored.Bucket, limit, now)
+
+	allowed := stored.Tokens >= 1
+	if allowed {
+		stored.Tokens--
+	}
+
+	bucket := stored.Bucket
+	return &bucket, allowed, nil
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, stored := range s.buckets {
+		if refill(stored.Bucket, stored.limit, now).Tokens >= float64(stored.limit.Burst) {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastSweep = now
+}
@@ -0,0 +1,40 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_Take(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	limit := Limit{Rate: 2, Burst: 3}
+	store := NewMemoryStore()
+
+	for i := range 3 {
+		bucket, allowed, err := store.Take(context.TODO(), "ip:10.0.0.1", limit, now)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.Equal(t, float64(2-i), bucket.Tokens)
+	}
+
+	bucket, allowed, err := store.Take(context.TODO(), "ip:10.0.0.1", limit, now)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Zero(t, bucket.Tokens)
+
+	bucket, allowed, err = store.Take(context.TODO(), "ip:10.0.0.1", limit, now.Add(750*time.Millisecond))
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.InDelta(t, 0.5, bucket.Tokens, 0.0001)
+
+	_, _, err = store.Take(context.TODO(), "ip:10.0.0.2", limit, now.Add(time.Second))
+	require.NoError(t, err)
+	_, _, err = store.Take(context.TODO(), "ip:10.0.0.3", limit, now.Add(2*time.Minute))
+	require.NoError(t, err)
+	assert.Len(t, store.buckets, 1)
+	assert.Contains(t, store.buckets, "ip:10.0.0.3")
+}
@@ -0,0 +1,170 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/tracing"
+)
+
+type PgStore struct {
+	connectionPool *pgxpool.Pool
+	mutex          sync.Mutex
+	lastSweep      time.Time
+}
+
+func NewPgStore(log *zap.Logger, host, port, username, password, database string) *PgStore {
+	credentials := fmt.Sprintf("user=%s password=%s host=%s port=%s dbname=%s sslmode=disable", username, password, host, port, database)
+	pgConfig, err := pgxpool.ParseConfig(credentials)
+	if err != nil {
+		log.Fatal("failed to parse database config", zap.Error(err))
+	}
+	pgConfig.ConnConfig.Tracer = tracing.QueryTracer{}
+
+	var pgConnectionPool *pgxpool.Pool
+	pgConnectionPool, err = pgxpool.NewWithConfig(context.Background(), pgConfig)
+	if err != nil {
+		log.Fatal("failed to connect database", zap.Error(err))
+	}
+
+	var connection *pgxpool.Conn
+	connection, err = pgConnectionPool.Acquire(context.Background())
+	if err != nil {
+		log.Fatal("failed to acquire connection", zap.Error(err))
+	}
+	defer connection.Release()
+
+	err = connection.Ping(context.Background())
+	if err != nil {
+		log.Fatal("failed to ping database", zap.Error(err))
+	}
+
+	return &PgStore{
+		connectionPool: pgConnectionPool,
+	}
+}
+
+func (s *PgStore) Stat() *pgxpool.Stat {
+	return s.connectionPool.Stat()
+}
+
+func (s *PgStore) Ping(ctx context.Context) error {
+	return s.connectionPool.Ping(ctx)
+}
+
+func (s *PgStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (*Bucket, bool, error) {
+	connection, err := s.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, false, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to acquire connection",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer connection.Release()
+
+	if s.sweepDue(now) {
+		if _, err = connection.Exec(ctx, "delete from rate_limit_buckets where full_at < $1", now); err != nil {
+			return nil, false, customError.CustomError{
+				Code:     fiber.StatusInternalServerError,
+				Message:  "failed to sweep rate limit buckets",
+				Severity: zap.ErrorLevel,
+				Fields:   []zap.Field{zap.Error(err)},
+			}
+		}
+	}
+
+	var tx pgx.Tx
+	tx, err = connection.Begin(ctx)
+	if err != nil {
+		return nil, false, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to begin transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	_, err = tx.Exec(
+		ctx,
+		"insert into rate_limit_buckets (key, tokens, updated_at, full_at) values ($1, $2, $3, $3) on conflict (key) do nothing",
+		key,
+		float64(limit.Burst),
+		now,
+	)
+	if err != nil {
+		return nil, false, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to create rate limit bucket",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	var bucket Bucket
+	err = tx.QueryRow(ctx, "select tokens, updated_at from rate_limit_buckets where key = $1 for update", key).Scan(&bucket.Tokens, &bucket.UpdatedAt)
+	if err != nil {
+		return nil, false, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to get rate limit bucket",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	bucket = refill(bucket, limit, now)
+	allowed := bucket.Tokens >= 1
+	if allowed {
+		bucket.Tokens--
+	}
+	fullAt := now.Add(time.Duration((float64(limit.Burst) - bucket.Tokens) / limit.Rate * float64(time.Second)))
+
+	_, err = tx.Exec(
+		ctx,
+		"update rate_limit_buckets set tokens = $2, updated_at = $3, full_at = $4 where key = $1",
+		key,
+		bucket.Tokens,
+		bucket.UpdatedAt,
+		fullAt,
+	)
+	if err != nil {
+		return nil, false, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to update rate limit bucket",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, false, customError.CustomError{
+			Code:     fiber.StatusInternalServerError,
+			Message:  "failed to commit transaction",
+			Severity: zap.ErrorLevel,
+			Fields:   []zap.Field{zap.Error(err)},
+		}
+	}
+
+	return &bucket, allowed, nil
+}
+
+func (s *PgStore) sweepDue(now time.Time) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return false
+	}
+	s.lastSweep = now
+	return true
+}
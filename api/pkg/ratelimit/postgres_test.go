@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+)
+
+func TestPgStore_Take(t *testing.T) {
+	pgContainer := setupContainer(t)
+	pgHost, err := pgContainer.Host(context.Background())
+	require.NoError(t, err)
+
+	pgPort, err := pgContainer.MappedPort(context.Background(), "5432/tcp")
+	require.NoError(t, err)
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	limit := Limit{Rate: 1, Burst: 5}
+	replicas := []*PgStore{
+		NewPgStore(nil, pgHost, pgPort.Port(), "root", "root", "test"),
+		NewPgStore(nil, pgHost, pgPort.Port(), "root", "root", "test"),
+	}
+
+	t.Run("shared between replicas", func(t *testing.T) {
+		var (
+			wg      sync.WaitGroup
+			mutex   sync.Mutex
+			allowed int
+		)
+		for i := range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, ok, err := replicas[i%2].Take(context.TODO(), "user:jane|default", limit, now)
+				assert.NoError(t, err)
+				if ok {
+					mutex.Lock()
+					allowed++
+					mutex.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 5, allowed)
+	})
+
+	t.Run("refill", func(t *testing.T) {
+		bucket, ok, err := replicas[0].Take(context.TODO(), "user:jane|default", limit, now.Add(2*time.Second))
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.InDelta(t, 1, bucket.Tokens, 0.0001)
+	})
+
+	t.Run("sweep", func(t *testing.T) {
+		_, _, err := replicas[1].Take(context.TODO(), "user:john|default", limit, now.Add(time.Hour))
+		require.NoError(t, err)
+
+		var count int
+		require.NoError(t, replicas[1].connectionPool.QueryRow(context.TODO(), "select count(*) from rate_limit_buckets").Scan(&count))
+		assert.Equal(t, 1, count)
+	})
+}
+
+func setupContainer(t *testing.T) *postgres.PostgresContainer {
+	ctx := context.Background()
+	postgresContainer, err := postgres.Run(
+		ctx,
+		"postgres",
+		postgres.WithDatabase("test"),
+		postgres.WithUsername("root"),
+		postgres.WithPassword("root"),
+		postgres.BasicWaitStrategies(),
+		postgres.WithSQLDriver("pgx"),
+		postgres.WithInitScripts("../../.scripts/init.sql"),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		err = postgresContainer.Terminate(ctx)
+		require.NoError(t, err)
+	})
+
+	err = postgresContainer.Snapshot(ctx)
+	require.NoError(t, err)
+
+	return postgresContainer
+}
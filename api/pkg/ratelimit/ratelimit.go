@@ -0,0 +1,159 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+
+	"invoice-api/pkg/auth"
+	customError "invoice-api/pkg/error"
+)
+
+const (
+	HeaderLimit     = "RateLimit-Limit"
+	HeaderRemaining = "RateLimit-Remaining"
+	HeaderReset     = "RateLimit-Reset"
+)
+
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+type Route struct {
+	Method string
+	Path   string
+	Limit
+}
+
+type Config struct {
+	Default Limit
+	Routes  []Route
+}
+
+type Bucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (*Bucket, bool, error)
+}
+
+// KeyFunc names the bucket a request is charged to. An empty key lets the
+// request through without charging a bucket.
+type KeyFunc func(ctx *fiber.Ctx) string
+
+type limiter struct {
+	config Config
+	store  Store
+	key    KeyFunc
+	now    func() time.Time
+}
+
+func New(config Config, store Store, key KeyFunc) fiber.Handler {
+	l := &limiter{config: config, store: store, key: key, now: time.Now}
+	return l.handle
+}
+
+func (l *limiter) handle(ctx *fiber.Ctx) error {
+	name, limit := l.match(ctx.Method(), ctx.Path())
+	if limit.Rate <= 0 || limit.Burst <= 0 {
+		return ctx.Next()
+	}
+
+	client := l.key(ctx)
+	if client == "" {
+		return ctx.Next()
+	}
+
+	key := client + "|" + name
+	bucket, allowed, err := l.store.Take(ctx.UserContext(), key, limit, l.now().UTC())
+	if err != nil {
+		ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Warn("failed to apply rate limit", zap.Error(err))
+		return ctx.Next()
+	}
+
+	ctx.Set(HeaderLimit, strconv.Itoa(limit.Burst))
+	ctx.Set(HeaderRemaining, strconv.Itoa(int(math.Floor(bucket.Tokens))))
+	ctx.Set(HeaderReset, strconv.Itoa(seconds((float64(limit.Burst)-bucket.Tokens)/limit.Rate)))
+	if !allowed {
+		ctx.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds((1-bucket.Tokens)/limit.Rate)))
+		return customError.CustomError{
+			Code:     fiber.StatusTooManyRequests,
+			Message:  "too many requests",
+			Severity: zap.WarnLevel,
+			Fields:   []zap.Field{zap.String("key", key)},
+		}
+	}
+
+	return ctx.Next()
+}
+
+func (l *limiter) match(method, path string) (string, Limit) {
+	name, limit, matched := "default", l.config.Default, -1
+	for _, route := range l.config.Routes {
+		if route.Method != "" && !strings.EqualFold(route.Method, method) {
+			continue
+		}
+		if path != route.Path && !strings.HasPrefix(path, strings.TrimSuffix(route.Path, "/")+"/") {
+			continue
+		}
+
+		specificity := len(route.Path) * 2
+		if route.Method != "" {
+			specificity++
+		}
+		if specificity > matched {
+			name, limit, matched = strings.TrimSpace(strings.ToUpper(route.Method)+" "+route.Path), route.Limit, specificity
+		}
+	}
+
+	return name, limit
+}
+
+// ByIP charges every request to its client IP. It runs before
+// authentication, so it only relies on what the client cannot choose freely.
+func ByIP(ctx *fiber.Ctx) string {
+	return "ip:" + ctx.IP()
+}
+
+// BySubject charges authenticated requests to their API key or token subject
+// and runs after authentication, so the identity has been verified.
+// Anonymous requests are only limited by ByIP.
+func BySubject(ctx *fiber.Ctx) string {
+	subject := auth.Subject(ctx)
+	switch {
+	case subject == "":
+		return ""
+	case strings.HasPrefix(subject, "api-key:"):
+		return subject
+	default:
+		return "user:" + subject
+	}
+}
+
+func refill(bucket Bucket, limit Limit, now time.Time) Bucket {
+	elapsed := now.Sub(bucket.UpdatedAt).Seconds()
+	if elapsed < 0 {
+		elapsed = 0
+	}
+
+	return Bucket{
+		Tokens:    math.Min(float64(limit.Burst), bucket.Tokens+elapsed*limit.Rate),
+		UpdatedAt: now,
+	}
+}
+
+func seconds(value float64) int {
+	if value <= 0 {
+		return 0
+	}
+
+	return int(math.Ceil(value))
+}
@@ -0,0 +1,151 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"invoice-api/pkg/auth"
+	customError "invoice-api/pkg/error"
+)
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, Limit, time.Time) (*Bucket, bool, error) {
+	return nil, false, errors.New("store is down")
+}
+
+func TestNew(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	config := Config{
+		Default: Limit{Rate: 1, Burst: 2},
+		Routes: []Route{
+			{Path: "/reports", Limit: Limit{Rate: 0.5, Burst: 1}},
+			{Method: http.MethodPost, Path: "/usage/events", Limit: Limit{Rate: 10, Burst: 3}},
+			{Path: "/health", Limit: Limit{}},
+		},
+	}
+
+	setupServer := func(t *testing.T, store Store, key KeyFunc) (*fiber.App, *time.Time) {
+		clock := now
+		l := &limiter{config: config, store: store, key: key, now: func() time.Time { return clock }}
+
+		server := fiber.New(fiber.Config{
+			ErrorHandler:          customError.ErrorHandler,
+			DisableStartupMessage: true,
+		})
+		server.Use(func(ctx *fiber.Ctx) error {
+			ctx.Locals(customError.ContextKeyLog, zap.NewNop())
+			if subject := ctx.Get("X-Subject"); subject != "" {
+				ctx.Locals(auth.ContextKeySubject, subject)
+			}
+			return ctx.Next()
+		})
+		server.Use(l.handle)
+		server.All("/*", func(ctx *fiber.Ctx) error {
+			return ctx.SendStatus(fiber.StatusOK)
+		})
+
+		return server, &clock
+	}
+	send := func(t *testing.T, server *fiber.App, method, path, subject string) *http.Response {
+		req := httptest.NewRequest(method, path, nil)
+		if subject != "" {
+			req.Header.Set("X-Subject", subject)
+		}
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("headers and retry after", func(t *testing.T) {
+		server, clock := setupServer(t, NewMemoryStore(), ByIP)
+
+		res := send(t, server, http.MethodGet, "/invoices", "")
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+		assert.Equal(t, "2", res.Header.Get(HeaderLimit))
+		assert.Equal(t, "1", res.Header.Get(HeaderRemaining))
+		assert.Equal(t, "1", res.Header.Get(HeaderReset))
+
+		res = send(t, server, http.MethodGet, "/invoices", "")
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+		assert.Equal(t, "0", res.Header.Get(HeaderRemaining))
+		assert.Equal(t, "2", res.Header.Get(HeaderReset))
+
+		res = send(t, server, http.MethodGet, "/invoices", "")
+		assert.Equal(t, fiber.StatusTooManyRequests, res.StatusCode)
+		assert.Equal(t, "1", res.Header.Get(fiber.HeaderRetryAfter))
+
+		*clock = clock.Add(time.Second)
+		res = send(t, server, http.MethodGet, "/invoices", "")
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	})
+
+	t.Run("keyed by ip", func(t *testing.T) {
+		server, _ := setupServer(t, NewMemoryStore(), ByIP)
+
+		for range 2 {
+			assert.Equal(t, fiber.StatusOK, send(t, server, http.MethodGet, "/invoices", "api-key:ik_0123abcd").StatusCode)
+		}
+		assert.Equal(t, fiber.StatusTooManyRequests, send(t, server, http.MethodGet, "/invoices", "api-key:ik_4567ef01").StatusCode)
+		assert.Equal(t, fiber.StatusTooManyRequests, send(t, server, http.MethodGet, "/invoices", "jane").StatusCode)
+		assert.Equal(t, fiber.StatusTooManyRequests, send(t, server, http.MethodGet, "/invoices", "").StatusCode)
+	})
+
+	t.Run("keyed by subject", func(t *testing.T) {
+		server, _ := setupServer(t, NewMemoryStore(), BySubject)
+
+		for range 2 {
+			assert.Equal(t, fiber.StatusOK, send(t, server, http.MethodGet, "/invoices", "jane").StatusCode)
+		}
+		assert.Equal(t, fiber.StatusTooManyRequests, send(t, server, http.MethodGet, "/invoices", "jane").StatusCode)
+		assert.Equal(t, fiber.StatusOK, send(t, server, http.MethodGet, "/invoices", "api-key:ik_0123abcd").StatusCode)
+
+		for range 3 {
+			res := send(t, server, http.MethodGet, "/invoices", "")
+			assert.Equal(t, fiber.StatusOK, res.StatusCode)
+			assert.Empty(t, res.Header.Get(HeaderLimit))
+		}
+	})
+
+	t.Run("route limits", func(t *testing.T) {
+		server, _ := setupServer(t, NewMemoryStore(), ByIP)
+
+		assert.Equal(t, fiber.StatusOK, send(t, server, http.MethodGet, "/reports/aging", "").StatusCode)
+		res := send(t, server, http.MethodGet, "/reports/revenue", "")
+		assert.Equal(t, fiber.StatusTooManyRequests, res.StatusCode)
+		assert.Equal(t, "2", res.Header.Get(fiber.HeaderRetryAfter))
+
+		for range 3 {
+			res = send(t, server, http.MethodPost, "/usage/events", "")
+			assert.Equal(t, fiber.StatusOK, res.StatusCode)
+			assert.Equal(t, "3", res.Header.Get(HeaderLimit))
+		}
+		assert.Equal(t, fiber.StatusTooManyRequests, send(t, server, http.MethodPost, "/usage/events", "").StatusCode)
+		assert.Equal(t, "2", send(t, server, http.MethodGet, "/usage/events", "").Header.Get(HeaderLimit))
+		assert.Equal(t, "2", send(t, server, http.MethodGet, "/reportsarchive", "").Header.Get(HeaderLimit))
+
+		for range 5 {
+			res = send(t, server, http.MethodGet, "/health", "")
+			assert.Equal(t, fiber.StatusOK, res.StatusCode)
+			assert.Empty(t, res.Header.Get(HeaderLimit))
+		}
+	})
+
+	t.Run("store error", func(t *testing.T) {
+		server, _ := setupServer(t, failingStore{}, ByIP)
+
+		res := send(t, server, http.MethodGet, "/invoices", "")
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+		assert.Empty(t, res.Header.Get(HeaderLimit))
+	})
+}
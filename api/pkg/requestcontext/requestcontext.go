@@ -0,0 +1,114 @@
+package requestcontext
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	customError "invoice-api/pkg/error"
+)
+
+type contextKey int
+
+const (
+	actorKey contextKey = iota
+	requestIdKey
+	tenantKey
+	loggerKey
+)
+
+const AnonymousActor = "anonymous"
+
+// requestIdPattern limits client supplied request ids to short tokens that
+// are safe to echo in headers, logs and outbox events.
+var requestIdPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+func Actor(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey).(string); ok && actor != "" {
+		return actor
+	}
+
+	return AnonymousActor
+}
+
+func WithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdKey, requestId)
+}
+
+func RequestId(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIdKey).(string)
+	return requestId
+}
+
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey, tenant)
+}
+
+func Tenant(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey).(string)
+	return tenant
+}
+
+func WithLogger(ctx context.Context, log *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, log)
+}
+
+func Logger(ctx context.Context) *zap.Logger {
+	if log, ok := ctx.Value(loggerKey).(*zap.Logger); ok {
+		return log
+	}
+
+	return zap.NewNop()
+}
+
+func SetLogger(ctx *fiber.Ctx, log *zap.Logger) {
+	ctx.Locals(customError.ContextKeyLog, log)
+	ctx.SetUserContext(WithLogger(ctx.UserContext(), log))
+}
+
+func New() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		start := time.Now()
+		requestId := ctx.Get(fiber.HeaderXRequestID)
+		if !requestIdPattern.MatchString(requestId) {
+			requestId = uuid.NewString()
+		}
+
+		ctx.Set(fiber.HeaderXRequestID, requestId)
+		ctx.SetUserContext(WithRequestId(ctx.UserContext(), requestId))
+
+		log, ok := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+		if !ok {
+			log = zap.NewNop()
+		}
+		log = log.With(
+			zap.String("requestId", requestId),
+			zap.String("httpMethod", ctx.Method()),
+			zap.String("path", ctx.Path()),
+			zap.String("clientIp", ctx.IP()),
+		)
+		SetLogger(ctx, log)
+
+		err := ctx.Next()
+
+		status := ctx.Response().StatusCode()
+		if err != nil {
+			status = customError.StatusCode(err)
+		}
+		log.Info(
+			"request completed",
+			zap.String("route", ctx.Route().Path),
+			zap.Int("status", status),
+			zap.Duration("latency", time.Since(start)),
+		)
+		return err
+	}
+}
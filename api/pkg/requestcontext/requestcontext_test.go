@@ -0,0 +1,128 @@
+package requestcontext
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	customError "invoice-api/pkg/error"
+)
+
+func TestActor(t *testing.T) {
+	assert.Equal(t, AnonymousActor, Actor(context.Background()))
+	assert.Equal(t, "jane", Actor(WithActor(context.Background(), "jane")))
+}
+
+func TestRequestId(t *testing.T) {
+	assert.Empty(t, RequestId(context.Background()))
+	assert.Equal(t, "abc", RequestId(WithRequestId(context.Background(), "abc")))
+}
+
+func TestTenant(t *testing.T) {
+	assert.Empty(t, Tenant(context.Background()))
+	assert.Equal(t, "acme", Tenant(WithTenant(context.Background(), "acme")))
+}
+
+func TestLogger(t *testing.T) {
+	assert.NotNil(t, Logger(context.Background()))
+
+	log := zap.NewExample()
+	assert.Same(t, log, Logger(WithLogger(context.Background(), log)))
+}
+
+func TestNew(t *testing.T) {
+	server := fiber.New()
+	server.Use(New())
+	server.Get("/", func(ctx *fiber.Ctx) error {
+		return ctx.SendString(RequestId(ctx.UserContext()))
+	})
+
+	t.Run("request id from header", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/", nil)
+		require.NoError(t, err)
+		req.Header.Set(fiber.HeaderXRequestID, "request-1")
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, "request-1", res.Header.Get(fiber.HeaderXRequestID))
+	})
+
+	t.Run("invalid request id from header", func(t *testing.T) {
+		for _, requestId := range []string{"request 1", "../etc", "<script>", strings.Repeat("a", 129)} {
+			req, err := http.NewRequest(http.MethodGet, "/", nil)
+			require.NoError(t, err)
+			req.Header.Set(fiber.HeaderXRequestID, requestId)
+
+			res, err := server.Test(req, -1)
+			require.NoError(t, err)
+			assert.Len(t, res.Header.Get(fiber.HeaderXRequestID), 36, requestId)
+		}
+	})
+
+	t.Run("generated request id", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/", nil)
+		require.NoError(t, err)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Len(t, res.Header.Get(fiber.HeaderXRequestID), 36)
+	})
+
+	t.Run("request logger and access log", func(t *testing.T) {
+		core, logs := observer.New(zapcore.InfoLevel)
+		server := fiber.New(fiber.Config{
+			ErrorHandler:          customError.ErrorHandler,
+			DisableStartupMessage: true,
+		})
+		server.Use(func(ctx *fiber.Ctx) error {
+			ctx.Locals(customError.ContextKeyLog, zap.New(core))
+			return ctx.Next()
+		})
+		server.Use(New())
+		server.Get("/invoices/:id", func(ctx *fiber.Ctx) error {
+			log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger)
+			log = log.With(zap.String("method", "GetInvoiceById"))
+			SetLogger(ctx, log)
+
+			Logger(ctx.UserContext()).Info("repository called")
+			return customError.CustomError{Code: fiber.StatusNotFound, Message: "invoice not found", Severity: zap.WarnLevel}
+		})
+
+		req, err := http.NewRequest(http.MethodGet, "/invoices/1", nil)
+		require.NoError(t, err)
+		req.Header.Set(fiber.HeaderXRequestID, "request-2")
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, res.StatusCode)
+
+		called := logs.FilterMessage("repository called").All()
+		require.Len(t, called, 1)
+		fields := called[0].ContextMap()
+		assert.Equal(t, "request-2", fields["requestId"])
+		assert.Equal(t, http.MethodGet, fields["httpMethod"])
+		assert.Equal(t, "/invoices/1", fields["path"])
+		assert.Equal(t, "0.0.0.0", fields["clientIp"])
+		assert.Equal(t, "GetInvoiceById", fields["method"])
+
+		notFound := logs.FilterMessage("invoice not found").All()
+		require.Len(t, notFound, 1)
+		assert.Equal(t, "GetInvoiceById", notFound[0].ContextMap()["method"])
+
+		completed := logs.FilterMessage("request completed").All()
+		require.Len(t, completed, 1)
+		fields = completed[0].ContextMap()
+		assert.Equal(t, "request-2", fields["requestId"])
+		assert.Equal(t, "/invoices/:id", fields["route"])
+		assert.Equal(t, int64(fiber.StatusNotFound), fields["status"])
+		assert.Contains(t, fields, "latency")
+	})
+}
@@ -0,0 +1,60 @@
+package signature
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/digitorus/pkcs7"
+)
+
+func (s *Signer) SignDetached(content []byte) ([]byte, error) {
+	signedData, err := pkcs7.NewSignedData(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize signed data: %w", err)
+	}
+
+	signedData.SetDigestAlgorithm(pkcs7.OIDDigestAlgorithmSHA256)
+	if err = signedData.AddSigner(s.certificate, s.key, pkcs7.SignerInfoConfig{}); err != nil {
+		return nil, fmt.Errorf("failed to add signer: %w", err)
+	}
+	signedData.Detach()
+
+	signature, err := signedData.Finish()
+	if err != nil {
+		return nil, fmt.Errorf("failed to finish signed data: %w", err)
+	}
+
+	return signature, nil
+}
+
+func (s *Signer) VerifyDetached(content, signature []byte) (*Result, error) {
+	p7, err := pkcs7.Parse(signature)
+	if err != nil {
+		return &Result{Reason: fmt.Sprintf("failed to parse signature: %s", err)}, nil
+	}
+	p7.Content = content
+
+	certificate := p7.GetOnlySigner()
+	if certificate == nil {
+		return &Result{Reason: "signature must have exactly one signer"}, nil
+	}
+
+	result := &Result{
+		Subject: certificate.Subject.String(),
+		Issuer:  certificate.Issuer.String(),
+	}
+
+	if err = p7.Verify(); err != nil {
+		result.Reason = fmt.Sprintf("signature does not match the document: %s", err)
+		return result, nil
+	}
+
+	var signingTime time.Time
+	if err = p7.UnmarshalSignedAttribute(pkcs7.OIDAttributeSigningTime, &signingTime); err == nil {
+		result.SigningTime = signingTime.UTC()
+	}
+
+	result.Valid = true
+	result.Trusted = s.isTrusted(certificate)
+	return result, nil
+}
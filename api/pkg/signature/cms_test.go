@@ -0,0 +1,46 @@
+package signature
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigner_SignDetached(t *testing.T) {
+	content := []byte("%PDF-1.4 test document")
+
+	for name, signer := range newTestSigners(t) {
+		t.Run(name, func(t *testing.T) {
+			signature, err := signer.SignDetached(content)
+			require.NoError(t, err)
+			assert.NotContains(t, string(signature), string(content))
+
+			result, err := signer.VerifyDetached(content, signature)
+			require.NoError(t, err)
+			assert.True(t, result.Valid, result.Reason)
+			assert.True(t, result.Trusted)
+			assert.False(t, result.SigningTime.IsZero())
+		})
+	}
+}
+
+func TestSigner_VerifyDetached(t *testing.T) {
+	signer := newTestSigners(t)["rsa"]
+	signature, err := signer.SignDetached([]byte("original"))
+	require.NoError(t, err)
+
+	t.Run("tampered document", func(t *testing.T) {
+		result, err := signer.VerifyDetached([]byte("tampered"), signature)
+
+		require.NoError(t, err)
+		assert.False(t, result.Valid)
+	})
+
+	t.Run("malformed signature", func(t *testing.T) {
+		result, err := signer.VerifyDetached([]byte("original"), []byte("not a signature"))
+
+		require.NoError(t, err)
+		assert.False(t, result.Valid)
+	})
+}
@@ -0,0 +1,152 @@
+package signature
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+type Signer struct {
+	key         crypto.Signer
+	certificate *x509.Certificate
+	trusted     *x509.CertPool
+}
+
+type Result struct {
+	Valid       bool      `json:"valid"`
+	Trusted     bool      `json:"trusted"`
+	Subject     string    `json:"subject,omitempty"`
+	Issuer      string    `json:"issuer,omitempty"`
+	SigningTime time.Time `json:"signingTime,omitempty"`
+	Reason      string    `json:"reason,omitempty"`
+}
+
+func NewSigner(certificatePath, keyPath, trustedCertificatesPath string) (*Signer, error) {
+	certificatePEM, err := os.ReadFile(certificatePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate: %w", err)
+	}
+
+	certificates, err := parseCertificates(certificatePEM)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+
+	key, err := parsePrivateKey(keyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	if !publicKeysEqual(key.Public(), certificates[0].PublicKey) {
+		return nil, errors.New("private key does not match certificate")
+	}
+
+	trusted := x509.NewCertPool()
+	if trustedCertificatesPath == "" {
+		trusted.AddCert(certificates[len(certificates)-1])
+	} else {
+		var trustedPEM []byte
+		trustedPEM, err = os.ReadFile(trustedCertificatesPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read trusted certificates: %w", err)
+		}
+		if !trusted.AppendCertsFromPEM(trustedPEM) {
+			return nil, errors.New("no trusted certificates found")
+		}
+	}
+
+	return &Signer{
+		key:         key,
+		certificate: certificates[0],
+		trusted:     trusted,
+	}, nil
+}
+
+func (s *Signer) Certificate() *x509.Certificate {
+	return s.certificate
+}
+
+func (s *Signer) isTrusted(certificate *x509.Certificate) bool {
+	_, err := certificate.Verify(x509.VerifyOptions{
+		Roots:     s.trusted,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+
+	return err == nil
+}
+
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certificates []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		certificates = append(certificates, certificate)
+	}
+
+	if len(certificates) == 0 {
+		return nil, errors.New("no certificate found")
+	}
+
+	return certificates, nil
+}
+
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no private key found")
+	}
+
+	var key any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k, nil
+	case *ecdsa.PrivateKey:
+		return k, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+}
+
+func publicKeysEqual(a, b crypto.PublicKey) bool {
+	type equaler interface {
+		Equal(crypto.PublicKey) bool
+	}
+
+	key, ok := a.(equaler)
+	return ok && key.Equal(b)
+}
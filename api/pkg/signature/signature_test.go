@@ -0,0 +1,97 @@
+package signature
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestCertificate(t *testing.T, key crypto.Signer) (string, string) {
+	t.Helper()
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "Invoice Test Signer", Organization: []string{"Invoice Manager"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+
+	privateKey, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certificatePath := filepath.Join(dir, "certificate.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certificatePath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate}), 0o600))
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateKey}), 0o600))
+
+	return certificatePath, keyPath
+}
+
+func newTestSigners(t *testing.T) map[string]*Signer {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	signers := make(map[string]*Signer)
+	for name, key := range map[string]crypto.Signer{"rsa": rsaKey, "ecdsa": ecdsaKey} {
+		certificatePath, keyPath := writeTestCertificate(t, key)
+		signer, err := NewSigner(certificatePath, keyPath, "")
+		require.NoError(t, err)
+		signers[name] = signer
+	}
+
+	return signers
+}
+
+func TestNewSigner(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	certificatePath, keyPath := writeTestCertificate(t, key)
+
+	t.Run("happy path", func(t *testing.T) {
+		signer, err := NewSigner(certificatePath, keyPath, certificatePath)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Invoice Test Signer", signer.Certificate().Subject.CommonName)
+	})
+
+	t.Run("missing files", func(t *testing.T) {
+		_, err := NewSigner(filepath.Join(t.TempDir(), "missing.pem"), keyPath, "")
+		assert.Error(t, err)
+
+		_, err = NewSigner(certificatePath, filepath.Join(t.TempDir(), "missing.pem"), "")
+		assert.Error(t, err)
+	})
+
+	t.Run("key does not match certificate", func(t *testing.T) {
+		otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		_, otherKeyPath := writeTestCertificate(t, otherKey)
+
+		_, err = NewSigner(certificatePath, otherKeyPath, "")
+		assert.Error(t, err)
+	})
+}
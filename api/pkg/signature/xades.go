@@ -0,0 +1,423 @@
+package signature
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/google/uuid"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/russellhaering/goxmldsig/etreeutils"
+)
+
+const (
+	xmlDSigNamespace          = "http://www.w3.org/2000/09/xmldsig#"
+	xadesNamespace            = "http://uri.etsi.org/01903/v1.3.2#"
+	ublExtensionNamespace     = "urn:oasis:names:specification:ubl:schema:xsd:CommonExtensionComponents-2"
+	excC14NAlgorithm          = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	envelopedAlgorithm        = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+	sha256Algorithm           = "http://www.w3.org/2001/04/xmlenc#sha256"
+	rsaSHA256Algorithm        = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	ecdsaSHA256Algorithm      = "http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha256"
+	signedPropertiesReference = "http://uri.etsi.org/01903#SignedProperties"
+)
+
+func (s *Signer) SignXML(document []byte) ([]byte, error) {
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(document); err != nil {
+		return nil, fmt.Errorf("failed to parse xml document: %w", err)
+	}
+
+	root := doc.Root()
+	if root == nil {
+		return nil, errors.New("xml document is empty")
+	}
+
+	documentDigest, err := digestElement(root.Copy())
+	if err != nil {
+		return nil, err
+	}
+
+	signatureMethod := rsaSHA256Algorithm
+	if _, ok := s.key.Public().(*ecdsa.PublicKey); ok {
+		signatureMethod = ecdsaSHA256Algorithm
+	}
+
+	signatureId := "Signature-" + uuid.NewString()
+	signedPropertiesId := "SignedProperties-" + uuid.NewString()
+
+	signature := etree.NewElement("ds:Signature")
+	signature.CreateAttr("xmlns:ds", xmlDSigNamespace)
+	signature.CreateAttr("Id", signatureId)
+
+	signedInfo := signature.CreateElement("ds:SignedInfo")
+	signedInfo.CreateElement("ds:CanonicalizationMethod").CreateAttr("Algorithm", excC14NAlgorithm)
+	signedInfo.CreateElement("ds:SignatureMethod").CreateAttr("Algorithm", signatureMethod)
+
+	documentReference := signedInfo.CreateElement("ds:Reference")
+	documentReference.CreateAttr("URI", "")
+	transforms := documentReference.CreateElement("ds:Transforms")
+	transforms.CreateElement("ds:Transform").CreateAttr("Algorithm", envelopedAlgorithm)
+	transforms.CreateElement("ds:Transform").CreateAttr("Algorithm", excC14NAlgorithm)
+	documentReference.CreateElement("ds:DigestMethod").CreateAttr("Algorithm", sha256Algorithm)
+	documentReference.CreateElement("ds:DigestValue").SetText(documentDigest)
+
+	propertiesReference := signedInfo.CreateElement("ds:Reference")
+	propertiesReference.CreateAttr("Type", signedPropertiesReference)
+	propertiesReference.CreateAttr("URI", "#"+signedPropertiesId)
+	propertiesReference.CreateElement("ds:Transforms").CreateElement("ds:Transform").CreateAttr("Algorithm", excC14NAlgorithm)
+	propertiesReference.CreateElement("ds:DigestMethod").CreateAttr("Algorithm", sha256Algorithm)
+	propertiesDigestValue := propertiesReference.CreateElement("ds:DigestValue")
+
+	signatureValue := signature.CreateElement("ds:SignatureValue")
+	signature.CreateElement("ds:KeyInfo").
+		CreateElement("ds:X509Data").
+		CreateElement("ds:X509Certificate").
+		SetText(base64.StdEncoding.EncodeToString(s.certificate.Raw))
+
+	certificateDigest := sha256.Sum256(s.certificate.Raw)
+	qualifyingProperties := signature.CreateElement("ds:Object").CreateElement("xades:QualifyingProperties")
+	qualifyingProperties.CreateAttr("xmlns:xades", xadesNamespace)
+	qualifyingProperties.CreateAttr("Target", "#"+signatureId)
+	signedProperties := qualifyingProperties.CreateElement("xades:SignedProperties")
+	signedProperties.CreateAttr("Id", signedPropertiesId)
+	signedSignatureProperties := signedProperties.CreateElement("xades:SignedSignatureProperties")
+	signedSignatureProperties.CreateElement("xades:SigningTime").SetText(time.Now().UTC().Format(time.RFC3339))
+	certificate := signedSignatureProperties.CreateElement("xades:SigningCertificate").CreateElement("xades:Cert")
+	certificateDigestElement := certificate.CreateElement("xades:CertDigest")
+	certificateDigestElement.CreateElement("ds:DigestMethod").CreateAttr("Algorithm", sha256Algorithm)
+	certificateDigestElement.CreateElement("ds:DigestValue").SetText(base64.StdEncoding.EncodeToString(certificateDigest[:]))
+	issuerSerial := certificate.CreateElement("xades:IssuerSerial")
+	issuerSerial.CreateElement("ds:X509IssuerName").SetText(s.certificate.Issuer.String())
+	issuerSerial.CreateElement("ds:X509SerialNumber").SetText(s.certificate.SerialNumber.String())
+
+	signatureParent(root).AddChild(signature)
+
+	propertiesDigest, err := digestElement(signedProperties)
+	if err != nil {
+		return nil, err
+	}
+	propertiesDigestValue.SetText(propertiesDigest)
+
+	canonicalSignedInfo, err := canonicalize(signedInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	rawSignature, err := s.sign(canonicalSignedInfo)
+	if err != nil {
+		return nil, err
+	}
+	signatureValue.SetText(base64.StdEncoding.EncodeToString(rawSignature))
+
+	return doc.WriteToBytes()
+}
+
+func (s *Signer) VerifyXML(document []byte) (*Result, error) {
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(document); err != nil {
+		return nil, fmt.Errorf("failed to parse xml document: %w", err)
+	}
+
+	root := doc.Root()
+	if root == nil {
+		return nil, errors.New("xml document is empty")
+	}
+
+	signature := findElement(root, xmlDSigNamespace, "Signature")
+	if signature == nil {
+		return &Result{Reason: "document is not signed"}, nil
+	}
+
+	certificate, err := signatureCertificate(signature)
+	if err != nil {
+		return &Result{Reason: err.Error()}, nil
+	}
+
+	result := &Result{
+		Subject: certificate.Subject.String(),
+		Issuer:  certificate.Issuer.String(),
+	}
+
+	signedInfo := findChild(signature, xmlDSigNamespace, "SignedInfo")
+	if signedInfo == nil {
+		result.Reason = "signature has no SignedInfo"
+		return result, nil
+	}
+
+	documentReferences := 0
+	for _, reference := range findChildren(signedInfo, xmlDSigNamespace, "Reference") {
+		if reason := verifyReference(root, signature, reference); reason != "" {
+			result.Reason = reason
+			return result, nil
+		}
+		if reference.SelectAttrValue("URI", "") == "" {
+			documentReferences++
+		}
+	}
+	if documentReferences != 1 {
+		result.Reason = fmt.Sprintf("signature must reference the whole document exactly once, found %d references", documentReferences)
+		return result, nil
+	}
+
+	canonicalSignedInfo, err := canonicalize(signedInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	signatureValue, err := base64.StdEncoding.DecodeString(strings.TrimSpace(childText(signature, xmlDSigNamespace, "SignatureValue")))
+	if err != nil {
+		result.Reason = "signature value is not valid base64"
+		return result, nil
+	}
+
+	if err = verifySignature(certificate.PublicKey, canonicalSignedInfo, signatureValue); err != nil {
+		result.Reason = "signature value does not match SignedInfo"
+		return result, nil
+	}
+
+	signedSignatureProperties := findElement(signature, xadesNamespace, "SignedSignatureProperties")
+	if signedSignatureProperties == nil {
+		result.Reason = "signature has no XAdES signed properties"
+		return result, nil
+	}
+
+	certificateDigest := sha256.Sum256(certificate.Raw)
+	certDigest := findElement(signedSignatureProperties, xadesNamespace, "CertDigest")
+	if certDigest == nil || childText(certDigest, xmlDSigNamespace, "DigestValue") != base64.StdEncoding.EncodeToString(certificateDigest[:]) {
+		result.Reason = "signing certificate digest does not match the signer certificate"
+		return result, nil
+	}
+
+	if signingTime, err := time.Parse(time.RFC3339, childText(signedSignatureProperties, xadesNamespace, "SigningTime")); err == nil {
+		result.SigningTime = signingTime
+	}
+
+	result.Valid = true
+	result.Trusted = s.isTrusted(certificate)
+	return result, nil
+}
+
+func verifyReference(root, signature, reference *etree.Element) string {
+	uri := reference.SelectAttrValue("URI", "")
+	var target *etree.Element
+	switch {
+	case reference.SelectAttr("URI") == nil:
+	case uri == "":
+		target = root.Copy()
+		removeElement(findElement(target, xmlDSigNamespace, "Signature"))
+	case strings.HasPrefix(uri, "#"):
+		target = findElementById(signature, strings.TrimPrefix(uri, "#"))
+	}
+	if target == nil {
+		return fmt.Sprintf("reference %q cannot be resolved", uri)
+	}
+
+	if reason := verifyTransforms(reference, uri == ""); reason != "" {
+		return fmt.Sprintf("reference %q %s", uri, reason)
+	}
+
+	digestMethod := findChild(reference, xmlDSigNamespace, "DigestMethod")
+	if digestMethod == nil || digestMethod.SelectAttrValue("Algorithm", "") != sha256Algorithm {
+		return fmt.Sprintf("reference %q uses an unsupported digest method", uri)
+	}
+
+	digest, err := digestElement(target)
+	if err != nil || digest != strings.TrimSpace(childText(reference, xmlDSigNamespace, "DigestValue")) {
+		return fmt.Sprintf("digest of reference %q does not match", uri)
+	}
+
+	return ""
+}
+
+// verifyTransforms only accepts the transforms SignXML applies: the enveloped
+// signature transform, which the document reference must use, followed by
+// exclusive canonicalization, which digestElement always applies.
+func verifyTransforms(reference *etree.Element, enveloped bool) string {
+	var algorithms []string
+	if transforms := findChild(reference, xmlDSigNamespace, "Transforms"); transforms != nil {
+		for _, transform := range findChildren(transforms, xmlDSigNamespace, "Transform") {
+			algorithms = append(algorithms, transform.SelectAttrValue("Algorithm", ""))
+		}
+	}
+
+	if enveloped {
+		if len(algorithms) == 0 || algorithms[0] != envelopedAlgorithm {
+			return "is not an enveloped signature reference"
+		}
+		algorithms = algorithms[1:]
+	}
+	for _, algorithm := range algorithms {
+		if algorithm != excC14NAlgorithm {
+			return fmt.Sprintf("uses the unsupported transform %q", algorithm)
+		}
+	}
+
+	return ""
+}
+
+func (s *Signer) sign(data []byte) ([]byte, error) {
+	digest := sha256.Sum256(data)
+	signature, err := s.key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: %w", err)
+	}
+
+	if _, ok := s.key.Public().(*ecdsa.PublicKey); !ok {
+		return signature, nil
+	}
+
+	var ecdsaSignature struct{ R, S *big.Int }
+	if _, err = asn1.Unmarshal(signature, &ecdsaSignature); err != nil {
+		return nil, fmt.Errorf("failed to decode ecdsa signature: %w", err)
+	}
+
+	size := (s.key.Public().(*ecdsa.PublicKey).Curve.Params().BitSize + 7) / 8
+	raw := make([]byte, 2*size)
+	ecdsaSignature.R.FillBytes(raw[:size])
+	ecdsaSignature.S.FillBytes(raw[size:])
+	return raw, nil
+}
+
+func verifySignature(publicKey crypto.PublicKey, data, signature []byte) error {
+	digest := sha256.Sum256(data)
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature)
+	case *ecdsa.PublicKey:
+		size := len(signature) / 2
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(key, digest[:], r, s) {
+			return errors.New("ecdsa verification failed")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+}
+
+func signatureCertificate(signature *etree.Element) (*x509.Certificate, error) {
+	encoded := findElement(signature, xmlDSigNamespace, "X509Certificate")
+	if encoded == nil {
+		return nil, errors.New("signature has no X509Certificate")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(encoded.Text()), ""))
+	if err != nil {
+		return nil, errors.New("signature certificate is not valid base64")
+	}
+
+	certificate, err := x509.ParseCertificate(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signature certificate: %w", err)
+	}
+
+	return certificate, nil
+}
+
+func signatureParent(root *etree.Element) *etree.Element {
+	for _, extensionContent := range root.FindElements("//ExtensionContent") {
+		if extensionContent.NamespaceURI() == ublExtensionNamespace && len(extensionContent.ChildElements()) == 0 {
+			return extensionContent
+		}
+	}
+
+	return root
+}
+
+func canonicalize(el *etree.Element) ([]byte, error) {
+	ctx, err := etreeutils.NSBuildParentContext(el)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve namespaces: %w", err)
+	}
+
+	detached, err := etreeutils.NSDetatch(ctx, el)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detach element: %w", err)
+	}
+
+	return dsig.MakeC14N10ExclusiveCanonicalizerWithPrefixList("").Canonicalize(detached)
+}
+
+func digestElement(el *etree.Element) (string, error) {
+	canonical, err := canonicalize(el)
+	if err != nil {
+		return "", err
+	}
+
+	digest := sha256.Sum256(canonical)
+	return base64.StdEncoding.EncodeToString(digest[:]), nil
+}
+
+func findElement(el *etree.Element, namespace, tag string) *etree.Element {
+	for _, child := range el.ChildElements() {
+		if child.Tag == tag && child.NamespaceURI() == namespace {
+			return child
+		}
+		if found := findElement(child, namespace, tag); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+func findElementById(el *etree.Element, id string) *etree.Element {
+	for _, child := range el.ChildElements() {
+		if child.SelectAttrValue("Id", "") == id {
+			return child
+		}
+		if found := findElementById(child, id); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+func findChildren(el *etree.Element, namespace, tag string) []*etree.Element {
+	var children []*etree.Element
+	for _, child := range el.ChildElements() {
+		if child.Tag == tag && child.NamespaceURI() == namespace {
+			children = append(children, child)
+		}
+	}
+
+	return children
+}
+
+func findChild(el *etree.Element, namespace, tag string) *etree.Element {
+	if children := findChildren(el, namespace, tag); len(children) > 0 {
+		return children[0]
+	}
+
+	return nil
+}
+
+func childText(el *etree.Element, namespace, tag string) string {
+	if child := findChild(el, namespace, tag); child != nil {
+		return child.Text()
+	}
+
+	return ""
+}
+
+func removeElement(el *etree.Element) {
+	if el == nil || el.Parent() == nil {
+		return
+	}
+
+	el.Parent().RemoveChild(el)
+}
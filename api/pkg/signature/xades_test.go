@@ -0,0 +1,122 @@
+package signature
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testXMLDocument = `<?xml version="1.0" encoding="UTF-8"?>
+<Invoice xmlns="urn:oasis:names:specification:ubl:schema:xsd:Invoice-2" xmlns:cbc="urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2" xmlns:ext="urn:oasis:names:specification:ubl:schema:xsd:CommonExtensionComponents-2"><ext:UBLExtensions><ext:UBLExtension><ext:ExtensionContent/></ext:UBLExtension></ext:UBLExtensions><cbc:ID>ABC2025000000001</cbc:ID><cbc:IssueDate>2025-03-18</cbc:IssueDate></Invoice>`
+
+func TestSigner_SignXML(t *testing.T) {
+	for name, signer := range newTestSigners(t) {
+		t.Run(name, func(t *testing.T) {
+			signed, err := signer.SignXML([]byte(testXMLDocument))
+			require.NoError(t, err)
+			assert.Contains(t, string(signed), "<ext:ExtensionContent><ds:Signature")
+			assert.Contains(t, string(signed), "xades:SignedProperties")
+
+			result, err := signer.VerifyXML(signed)
+			require.NoError(t, err)
+			assert.True(t, result.Valid, result.Reason)
+			assert.True(t, result.Trusted)
+			assert.Contains(t, result.Subject, "Invoice Test Signer")
+			assert.False(t, result.SigningTime.IsZero())
+		})
+	}
+
+	t.Run("invalid document", func(t *testing.T) {
+		_, err := newTestSigners(t)["rsa"].SignXML([]byte("<Invoice>"))
+		assert.Error(t, err)
+	})
+}
+
+func TestSigner_VerifyXML(t *testing.T) {
+	signers := newTestSigners(t)
+	signer := signers["rsa"]
+	signed, err := signer.SignXML([]byte(testXMLDocument))
+	require.NoError(t, err)
+
+	t.Run("tampered document", func(t *testing.T) {
+		tampered := strings.Replace(string(signed), "ABC2025000000001", "ABC2025000000002", 1)
+
+		result, err := signer.VerifyXML([]byte(tampered))
+
+		require.NoError(t, err)
+		assert.False(t, result.Valid)
+		assert.Contains(t, result.Reason, `reference ""`)
+	})
+
+	t.Run("tampered signing time", func(t *testing.T) {
+		tampered := strings.Replace(string(signed), "<xades:SigningTime>2", "<xades:SigningTime>1", 1)
+
+		result, err := signer.VerifyXML([]byte(tampered))
+
+		require.NoError(t, err)
+		assert.False(t, result.Valid)
+		assert.Contains(t, result.Reason, "#SignedProperties-")
+	})
+
+	t.Run("unsupported transform", func(t *testing.T) {
+		tampered := strings.Replace(string(signed), `<ds:Transform Algorithm="`+excC14NAlgorithm+`"/>`, `<ds:Transform Algorithm="http://www.w3.org/TR/1999/REC-xslt-19991116"/>`, 1)
+
+		result, err := signer.VerifyXML([]byte(tampered))
+
+		require.NoError(t, err)
+		assert.False(t, result.Valid)
+		assert.Contains(t, result.Reason, "unsupported transform")
+	})
+
+	t.Run("missing enveloped transform", func(t *testing.T) {
+		tampered := strings.Replace(string(signed), `<ds:Transform Algorithm="`+envelopedAlgorithm+`"/>`, "", 1)
+
+		result, err := signer.VerifyXML([]byte(tampered))
+
+		require.NoError(t, err)
+		assert.False(t, result.Valid)
+		assert.Contains(t, result.Reason, "is not an enveloped signature reference")
+	})
+
+	t.Run("duplicate document reference", func(t *testing.T) {
+		start := strings.Index(string(signed), `<ds:Reference URI="">`)
+		end := strings.Index(string(signed), "</ds:Reference>") + len("</ds:Reference>")
+		reference := string(signed)[start:end]
+		tampered := strings.Replace(string(signed), reference, reference+reference, 1)
+
+		result, err := signer.VerifyXML([]byte(tampered))
+
+		require.NoError(t, err)
+		assert.False(t, result.Valid)
+		assert.Contains(t, result.Reason, "exactly once")
+	})
+
+	t.Run("missing document reference", func(t *testing.T) {
+		start := strings.Index(string(signed), `<ds:Reference URI="">`)
+		end := strings.Index(string(signed), "</ds:Reference>") + len("</ds:Reference>")
+		tampered := string(signed)[:start] + string(signed)[end:]
+
+		result, err := signer.VerifyXML([]byte(tampered))
+
+		require.NoError(t, err)
+		assert.False(t, result.Valid)
+		assert.Contains(t, result.Reason, "exactly once")
+	})
+
+	t.Run("unsigned document", func(t *testing.T) {
+		result, err := signer.VerifyXML([]byte(testXMLDocument))
+
+		require.NoError(t, err)
+		assert.False(t, result.Valid)
+	})
+
+	t.Run("untrusted signer", func(t *testing.T) {
+		result, err := signers["ecdsa"].VerifyXML(signed)
+
+		require.NoError(t, err)
+		assert.True(t, result.Valid)
+		assert.False(t, result.Trusted)
+	})
+}
@@ -0,0 +1,149 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"invoice-api/pkg/auth"
+	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/requestcontext"
+)
+
+const (
+	DefaultHeader = "X-Tenant-Id"
+	DefaultTenant = "default"
+
+	Role       = "invoice_tenant"
+	SystemRole = "invoice_system"
+)
+
+var (
+	ErrNoTenant = errors.New("no tenant in context")
+
+	tenantPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+)
+
+type systemKey struct{}
+
+type beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+type Config struct {
+	Header  string
+	Default string
+	// TrustedSubjects may pick any tenant with Header. Other authenticated
+	// principals must be bound to a tenant by their credentials, and
+	// anonymous requests always use Default.
+	TrustedSubjects []string
+}
+
+func New(config Config) fiber.Handler {
+	if config.Header == "" {
+		config.Header = DefaultHeader
+	}
+	if config.Default == "" {
+		config.Default = DefaultTenant
+	}
+
+	return func(ctx *fiber.Ctx) error {
+		bound := requestcontext.Tenant(ctx.UserContext())
+		requested := ctx.Get(config.Header)
+		subject := auth.Subject(ctx)
+
+		tenant := bound
+		switch {
+		case bound != "":
+		case subject == "":
+			tenant = config.Default
+		case slices.Contains(config.TrustedSubjects, subject):
+			tenant = requested
+			if tenant == "" {
+				tenant = config.Default
+			}
+		default:
+			return customError.CustomError{
+				Code:     fiber.StatusForbidden,
+				Message:  "tenant is not bound",
+				Severity: zap.WarnLevel,
+				Fields:   []zap.Field{zap.String("subject", subject)},
+			}
+		}
+
+		if requested != "" && requested != tenant {
+			return customError.CustomError{
+				Code:     fiber.StatusForbidden,
+				Message:  "tenant is not allowed",
+				Severity: zap.WarnLevel,
+				Fields:   []zap.Field{zap.String("tenant", requested), zap.String("boundTenant", tenant)},
+			}
+		}
+		if !tenantPattern.MatchString(tenant) {
+			return customError.CustomError{
+				Code:     fiber.StatusBadRequest,
+				Message:  "invalid tenant",
+				Severity: zap.WarnLevel,
+				Fields:   []zap.Field{zap.String("tenant", tenant)},
+			}
+		}
+
+		log := ctx.Locals(customError.ContextKeyLog).(*zap.Logger).With(zap.String("tenant", tenant))
+		ctx.Locals(customError.ContextKeyLog, log)
+		ctx.SetUserContext(requestcontext.WithTenant(ctx.UserContext(), tenant))
+		return ctx.Next()
+	}
+}
+
+// WithSystem marks ctx as running on behalf of the service itself, so that
+// transactions without a tenant use SystemRole instead of being refused.
+func WithSystem(ctx context.Context) context.Context {
+	return context.WithValue(ctx, systemKey{}, true)
+}
+
+func IsSystem(ctx context.Context) bool {
+	system, _ := ctx.Value(systemKey{}).(bool)
+	return system
+}
+
+// Begin starts a transaction scoped to the tenant in ctx. Without a tenant
+// the transaction runs as SystemRole when ctx is marked by WithSystem and is
+// refused otherwise.
+func Begin(ctx context.Context, db beginner) (pgx.Tx, error) {
+	tenant, role := requestcontext.Tenant(ctx), Role
+	if tenant == "" {
+		if !IsSystem(ctx) {
+			return nil, ErrNoTenant
+		}
+		role = SystemRole
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = tx.Exec(
+		ctx,
+		"select set_config('app.tenant_id', $1, true), set_config('role', $2, true)",
+		tenant,
+		role,
+	); err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// AfterConnect drops the privileges of new connections to Role, so that
+// statements issued outside of Begin see no tenant data.
+func AfterConnect(ctx context.Context, connection *pgx.Conn) error {
+	_, err := connection.Exec(ctx, "set role "+Role)
+	return err
+}
@@ -0,0 +1,198 @@
+package tenant
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"invoice-api/pkg/auth"
+	customError "invoice-api/pkg/error"
+	"invoice-api/pkg/requestcontext"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		subject string
+		bound   string
+		header  string
+		status  int
+		tenant  string
+	}{
+		{
+			name:   "anonymous",
+			status: fiber.StatusOK,
+			tenant: DefaultTenant,
+		},
+		{
+			name:   "anonymous with default tenant header",
+			header: DefaultTenant,
+			status: fiber.StatusOK,
+			tenant: DefaultTenant,
+		},
+		{
+			name:   "anonymous with header for another tenant",
+			header: "acme",
+			status: fiber.StatusForbidden,
+		},
+		{
+			name:    "tenant from credentials",
+			subject: "jane",
+			bound:   "acme",
+			status:  fiber.StatusOK,
+			tenant:  "acme",
+		},
+		{
+			name:    "matching header",
+			subject: "jane",
+			bound:   "acme",
+			header:  "acme",
+			status:  fiber.StatusOK,
+			tenant:  "acme",
+		},
+		{
+			name:    "header for another tenant",
+			subject: "jane",
+			bound:   "acme",
+			header:  "globex",
+			status:  fiber.StatusForbidden,
+		},
+		{
+			name:    "credentials without tenant",
+			subject: "jane",
+			status:  fiber.StatusForbidden,
+		},
+		{
+			name:    "credentials without tenant and header",
+			subject: "jane",
+			header:  "acme",
+			status:  fiber.StatusForbidden,
+		},
+		{
+			name:    "trusted subject with header",
+			subject: "operator",
+			header:  "acme",
+			status:  fiber.StatusOK,
+			tenant:  "acme",
+		},
+		{
+			name:    "trusted subject without header",
+			subject: "operator",
+			status:  fiber.StatusOK,
+			tenant:  DefaultTenant,
+		},
+		{
+			name:    "invalid tenant",
+			subject: "operator",
+			header:  "acme'; drop table invoices; --",
+			status:  fiber.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := fiber.New(fiber.Config{
+				ErrorHandler:          customError.ErrorHandler,
+				DisableStartupMessage: true,
+			})
+			server.Use(func(ctx *fiber.Ctx) error {
+				ctx.Locals(customError.ContextKeyLog, zap.NewNop())
+				if test.subject != "" {
+					ctx.Locals(auth.ContextKeySubject, test.subject)
+				}
+				if test.bound != "" {
+					ctx.SetUserContext(requestcontext.WithTenant(ctx.UserContext(), test.bound))
+				}
+				return ctx.Next()
+			})
+			server.Use(New(Config{TrustedSubjects: []string{"operator"}}))
+			server.Get("/", func(ctx *fiber.Ctx) error {
+				ctx.Set("X-Tenant", requestcontext.Tenant(ctx.UserContext()))
+				return ctx.SendStatus(fiber.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.header != "" {
+				req.Header.Set(DefaultHeader, test.header)
+			}
+
+			res, err := server.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, test.status, res.StatusCode)
+			assert.Equal(t, test.tenant, res.Header.Get("X-Tenant"))
+		})
+	}
+}
+
+type fakeTx struct {
+	pgx.Tx
+	args []any
+}
+
+func (tx *fakeTx) Exec(_ context.Context, _ string, args ...any) (pgconn.CommandTag, error) {
+	tx.args = args
+	return pgconn.CommandTag{}, nil
+}
+
+type fakeBeginner struct {
+	tx *fakeTx
+}
+
+func (b *fakeBeginner) Begin(context.Context) (pgx.Tx, error) {
+	b.tx = &fakeTx{}
+	return b.tx, nil
+}
+
+func TestBegin(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		args []any
+		err  error
+	}{
+		{
+			name: "tenant",
+			ctx:  requestcontext.WithTenant(context.Background(), "acme"),
+			args: []any{"acme", Role},
+		},
+		{
+			name: "tenant in system context",
+			ctx:  requestcontext.WithTenant(WithSystem(context.Background()), "acme"),
+			args: []any{"acme", Role},
+		},
+		{
+			name: "system",
+			ctx:  WithSystem(context.Background()),
+			args: []any{"", SystemRole},
+		},
+		{
+			name: "no tenant",
+			ctx:  context.Background(),
+			err:  ErrNoTenant,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := &fakeBeginner{}
+			tx, err := Begin(test.ctx, db)
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+				assert.Nil(t, db.tx)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Same(t, db.tx, tx)
+			assert.Equal(t, test.args, db.tx.args)
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	customError "invoice-api/pkg/error"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+
+	instrumentationName = "invoice-api"
+)
+
+type Config struct {
+	Exporter    string
+	Endpoint    string
+	Insecure    bool
+	ServiceName string
+	SampleRatio float64
+	Output      io.Writer
+}
+
+func NewProvider(ctx context.Context, config Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var (
+		exporter sdktrace.SpanExporter
+		err      error
+	)
+	switch config.Exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		output := config.Output
+		if output == nil {
+			output = os.Stdout
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(output))
+	case ExporterOTLP:
+		options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(config.Endpoint)}
+		if config.Insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, options...)
+	default:
+		err = errors.New("unknown tracing exporter " + config.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var serviceResource *resource.Resource
+	serviceResource, err = resource.Merge(
+		resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(config.ServiceName)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(serviceResource),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+func New() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		parent := otel.GetTextMapPropagator().Extract(ctx.UserContext(), headerCarrier{ctx: ctx})
+
+		spanCtx, span := tracer().Start(
+			parent,
+			ctx.Method()+" "+ctx.Path(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(ctx.Method()),
+				semconv.URLPath(ctx.Path()),
+				semconv.ClientAddress(ctx.IP()),
+			),
+		)
+		defer span.End()
+		ctx.SetUserContext(spanCtx)
+
+		if log, ok := ctx.Locals(customError.ContextKeyLog).(*zap.Logger); ok && span.SpanContext().IsValid() {
+			ctx.Locals(customError.ContextKeyLog, log.With(
+				zap.String("traceId", span.SpanContext().TraceID().String()),
+				zap.String("spanId", span.SpanContext().SpanID().String()),
+			))
+		}
+
+		err := ctx.Next()
+
+		status := ctx.Response().StatusCode()
+		if err != nil {
+			status = customError.StatusCode(err)
+		}
+		route := ctx.Route().Path
+		span.SetName(ctx.Method() + " " + route)
+		span.SetAttributes(semconv.HTTPRoute(route), semconv.HTTPResponseStatusCode(status))
+		if err != nil {
+			recordError(span, err)
+		} else if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, strconv.Itoa(status))
+		}
+
+		return err
+	}
+}
+
+type headerCarrier struct {
+	ctx *fiber.Ctx
+}
+
+func (c headerCarrier) Get(key string) string {
+	return c.ctx.Get(key)
+}
+
+func (c headerCarrier) Set(key, value string) {
+	c.ctx.Request().Header.Set(key, value)
+}
+
+func (c headerCarrier) Keys() []string {
+	var keys []string
+	c.ctx.Request().Header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
+
+func StartSpan(ctx *fiber.Ctx, name string) func() {
+	spanCtx, span := tracer().Start(ctx.UserContext(), name)
+	ctx.SetUserContext(spanCtx)
+	return func() {
+		span.End()
+	}
+}
+
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+func StartRepository(ctx context.Context, repository, operation string) (context.Context, func(*error)) {
+	ctx, span := tracer().Start(ctx, repository+".Repository."+operation, trace.WithSpanKind(trace.SpanKindInternal))
+	return ctx, func(err *error) {
+		end(span, *err)
+	}
+}
+
+func end(span trace.Span, err error) {
+	if err != nil {
+		recordError(span, err)
+	}
+	span.End()
+}
+
+func recordError(span trace.Span, err error) {
+	status := customError.StatusCode(err)
+
+	var cerr customError.CustomError
+	if errors.As(err, &cerr) {
+		err = errors.New(cerr.Message)
+	}
+
+	span.RecordError(err)
+	if status >= fiber.StatusInternalServerError {
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+type QueryTracer struct{}
+
+type querySpanKey struct{}
+
+func (QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx, span := tracer().Start(
+		ctx,
+		"postgresql.query",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBQueryText(data.SQL),
+			attribute.Int("db.query.args", len(data.Args)),
+		),
+	)
+	return context.WithValue(ctx, querySpanKey{}, span)
+}
+
+func (QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span, ok := ctx.Value(querySpanKey{}).(trace.Span)
+	if !ok {
+		return
+	}
+	if data.Err == nil {
+		span.SetAttributes(attribute.Int64("db.response.rows_affected", data.CommandTag.RowsAffected()))
+	}
+	end(span, data.Err)
+}
@@ -0,0 +1,163 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	customError "invoice-api/pkg/error"
+)
+
+func setupRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		_ = provider.Shutdown(context.Background())
+	})
+
+	return recorder
+}
+
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	values := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		values[kv.Key] = kv.Value
+	}
+	return values
+}
+
+func TestNewProvider(t *testing.T) {
+	t.Run("none", func(t *testing.T) {
+		shutdown, err := NewProvider(context.Background(), Config{Exporter: ExporterNone})
+		require.NoError(t, err)
+		assert.NoError(t, shutdown(context.Background()))
+	})
+
+	t.Run("unknown exporter", func(t *testing.T) {
+		_, err := NewProvider(context.Background(), Config{Exporter: "jaeger"})
+		assert.Error(t, err)
+	})
+
+	t.Run("stdout", func(t *testing.T) {
+		previous := otel.GetTracerProvider()
+		t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+		var output bytes.Buffer
+		shutdown, err := NewProvider(context.Background(), Config{
+			Exporter:    ExporterStdout,
+			ServiceName: "invoice-api-test",
+			SampleRatio: 1,
+			Output:      &output,
+		})
+		require.NoError(t, err)
+
+		_, endSpan := StartRepository(context.Background(), "invoice", "GetInvoiceById")
+		endSpan(new(error))
+		require.NoError(t, shutdown(context.Background()))
+
+		assert.Contains(t, output.String(), `"Name":"invoice.Repository.GetInvoiceById"`)
+		assert.Contains(t, output.String(), "invoice-api-test")
+	})
+}
+
+func TestNew(t *testing.T) {
+	recorder := setupRecorder(t)
+	_, err := NewProvider(context.Background(), Config{Exporter: ExporterNone})
+	require.NoError(t, err)
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	server := fiber.New(fiber.Config{
+		ErrorHandler:          customError.ErrorHandler,
+		DisableStartupMessage: true,
+	})
+	server.Use(func(ctx *fiber.Ctx) error {
+		ctx.Locals(customError.ContextKeyLog, zap.New(core))
+		return ctx.Next()
+	})
+	server.Use(New())
+	server.Get("/invoices/:id", func(ctx *fiber.Ctx) error {
+		defer StartSpan(ctx, "invoice.Handler.GetInvoiceById")()
+
+		ctx.Locals(customError.ContextKeyLog).(*zap.Logger).Info("handled")
+		_, endSpan := StartRepository(ctx.UserContext(), "invoice", "GetInvoiceById")
+		err := error(customError.CustomError{Code: fiber.StatusInternalServerError, Message: "failed to get invoice"})
+		endSpan(&err)
+		return err
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/invoices/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	res, err := server.Test(req, -1)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusInternalServerError, res.StatusCode)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	repositorySpan, handlerSpan, serverSpan := spans[0], spans[1], spans[2]
+
+	assert.Equal(t, "GET /invoices/:id", serverSpan.Name())
+	assert.Equal(t, trace.SpanKindServer, serverSpan.SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", serverSpan.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", serverSpan.Parent().SpanID().String())
+	assert.Equal(t, codes.Error, serverSpan.Status().Code)
+	assert.Equal(t, "/invoices/:id", attributes(serverSpan)["http.route"].AsString())
+	assert.Equal(t, int64(500), attributes(serverSpan)["http.response.status_code"].AsInt64())
+
+	assert.Equal(t, "invoice.Handler.GetInvoiceById", handlerSpan.Name())
+	assert.Equal(t, serverSpan.SpanContext().SpanID(), handlerSpan.Parent().SpanID())
+
+	assert.Equal(t, "invoice.Repository.GetInvoiceById", repositorySpan.Name())
+	assert.Equal(t, handlerSpan.SpanContext().SpanID(), repositorySpan.Parent().SpanID())
+	assert.Equal(t, codes.Error, repositorySpan.Status().Code)
+
+	handled := logs.FilterMessage("handled").All()
+	require.Len(t, handled, 1)
+	fields := handled[0].ContextMap()
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", fields["traceId"])
+	assert.Equal(t, serverSpan.SpanContext().SpanID().String(), fields["spanId"])
+}
+
+func TestQueryTracer(t *testing.T) {
+	recorder := setupRecorder(t)
+	tracer := QueryTracer{}
+
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "select 1", Args: []any{1}})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("SELECT 1")})
+
+	ctx = tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "select * from missing"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: errors.New("relation does not exist")})
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	assert.Equal(t, "postgresql.query", spans[0].Name())
+	assert.Equal(t, trace.SpanKindClient, spans[0].SpanKind())
+	assert.Equal(t, "postgresql", attributes(spans[0])["db.system"].AsString())
+	assert.Equal(t, "select 1", attributes(spans[0])["db.query.text"].AsString())
+	assert.Equal(t, int64(1), attributes(spans[0])["db.response.rows_affected"].AsInt64())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Len(t, spans[1].Events(), 1)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"invoice-api/internal/invoice"
+	"invoice-api/pkg/requestcontext"
+	"invoice-api/pkg/tenant"
+)
+
+func runVerifyChain(repository invoice.Repository, args []string, output io.Writer) int {
+	flags := flag.NewFlagSet("verify-chain", flag.ContinueOnError)
+	flags.SetOutput(output)
+	series := flags.String("series", "", "invoice series to verify (DMP or SSP), all series when empty")
+	from := flags.String("from", "", "first finalization date to verify (YYYY-MM-DD)")
+	to := flags.String("to", "", "last finalization date to verify (YYYY-MM-DD)")
+	tenantId := flags.String("tenant", tenant.DefaultTenant, "tenant whose invoice chain is verified")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	fromDate, toDate, err := invoice.ParseChainRange(*from, *to)
+	if err != nil {
+		_, _ = fmt.Fprintf(output, "invalid date range: %s\n", err)
+		return 2
+	}
+
+	ctx := requestcontext.WithTenant(context.Background(), *tenantId)
+	verifications, err := invoice.VerifyChains(ctx, repository, *series, fromDate, toDate)
+	if err != nil {
+		_, _ = fmt.Fprintf(output, "failed to verify invoice chain: %s\n", err)
+		return 1
+	}
+
+	exitCode := 0
+	for _, verification := range verifications {
+		if verification.Valid {
+			_, _ = fmt.Fprintf(output, "%s: ok, %d invoices verified\n", verification.Series, verification.Checked)
+			continue
+		}
+
+		exitCode = 1
+		_, _ = fmt.Fprintf(
+			output,
+			"%s: broken link at sequence number %d (invoice %s): %s\n",
+			verification.Series,
+			verification.BrokenLink.SequenceNumber,
+			verification.BrokenLink.InvoiceId,
+			verification.BrokenLink.Reason,
+		)
+	}
+
+	return exitCode
+}
@@ -14,6 +14,14 @@ type Invoice = {
   amount: number;
   date: string;
   status: "PAID" | "UNPAID" | "PENDING";
+  deletedAt?: string;
+};
+
+type InvoiceEvent = {
+  id: number;
+  type: "CREATED" | "UPDATED" | "DELETED" | "RESTORED" | "PURGED";
+  invoiceId: string;
+  invoice?: Invoice;
 };
 
 const columns: TableProps<Invoice>["columns"] = [
@@ -69,8 +77,10 @@ const Home = () => {
   const [invoices, setInvoices] = useState<Array<Invoice>>([]);
   const [isLoading, setLoading] = useState<boolean>(false);
   const [error, setError] = useState<Error | undefined>(undefined);
+  const [search, setSearch] = useState<string>("");
 
   function onSearch(value: string) {
+    setSearch(value);
     setLoading(true);
     fetch(process.env.NEXT_PUBLIC_API_URL + "/invoices?search=" + value)
       .then((res) => res.json())
@@ -87,6 +97,25 @@ const Home = () => {
       .finally(() => setLoading(false));
   }, []);
 
+  useEffect(() => {
+    const stream = new EventSource(
+      process.env.NEXT_PUBLIC_API_URL + "/invoices/stream?search=" + encodeURIComponent(search),
+    );
+    stream.onmessage = (message) => {
+      const event: InvoiceEvent = JSON.parse(message.data);
+      setInvoices((current) => {
+        const rest = current.filter((invoice) => invoice.id !== event.invoiceId);
+        if (!event.invoice || event.invoice.deletedAt) return rest;
+
+        const index = current.findIndex((invoice) => invoice.id === event.invoiceId);
+        if (index === -1) return [event.invoice, ...rest];
+        return current.map((invoice) => (invoice.id === event.invoiceId ? event.invoice! : invoice));
+      });
+    };
+
+    return () => stream.close();
+  }, [search]);
+
   if (error) return <Alert message="Error" description={error.message} type="error" />;
 
   return (